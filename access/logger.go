@@ -6,7 +6,9 @@
 package access
 
 import (
+	"bufio"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -24,12 +26,67 @@ type LogFunc func(format string, a ...interface{})
 // through this middleware and does whatever log writing it wants with that
 // information.
 // LogWriterFunc should be thread safe.
+//
+// For a streaming response, such as a WebSocket connection or a stream of server-sent events, LogWriterFunc is called
+// twice: once when the streaming starts, with res.Closed() returning false, and once when the response is completed.
 type LogWriterFunc func(req *http.Request, res *LogResponseWriter, elapsed float64)
 
+// Options specifies what the access loggers log.
+type Options struct {
+	// SkipStreamStart indicates whether to skip logging the start of streaming responses,
+	// so that every request is logged exactly once, when its response is completed.
+	SkipStreamStart bool
+	// TLS indicates whether to log the TLS versions of the connections over which the requests are received,
+	// e.g. "TLS 1.3". The version of a request not received over TLS is logged as "-".
+	TLS bool
+	// Headers lists the response headers to be captured in LogResponseWriter.Headers, e.g. "Content-Type"
+	// and "Cache-Control". LeveledLogger and SlogLogger log them with the lowercase header names prefixed
+	// with "header." as the keys.
+	Headers []string
+	// BodySize is the maximum number of bytes at the beginning of the response body to be captured in
+	// LogResponseWriter.Body. LeveledLogger and SlogLogger log them with the "body" key. Zero disables capturing.
+	BodySize int
+	// Exit indicates whether to capture the handler that ended the handler chain (see routing.Context.ChainExit)
+	// in LogResponseWriter.Exit. LeveledLogger and SlogLogger log its name and the reason with the "exit.handler"
+	// and "exit.reason" keys, which shows where the requests end, e.g. in an authentication handler.
+	Exit bool
+	// Bot indicates whether to capture the bot sending the request as detected by routing.BotDetector in
+	// LogResponseWriter.Bot. LeveledLogger and SlogLogger log its name with the "bot" key, followed by "spoofed"
+	// if the bot is spoofed, so that the crawler traffic can be told from that of the users.
+	Bot bool
+}
+
+// getOptions returns the first of the given options, or the default options if none is given.
+func getOptions(opts []Options) Options {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return Options{}
+}
+
+// botName returns the name of the bot to be logged, which is followed by "spoofed" if the bot is spoofed.
+func botName(bot *routing.Bot) string {
+	if bot.Spoofed {
+		return bot.Name + " spoofed"
+	}
+	return bot.Name
+}
+
+// tlsVersion returns the TLS version of the connection over which the request is received,
+// or "-" if the request is not received over TLS.
+func tlsVersion(req *http.Request) string {
+	if info := routing.GetTLSInfo(req); info != nil {
+		return info.VersionName()
+	}
+	return "-"
+}
+
 // CustomLogger returns a handler that calls the LogWriterFunc passed to it for every request.
 // The LogWriterFunc is provided with the http.Request and LogResponseWriter objects for the
 // request, as well as the elapsed time since the request first came through the middleware.
-// LogWriterFunc can then do whatever logging it needs to do.
+// LogWriterFunc can then do whatever logging it needs to do. The requests matching the routes marked
+// with the routing.Sensitive metadata are not logged if their Sensitivity.Skip is true, and the
+// LogWriterFunc is provided with the copies of the requests redacted by Sensitivity.Redact otherwise.
 //
 //     import (
 //         "log"
@@ -43,27 +100,53 @@ type LogWriterFunc func(req *http.Request, res *LogResponseWriter, elapsed float
 //     }
 //     r := routing.New()
 //     r.Use(access.CustomLogger(myCustomLogger))
-func CustomLogger(loggerFunc LogWriterFunc) routing.Handler {
+func CustomLogger(loggerFunc LogWriterFunc, opts ...Options) routing.Handler {
+	options := getOptions(opts)
 	return func(c *routing.Context) error {
+		sensitivity := c.Sensitivity()
+		if sensitivity != nil && sensitivity.Skip {
+			return c.Next()
+		}
 		startTime := time.Now()
 
-		req := c.Request
-		rw := &LogResponseWriter{c.Response, http.StatusOK, 0}
+		req := sensitivity.Redact(c.Request)
+		rw := newLogResponseWriter(c.Response, options, sensitivity)
+		if !options.SkipStreamStart {
+			rw.state().onStream = func() {
+				loggerFunc(req, rw, elapsedSince(startTime))
+			}
+		}
 		c.Response = rw
+		if options.Exit {
+			c.TrackChainExit()
+		}
 
 		err := c.Next()
 
-		elapsed := float64(time.Now().Sub(startTime).Nanoseconds()) / 1e6
-		loggerFunc(req, rw, elapsed)
+		rw.close()
+		if options.Exit {
+			exit := c.ChainExit()
+			rw.state().exit = &exit
+		}
+		if options.Bot {
+			rw.state().bot = c.Bot()
+		}
+		loggerFunc(req, rw, elapsedSince(startTime))
 
 		return err
 	}
+}
 
+// elapsedSince returns the time elapsed since the given time in milliseconds.
+func elapsedSince(t time.Time) float64 {
+	return float64(time.Since(t).Nanoseconds()) / 1e6
 }
 
 // Logger returns a handler that logs a message for every request.
 // The access log messages contain information including client IPs, time used to serve each request, request line,
-// response status and size.
+// response status and size. For a streaming response, a message ending with "streaming" is also logged
+// when the streaming starts, unless Options.SkipStreamStart is true. If Options.TLS is true, the messages end with
+// the TLS versions of the requests.
 //
 //     import (
 //         "log"
@@ -73,23 +156,192 @@ func CustomLogger(loggerFunc LogWriterFunc) routing.Handler {
 //
 //     r := routing.New()
 //     r.Use(access.Logger(log.Printf))
-func Logger(log LogFunc) routing.Handler {
+func Logger(log LogFunc, opts ...Options) routing.Handler {
+	options := getOptions(opts)
 	var logger = func(req *http.Request, rw *LogResponseWriter, elapsed float64) {
 		clientIP := GetClientIP(req)
 		requestLine := fmt.Sprintf("%s %s %s", req.Method, req.URL.String(), req.Proto)
-		log(`[%s] [%.3fms] %s %d %d`, clientIP, elapsed, requestLine, rw.Status, rw.BytesWritten)
+		suffix := ""
+		if options.TLS {
+			suffix = " " + tlsVersion(req)
+		}
+		if !rw.Closed() {
+			log(`[%s] [%.3fms] %s %d streaming%s`, clientIP, elapsed, requestLine, rw.Status, suffix)
+			return
+		}
+		log(`[%s] [%.3fms] %s %d %d%s`, clientIP, elapsed, requestLine, rw.Status, rw.BytesWritten, suffix)
+	}
+	return CustomLogger(logger, opts...)
+}
+
+// LeveledLogger returns a handler that logs a message for every request using the given leveled logger.
+// The message is the request line, followed by the key-value pairs of the client IP ("ip"), response status ("status"),
+// response size ("size"), and time used to serve the request in milliseconds ("duration"). The message is logged
+// at the error level if the response status is 5xx, at the warning level if it is 4xx, and at the info level otherwise,
+// so that the server errors can be sent to a different destination from the regular access log.
+// For a streaming response, the start of the streaming is also logged with the "streaming" key, unless
+// Options.SkipStreamStart is true, and the final message contains the "streaming" key too. If Options.TLS is true,
+// the messages also contain the TLS versions of the requests ("tls").
+//
+//     import (
+//         "log/slog"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/access"
+//     )
+//
+//     r := routing.New()
+//     r.Use(access.LeveledLogger(slog.Default()))
+func LeveledLogger(logger routing.Logger, opts ...Options) routing.Handler {
+	options := getOptions(opts)
+	return CustomLogger(func(req *http.Request, rw *LogResponseWriter, elapsed float64) {
+		log := logger.Info
+		if rw.Status >= http.StatusInternalServerError {
+			log = logger.Error
+		} else if rw.Status >= http.StatusBadRequest {
+			log = logger.Warn
+		}
+		requestLine := fmt.Sprintf("%s %s %s", req.Method, req.URL.String(), req.Proto)
+		kv := []interface{}{"ip", GetClientIP(req), "status", rw.Status}
+		if rw.Closed() {
+			kv = append(kv, "size", rw.BytesWritten, "duration", elapsed)
+		}
+		if rw.Streaming() {
+			kv = append(kv, "streaming", true)
+		}
+		if options.TLS {
+			kv = append(kv, "tls", tlsVersion(req))
+		}
+		if rw.Closed() {
+			kv = append(kv, capturedKeyValues(rw)...)
+		}
+		if exit := rw.Exit(); exit != nil {
+			kv = append(kv, "exit.handler", exit.Handler, "exit.reason", exit.Reason)
+		}
+		if bot := rw.Bot(); bot != nil {
+			kv = append(kv, "bot", botName(bot))
+		}
+		log(requestLine, kv...)
+	}, opts...)
+}
 
+// capturedKeyValues returns the captured response headers and body as key-value pairs. The headers are
+// in the order of Options.Headers, and the multiple values of a header are joined with commas.
+func capturedKeyValues(rw *LogResponseWriter) []interface{} {
+	kv := []interface{}{}
+	w := rw.state()
+	if w == nil {
+		return kv
+	}
+	for _, name := range w.captureHeaders {
+		if values := w.headers[http.CanonicalHeaderKey(name)]; len(values) > 0 {
+			kv = append(kv, "header."+strings.ToLower(name), strings.Join(values, ","))
+		}
+	}
+	if w.bodySize > 0 || w.body != nil {
+		kv = append(kv, "body", string(w.body))
 	}
-	return CustomLogger(logger)
+	return kv
 }
 
 // LogResponseWriter wraps http.ResponseWriter in order to capture HTTP status and response length information.
+//
+// The LogResponseWriter created by the access loggers also detects streaming responses, i.e., the WebSocket
+// connections or other hijacked connections, the responses with the 101 (Switching Protocols) status,
+// and the server-sent events ("text/event-stream"), and captures the information specified by Options,
+// which can be obtained via its methods, such as Streaming and Headers.
 type LogResponseWriter struct {
 	http.ResponseWriter
 	Status       int
 	BytesWritten int64
 }
 
+// responseWriter is the http.ResponseWriter wrapped by the LogResponseWriter created by the access loggers.
+// It keeps the state of the response beyond the status and the size.
+type responseWriter struct {
+	http.ResponseWriter
+	lw             *LogResponseWriter
+	streaming      bool
+	closed         bool
+	headers        http.Header
+	body           []byte
+	exit           *routing.ChainExit
+	bot            *routing.Bot
+	wroteHeader    bool
+	onStream       func()
+	captureHeaders []string
+	bodySize       int
+}
+
+// newLogResponseWriter creates a LogResponseWriter capturing the response headers and body as specified by options.
+// The body is not captured if the sensitivity of the route requires redacting it.
+func newLogResponseWriter(res http.ResponseWriter, options Options, sensitivity *routing.Sensitivity) *LogResponseWriter {
+	w := &responseWriter{
+		ResponseWriter: res,
+		captureHeaders: options.Headers,
+		bodySize:       options.BodySize,
+	}
+	if sensitivity != nil && sensitivity.RedactBody && w.bodySize > 0 {
+		w.bodySize = 0
+		w.body = []byte(routing.Redacted)
+	}
+	w.lw = &LogResponseWriter{ResponseWriter: w, Status: http.StatusOK}
+	return w.lw
+}
+
+// state returns the state of the response kept by the access loggers, or nil if the LogResponseWriter
+// is not created by them.
+func (r *LogResponseWriter) state() *responseWriter {
+	w, _ := r.ResponseWriter.(*responseWriter)
+	return w
+}
+
+// Streaming returns whether the response is a streaming one.
+func (r *LogResponseWriter) Streaming() bool {
+	w := r.state()
+	return w != nil && w.streaming
+}
+
+// Closed returns whether the response is completed. It is false when a streaming response has just started.
+func (r *LogResponseWriter) Closed() bool {
+	w := r.state()
+	return w == nil || w.closed
+}
+
+// Headers returns the response headers listed in Options.Headers, captured when the headers are written.
+// It is nil if Options.Headers is empty.
+func (r *LogResponseWriter) Headers() http.Header {
+	if w := r.state(); w != nil {
+		return w.headers
+	}
+	return nil
+}
+
+// Body returns the beginning of the response body, up to Options.BodySize bytes. It is routing.Redacted
+// for the routes whose routing.Sensitivity requires redacting the body.
+func (r *LogResponseWriter) Body() []byte {
+	if w := r.state(); w != nil {
+		return w.body
+	}
+	return nil
+}
+
+// Exit returns the handler that ended the handler chain when the response is completed. It is nil
+// if Options.Exit is false.
+func (r *LogResponseWriter) Exit() *routing.ChainExit {
+	if w := r.state(); w != nil {
+		return w.exit
+	}
+	return nil
+}
+
+// Bot returns the bot sending the request. It is nil if the request is not sent by a bot or Options.Bot is false.
+func (r *LogResponseWriter) Bot() *routing.Bot {
+	if w := r.state(); w != nil {
+		return w.bot
+	}
+	return nil
+}
+
 func (r *LogResponseWriter) Write(p []byte) (int, error) {
 	written, err := r.ResponseWriter.Write(p)
 	r.BytesWritten += int64(written)
@@ -102,6 +354,166 @@ func (r *LogResponseWriter) WriteHeader(status int) {
 	r.ResponseWriter.WriteHeader(status)
 }
 
+// close marks the response as completed.
+func (r *LogResponseWriter) close() {
+	if w := r.state(); w != nil {
+		w.close()
+	}
+}
+
+// Written returns the status and the number of bytes of the response body that have been written.
+// The status is 0 if nothing has been written.
+func (r *LogResponseWriter) Written() (int, int64) {
+	if w := r.state(); w != nil && !w.wroteHeader {
+		return 0, 0
+	}
+	return r.Status, r.BytesWritten
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports flushing.
+func (r *LogResponseWriter) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection from the HTTP server, e.g. to serve WebSocket. For the LogResponseWriter created
+// by the access loggers, the response is then treated as a streaming one, and the bytes written to the returned
+// connection are counted in BytesWritten. If no status has been written, the status is assumed to be
+// 101 (Switching Protocols).
+func (r *LogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (r *LogResponseWriter) Unwrap() http.ResponseWriter {
+	if w := r.state(); w != nil {
+		return w.ResponseWriter
+	}
+	return r.ResponseWriter
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+	written, err := w.ResponseWriter.Write(p)
+	if n := w.bodySize - len(w.body); n > 0 && written > 0 {
+		if n > written {
+			n = written
+		}
+		w.body = append(w.body, p[:n]...)
+	}
+	return written, err
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.writeHeader(status)
+	} else {
+		w.detectStreaming(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// writeHeader marks the headers as written with the given status, capturing the headers and detecting streaming.
+func (w *responseWriter) writeHeader(status int) {
+	w.wroteHeader = true
+	w.captureHeader()
+	w.detectStreaming(status)
+}
+
+// captureHeader copies the values of the headers listed in Options.Headers.
+func (w *responseWriter) captureHeader() {
+	if len(w.captureHeaders) == 0 {
+		return
+	}
+	header := w.Header()
+	w.headers = make(http.Header, len(w.captureHeaders))
+	for _, name := range w.captureHeaders {
+		name = http.CanonicalHeaderKey(name)
+		if values := header[name]; len(values) > 0 {
+			w.headers[name] = append([]string(nil), values...)
+		}
+	}
+}
+
+// close marks the response as completed. The headers are captured if nothing has been written.
+func (w *responseWriter) close() {
+	if !w.wroteHeader {
+		w.captureHeader()
+	}
+	w.closed = true
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports flushing.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection from the HTTP server and treats the response as a streaming one.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.lw.Status = http.StatusSwitchingProtocols
+		w.captureHeader()
+	}
+	w.startStreaming()
+	return &countingConn{conn, w.lw}, rw, nil
+}
+
+// detectStreaming checks if the response being written is a streaming one.
+func (w *responseWriter) detectStreaming(status int) {
+	if status == http.StatusSwitchingProtocols || strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.startStreaming()
+	}
+}
+
+// startStreaming marks the response as a streaming one and calls onStream for the first time.
+func (w *responseWriter) startStreaming() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	if w.onStream != nil {
+		w.onStream()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingConn wraps a hijacked connection in order to count the bytes written to it.
+type countingConn struct {
+	net.Conn
+	rw *LogResponseWriter
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	written, err := c.Conn.Write(p)
+	c.rw.BytesWritten += int64(written)
+	return written, err
+}
+
 // GetClientIP returns the client IP address from the given HTTP request.
 func GetClientIP(req *http.Request) string {
 	ip := req.Header.Get("X-Real-IP")
@@ -111,6 +523,14 @@ func GetClientIP(req *http.Request) string {
 			ip = req.RemoteAddr
 		}
 	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		// "host:port" or "[ipv6-host]:port", such as the address of a TCP connection or that passed by FastCGI
+		return host
+	}
+	if net.ParseIP(ip) != nil {
+		// an IP address without port, such as the address passed by CGI
+		return ip
+	}
 	if colon := strings.LastIndex(ip, ":"); colon != -1 {
 		ip = ip[:colon]
 	}
@@ -5,9 +5,13 @@
 package access
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -56,6 +60,234 @@ func TestLogResponseWriter(t *testing.T) {
 	assert.Equal(t, "test", res.Body.String())
 }
 
+func TestLogResponseWriterUnwrap(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := &LogResponseWriter{res, 0, 0}
+	assert.Equal(t, res, w.Unwrap())
+	assert.True(t, w.Closed())
+	assert.False(t, w.Streaming())
+
+	w = newLogResponseWriter(res, Options{}, nil)
+	assert.Equal(t, res, w.Unwrap())
+	assert.False(t, w.Closed())
+}
+
+func TestLoggerStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	router := routing.New()
+	router.Use(Logger(getLogger(&buf)))
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream")
+		assert.Equal(t, "", buf.String())
+		c.Response.(http.Flusher).Flush()
+		assert.Contains(t, buf.String(), "] GET /events HTTP/1.1 200 streaming")
+		return c.Write("data: a\n\n")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	router.ServeHTTP(res, req)
+	assert.Regexp(t, `^\[\S*\] \[[\d.]+ms\] GET /events HTTP/1.1 200 streaming\[\S*\] \[[\d.]+ms\] GET /events HTTP/1.1 200 9$`, buf.String())
+	assert.True(t, res.Flushed)
+
+	buf.Reset()
+	router = routing.New()
+	router.Use(Logger(getLogger(&buf), Options{SkipStreamStart: true}))
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream")
+		return c.Write("data: a\n\n")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Regexp(t, `^\[\S*\] \[[\d.]+ms\] GET /events HTTP/1.1 200 9$`, buf.String())
+}
+
+func TestLoggerTLS(t *testing.T) {
+	var buf bytes.Buffer
+	h := Logger(getLogger(&buf), Options{TLS: true})
+
+	req, _ := http.NewRequest("GET", "https://127.0.0.1/users", nil)
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS12}
+	c := routing.NewContext(httptest.NewRecorder(), req, h, handler1)
+	assert.NotNil(t, c.Next())
+	assert.Regexp(t, `GET https://127.0.0.1/users HTTP/1.1 200 0 TLS 1.2$`, buf.String())
+
+	buf.Reset()
+	req, _ = http.NewRequest("GET", "http://127.0.0.1/users", nil)
+	c = routing.NewContext(httptest.NewRecorder(), req, h, handler1)
+	assert.NotNil(t, c.Next())
+	assert.Regexp(t, `GET http://127.0.0.1/users HTTP/1.1 200 0 -$`, buf.String())
+
+	logger := &testLogger{}
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS13}
+	c = routing.NewContext(httptest.NewRecorder(), req, LeveledLogger(logger, Options{TLS: true}), handler1)
+	assert.NotNil(t, c.Next())
+	assert.Regexp(t, `^I GET http://127.0.0.1/users HTTP/1.1 \[ip \S* status 200 size 0 duration [\d.e-]+ tls TLS 1.3\]
+$`, logger.String())
+}
+
+func TestLoggerSensitive(t *testing.T) {
+	var buf bytes.Buffer
+	router := routing.New()
+	router.Use(Logger(getLogger(&buf)))
+	handler := func(c *routing.Context) error {
+		return c.Write("ok")
+	}
+	router.Get("/users", handler)
+	router.Get("/login", handler).Set(routing.Sensitive, routing.Sensitivity{RedactQuery: true})
+	router.Get("/token", handler).Set(routing.Sensitive, routing.Sensitivity{Skip: true})
+
+	tests := []struct {
+		tag, url, log string
+	}{
+		{"t1", "/users?page=1", `GET /users\?page=1 HTTP/1.1 200 2$`},
+		{"t2", "/login?code=abc", `GET /login\?code=REDACTED HTTP/1.1 200 2$`},
+		{"t3", "/token?code=abc", `^$`},
+	}
+	for _, test := range tests {
+		buf.Reset()
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.url, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, "ok", res.Body.String(), test.tag)
+		assert.Regexp(t, test.log, buf.String(), test.tag)
+	}
+}
+
+func TestLoggerCapture(t *testing.T) {
+	var captured *LogResponseWriter
+	h := CustomLogger(func(req *http.Request, rw *LogResponseWriter, elapsed float64) {
+		captured = rw
+	}, Options{Headers: []string{"content-type", "Cache-Control", "X-Missing"}, BodySize: 5})
+	router := routing.New()
+	router.Use(h)
+	router.Get("/users", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "application/json")
+		c.Response.Header().Add("Cache-Control", "no-cache")
+		c.Response.Header().Add("Cache-Control", "private")
+		c.Write(`{"name":`)
+		c.Response.Header().Set("Content-Type", "text/plain")
+		return c.Write(`"a"}`)
+	})
+	router.Get("/empty", func(c *routing.Context) error {
+		c.Response.Header().Set("Cache-Control", "no-store")
+		return nil
+	})
+	router.Get("/login", func(c *routing.Context) error {
+		return c.Write("secret")
+	}).Set(routing.Sensitive, routing.Sensitivity{RedactBody: true})
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, http.Header{
+		"Content-Type":  {"application/json"},
+		"Cache-Control": {"no-cache", "private"},
+	}, captured.Headers())
+	assert.Equal(t, `{"nam`, string(captured.Body()))
+	assert.Equal(t, []interface{}{
+		"header.content-type", "application/json",
+		"header.cache-control", "no-cache,private",
+		"body", `{"nam`,
+	}, capturedKeyValues(captured))
+
+	req, _ = http.NewRequest("GET", "/empty", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, http.Header{"Cache-Control": {"no-store"}}, captured.Headers())
+	assert.Equal(t, "", string(captured.Body()))
+
+	req, _ = http.NewRequest("GET", "/login", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, routing.Redacted, string(captured.Body()))
+
+	// nothing is captured by default
+	h = CustomLogger(func(req *http.Request, rw *LogResponseWriter, elapsed float64) {
+		captured = rw
+	})
+	req, _ = http.NewRequest("GET", "/users", nil)
+	c := routing.NewContext(httptest.NewRecorder(), req, h, func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+	assert.Nil(t, c.Next())
+	assert.Nil(t, captured.Headers())
+	assert.Nil(t, captured.Body())
+	assert.Equal(t, []interface{}{}, capturedKeyValues(captured))
+
+	logger := &testLogger{}
+	req, _ = http.NewRequest("GET", "/users", nil)
+	c = routing.NewContext(httptest.NewRecorder(), req, LeveledLogger(logger, Options{Headers: []string{"Content-Type"}, BodySize: 10}), func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/plain")
+		return c.Write("ok")
+	})
+	assert.Nil(t, c.Next())
+	assert.Regexp(t, ` header.content-type text/plain body ok\]\n$`, logger.String())
+}
+
+func TestLoggerExit(t *testing.T) {
+	var captured *LogResponseWriter
+	logger := &testLogger{}
+	router := routing.New()
+	router.Use(
+		CustomLogger(func(req *http.Request, res *LogResponseWriter, elapsed float64) {
+			captured = res
+		}, Options{Exit: true}),
+		LeveledLogger(logger, Options{Exit: true}),
+	)
+	router.Get("/users", func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusUnauthorized)
+	}, func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	if assert.NotNil(t, captured.Exit()) {
+		assert.Equal(t, 2, captured.Exit().Index)
+		assert.Equal(t, routing.ExitError, captured.Exit().Reason)
+	}
+	assert.Regexp(t, ` exit.handler access.TestLoggerExit.func2 exit.reason error\]\n$`, logger.String())
+}
+
+func TestLoggerHijack(t *testing.T) {
+	logs := make(chan string, 2)
+	router := routing.New()
+	router.Use(Logger(func(format string, a ...interface{}) {
+		logs <- fmt.Sprintf(format, a...)
+	}))
+	router.Get("/ws", func(c *routing.Context) error {
+		conn, _, err := c.Response.(http.Hijacker).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r\nhello"))
+		return err
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	res, err := http.ReadResponse(reader, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+		data, _ := ioutil.ReadAll(reader)
+		assert.Equal(t, "hello", string(data))
+	}
+	assert.Regexp(t, `GET /ws HTTP/1.1 101 streaming$`, <-logs)
+	assert.Regexp(t, `GET /ws HTTP/1.1 101 61$`, <-logs)
+
+	// the wrapped writer does not support hijacking
+	w := newLogResponseWriter(httptest.NewRecorder(), Options{}, nil)
+	_, _, err = w.Hijack()
+	assert.Equal(t, http.ErrNotSupported, err)
+	assert.False(t, w.Streaming())
+}
+
 func TestGetClientIP(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/users/", nil)
 	req.Header.Set("X-Real-IP", "192.168.100.1")
@@ -70,6 +302,11 @@ func TestGetClientIP(t *testing.T) {
 
 	req.RemoteAddr = "192.168.100.3:8080"
 	assert.Equal(t, "192.168.100.3", GetClientIP(req))
+
+	req.RemoteAddr = "[2001:db8::1]:8080"
+	assert.Equal(t, "2001:db8::1", GetClientIP(req))
+	req.RemoteAddr = "2001:db8::1"
+	assert.Equal(t, "2001:db8::1", GetClientIP(req))
 }
 
 func getLogger(buf *bytes.Buffer) LogFunc {
@@ -81,3 +318,72 @@ func getLogger(buf *bytes.Buffer) LogFunc {
 func handler1(c *routing.Context) error {
 	return errors.New("abc")
 }
+
+type testLogger struct {
+	bytes.Buffer
+}
+
+func (l *testLogger) Debug(msg string, kv ...interface{}) { fmt.Fprintln(l, "D", msg, kv) }
+func (l *testLogger) Info(msg string, kv ...interface{})  { fmt.Fprintln(l, "I", msg, kv) }
+func (l *testLogger) Warn(msg string, kv ...interface{})  { fmt.Fprintln(l, "W", msg, kv) }
+func (l *testLogger) Error(msg string, kv ...interface{}) { fmt.Fprintln(l, "E", msg, kv) }
+
+func TestLeveledLogger(t *testing.T) {
+	logger := &testLogger{}
+	router := routing.New()
+	router.Use(LeveledLogger(logger))
+	router.Get("/ok", func(c *routing.Context) error { return c.Write("ok") })
+	router.Get("/missing", func(c *routing.Context) error {
+		c.Response.WriteHeader(http.StatusNotFound)
+		return nil
+	})
+	router.Get("/error", func(c *routing.Context) error {
+		c.Response.WriteHeader(http.StatusInternalServerError)
+		return c.Write("fail")
+	})
+
+	for _, path := range []string{"/ok", "/missing", "/error"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://127.0.0.1"+path, nil)
+		req.RemoteAddr = "192.168.100.1:1234"
+		router.ServeHTTP(res, req)
+	}
+	assert.Regexp(t, `^I GET http://127.0.0.1/ok HTTP/1.1 \[ip 192.168.100.1 status 200 size 2 duration [\d.e-]+\]
+W GET http://127.0.0.1/missing HTTP/1.1 \[ip 192.168.100.1 status 404 size 0 duration [\d.e-]+\]
+E GET http://127.0.0.1/error HTTP/1.1 \[ip 192.168.100.1 status 500 size 4 duration [\d.e-]+\]
+$`, logger.String())
+}
+
+func TestLeveledLoggerStreaming(t *testing.T) {
+	logger := &testLogger{}
+	router := routing.New()
+	router.Use(LeveledLogger(logger))
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		return c.Write("data: a\n\n")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/events", nil)
+	req.RemoteAddr = "192.168.100.1:1234"
+	router.ServeHTTP(res, req)
+	assert.Regexp(t, `^I GET http://127.0.0.1/events HTTP/1.1 \[ip 192.168.100.1 status 200 streaming true\]
+I GET http://127.0.0.1/events HTTP/1.1 \[ip 192.168.100.1 status 200 size 9 duration [\d.e-]+ streaming true\]
+$`, logger.String())
+}
+
+func TestLoggerBot(t *testing.T) {
+	logger := &testLogger{}
+	router := routing.New()
+	router.Use(LeveledLogger(logger, Options{Bot: true}), routing.BotDetector(routing.BotOptions{}))
+	router.Get("/", func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+
+	for _, ua := range []string{"Mozilla/5.0 (compatible; bingbot/2.0)", "Mozilla/5.0 Firefox/120.0"} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", ua)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Regexp(t, `duration [\d.e-]+ bot bingbot\]\n.*duration [\d.e-]+\]\n$`, logger.String())
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package access
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// SlogLogger returns a handler that logs a message for every request using the given slog logger.
+// The message is the request line, and the attributes include those returned by routing.SlogAttrs, the client IP
+// ("ip"), response status ("status"), response size ("size"), and time used to serve the request ("duration").
+// Like LeveledLogger, the message is logged at the error level if the response status is 5xx, at the warning level
+// if it is 4xx, and at the info level otherwise. The streaming responses and the TLS versions are logged
+// in the same way as LeveledLogger, and so are the requests matching the sensitive routes (see CustomLogger).
+//
+//     import (
+//         "log/slog"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/access"
+//     )
+//
+//     r := routing.New()
+//     r.Use(access.SlogLogger(slog.Default()))
+func SlogLogger(logger *slog.Logger, opts ...Options) routing.Handler {
+	options := getOptions(opts)
+	return func(c *routing.Context) error {
+		sensitivity := c.Sensitivity()
+		if sensitivity != nil && sensitivity.Skip {
+			return c.Next()
+		}
+		startTime := time.Now()
+
+		req := sensitivity.Redact(c.Request)
+		requestLine := fmt.Sprintf("%s %s %s", req.Method, req.URL.String(), req.Proto)
+		rw := newLogResponseWriter(c.Response, options, sensitivity)
+		if !options.SkipStreamStart {
+			rw.state().onStream = func() {
+				attrs := append(routing.SlogAttrs(c),
+					slog.String("ip", GetClientIP(req)),
+					slog.Int("status", rw.Status),
+					slog.Bool("streaming", true),
+				)
+				if options.TLS {
+					attrs = append(attrs, slog.String("tls", tlsVersion(req)))
+				}
+				logger.LogAttrs(req.Context(), slog.LevelInfo, requestLine, attrs...)
+			}
+		}
+		c.Response = rw
+		if options.Exit {
+			c.TrackChainExit()
+		}
+
+		err := c.Next()
+
+		rw.close()
+		level := slog.LevelInfo
+		if rw.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if rw.Status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		attrs := append(routing.SlogAttrs(c),
+			slog.String("ip", GetClientIP(req)),
+			slog.Int("status", rw.Status),
+			slog.Int64("size", rw.BytesWritten),
+			slog.Duration("duration", time.Since(startTime)),
+		)
+		if rw.Streaming() {
+			attrs = append(attrs, slog.Bool("streaming", true))
+		}
+		if options.TLS {
+			attrs = append(attrs, slog.String("tls", tlsVersion(req)))
+		}
+		kv := capturedKeyValues(rw)
+		for i := 0; i < len(kv); i += 2 {
+			attrs = append(attrs, slog.String(kv[i].(string), kv[i+1].(string)))
+		}
+		if options.Exit {
+			exit := c.ChainExit()
+			attrs = append(attrs, slog.String("exit.handler", exit.Handler), slog.String("exit.reason", exit.Reason))
+		}
+		if bot := c.Bot(); options.Bot && bot != nil {
+			attrs = append(attrs, slog.String("bot", botName(bot)))
+		}
+		logger.LogAttrs(req.Context(), level, requestLine, attrs...)
+
+		return err
+	}
+}
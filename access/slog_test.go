@@ -0,0 +1,107 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package access
+
+import (
+	"bytes"
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	router := routing.New()
+	router.Use(SlogLogger(logger))
+	router.Get("/users/<id>", func(c *routing.Context) error { return c.Write("ok") })
+	router.Get("/error", func(c *routing.Context) error {
+		c.Response.WriteHeader(http.StatusInternalServerError)
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/users/1", nil)
+	req.RemoteAddr = "192.168.100.1:1234"
+	req.Header.Set("X-Request-ID", "abc")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, `level=INFO msg="GET http://127.0.0.1/users/1 HTTP/1.1" request_id=abc method=GET path=/users/1 route=/users/<id> ip=192.168.100.1 status=200 size=2`+"\n", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "http://127.0.0.1/error", nil)
+	req.RemoteAddr = "192.168.100.1:1234"
+	router.ServeHTTP(res, req)
+	assert.Equal(t, `level=ERROR msg="GET http://127.0.0.1/error HTTP/1.1" method=GET path=/error route=/error ip=192.168.100.1 status=500 size=0`+"\n", buf.String())
+}
+
+func TestSlogLoggerStreaming(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	router := routing.New()
+	router.Use(SlogLogger(logger))
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream")
+		return c.Write("data: a\n\n")
+	})
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1/events", nil)
+	req.RemoteAddr = "192.168.100.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `level=INFO msg="GET http://127.0.0.1/events HTTP/1.1" method=GET path=/events route=/events ip=192.168.100.1 status=200 streaming=true
+level=INFO msg="GET http://127.0.0.1/events HTTP/1.1" method=GET path=/events route=/events ip=192.168.100.1 status=200 size=9 streaming=true
+`, buf.String())
+
+	buf.Reset()
+	router = routing.New()
+	router.Use(SlogLogger(logger, Options{SkipStreamStart: true}))
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream")
+		return c.Write("data: a\n\n")
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `level=INFO msg="GET http://127.0.0.1/events HTTP/1.1" method=GET path=/events route=/events ip=192.168.100.1 status=200 size=9 streaming=true
+`, buf.String())
+}
+
+func TestSlogLoggerTLS(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	router := routing.New()
+	router.Use(SlogLogger(logger, Options{TLS: true}))
+	router.Get("/users", func(c *routing.Context) error { return c.Write("ok") })
+
+	req, _ := http.NewRequest("GET", "https://127.0.0.1/users", nil)
+	req.RemoteAddr = "192.168.100.1:1234"
+	req.TLS = &tls.ConnectionState{Version: tls.VersionTLS13}
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `level=INFO msg="GET https://127.0.0.1/users HTTP/1.1" method=GET path=/users route=/users ip=192.168.100.1 status=200 size=2 tls="TLS 1.3"`+"\n", buf.String())
+}
@@ -0,0 +1,228 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// RouteConflict describes a route whose matching requests may be taken over by another route
+	// that takes precedence over it.
+	RouteConflict struct {
+		Route *Route // the route that loses the requests
+		Other *Route // the route that takes precedence
+	}
+
+	// RouteReport is the result of analyzing the routing table by Router.Analyze.
+	RouteReport struct {
+		// Unreachable lists the routes that can never be matched because every request matching
+		// them is matched by another route first.
+		Unreachable []RouteConflict
+		// Ambiguous lists the routes that share some but not all matching requests with another route
+		// taking precedence over them.
+		Ambiguous []RouteConflict
+	}
+
+	// patternToken is a literal character or a parameter in a route path.
+	patternToken struct {
+		literal byte
+		param   *paramClass
+	}
+
+	// paramClass describes the strings that can be matched by a parameter.
+	paramClass struct {
+		pattern string
+		chars   int // the characters matched by the parameter: anyChars, segmentChars, digitChars, or unknownChars
+		min     int // the minimal length of the matching strings
+	}
+)
+
+const (
+	unknownChars = iota
+	digitChars
+	segmentChars
+	anyChars
+)
+
+// paramClasses lists the parameter patterns whose matching strings are known to the analyzer.
+var paramClasses = map[string]paramClass{
+	"":       {"", segmentChars, 0},
+	"[^/]*":  {"[^/]*", segmentChars, 0},
+	"[^/]+":  {"[^/]+", segmentChars, 1},
+	".*":     {".*", anyChars, 0},
+	".+":     {".+", anyChars, 1},
+	`\d*`:    {`\d*`, digitChars, 0},
+	`\d+`:    {`\d+`, digitChars, 1},
+	"[0-9]*": {"[0-9]*", digitChars, 0},
+	"[0-9]+": {"[0-9]+", digitChars, 1},
+}
+
+// Analyze examines the routing table and reports the routes that are unreachable because they are shadowed
+// by the routes taking precedence over them (e.g. "/users/me" added after "/users/<id>"), as well as the routes
+// that overlap with such routes. Only the routes bound to the same scheme and HTTP method are compared.
+// Parameters with regular expressions other than the common ones (such as \d+ and [^/]+) are assumed to
+// match any non-empty string, so the report is a heuristic suitable for checking the routing table in CI:
+//
+//     if report := router.Analyze(); len(report.Unreachable) > 0 {
+//         log.Fatal(report)
+//     }
+func (r *Router) Analyze() *RouteReport {
+	report := &RouteReport{}
+	keys := map[string][]*Route{}
+	for _, route := range r.routes {
+		key := route.group.scheme + " " + route.method
+		keys[key] = append(keys[key], route)
+	}
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		routes := keys[key]
+		sort.SliceStable(routes, func(i, j int) bool {
+			return routes[i].priority > routes[j].priority
+		})
+		patterns := make([][]patternToken, len(routes))
+		for i, route := range routes {
+			patterns[i] = parsePattern(storeKey(route))
+		}
+	next:
+		for j := 1; j < len(routes); j++ {
+			for i := 0; i < j; i++ {
+				if covers(patterns[i], patterns[j]) {
+					report.Unreachable = append(report.Unreachable, RouteConflict{routes[j], routes[i]})
+					continue next
+				}
+			}
+			for i := 0; i < j; i++ {
+				if overlaps(patterns[i], patterns[j]) {
+					report.Ambiguous = append(report.Ambiguous, RouteConflict{routes[j], routes[i]})
+				}
+			}
+		}
+	}
+	return report
+}
+
+// String returns a human readable description of the report, one conflict per line.
+func (r *RouteReport) String() string {
+	var s strings.Builder
+	for _, c := range r.Unreachable {
+		fmt.Fprintf(&s, "unreachable: %v is shadowed by %v\n", c.Route, c.Other)
+	}
+	for _, c := range r.Ambiguous {
+		fmt.Fprintf(&s, "ambiguous: %v overlaps with %v\n", c.Route, c.Other)
+	}
+	return s.String()
+}
+
+// parsePattern splits a route path into literal characters and parameters.
+func parsePattern(path string) []patternToken {
+	tokens := []patternToken{}
+	for i := 0; i < len(path); i++ {
+		end := -1
+		if path[i] == '<' {
+			end = strings.IndexByte(path[i:], '>')
+		}
+		if end < 0 {
+			tokens = append(tokens, patternToken{literal: path[i]})
+			continue
+		}
+		pattern := ""
+		if j := strings.IndexByte(path[i:i+end], ':'); j >= 0 {
+			pattern = path[i+j+1 : i+end]
+		}
+		class, ok := paramClasses[pattern]
+		if !ok {
+			class = paramClass{pattern, unknownChars, 1}
+		}
+		tokens = append(tokens, patternToken{param: &class})
+		i += end
+	}
+	return tokens
+}
+
+// accepts checks if the parameter may match the given character.
+func (p *paramClass) accepts(c byte) bool {
+	switch p.chars {
+	case anyChars, unknownChars:
+		return true
+	case segmentChars:
+		return c != '/'
+	}
+	return c >= '0' && c <= '9'
+}
+
+// covers checks if the pattern a matches every path matched by the pattern b.
+func covers(a, b []patternToken) bool {
+	if len(a) == 0 {
+		return len(b) == 0
+	}
+	if a[0].param == nil {
+		return len(b) > 0 && b[0].param == nil && b[0].literal == a[0].literal && covers(a[1:], b[1:])
+	}
+	p := a[0].param
+	// the parameter consumes the tokens b[:k] which may be matched as a whole by it
+	length := 0
+	for k := 0; k <= len(b); k++ {
+		if length >= p.min && covers(a[1:], b[k:]) {
+			return true
+		}
+		if k == len(b) {
+			break
+		}
+		if t := b[k]; t.param == nil {
+			if p.chars == unknownChars || !p.accepts(t.literal) {
+				break
+			}
+			length++
+		} else if p.chars == anyChars || p.chars != unknownChars && t.param.chars != unknownChars && p.chars >= t.param.chars {
+			length += t.param.min
+		} else {
+			// a parameter with an unknown pattern is only covered by an identical one
+			return k == 0 && p.pattern == t.param.pattern && covers(a[1:], b[1:])
+		}
+	}
+	return false
+}
+
+// overlaps checks if some paths are matched by both patterns a and b.
+func overlaps(a, b []patternToken) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return canBeEmpty(a) && canBeEmpty(b)
+	}
+	if a[0].param == nil && b[0].param == nil {
+		return a[0].literal == b[0].literal && overlaps(a[1:], b[1:])
+	}
+	return a[0].param != nil && consumes(a[0].param, a[1:], b) ||
+		b[0].param != nil && consumes(b[0].param, b[1:], a)
+}
+
+// consumes checks if the parameter p followed by the pattern a may match a path matched by the pattern b.
+func consumes(p *paramClass, a, b []patternToken) bool {
+	for k := 0; k <= len(b); k++ {
+		if (k > 0 || p.min == 0) && overlaps(a, b[k:]) {
+			return true
+		}
+		if k < len(b) && b[k].param == nil && !p.accepts(b[k].literal) {
+			break
+		}
+	}
+	return false
+}
+
+// canBeEmpty checks if the pattern may match an empty string.
+func canBeEmpty(tokens []patternToken) bool {
+	for _, t := range tokens {
+		if t.param == nil || t.param.min > 0 {
+			return false
+		}
+	}
+	return true
+}
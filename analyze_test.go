@@ -0,0 +1,64 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoversOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b             string
+		covers, overlaps bool
+	}{
+		{"/users", "/users", true, true},
+		{"/users", "/user", false, false},
+		{"/users", "/users/<id>", false, false},
+		{"/users/<id>", "/users/me", true, true},
+		{"/users/me", "/users/<id>", false, true},
+		{"/users/<id>", "/users/<name:[^/]+>", true, true},
+		{"/users/<id:\\d+>", "/users/<id>", false, true},
+		{"/users/<id:\\d+>", "/users/me", false, false},
+		{"/users/<id:\\d+>", "/users/123", true, true},
+		{"/users/<id>", "/users/<id>/posts", false, false},
+		{"/users/<:.*>", "/users/<id>/posts", true, true},
+		{"/users/<:.*>", "/users", false, false},
+		{"/users<:.*>", "/users", true, true},
+		{"/<:.+>", "/", false, false},
+		{"/users/<id:[a-z]+>", "/users/<name:[a-z]+>", true, true},
+		{"/users/<id:[a-z]+>", "/users/me", false, true},
+		{"/users/<id>/<:.*>", "/users/me/posts", true, true},
+		{"/users/<id>/posts", "/users/me/<x>", false, true},
+	}
+	for _, test := range tests {
+		a, b := parsePattern(test.a), parsePattern(test.b)
+		assert.Equal(t, test.covers, covers(a, b), "covers "+test.a+" "+test.b)
+		assert.Equal(t, test.overlaps, overlaps(a, b), "overlaps "+test.a+" "+test.b)
+	}
+}
+
+func TestRouterAnalyze(t *testing.T) {
+	router := New()
+	router.Get("/users/<id>")
+	router.Get("/users/me")
+	router.Get("/users/<id>/posts")
+	router.Get("/posts/*")
+	router.Get("/posts/<id:\\d+>")
+	router.Post("/users/me")
+	router.Post("/users/<id>")
+	router.Get("/orders/latest").Priority(1)
+	router.Get("/orders/<id>")
+	router.Scheme("https").Get("/users/<id>")
+
+	report := router.Analyze()
+	assert.Equal(t, "unreachable: GET /users/me is shadowed by GET /users/<id>\n"+
+		"unreachable: GET /posts/<id:\\d+> is shadowed by GET /posts/*\n"+
+		"ambiguous: GET /orders/<id> overlaps with GET /orders/latest\n"+
+		"ambiguous: POST /users/<id> overlaps with POST /users/me\n", report.String())
+
+	assert.Equal(t, "", New().Analyze().String())
+}
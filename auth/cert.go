@@ -0,0 +1,122 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"bufio"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// CertAllowlist is a list of client certificate identities that are allowed to access the routes protected
+// by ClientCert. An identity matches a certificate if it equals the subject common name, or any of the DNS names,
+// email addresses, or URIs in the subject alternative names of the certificate. The identity "*" matches
+// any verified certificate.
+//
+// The identities can be replaced at any time by calling Set or Load, which makes it possible to
+// update the access policy without restarting the server. CertAllowlist is safe for concurrent use.
+type CertAllowlist struct {
+	identities atomic.Value
+}
+
+// NewCertAllowlist creates a CertAllowlist with the given identities.
+func NewCertAllowlist(identities ...string) *CertAllowlist {
+	l := &CertAllowlist{}
+	l.Set(identities...)
+	return l
+}
+
+// Set replaces the identities in the allowlist with the given ones.
+func (l *CertAllowlist) Set(identities ...string) {
+	m := make(map[string]bool, len(identities))
+	for _, identity := range identities {
+		m[identity] = true
+	}
+	l.identities.Store(m)
+}
+
+// Load replaces the identities in the allowlist with those listed in the given file, one identity per line.
+// Empty lines and lines starting with "#" are ignored. If the file cannot be read, the allowlist is unchanged.
+func (l *CertAllowlist) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	identities := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			identities = append(identities, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	l.Set(identities...)
+	return nil
+}
+
+// Allows checks if the given certificate matches any identity in the allowlist.
+func (l *CertAllowlist) Allows(cert *x509.Certificate) bool {
+	m, _ := l.identities.Load().(map[string]bool)
+	if m["*"] || m[cert.Subject.CommonName] && cert.Subject.CommonName != "" {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if m[name] {
+			return true
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if m[email] {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if m[uri.String()] {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientCert returns a routing.Handler that restricts access to the clients presenting a TLS certificate
+// whose identity is in the given allowlist. It should be used with a server that requests and verifies
+// client certificates (e.g. tls.Config.ClientAuth is tls.VerifyClientCertIfGiven), so that different
+// route groups can be restricted to different client identities:
+//
+//   import (
+//     "github.com/go-ozzo/ozzo-routing/v2"
+//     "github.com/go-ozzo/ozzo-routing/v2/auth"
+//   )
+//
+//   allowlist := auth.NewCertAllowlist("billing.internal", "spiffe://example.org/admin")
+//   r := routing.New()
+//   admin := r.Group("/admin", auth.ClientCert(allowlist))
+//
+// Only certificates verified by the server are accepted. If the request has no such certificate,
+// an http.StatusUnauthorized error will be returned; if the certificate is not allowed, an http.StatusForbidden
+// error will be returned. Otherwise, the verified certificate (*x509.Certificate) will be made available
+// as the user identity.
+func ClientCert(allowlist *CertAllowlist) routing.Handler {
+	return func(c *routing.Context) error {
+		tls := c.Request.TLS
+		if tls == nil || len(tls.VerifiedChains) == 0 || len(tls.VerifiedChains[0]) == 0 {
+			return routing.NewHTTPError(http.StatusUnauthorized, "a verified client certificate is required")
+		}
+		cert := tls.VerifiedChains[0][0]
+		if !allowlist.Allows(cert) {
+			return routing.NewHTTPError(http.StatusForbidden, "the client certificate is not allowed")
+		}
+		c.Set(User, Identity(cert))
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertAllowlist(t *testing.T) {
+	uri, _ := url.Parse("spiffe://example.org/admin")
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "billing"},
+		DNSNames:       []string{"billing.internal"},
+		EmailAddresses: []string{"ops@example.org"},
+		URIs:           []*url.URL{uri},
+	}
+	l := NewCertAllowlist()
+	assert.False(t, l.Allows(cert))
+	for _, identity := range []string{"billing", "billing.internal", "ops@example.org", "spiffe://example.org/admin", "*"} {
+		l.Set(identity)
+		assert.True(t, l.Allows(cert), identity)
+	}
+	l.Set("other")
+	assert.False(t, l.Allows(cert))
+	l.Set("")
+	assert.False(t, l.Allows(&x509.Certificate{}))
+
+	file, _ := ioutil.TempFile("", "allowlist")
+	defer os.Remove(file.Name())
+	file.WriteString("# allowed clients\n\n  billing.internal  \nother\n")
+	file.Close()
+	assert.Nil(t, l.Load(file.Name()))
+	assert.True(t, l.Allows(cert))
+	assert.NotNil(t, l.Load(file.Name()+".missing"))
+	assert.True(t, l.Allows(cert))
+}
+
+func TestClientCert(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+	h := ClientCert(NewCertAllowlist("billing"))
+
+	req, _ := http.NewRequest("GET", "/admin", nil)
+	c := routing.NewContext(httptest.NewRecorder(), req)
+	err := h(c)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, err.(routing.HTTPError).StatusCode())
+	}
+
+	// unverified certificates are not accepted
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	err = h(c)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, err.(routing.HTTPError).StatusCode())
+	}
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	assert.Nil(t, h(c))
+	assert.Equal(t, cert, c.Get(User))
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "other"}}}}
+	err = h(c)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusForbidden, err.(routing.HTTPError).StatusCode())
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Binding binds the methods of a controller struct to routes as handlers. The dependencies of the handlers,
+// such as a database connection, are injected into the controller when the binding is created, so that they
+// do not need to be global variables or captured by closures. A controller method can be used as a handler
+// if it has the signature of Handler, i.e., func(*routing.Context) error.
+type Binding struct {
+	controller reflect.Value
+	perRequest bool
+	methods    map[string]int
+}
+
+// Bind creates a Binding that calls the methods of the given controller for all requests.
+// The controller is shared by concurrent requests and must be safe for concurrent use.
+//
+//     users := routing.Bind(&UserController{DB: db})
+//     router.Get("/users/<id>", users.Handler("Show"))
+func Bind(controller interface{}) *Binding {
+	return newBinding(controller, false)
+}
+
+// BindPerRequest creates a Binding that constructs a new controller for every request by copying the given
+// controller, which must be a pointer to a struct. The injected dependencies are shared by the copies, while the
+// changes made to the controller fields by the handlers of a request are not visible to other requests.
+func BindPerRequest(controller interface{}) *Binding {
+	v := reflect.ValueOf(controller)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("the controller must be a pointer to a struct, %T given", controller))
+	}
+	return newBinding(controller, true)
+}
+
+func newBinding(controller interface{}, perRequest bool) *Binding {
+	v := reflect.ValueOf(controller)
+	handlerType := reflect.TypeOf((*Handler)(nil)).Elem()
+	methods := map[string]int{}
+	for i := 0; i < v.NumMethod(); i++ {
+		if v.Method(i).Type().ConvertibleTo(handlerType) {
+			methods[v.Type().Method(i).Name] = i
+		}
+	}
+	return &Binding{controller: v, perRequest: perRequest, methods: methods}
+}
+
+// Has checks if the controller has a method with the given name that can be used as a handler.
+func (b *Binding) Has(method string) bool {
+	_, ok := b.methods[method]
+	return ok
+}
+
+// Handler returns a handler that calls the named method of the controller.
+// It panics if the controller has no such method that can be used as a handler.
+func (b *Binding) Handler(method string) Handler {
+	i, ok := b.methods[method]
+	if !ok {
+		panic(fmt.Sprintf("%v has no handler method named %q", b.controller.Type(), method))
+	}
+	if !b.perRequest {
+		return b.controller.Method(i).Interface().(func(*Context) error)
+	}
+	return func(c *Context) error {
+		v := reflect.New(b.controller.Type().Elem())
+		v.Elem().Set(b.controller.Elem())
+		return v.Method(i).Interface().(func(*Context) error)(c)
+	}
+}
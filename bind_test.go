@@ -0,0 +1,73 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type counterController struct {
+	Prefix string
+	count  int
+}
+
+func (cc *counterController) Index(c *Context) error {
+	cc.count++
+	return c.Write(fmt.Sprintf("%v%v", cc.Prefix, cc.count))
+}
+
+func (cc *counterController) Show(c *Context) error {
+	return c.Write(cc.Prefix + c.Param("id"))
+}
+
+func (cc *counterController) Helper() string {
+	return cc.Prefix
+}
+
+func TestBind(t *testing.T) {
+	b := Bind(&counterController{Prefix: "s"})
+	assert.True(t, b.Has("Index"))
+	assert.True(t, b.Has("Show"))
+	assert.False(t, b.Has("Helper"))
+	assert.False(t, b.Has("Update"))
+	assert.Panics(t, func() { b.Handler("Helper") })
+
+	router := New()
+	router.Get("/singleton", b.Handler("Index"))
+	router.Get("/request", BindPerRequest(&counterController{Prefix: "r"}).Handler("Index"))
+
+	for _, expected := range []string{"s1", "s2"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/singleton", nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, expected, res.Body.String())
+	}
+	for _, expected := range []string{"r1", "r1"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/request", nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, expected, res.Body.String())
+	}
+
+	assert.Panics(t, func() { BindPerRequest(counterController{}) })
+}
+
+func TestBindResource(t *testing.T) {
+	router := New()
+	router.Resource("/counters", BindPerRequest(&counterController{Prefix: "c"}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/counters/5", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "c5", res.Body.String())
+
+	assert.NotNil(t, router.Route("counters.index"))
+	assert.Nil(t, router.Route("counters.update"))
+}
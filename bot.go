@@ -0,0 +1,235 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RequestBot is the key used to store and retrieve the bot detected by BotDetector in Context.
+const RequestBot = "Bot"
+
+type (
+	// Bot describes a bot or crawler sending a request.
+	Bot struct {
+		// Name is the name of the bot, e.g. "Googlebot".
+		Name string
+		// Verified indicates whether the client IP address is verified to belong to the bot by VerifyBot.
+		Verified bool
+		// Spoofed indicates whether the client claims to be a bot that can be verified, but the verification fails.
+		// It is false if the verification cannot be completed, e.g. because of a DNS failure.
+		Spoofed bool
+	}
+
+	// BotClassifier classifies the client sending a request. It returns nil if the client is not a bot.
+	BotClassifier func(c *Context) *Bot
+
+	// BotResolver looks up the host names of IP addresses and the IP addresses of host names.
+	// It is satisfied by *net.Resolver.
+	BotResolver interface {
+		LookupAddr(ctx context.Context, addr string) ([]string, error)
+		LookupHost(ctx context.Context, host string) ([]string, error)
+	}
+
+	// BotOptions specifies how BotDetector detects bots.
+	BotOptions struct {
+		// Classifier classifies the clients. Defaults to UserAgentBots(DefaultBotPatterns...).
+		Classifier BotClassifier
+		// Domains maps the names of the bots to the domains of their host names, which are used to verify
+		// the bots via VerifyBot. Nil disables verification. DefaultBotDomains lists those of the major crawlers.
+		Domains map[string][]string
+		// Resolver resolves the host names and IP addresses when verifying bots. Defaults to net.DefaultResolver.
+		Resolver BotResolver
+		// ClientIP returns the client IP address of a request. Defaults to the host part of http.Request.RemoteAddr.
+		ClientIP func(*http.Request) string
+		// CacheSize is the maximum number of the client IP addresses whose verification results are cached.
+		// Defaults to 10000.
+		CacheSize int
+	}
+)
+
+// DefaultBotPatterns lists the User-Agent substrings of the common bots and crawlers, which are matched
+// case-insensitively in order. The generic ones are listed last.
+var DefaultBotPatterns = []string{
+	"Googlebot", "AdsBot-Google", "Mediapartners-Google", "bingbot", "Slurp", "DuckDuckBot", "Baiduspider",
+	"YandexBot", "Applebot", "facebookexternalhit", "Twitterbot", "LinkedInBot", "AhrefsBot", "SemrushBot",
+	"MJ12bot", "DotBot", "PetalBot", "GPTBot", "CCBot",
+	"bot", "crawler", "spider",
+}
+
+// DefaultBotDomains maps the names of the major crawlers to the domains of their host names, as published by
+// the search engines for verifying their crawlers via reverse DNS lookups.
+var DefaultBotDomains = map[string][]string{
+	"Googlebot":            {"googlebot.com", "google.com"},
+	"AdsBot-Google":        {"googlebot.com", "google.com"},
+	"Mediapartners-Google": {"googlebot.com", "google.com"},
+	"bingbot":              {"search.msn.com"},
+	"Applebot":             {"applebot.apple.com"},
+	"YandexBot":            {"yandex.ru", "yandex.net", "yandex.com"},
+	"Baiduspider":          {"baidu.com", "baidu.jp"},
+}
+
+// UserAgentBots returns a BotClassifier that classifies a client as a bot if its User-Agent header contains
+// any of the given patterns case-insensitively. The name of the bot is the first matching pattern.
+func UserAgentBots(patterns ...string) BotClassifier {
+	lower := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lower[i] = strings.ToLower(pattern)
+	}
+	return func(c *Context) *Bot {
+		ua := strings.ToLower(c.Request.Header.Get("User-Agent"))
+		if ua == "" {
+			return nil
+		}
+		for i, pattern := range lower {
+			if strings.Contains(ua, pattern) {
+				return &Bot{Name: patterns[i]}
+			}
+		}
+		return nil
+	}
+}
+
+// BotDetector returns a handler that detects whether the current request is sent by a bot or crawler,
+// so that the following handlers, such as rate limiters and access loggers, can treat the crawler traffic
+// differently. The detected bot can be retrieved via Context.Bot. Because the User-Agent header can be forged,
+// the bots listed in BotOptions.Domains are verified by the reverse DNS lookups of the client IP addresses.
+// For example,
+//
+//     r := routing.New()
+//     r.Use(routing.BotDetector(routing.BotOptions{Domains: routing.DefaultBotDomains}))
+//     r.Get("/search", func(c *routing.Context) error {
+//         if bot := c.Bot(); bot != nil && bot.Spoofed {
+//             return routing.NewHTTPError(http.StatusForbidden)
+//         }
+//         ...
+//     })
+func BotDetector(opts BotOptions) Handler {
+	if opts.Classifier == nil {
+		opts.Classifier = UserAgentBots(DefaultBotPatterns...)
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+	if opts.ClientIP == nil {
+		opts.ClientIP = remoteIP
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	var mu sync.Mutex
+	verified := map[string]bool{}
+	return func(c *Context) error {
+		bot := opts.Classifier(c)
+		if bot == nil {
+			return nil
+		}
+		if domains := opts.Domains[bot.Name]; len(domains) > 0 {
+			ip := opts.ClientIP(c.Request)
+			key := bot.Name + " " + ip
+			mu.Lock()
+			ok, found := verified[key]
+			mu.Unlock()
+			if !found {
+				var err error
+				if ok, err = VerifyBot(c.Request.Context(), opts.Resolver, ip, domains...); err == nil {
+					mu.Lock()
+					if len(verified) >= opts.CacheSize {
+						verified = map[string]bool{}
+					}
+					verified[key] = ok
+					mu.Unlock()
+					found = true
+				}
+			}
+			bot.Verified, bot.Spoofed = ok, found && !ok
+		}
+		c.Set(RequestBot, bot)
+		return nil
+	}
+}
+
+// Bot returns the bot sending the current request as detected by BotDetector, or nil if the request is not
+// sent by a bot or BotDetector is not used.
+func (c *Context) Bot() *Bot {
+	bot, _ := c.Get(RequestBot).(*Bot)
+	return bot
+}
+
+// IsBot returns whether the current request is sent by a bot as detected by BotDetector.
+func (c *Context) IsBot() bool {
+	return c.Bot() != nil
+}
+
+// BotKey returns a key function for rate limiting or sampling that returns "bot:" followed by the bot name
+// for the requests sent by bots, and the key returned by the given function otherwise. This makes all requests
+// of a bot share the same key, so that they are limited together regardless of their client IP addresses.
+// The spoofed bots (see Bot.Spoofed) get the keys returned by the given function, so that they cannot
+// share the limits of the bots they claim to be.
+func BotKey(key SplitKeyFunc) SplitKeyFunc {
+	return func(c *Context) string {
+		if bot := c.Bot(); bot != nil && !bot.Spoofed {
+			return "bot:" + bot.Name
+		}
+		return key(c)
+	}
+}
+
+// VerifyBot verifies that the given IP address belongs to any of the given domains by looking up its host names
+// and confirming that a host name in the domains resolves back to the IP address, which is the method recommended
+// by the major search engines to verify their crawlers. An error is returned if the lookups fail for reasons
+// other than the IP address or the host name not being found.
+func VerifyBot(ctx context.Context, resolver BotResolver, ip string, domains ...string) (bool, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false, nil
+	}
+	names, err := resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		return false, lookupError(err)
+	}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if !inDomains(name, domains) {
+			continue
+		}
+		ips, err := resolver.LookupHost(ctx, name)
+		if err != nil {
+			if err = lookupError(err); err != nil {
+				return false, err
+			}
+			continue
+		}
+		for _, s := range ips {
+			if addr.Equal(net.ParseIP(s)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// inDomains checks if the host name is any of the given domains or their subdomains.
+func inDomains(name string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupError returns nil if the given DNS lookup error means the name is not found, or the error otherwise.
+func lookupError(err error) error {
+	if e, ok := err.(*net.DNSError); ok && e.IsNotFound {
+		return nil
+	}
+	return err
+}
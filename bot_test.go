@@ -0,0 +1,113 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBotResolver struct {
+	addrs   map[string][]string
+	hosts   map[string][]string
+	lookups int
+}
+
+func (r *testBotResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.lookups++
+	if addr == "10.0.0.9" {
+		return nil, errors.New("timeout")
+	}
+	if names, ok := r.addrs[addr]; ok {
+		return names, nil
+	}
+	return nil, &net.DNSError{Err: "not found", Name: addr, IsNotFound: true}
+}
+
+func (r *testBotResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ips, ok := r.hosts[host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "not found", Name: host, IsNotFound: true}
+}
+
+func TestVerifyBot(t *testing.T) {
+	resolver := &testBotResolver{
+		addrs: map[string][]string{
+			"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+			"10.0.0.1":    {"crawl.googlebot.com.evil.com."},
+			"10.0.0.2":    {"fake.googlebot.com."},
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"},
+			"fake.googlebot.com":              {"66.249.66.2"},
+		},
+	}
+	tests := []struct {
+		id, ip   string
+		expected bool
+		err      bool
+	}{
+		{"t1", "66.249.66.1", true, false},
+		{"t2", "10.0.0.1", false, false},
+		{"t3", "10.0.0.2", false, false},
+		{"t4", "10.0.0.3", false, false},
+		{"t5", "invalid", false, false},
+		{"t6", "10.0.0.9", false, true},
+	}
+	for _, test := range tests {
+		ok, err := VerifyBot(context.Background(), resolver, test.ip, "googlebot.com", "google.com")
+		assert.Equal(t, test.expected, ok, test.id)
+		assert.Equal(t, test.err, err != nil, test.id)
+	}
+}
+
+func TestBotDetector(t *testing.T) {
+	resolver := &testBotResolver{
+		addrs: map[string][]string{"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."}},
+		hosts: map[string][]string{"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"}},
+	}
+	h := BotDetector(BotOptions{Domains: DefaultBotDomains, Resolver: resolver})
+	key := BotKey(func(c *Context) string { return "ip:" + remoteIP(c.Request) })
+
+	tests := []struct {
+		id, ua, ip string
+		bot        *Bot
+		key        string
+	}{
+		{"t1", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0", "1.2.3.4", nil, "ip:1.2.3.4"},
+		{"t2", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "66.249.66.1", &Bot{Name: "Googlebot", Verified: true}, "bot:Googlebot"},
+		{"t3", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "1.2.3.4", &Bot{Name: "Googlebot", Spoofed: true}, "ip:1.2.3.4"},
+		{"t4", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "10.0.0.9", &Bot{Name: "Googlebot"}, "bot:Googlebot"},
+		{"t5", "Mozilla/5.0 (compatible; AhrefsBot/7.0)", "1.2.3.4", &Bot{Name: "AhrefsBot"}, "bot:AhrefsBot"},
+		{"t6", "my-crawler/1.0", "1.2.3.4", &Bot{Name: "crawler"}, "bot:crawler"},
+		{"t7", "", "1.2.3.4", nil, "ip:1.2.3.4"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", test.ua)
+		req.RemoteAddr = test.ip + ":1234"
+		c := NewContext(nil, req)
+		assert.Nil(t, h(c), test.id)
+		assert.Equal(t, test.bot, c.Bot(), test.id)
+		assert.Equal(t, test.bot != nil, c.IsBot(), test.id)
+		assert.Equal(t, test.key, key(c), test.id)
+	}
+
+	// the verification results are cached, except for the failed lookups
+	lookups := resolver.lookups
+	for _, ip := range []string{"66.249.66.1", "1.2.3.4", "10.0.0.9"} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("User-Agent", "Googlebot")
+		req.RemoteAddr = ip + ":1234"
+		h(NewContext(nil, req))
+	}
+	assert.Equal(t, lookups+1, resolver.lookups)
+}
@@ -0,0 +1,174 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+type (
+	// ErrorBudget tracks the rolling rate of the 5xx responses of each route and raises an alert when the rate
+	// exceeds a threshold, which gives basic alerting signals without a metrics infrastructure. Set Router.ErrorBudget
+	// to enable tracking:
+	//
+	//     router.ErrorBudget = &routing.ErrorBudget{
+	//         Threshold: 0.05,
+	//         Window:    5 * time.Minute,
+	//         Alert: func(a routing.ErrorBudgetAlert) {
+	//             notifyOnCall(a.String())
+	//         },
+	//     }
+	//
+	// The alerts of a route are raised at most once per Cooldown. ErrorBudget is safe for concurrent use,
+	// but its fields should not be modified after the router starts serving requests.
+	ErrorBudget struct {
+		// Threshold is the ratio of the 5xx responses to all responses of a route, between 0 and 1, above which
+		// an alert is raised. Defaults to 0.01.
+		Threshold float64
+		// Window is the period over which the rate is calculated. Defaults to DefaultErrorBudgetWindow.
+		Window time.Duration
+		// MinRequests is the minimum number of requests of a route in the window for an alert to be raised,
+		// so that a few failures of a rarely used route do not trigger alerts. Defaults to 10.
+		MinRequests int
+		// Cooldown is the minimum interval between the alerts of a route. Defaults to Window.
+		Cooldown time.Duration
+		// Alert is called when an alert is raised. It is called synchronously by the request that raises the alert.
+		// If nil, the alert is logged by Logger at the error level.
+		Alert func(ErrorBudgetAlert)
+		// Logger logs the alerts if Alert is nil. If both are nil, the alerts are logged by the standard logger.
+		Logger Logger
+
+		mu     sync.Mutex
+		routes map[string]*budgetWindow
+		now    func() time.Time
+	}
+
+	// ErrorBudgetAlert describes a route whose 5xx rate exceeds the threshold of an ErrorBudget.
+	ErrorBudgetAlert struct {
+		Route    string        // the route, e.g. "GET /users/<id>", or "" for the unmatched requests
+		Requests int           // the number of the requests of the route in the window
+		Errors   int           // the number of the 5xx responses of the route in the window
+		Rate     float64       // the ratio of Errors to Requests
+		Window   time.Duration // the period over which the rate is calculated
+		Time     time.Time     // the time when the alert is raised
+	}
+
+	// budgetWindow counts the requests and errors of a route in the slots of the rolling window.
+	budgetWindow struct {
+		slots     [budgetSlots]budgetSlot
+		lastAlert time.Time
+	}
+
+	// budgetSlot counts the requests and errors in a slot of the rolling window.
+	budgetSlot struct {
+		index            int64
+		requests, errors int
+	}
+)
+
+// DefaultErrorBudgetWindow is the default period over which ErrorBudget calculates the 5xx rates.
+const DefaultErrorBudgetWindow = 5 * time.Minute
+
+// budgetSlots is the number of slots that a rolling window is divided into.
+const budgetSlots = 10
+
+// String returns a message describing the alert.
+func (a ErrorBudgetAlert) String() string {
+	route := a.Route
+	if route == "" {
+		route = "unmatched requests"
+	}
+	return fmt.Sprintf("%v: %d of %d responses (%.1f%%) in the last %v are server errors", route, a.Errors, a.Requests, a.Rate*100, a.Window)
+}
+
+// Add records a response served by the given route, which is nil if the request matches no route, and raises
+// an alert if the 5xx rate of the route exceeds the threshold. It is called by the router when Router.ErrorBudget is set.
+func (b *ErrorBudget) Add(route *Route, status int) {
+	key := ""
+	if route != nil {
+		key = route.String()
+	}
+	now := time.Now()
+	if b.now != nil {
+		now = b.now()
+	}
+	window := b.Window
+	if window <= 0 {
+		window = DefaultErrorBudgetWindow
+	}
+	size := int64(window / budgetSlots)
+	if size == 0 {
+		size = 1
+	}
+	slot := now.UnixNano() / size
+
+	b.mu.Lock()
+	if b.routes == nil {
+		b.routes = map[string]*budgetWindow{}
+	}
+	w := b.routes[key]
+	if w == nil {
+		w = &budgetWindow{}
+		b.routes[key] = w
+	}
+	s := &w.slots[slot%budgetSlots]
+	if s.index != slot {
+		*s = budgetSlot{index: slot}
+	}
+	s.requests++
+	if status >= 500 {
+		s.errors++
+	}
+	alert := ErrorBudgetAlert{Route: key, Window: window, Time: now}
+	for _, s := range w.slots {
+		if s.index > slot-budgetSlots {
+			alert.Requests += s.requests
+			alert.Errors += s.errors
+		}
+	}
+	alert.Rate = float64(alert.Errors) / float64(alert.Requests)
+	raise := status >= 500 && alert.Requests >= b.minRequests() && alert.Rate > b.threshold() &&
+		(w.lastAlert.IsZero() || now.Sub(w.lastAlert) >= b.cooldown(window))
+	if raise {
+		w.lastAlert = now
+	}
+	b.mu.Unlock()
+
+	if !raise {
+		return
+	}
+	switch {
+	case b.Alert != nil:
+		b.Alert(alert)
+	case b.Logger != nil:
+		b.Logger.Error("error budget exceeded", "route", alert.Route, "requests", alert.Requests, "errors", alert.Errors, "rate", alert.Rate)
+	default:
+		log.Printf("error budget exceeded: %v", alert)
+	}
+}
+
+func (b *ErrorBudget) threshold() float64 {
+	if b.Threshold <= 0 {
+		return 0.01
+	}
+	return b.Threshold
+}
+
+func (b *ErrorBudget) minRequests() int {
+	if b.MinRequests <= 0 {
+		return 10
+	}
+	return b.MinRequests
+}
+
+func (b *ErrorBudget) cooldown(window time.Duration) time.Duration {
+	if b.Cooldown <= 0 {
+		return window
+	}
+	return b.Cooldown
+}
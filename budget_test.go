@@ -0,0 +1,84 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorBudget(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var alerts []ErrorBudgetAlert
+	budget := &ErrorBudget{
+		Threshold:   0.2,
+		Window:      time.Minute,
+		MinRequests: 5,
+		Alert: func(a ErrorBudgetAlert) {
+			alerts = append(alerts, a)
+		},
+		now: func() time.Time { return now },
+	}
+	router := New()
+	router.ErrorBudget = budget
+	router.Get("/users/<id>", func(c *Context) error {
+		if c.Param("id") == "0" {
+			return NewHTTPError(http.StatusServiceUnavailable)
+		}
+		return c.Write("ok")
+	})
+	serve := func(path string) {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(res, req)
+	}
+
+	// not enough requests
+	serve("/users/0")
+	serve("/users/0")
+	assert.Empty(t, alerts)
+	serve("/users/1")
+	serve("/users/1")
+	serve("/users/0")
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, ErrorBudgetAlert{
+			Route:    "GET /users/<id>",
+			Requests: 5,
+			Errors:   3,
+			Rate:     0.6,
+			Window:   time.Minute,
+			Time:     now,
+		}, alerts[0])
+		assert.Equal(t, "GET /users/<id>: 3 of 5 responses (60.0%) in the last 1m0s are server errors", alerts[0].String())
+	}
+
+	// cooldown
+	now = now.Add(30 * time.Second)
+	serve("/users/0")
+	assert.Len(t, alerts, 1)
+
+	// the errors out of the window are not counted
+	now = now.Add(time.Minute)
+	for i := 0; i < 7; i++ {
+		serve("/users/1")
+	}
+	serve("/users/0")
+	assert.Len(t, alerts, 1)
+	serve("/users/0")
+	if assert.Len(t, alerts, 2) {
+		assert.Equal(t, 9, alerts[1].Requests)
+		assert.Equal(t, 2, alerts[1].Errors)
+	}
+
+	// unmatched requests are tracked separately, and 404 is not an error
+	for i := 0; i < 10; i++ {
+		serve("/unknown")
+	}
+	assert.Len(t, alerts, 2)
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// BufferedResponseWriter wraps http.ResponseWriter in order to hold the status and the body of a response
+// in memory until Commit is called, so that they can still be inspected and rewritten after the handlers
+// have written the response. The response headers are those of the wrapped writer, which can be modified freely
+// until the response is committed.
+//
+// If MaxSize is positive and writing the body would exceed it, the response is committed automatically
+// and the rest of the body is written directly to the wrapped writer. This is also the case when Flush is called,
+// e.g. by a handler streaming events to the client.
+type BufferedResponseWriter struct {
+	http.ResponseWriter
+	// Status is the HTTP status code of the response, or 0 if it has not been written yet.
+	Status int
+	// MaxSize is the maximum number of bytes to be buffered. Zero means no limit.
+	MaxSize   int64
+	body      bytes.Buffer
+	size      int64
+	rewritten bool
+	committed bool
+}
+
+// NewBufferedResponseWriter creates a BufferedResponseWriter that buffers at most maxSize bytes of
+// the response body written to w. Zero maxSize means no limit.
+func NewBufferedResponseWriter(w http.ResponseWriter, maxSize int64) *BufferedResponseWriter {
+	return &BufferedResponseWriter{ResponseWriter: w, MaxSize: maxSize}
+}
+
+// WriteHeader records the status code of the response. Only the first status code is recorded,
+// which can be changed later by setting Status.
+func (w *BufferedResponseWriter) WriteHeader(status int) {
+	if w.committed {
+		w.ResponseWriter.WriteHeader(status)
+	} else if w.Status == 0 {
+		w.Status = status
+	}
+}
+
+// Write appends the data to the buffered body. If the response is already committed,
+// the data is written to the wrapped writer.
+func (w *BufferedResponseWriter) Write(p []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	var (
+		n   int
+		err error
+	)
+	if w.committed {
+		n, err = w.ResponseWriter.Write(p)
+	} else if w.MaxSize > 0 && int64(w.body.Len()+len(p)) > w.MaxSize {
+		if err = w.Commit(); err == nil {
+			n, err = w.ResponseWriter.Write(p)
+		}
+	} else {
+		n, err = w.body.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// Written returns the status and the number of bytes of the response body that have been written,
+// including those that are still buffered.
+func (w *BufferedResponseWriter) Written() (int, int64) {
+	return w.Status, w.size
+}
+
+// Body returns the buffered response body. The returned slice is only valid until the next write.
+func (w *BufferedResponseWriter) Body() []byte {
+	return w.body.Bytes()
+}
+
+// SetBody replaces the buffered response body.
+func (w *BufferedResponseWriter) SetBody(body []byte) {
+	w.body.Reset()
+	w.body.Write(body)
+	w.size = int64(len(body))
+	w.rewritten = true
+}
+
+// Reset discards the buffered status and body, e.g. in order to replace the response with an error page.
+// The response headers are kept.
+func (w *BufferedResponseWriter) Reset() {
+	w.Status = 0
+	w.body.Reset()
+	w.size = 0
+	w.rewritten = true
+}
+
+// Committed returns whether the response has been sent to the wrapped writer.
+func (w *BufferedResponseWriter) Committed() bool {
+	return w.committed
+}
+
+// Commit sends the buffered status and body to the wrapped writer. If the body has been rewritten and
+// the Content-Length header is set, the header will be updated to the new length. If nothing has been
+// written, nothing is sent. After the response is committed, the writes will go directly to the wrapped writer.
+func (w *BufferedResponseWriter) Commit() error {
+	if w.committed {
+		return nil
+	}
+	w.committed = true
+	if w.Status == 0 && w.body.Len() == 0 {
+		return nil
+	}
+	if w.rewritten && w.Header().Get("Content-Length") != "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.body.Len()))
+	}
+	if w.Status != 0 {
+		w.ResponseWriter.WriteHeader(w.Status)
+	}
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	w.body.Reset()
+	return err
+}
+
+// Flush commits the response and then flushes the wrapped writer if it supports flushing.
+func (w *BufferedResponseWriter) Flush() {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	w.Commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *BufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
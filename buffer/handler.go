@@ -0,0 +1,60 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package buffer provides a handler that buffers responses so that they can be rewritten for the ozzo routing package.
+package buffer
+
+import (
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Rewriter inspects and rewrites a buffered response before it is sent to the client. It may change the status
+// (BufferedResponseWriter.Status), the headers, and the body (BufferedResponseWriter.SetBody) of the response.
+type Rewriter func(c *routing.Context, w *routing.BufferedResponseWriter) error
+
+// Handler returns a handler that buffers the response written by the handlers following this one, and calls
+// the given rewriters in order before sending the response to the client. For example, the following code
+// injects a toolbar into the HTML pages in development:
+//
+//     import (
+//         "bytes"
+//         "strings"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/buffer"
+//     )
+//
+//     r := routing.New()
+//     r.Use(buffer.Handler(1<<20, func(c *routing.Context, w *routing.BufferedResponseWriter) error {
+//         if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+//             w.SetBody(bytes.Replace(w.Body(), []byte("</body>"), []byte(toolbar+"</body>"), 1))
+//         }
+//         return nil
+//     }))
+//
+// At most maxSize bytes of the body are buffered (zero means no limit). If the body is larger, or if a handler
+// flushes the response, the response is sent as it is without calling the rewriters. If the handlers return an error,
+// the buffered response is discarded without calling the rewriters so that the error can be written in its place.
+// Place an error handler, such as fault.ErrorHandler, after this handler if the error responses should be rewritten
+// as well.
+func Handler(maxSize int64, rewriters ...Rewriter) routing.Handler {
+	return func(c *routing.Context) error {
+		rw := routing.NewBufferedResponseWriter(c.Response, maxSize)
+		c.Response = rw
+		if err := c.Next(); err != nil {
+			rw.Reset()
+			rw.Commit()
+			return err
+		}
+		if !rw.Committed() {
+			for _, rewrite := range rewriters {
+				if err := rewrite(c, rw); err != nil {
+					rw.Reset()
+					rw.Commit()
+					return err
+				}
+			}
+		}
+		return rw.Commit()
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package buffer
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	toolbar := func(c *routing.Context, w *routing.BufferedResponseWriter) error {
+		w.SetBody(bytes.Replace(w.Body(), []byte("</body>"), []byte("<div>toolbar</div></body>"), 1))
+		return nil
+	}
+	notFoundPage := func(c *routing.Context, w *routing.BufferedResponseWriter) error {
+		if w.Status == http.StatusNotFound {
+			w.Status = http.StatusGone
+			w.SetBody([]byte("<body>gone</body>"))
+		}
+		return nil
+	}
+	router := routing.New()
+	router.Use(Handler(100, notFoundPage, toolbar))
+	router.Get("/page", func(c *routing.Context) error {
+		return c.Write("<body>page</body>")
+	})
+	router.Get("/missing", func(c *routing.Context) error {
+		return c.WriteWithStatus("<body>missing</body>", http.StatusNotFound)
+	})
+	router.Get("/large", func(c *routing.Context) error {
+		return c.Write("<body>" + string(bytes.Repeat([]byte("x"), 100)) + "</body>")
+	})
+	router.Get("/error", func(c *routing.Context) error {
+		c.Write("<body>partial</body>")
+		return routing.NewHTTPError(http.StatusBadRequest)
+	})
+	router.Get("/empty", func(c *routing.Context) error {
+		return nil
+	})
+
+	tests := []struct {
+		tag    string
+		path   string
+		status int
+		body   string
+	}{
+		{"t1", "/page", http.StatusOK, "<body>page<div>toolbar</div></body>"},
+		{"t2", "/missing", http.StatusGone, "<body>gone<div>toolbar</div></body>"},
+		{"t3", "/large", http.StatusOK, "<body>" + string(bytes.Repeat([]byte("x"), 100)) + "</body>"},
+		{"t4", "/error", http.StatusBadRequest, "Bad Request\n"},
+		{"t5", "/empty", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+	}
+}
+
+func TestHandlerRewriterError(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(0, func(c *routing.Context, w *routing.BufferedResponseWriter) error {
+		return routing.NewHTTPError(http.StatusForbidden)
+	}))
+	router.Get("/page", func(c *routing.Context) error {
+		return c.Write("secret")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/page", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, "Forbidden\n", res.Body.String())
+
+	router = routing.New()
+	router.Use(Handler(0))
+	router.Get("/page", func(c *routing.Context) error {
+		return errors.New("failed")
+	})
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferedResponseWriter(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewBufferedResponseWriter(res, 0)
+	w.Header().Set("Content-Length", "5")
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
+	n, err := w.Write([]byte("hello"))
+	assert.Equal(t, 5, n)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, w.Status)
+	assert.Equal(t, "hello", string(w.Body()))
+	assert.False(t, w.Committed())
+	assert.Equal(t, "", res.Body.String())
+	assert.Equal(t, res, w.Unwrap())
+
+	w.Status = http.StatusOK
+	w.SetBody([]byte("hello, world"))
+	assert.Nil(t, w.Commit())
+	assert.True(t, w.Committed())
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "12", res.Header().Get("Content-Length"))
+	assert.Equal(t, "hello, world", res.Body.String())
+
+	// writes after the response is committed
+	w.Write([]byte("!"))
+	assert.Equal(t, "hello, world!", res.Body.String())
+	assert.Equal(t, "", string(w.Body()))
+	assert.Nil(t, w.Commit())
+	assert.Equal(t, "hello, world!", res.Body.String())
+}
+
+func TestBufferedResponseWriterMaxSize(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewBufferedResponseWriter(res, 8)
+	w.Header().Set("Content-Length", "11")
+	w.Write([]byte("hello"))
+	assert.False(t, w.Committed())
+	w.Write([]byte(" world"))
+	assert.True(t, w.Committed())
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "11", res.Header().Get("Content-Length"))
+	assert.Equal(t, "hello world", res.Body.String())
+}
+
+func TestBufferedResponseWriterReset(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewBufferedResponseWriter(res, 0)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte("error"))
+	w.Reset()
+	assert.Equal(t, 0, w.Status)
+	assert.Nil(t, w.Commit())
+	assert.False(t, res.Flushed)
+	assert.Equal(t, "", res.Body.String())
+
+	// the error response is written after the response is committed
+	w.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestBufferedResponseWriterFlush(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := NewBufferedResponseWriter(res, 0)
+	w.Write([]byte("data: 1\n\n"))
+	w.Flush()
+	assert.True(t, w.Committed())
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "data: 1\n\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	w = NewBufferedResponseWriter(res, 0)
+	w.Flush()
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.True(t, res.Flushed)
+}
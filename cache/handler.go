@@ -0,0 +1,139 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package cache provides a handler that sets HTTP caching headers for the ozzo routing package.
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Control is the name of the route metadata item that specifies the Cache-Control directives of a route.
+const Control = "cache"
+
+// Handler returns a handler that sets the Cache-Control and Expires response headers according to
+// the Cache-Control directives associated with the matching route. The directives can be specified
+// using the route metadata named Control, like the following:
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/cache"
+//     )
+//
+//     r := routing.New()
+//     r.Use(cache.Handler("no-cache"))
+//     r.Get("/users", getUsers).Set(cache.Control, "public, max-age=3600")
+//
+// The headers are only set for successful (2xx) and 304 responses. If the directives contain max-age,
+// the Expires header will be set accordingly.
+// The optional default directives are used for the routes without the metadata. If they are not given,
+// no header will be set for such routes. Headers that are set explicitly by the handlers following this one
+// will not be overwritten.
+//
+// Right before the cacheable responses are sent, the Vary and Content-Language headers are completed with the
+// content negotiation results recorded in routing.Context.Negotiation, even if a handler has overwritten them,
+// so that shared caches store the negotiated variants separately.
+func Handler(defaultControl ...string) routing.Handler {
+	def := ""
+	if len(defaultControl) > 0 {
+		def = defaultControl[0]
+	}
+	return func(c *routing.Context) error {
+		control := def
+		if route := c.Route(); route != nil {
+			if s, ok := route.Meta(Control).(string); ok {
+				control = s
+			}
+		}
+		if control == "" {
+			return nil
+		}
+		rw := &responseWriter{ResponseWriter: c.Response, context: c, control: control}
+		c.Response = rw
+		err := c.Next()
+		if err == nil && !rw.wroteHeader {
+			// nothing is written: the response will be sent with an implicit 200 status
+			rw.wroteHeader = true
+			c.Negotiation().Apply(rw.Header())
+			setHeaders(rw.Header(), control, time.Now())
+		}
+		return err
+	}
+}
+
+// responseWriter wraps http.ResponseWriter in order to set the caching headers right before the response is sent.
+type responseWriter struct {
+	http.ResponseWriter
+	context     *routing.Context
+	control     string
+	wroteHeader bool
+}
+
+// WriteHeader sets the caching headers and then writes HTTP headers.
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status >= 200 && status < 300 || status == http.StatusNotModified {
+			w.context.Negotiation().Apply(w.Header())
+			setHeaders(w.Header(), w.control, time.Now())
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sets the caching headers if the headers are not written yet, and then sends the buffered data
+// to the client if the wrapped writer supports flushing.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// setHeaders sets the Cache-Control and Expires headers unless they are already set.
+func setHeaders(header http.Header, control string, now time.Time) {
+	if header.Get("Cache-Control") != "" {
+		return
+	}
+	header.Set("Cache-Control", control)
+	if header.Get("Expires") != "" {
+		return
+	}
+	if maxAge, ok := parseMaxAge(control); ok {
+		header.Set("Expires", now.Add(time.Duration(maxAge)*time.Second).UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseMaxAge returns the number of seconds specified by the max-age directive.
+func parseMaxAge(control string) (int64, bool) {
+	for _, directive := range strings.Split(control, ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(strings.ToLower(directive), "max-age=") {
+			continue
+		}
+		if seconds, err := strconv.ParseInt(strings.Trim(directive[8:], `"`), 10, 64); err == nil && seconds >= 0 {
+			return seconds, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler("no-cache"))
+	router.Get("/users", func(c *routing.Context) error {
+		return c.Write("users")
+	}).Set(Control, "public, max-age=3600")
+	router.Get("/posts", func(c *routing.Context) error {
+		return c.Write("posts")
+	})
+	router.Get("/comments", func(c *routing.Context) error {
+		c.Response.Header().Set("Cache-Control", "private")
+		return c.Write("comments")
+	}).Set(Control, "public, max-age=3600")
+	router.Get("/orders", func(c *routing.Context) error {
+		return nil
+	}).Set(Control, "max-age=60")
+	router.Get("/events", func(c *routing.Context) error {
+		c.Response.(http.Flusher).Flush()
+		return nil
+	}).Set(Control, "no-store")
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "public, max-age=3600", res.Header().Get("Cache-Control"))
+	assert.NotEqual(t, "", res.Header().Get("Expires"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/posts", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "no-cache", res.Header().Get("Cache-Control"))
+	assert.Equal(t, "", res.Header().Get("Expires"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/comments", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "private", res.Header().Get("Cache-Control"))
+	assert.Equal(t, "", res.Header().Get("Expires"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/orders", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "max-age=60", res.Header().Get("Cache-Control"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/events", nil)
+	router.ServeHTTP(res, req)
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "no-store", res.Header().Get("Cache-Control"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/unknown", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "", res.Header().Get("Cache-Control"))
+}
+
+func Test_setHeaders(t *testing.T) {
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	header := http.Header{}
+	setHeaders(header, "public, max-age=60", now)
+	assert.Equal(t, "public, max-age=60", header.Get("Cache-Control"))
+	assert.Equal(t, "Sat, 02 Jan 2016 03:05:05 GMT", header.Get("Expires"))
+
+	header = http.Header{}
+	header.Set("Expires", "0")
+	setHeaders(header, "max-age=60", now)
+	assert.Equal(t, "max-age=60", header.Get("Cache-Control"))
+	assert.Equal(t, "0", header.Get("Expires"))
+
+	header = http.Header{}
+	setHeaders(header, "no-store", now)
+	assert.Equal(t, "no-store", header.Get("Cache-Control"))
+	assert.Equal(t, "", header.Get("Expires"))
+}
+
+func TestHandlerNegotiation(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler("public, max-age=60"))
+	router.Get("/users", func(c *routing.Context) error {
+		c.Negotiation().Language = "de"
+		c.Vary("Accept-Language")
+		// a handler overwriting the negotiated headers
+		c.Response.Header().Set("Vary", "Origin")
+		c.Response.Header().Del("Content-Language")
+		return c.Write("users")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "Origin, Accept-Language", res.Header().Get("Vary"))
+	assert.Equal(t, "de", res.Header().Get("Content-Language"))
+}
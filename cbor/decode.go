@@ -0,0 +1,629 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDepth is the maximum nesting depth of arrays, maps, and tags in a data item.
+const maxDepth = 1000
+
+// indefinite is the argument of a data item with an indefinite length.
+const indefinite = math.MaxUint64
+
+// Unmarshal decodes the CBOR data item in data and stores the result in the value pointed to by v.
+// Decoding a data item into an empty interface produces nil, bool, uint64 (for the unsigned integers greater than
+// math.MaxInt64), int64, float64, string, []byte, time.Time, []interface{}, map[string]interface{} (if all keys
+// are text strings), or map[interface{}]interface{}.
+func Unmarshal(data []byte, v interface{}) error {
+	r := bytes.NewReader(data)
+	d := NewDecoder(r)
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.r.Buffered() > 0 || r.Len() > 0 {
+		return errorf("unexpected data after the top-level data item")
+	}
+	return nil
+}
+
+// Decoder reads and decodes CBOR data items from an input stream.
+type Decoder struct {
+	r     *bufio.Reader
+	depth int
+}
+
+// NewDecoder returns a new decoder that reads from r. The decoder may read data from r beyond
+// the data items requested.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next CBOR data item from the input and stores it in the value pointed to by v.
+// It returns io.EOF if there is no more data item in the input.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("cbor: Decode requires a non-nil pointer")
+	}
+	if _, err := d.r.Peek(1); err != nil {
+		return err
+	}
+	d.depth = 0
+	major, arg, err := d.readHead()
+	if err != nil {
+		return err
+	}
+	return d.decode(major, arg, rv.Elem())
+}
+
+// readHead reads the initial byte and the argument of a data item.
+// A float (major type 7 with a 16-, 32-, or 64-bit argument) is returned with the pseudo major type majorFloat
+// and the bits of its float64 value as the argument.
+func (d *Decoder) readHead() (major byte, arg uint64, err error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, 0, unexpectedEOF(err)
+	}
+	major, info := b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info <= 27:
+		var buf [8]byte
+		n := 1 << (info - 24)
+		if _, err := io.ReadFull(d.r, buf[8-n:]); err != nil {
+			return 0, 0, unexpectedEOF(err)
+		}
+		arg := binary.BigEndian.Uint64(buf[:])
+		if major == majorSimple {
+			switch info {
+			case 24:
+				if arg < 32 {
+					return 0, 0, errorf("invalid simple value %v", arg)
+				}
+			case 25:
+				return majorFloat, math.Float64bits(halfToFloat(uint16(arg))), nil
+			case 26:
+				return majorFloat, math.Float64bits(float64(math.Float32frombits(uint32(arg)))), nil
+			default:
+				return majorFloat, arg, nil
+			}
+		}
+		return major, arg, nil
+	case info == 31 && major >= majorBytes && major <= majorMap:
+		return major, indefinite, nil
+	}
+	return 0, 0, errorf("invalid initial byte 0x%02x", b)
+}
+
+// isBreak checks if the next byte is the "break" stop code, and consumes it if so.
+func (d *Decoder) isBreak() (bool, error) {
+	b, err := d.r.Peek(1)
+	if err != nil {
+		return false, unexpectedEOF(err)
+	}
+	if b[0] == 0xff {
+		d.r.ReadByte()
+		return true, nil
+	}
+	return false, nil
+}
+
+// readString reads the content of a byte or text string with the given argument.
+func (d *Decoder) readString(major byte, arg uint64) ([]byte, error) {
+	if arg != indefinite {
+		if arg > math.MaxInt64 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, d.r, int64(arg)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return buf.Bytes(), nil
+	}
+	// an indefinite-length string consists of definite-length chunks of the same major type
+	var buf bytes.Buffer
+	for {
+		if ok, err := d.isBreak(); err != nil || ok {
+			return buf.Bytes(), err
+		}
+		m, n, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || n == indefinite {
+			return nil, errorf("invalid chunk in an indefinite-length %v", typeName(major))
+		}
+		chunk, err := d.readString(m, n)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(chunk)
+	}
+}
+
+// readItems calls f for each item of an array or map with the given argument.
+// The items of a map are the keys and values.
+func (d *Decoder) readItems(major byte, arg uint64, f func(i int) error) error {
+	if d.depth++; d.depth > maxDepth {
+		return errorf("exceeded the maximum nesting depth")
+	}
+	defer func() { d.depth-- }()
+	n := arg
+	if arg != indefinite && major == majorMap {
+		if n > math.MaxUint64/2 {
+			return unexpectedEOF(nil)
+		}
+		n *= 2
+	}
+	for i := 0; arg == indefinite || uint64(i) < n; i++ {
+		if arg == indefinite {
+			if ok, err := d.isBreak(); err != nil {
+				return err
+			} else if ok {
+				if major == majorMap && i%2 == 1 {
+					return errorf("missing value in an indefinite-length map")
+				}
+				return nil
+			}
+		}
+		if err := f(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeAny decodes the data item with the given head into an empty interface.
+func (d *Decoder) decodeAny(major byte, arg uint64) (interface{}, error) {
+	switch major {
+	case majorUint:
+		if arg > math.MaxInt64 {
+			return arg, nil
+		}
+		return int64(arg), nil
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errorf("negative integer -1-%v overflows int64", arg)
+		}
+		return -1 - int64(arg), nil
+	case majorBytes:
+		return d.readString(major, arg)
+	case majorText:
+		s, err := d.readString(major, arg)
+		return string(s), err
+	case majorArray:
+		items := make([]interface{}, 0, capacity(arg))
+		err := d.readItems(major, arg, func(int) error {
+			item, err := d.next()
+			items = append(items, item)
+			return err
+		})
+		return items, err
+	case majorMap:
+		var keys, values []interface{}
+		err := d.readItems(major, arg, func(i int) error {
+			item, err := d.next()
+			if i%2 == 0 {
+				keys = append(keys, item)
+			} else {
+				values = append(values, item)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return makeMap(keys, values)
+	case majorTag:
+		if d.depth++; d.depth > maxDepth {
+			return nil, errorf("exceeded the maximum nesting depth")
+		}
+		defer func() { d.depth-- }()
+		major, n, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if arg == tagDateTime && major == majorText || arg == tagEpochTime {
+			return d.decodeTime(major, n)
+		}
+		// the content of an unknown tag is returned as is
+		return d.decodeAny(major, n)
+	}
+	if major == majorFloat {
+		return math.Float64frombits(arg), nil
+	}
+	return decodeSimple(arg)
+}
+
+// next reads and decodes the next data item into an empty interface.
+func (d *Decoder) next() (interface{}, error) {
+	major, arg, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+	return d.decodeAny(major, arg)
+}
+
+// decodeSimple decodes a simple value.
+func decodeSimple(arg uint64) (interface{}, error) {
+	switch arg {
+	case simpleFalse & 0x1f:
+		return false, nil
+	case simpleTrue & 0x1f:
+		return true, nil
+	case simpleNull & 0x1f, simpleUndefined & 0x1f:
+		return nil, nil
+	}
+	return nil, errorf("unsupported simple value %v", arg)
+}
+
+// decode decodes the data item with the given head into v.
+func (d *Decoder) decode(major byte, arg uint64, v reflect.Value) error {
+	if major == majorSimple && (arg == simpleNull&0x1f || arg == simpleUndefined&0x1f) {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	if v.Type() == timeType {
+		if major == majorTag {
+			if d.depth++; d.depth > maxDepth {
+				return errorf("exceeded the maximum nesting depth")
+			}
+			defer func() { d.depth-- }()
+			var err error
+			if major, arg, err = d.readHead(); err != nil {
+				return err
+			}
+		}
+		t, err := d.decodeTime(major, arg)
+		if err == nil {
+			v.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(major, arg, v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return &UnmarshalTypeError{typeName(major), v.Type()}
+		}
+		value, err := d.decodeAny(major, arg)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			v.Set(reflect.ValueOf(value))
+		} else {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	switch major {
+	case majorUint, majorNegInt:
+		return d.decodeInt(major, arg, v)
+	case majorBytes, majorText:
+		s, err := d.readString(major, arg)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(string(s))
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(s)
+		default:
+			return &UnmarshalTypeError{typeName(major), v.Type()}
+		}
+		return nil
+	case majorArray:
+		return d.decodeArray(arg, v)
+	case majorMap:
+		switch v.Kind() {
+		case reflect.Map:
+			return d.decodeMap(arg, v)
+		case reflect.Struct:
+			return d.decodeStruct(arg, v)
+		}
+		return &UnmarshalTypeError{typeName(major), v.Type()}
+	case majorTag:
+		if d.depth++; d.depth > maxDepth {
+			return errorf("exceeded the maximum nesting depth")
+		}
+		defer func() { d.depth-- }()
+		m, n, err := d.readHead()
+		if err != nil {
+			return err
+		}
+		return d.decode(m, n, v)
+	}
+
+	if major == majorFloat {
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(math.Float64frombits(arg))
+			return nil
+		}
+		return &UnmarshalTypeError{typeName(major), v.Type()}
+	}
+	value, err := decodeSimple(arg)
+	if err != nil {
+		return err
+	}
+	if v.Kind() != reflect.Bool {
+		return &UnmarshalTypeError{"boolean", v.Type()}
+	}
+	v.SetBool(value.(bool))
+	return nil
+}
+
+// decodeInt decodes an integer into v.
+func (d *Decoder) decodeInt(major byte, arg uint64, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if arg > math.MaxInt64 {
+			return &UnmarshalTypeError{typeName(major), v.Type()}
+		}
+		n := int64(arg)
+		if major == majorNegInt {
+			n = -1 - n
+		}
+		if v.OverflowInt(n) {
+			return &UnmarshalTypeError{typeName(major), v.Type()}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if major == majorNegInt || v.OverflowUint(arg) {
+			return &UnmarshalTypeError{typeName(major), v.Type()}
+		}
+		v.SetUint(arg)
+	case reflect.Float32, reflect.Float64:
+		f := float64(arg)
+		if major == majorNegInt {
+			f = -1 - f
+		}
+		v.SetFloat(f)
+	default:
+		return &UnmarshalTypeError{typeName(major), v.Type()}
+	}
+	return nil
+}
+
+// decodeArray decodes an array into a slice or array value.
+func (d *Decoder) decodeArray(arg uint64, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 0, capacity(arg)))
+	case reflect.Array:
+		if arg != indefinite && arg > uint64(v.Len()) {
+			return &UnmarshalTypeError{"array of " + strconv.FormatUint(arg, 10) + " items", v.Type()}
+		}
+	default:
+		return &UnmarshalTypeError{typeName(majorArray), v.Type()}
+	}
+	return d.readItems(majorArray, arg, func(i int) error {
+		major, n, err := d.readHead()
+		if err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Array {
+			if i >= v.Len() {
+				return &UnmarshalTypeError{"array of more than " + strconv.Itoa(v.Len()) + " items", v.Type()}
+			}
+			return d.decode(major, n, v.Index(i))
+		}
+		item := reflect.New(v.Type().Elem()).Elem()
+		if err := d.decode(major, n, item); err != nil {
+			return err
+		}
+		v.Set(reflect.Append(v, item))
+		return nil
+	})
+}
+
+// decodeMap decodes a map into a map value.
+func (d *Decoder) decodeMap(arg uint64, v reflect.Value) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	key := reflect.New(v.Type().Key()).Elem()
+	return d.readItems(majorMap, arg, func(i int) error {
+		major, n, err := d.readHead()
+		if err != nil {
+			return err
+		}
+		if i%2 == 0 {
+			key = reflect.New(v.Type().Key()).Elem()
+			return d.decode(major, n, key)
+		}
+		value := reflect.New(v.Type().Elem()).Elem()
+		if err := d.decode(major, n, value); err != nil {
+			return err
+		}
+		if !key.Type().Comparable() || key.Kind() == reflect.Interface && key.Elem().IsValid() && !key.Elem().Type().Comparable() {
+			return errorf("unhashable map key")
+		}
+		v.SetMapIndex(key, value)
+		return nil
+	})
+}
+
+// decodeStruct decodes a map into a struct value. The map keys are matched with the field names, preferring
+// an exact match but also accepting a case-insensitive match. The entries without matching fields are ignored.
+func (d *Decoder) decodeStruct(arg uint64, v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	var target reflect.Value
+	return d.readItems(majorMap, arg, func(i int) error {
+		major, n, err := d.readHead()
+		if err != nil {
+			return err
+		}
+		if i%2 == 0 {
+			target = reflect.Value{}
+			if major != majorText {
+				_, err := d.decodeAny(major, n)
+				return err
+			}
+			name, err := d.readString(major, n)
+			if err != nil {
+				return err
+			}
+			if f := findField(fields, string(name)); f != nil {
+				target = fieldForSet(v, f.index)
+			}
+			return nil
+		}
+		if !target.IsValid() {
+			_, err := d.decodeAny(major, n)
+			return err
+		}
+		return d.decode(major, n, target)
+	})
+}
+
+// decodeTime decodes a date/time string in RFC 3339 format or a number of seconds since the epoch into time.Time.
+func (d *Decoder) decodeTime(major byte, arg uint64) (time.Time, error) {
+	switch {
+	case major == majorText:
+		s, err := d.readString(major, arg)
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(time.RFC3339Nano, string(s))
+		if err != nil {
+			return time.Time{}, errorf("invalid date/time string %q", s)
+		}
+		return t, nil
+	case major == majorUint || major == majorNegInt || major == majorFloat:
+		var f float64
+		if err := d.decode(major, arg, reflect.ValueOf(&f).Elem()); err != nil {
+			return time.Time{}, err
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+	return time.Time{}, &UnmarshalTypeError{typeName(major), timeType}
+}
+
+// UnmarshalTypeError is returned by Unmarshal when a CBOR data item cannot be decoded into the Go value.
+type UnmarshalTypeError struct {
+	Value string       // description of the CBOR data item, e.g. "text string"
+	Type  reflect.Type // the type of the Go value it could not be assigned to
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return "cbor: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+}
+
+// findField returns the field with the given name, or the first field whose name matches case-insensitively.
+func findField(fields []field, name string) *field {
+	var found *field
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+		if found == nil && strings.EqualFold(fields[i].name, name) {
+			found = &fields[i]
+		}
+	}
+	return found
+}
+
+// fieldForSet returns the nested field of the struct value, allocating the embedded struct pointers as needed.
+func fieldForSet(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// makeMap builds a map[string]interface{} if all keys are strings, or a map[interface{}]interface{} otherwise.
+func makeMap(keys, values []interface{}) (interface{}, error) {
+	strs := true
+	for _, key := range keys {
+		if _, ok := key.(string); !ok {
+			strs = false
+		}
+	}
+	if strs {
+		m := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			m[key.(string)] = values[i]
+		}
+		return m, nil
+	}
+	m := make(map[interface{}]interface{}, len(keys))
+	for i, key := range keys {
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return nil, errorf("unhashable map key of type %T", key)
+		}
+		m[key] = values[i]
+	}
+	return m, nil
+}
+
+// halfToFloat converts a half-precision float to float64.
+func halfToFloat(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// capacity returns the initial capacity of a slice holding the given number of items, which is limited
+// so that a malicious length does not cause a huge allocation.
+func capacity(n uint64) int {
+	if n > 1024 {
+		return 1024
+	}
+	return int(n)
+}
+
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF, since the input ends in the middle of a data item.
+func unexpectedEOF(err error) error {
+	if err == nil || err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestUnmarshalAny(t *testing.T) {
+	tests := []struct {
+		tag      string
+		data     string
+		expected interface{}
+	}{
+		// the examples from RFC 8949 Appendix A
+		{"t1", "00", int64(0)},
+		{"t2", "1818", int64(24)},
+		{"t3", "1b000000e8d4a51000", int64(1000000000000)},
+		{"t4", "1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"t5", "3903e7", int64(-1000)},
+		{"t6", "f90000", 0.0},
+		{"t7", "f93c00", 1.0},
+		{"t8", "f9c400", -4.0},
+		{"t9", "f90001", 5.960464477539063e-8},
+		{"t10", "f97c00", math.Inf(1)},
+		{"t11", "fa47c35000", 100000.0},
+		{"t12", "fb3ff199999999999a", 1.1},
+		{"t13", "f4", false},
+		{"t14", "f5", true},
+		{"t15", "f6", nil},
+		{"t16", "f7", nil},
+		{"t17", "6449455446", "IETF"},
+		{"t18", "4401020304", []byte{1, 2, 3, 4}},
+		{"t19", "8301820203820405", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"t20", "a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{"t21", "a26161016162820203", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"t22", "c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"t23", "c11a514b67b0", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"t24", "c1fb41d452d9ec200000", time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC)},
+		{"t25", "d74401020304", []byte{1, 2, 3, 4}},
+		// indefinite lengths
+		{"t26", "5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"t27", "7f657374726561646d696e67ff", "streaming"},
+		{"t28", "9f018202039f0405ffff", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"t29", "bf61610161629f0203ffff", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+	}
+	for _, test := range tests {
+		var value interface{}
+		err := Unmarshal(decodeHex(test.data), &value)
+		if assert.Nil(t, err, test.tag) {
+			if expected, ok := test.expected.(time.Time); ok {
+				assert.True(t, expected.Equal(value.(time.Time)), test.tag)
+			} else {
+				assert.Equal(t, test.expected, value, test.tag)
+			}
+		}
+	}
+}
+
+type decodeUser struct {
+	ID       int    `cbor:"id"`
+	Name     string `json:"name"`
+	Password string `cbor:"-"`
+	Age      uint8
+	Tags     []string
+	Scores   map[string]float64
+	Created  time.Time
+	Manager  *decodeUser
+}
+
+func TestUnmarshalStruct(t *testing.T) {
+	created := time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)
+	manager := &decodeUser{ID: 2, Name: "b"}
+	user := decodeUser{
+		ID:      1,
+		Name:    "a",
+		Age:     30,
+		Tags:    []string{"x", "y"},
+		Scores:  map[string]float64{"math": 1.5},
+		Created: created,
+		Manager: manager,
+	}
+	data, err := Marshal(user)
+	assert.Nil(t, err)
+
+	var result decodeUser
+	if assert.Nil(t, Unmarshal(data, &result)) {
+		assert.Equal(t, 1, result.ID)
+		assert.Equal(t, "a", result.Name)
+		assert.Equal(t, uint8(30), result.Age)
+		assert.Equal(t, []string{"x", "y"}, result.Tags)
+		assert.Equal(t, map[string]float64{"math": 1.5}, result.Scores)
+		assert.True(t, created.Equal(result.Created))
+		if assert.NotNil(t, result.Manager) {
+			assert.Equal(t, 2, result.Manager.ID)
+			assert.Nil(t, result.Manager.Manager)
+		}
+	}
+
+	// case-insensitive matching, unknown keys, and null values
+	data, _ = Marshal(map[string]interface{}{"ID": 3, "unknown": []int{1}, "tags": nil, "password": "x", "age": 4})
+	result = decodeUser{Tags: []string{"z"}}
+	if assert.Nil(t, Unmarshal(data, &result)) {
+		assert.Equal(t, decodeUser{ID: 3, Age: 4}, result)
+	}
+
+	// embedded structs
+	var author encodeAuthor
+	data, _ = Marshal(map[string]interface{}{"name": "a", "id": 1, "Age": 2})
+	if assert.Nil(t, Unmarshal(data, &author)) {
+		assert.Equal(t, encodeAuthor{encodeUser{ID: 1, Age: 2}, "a"}, author)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	var i int8
+	assert.Nil(t, Unmarshal(decodeHex("3863"), &i))
+	assert.Equal(t, int8(-100), i)
+
+	var f float32
+	assert.Nil(t, Unmarshal(decodeHex("1864"), &f))
+	assert.Equal(t, float32(100), f)
+	assert.Nil(t, Unmarshal(decodeHex("f93e00"), &f))
+	assert.Equal(t, float32(1.5), f)
+
+	var b bool
+	assert.Nil(t, Unmarshal(decodeHex("f5"), &b))
+	assert.True(t, b)
+
+	var p *string
+	assert.Nil(t, Unmarshal(decodeHex("6161"), &p))
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "a", *p)
+	}
+	assert.Nil(t, Unmarshal(decodeHex("f6"), &p))
+	assert.Nil(t, p)
+
+	var a [3]int
+	assert.Nil(t, Unmarshal(decodeHex("820102"), &a))
+	assert.Equal(t, [3]int{1, 2, 0}, a)
+
+	var bs []byte
+	assert.Nil(t, Unmarshal(decodeHex("6161"), &bs))
+	assert.Equal(t, []byte("a"), bs)
+
+	var m map[int]string
+	assert.Nil(t, Unmarshal(decodeHex("a1016161"), &m))
+	assert.Equal(t, map[int]string{1: "a"}, m)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var (
+		i  int
+		i8 int8
+		u  uint
+		s  string
+		b  bool
+		a  [1]int
+		st decodeUser
+		v  interface{}
+		e  error
+	)
+	tests := []struct {
+		tag      string
+		data     string
+		value    interface{}
+		expected string
+	}{
+		{"t1", "6161", &i, "cbor: cannot unmarshal text string into Go value of type int"},
+		{"t2", "190100", &i8, "cbor: cannot unmarshal unsigned integer into Go value of type int8"},
+		{"t3", "20", &u, "cbor: cannot unmarshal negative integer into Go value of type uint"},
+		{"t4", "01", &s, "cbor: cannot unmarshal unsigned integer into Go value of type string"},
+		{"t5", "f93c00", &b, "cbor: cannot unmarshal float into Go value of type bool"},
+		{"t6", "f5", &i, "cbor: cannot unmarshal boolean into Go value of type int"},
+		{"t7", "820102", &a, "cbor: cannot unmarshal array of 2 items into Go value of type [1]int"},
+		{"t8", "80", &st, "cbor: cannot unmarshal array into Go value of type cbor.decodeUser"},
+		{"t9", "a0", &s, "cbor: cannot unmarshal map into Go value of type string"},
+		{"t10", "01", &e, "cbor: cannot unmarshal unsigned integer into Go value of type error"},
+		{"t11", "0102", &i, "cbor: unexpected data after the top-level data item"},
+		{"t12", "19", &i, io.ErrUnexpectedEOF.Error()},
+		{"t13", "826161", &v, io.ErrUnexpectedEOF.Error()},
+		{"t14", "9f01", &v, io.ErrUnexpectedEOF.Error()},
+		{"t15", "1c", &v, "cbor: invalid initial byte 0x1c"},
+		{"t16", "f0", &v, "cbor: unsupported simple value 16"},
+		{"t17", "5f6161ff", &v, "cbor: invalid chunk in an indefinite-length byte string"},
+		{"t18", "bf01ff", &v, "cbor: missing value in an indefinite-length map"},
+		{"t19", "c06178", &v, `cbor: invalid date/time string "x"`},
+		{"t20", "a1800102", &v, "cbor: unhashable map key of type []interface {}"},
+		{"t21", "3bffffffffffffffff", &v, "cbor: negative integer -1-18446744073709551615 overflows int64"},
+		{"t22", strings.Repeat("81", maxDepth+1) + "01", &v, "cbor: exceeded the maximum nesting depth"},
+	}
+	for _, test := range tests {
+		err := Unmarshal(decodeHex(test.data), test.value)
+		if assert.NotNil(t, err, test.tag) {
+			assert.Equal(t, test.expected, err.Error(), test.tag)
+		}
+	}
+
+	assert.NotNil(t, Unmarshal(decodeHex("01"), nil))
+	assert.NotNil(t, Unmarshal(decodeHex("01"), i))
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(bytes.NewReader(decodeHex("016161")))
+	var (
+		i int
+		s string
+	)
+	assert.Nil(t, dec.Decode(&i))
+	assert.Nil(t, dec.Decode(&s))
+	assert.Equal(t, 1, i)
+	assert.Equal(t, "a", s)
+	assert.Equal(t, io.EOF, dec.Decode(&i))
+}
+
+func TestRoundTrip(t *testing.T) {
+	values := []interface{}{
+		int64(-12345),
+		3.25,
+		"hello, 世界",
+		[]interface{}{int64(1), "a", true, nil},
+		map[string]interface{}{"a": map[string]interface{}{"b": []byte{0xff}}},
+	}
+	for _, value := range values {
+		data, err := Marshal(value)
+		assert.Nil(t, err)
+		var result interface{}
+		assert.Nil(t, Unmarshal(data, &result))
+		assert.Equal(t, value, result)
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package cbor implements the encoding and decoding of CBOR (Concise Binary Object Representation, RFC 8949)
+// data items for the ozzo routing package.
+//
+// The mapping between CBOR and Go values mirrors that of encoding/json: structs are encoded as maps whose keys
+// are the field names, which can be customized using the "cbor" field tags, or the "json" field tags in absence
+// of the "cbor" ones. The tag options "omitempty" and "-" are also supported. time.Time values are encoded as
+// RFC 3339 date/time strings (tag 0).
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The CBOR major types.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+	majorFloat  = 8 // a pseudo major type used by the decoder to distinguish floats from simple values
+)
+
+// The simple values and the tag numbers used by the package.
+const (
+	simpleFalse     = 0xf4
+	simpleTrue      = 0xf5
+	simpleNull      = 0xf6
+	simpleUndefined = 0xf7
+	tagDateTime     = 0
+	tagEpochTime    = 1
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Marshal returns the CBOR encoding of the given value. The entries of maps are sorted by their encoded keys,
+// so that the same value always has the same encoding.
+func Marshal(v interface{}) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// Encoder writes CBOR data items to an output stream.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w}
+}
+
+// Encode writes the CBOR encoding of v to the stream.
+func (enc *Encoder) Encode(v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = enc.w.Write(data)
+	return err
+}
+
+// UnsupportedTypeError is returned by Marshal when attempting to encode an unsupported value type.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "cbor: unsupported type: " + e.Type.String()
+}
+
+type encoder struct {
+	bytes.Buffer
+}
+
+// writeHead writes the initial byte and the argument of a data item.
+func (e *encoder) writeHead(major byte, n uint64) {
+	switch {
+	case n < 24:
+		e.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		e.Write([]byte{major<<5 | 24, byte(n)})
+	case n <= math.MaxUint16:
+		var b [3]byte
+		b[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		e.Write(b[:])
+	case n <= math.MaxUint32:
+		var b [5]byte
+		b[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		e.Write(b[:])
+	default:
+		var b [9]byte
+		b[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		e.Write(b[:])
+	}
+}
+
+func (e *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.WriteByte(simpleNull)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.writeHead(majorTag, tagDateTime)
+		s := v.Interface().(time.Time).Format(time.RFC3339Nano)
+		e.writeHead(majorText, uint64(len(s)))
+		e.WriteString(s)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.WriteByte(simpleTrue)
+		} else {
+			e.WriteByte(simpleFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); n >= 0 {
+			e.writeHead(majorUint, uint64(n))
+		} else {
+			e.writeHead(majorNegInt, uint64(-1-n))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeHead(majorUint, v.Uint())
+	case reflect.Float32:
+		var b [5]byte
+		b[0] = majorSimple<<5 | 26
+		binary.BigEndian.PutUint32(b[1:], math.Float32bits(float32(v.Float())))
+		e.Write(b[:])
+	case reflect.Float64:
+		var b [9]byte
+		b[0] = majorSimple<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(v.Float()))
+		e.Write(b[:])
+	case reflect.String:
+		e.writeHead(majorText, uint64(v.Len()))
+		e.WriteString(v.String())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.WriteByte(simpleNull)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.WriteByte(simpleNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.writeHead(majorBytes, uint64(v.Len()))
+			e.Write(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.WriteByte(simpleNull)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return &UnsupportedTypeError{v.Type()}
+	}
+	return nil
+}
+
+func (e *encoder) encodeArray(v reflect.Value) error {
+	e.writeHead(majorArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) encodeMap(v reflect.Value) error {
+	type entry struct {
+		key, value []byte
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, value := &encoder{}, &encoder{}
+		if err := key.encode(iter.Key()); err != nil {
+			return err
+		}
+		if err := value.encode(iter.Value()); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key.Bytes(), value.Bytes()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	e.writeHead(majorMap, uint64(len(entries)))
+	for _, entry := range entries {
+		e.Write(entry.key)
+		e.Write(entry.value)
+	}
+	return nil
+}
+
+func (e *encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		values = append(values, fv)
+		names = append(names, f.name)
+	}
+	e.writeHead(majorMap, uint64(len(values)))
+	for i, fv := range values {
+		e.writeHead(majorText, uint64(len(names[i])))
+		e.WriteString(names[i])
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field describes a struct field that is encoded as a map entry.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map
+
+// cachedFields returns the encoded fields of the given struct type.
+func cachedFields(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+	fields := typeFields(t, nil, map[string]bool{})
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// typeFields collects the encoded fields of the struct type, including those promoted from the embedded structs.
+// The fields of an outer struct take precedence over the promoted fields with the same names.
+func typeFields(t reflect.Type, index []int, seen map[string]bool) []field {
+	fields := []field{}
+	embedded := []reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("cbor")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name, opts = tag[:j], tag[j:]
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, sf)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		fields = append(fields, field{
+			name:      name,
+			index:     append(append([]int{}, index...), i),
+			omitEmpty: strings.Contains(opts, ",omitempty"),
+		})
+	}
+	for _, sf := range embedded {
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fields = append(fields, typeFields(ft, append(append([]int{}, index...), sf.Index...), seen)...)
+	}
+	return fields
+}
+
+// fieldByIndex returns the nested field of the struct value. False is returned if the field is in
+// an embedded struct referenced by a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue checks if the value is empty as defined by the "omitempty" option of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// typeName returns the name of a major type used in error messages.
+func typeName(major byte) string {
+	return [...]string{"unsigned integer", "negative integer", "byte string", "text string", "array", "map", "tag", "simple value", "float"}[major]
+}
+
+// errorf creates an error prefixed by the package name.
+func errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("cbor: "+format, args...)
+}
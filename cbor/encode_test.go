@@ -0,0 +1,104 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type encodeUser struct {
+	ID       int    `cbor:"id"`
+	Name     string `json:"name"`
+	Email    string `cbor:"email,omitempty"`
+	Password string `cbor:"-"`
+	Age      uint8
+	internal int
+}
+
+type encodeAuthor struct {
+	encodeUser
+	Name string `cbor:"name"`
+}
+
+func TestMarshal(t *testing.T) {
+	var nilPtr *int
+	tests := []struct {
+		tag      string
+		value    interface{}
+		expected string
+	}{
+		// the examples from RFC 8949 Appendix A
+		{"t1", 0, "00"},
+		{"t2", 1, "01"},
+		{"t3", 23, "17"},
+		{"t4", 24, "1818"},
+		{"t5", 100, "1864"},
+		{"t6", 1000, "1903e8"},
+		{"t7", 1000000, "1a000f4240"},
+		{"t8", uint64(1000000000000), "1b000000e8d4a51000"},
+		{"t9", uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{"t10", -1, "20"},
+		{"t11", -10, "29"},
+		{"t12", -100, "3863"},
+		{"t13", -1000, "3903e7"},
+		{"t14", int64(math.MinInt64), "3b7fffffffffffffff"},
+		{"t15", 1.1, "fb3ff199999999999a"},
+		{"t16", float32(100000.0), "fa47c35000"},
+		{"t17", false, "f4"},
+		{"t18", true, "f5"},
+		{"t19", nil, "f6"},
+		{"t20", "", "60"},
+		{"t21", "a", "6161"},
+		{"t22", "IETF", "6449455446"},
+		{"t23", "ü", "62c3bc"},
+		{"t24", []byte{1, 2, 3, 4}, "4401020304"},
+		{"t25", []int{}, "80"},
+		{"t26", []int{1, 2, 3}, "83010203"},
+		{"t27", []interface{}{1, []int{2, 3}, [2]int{4, 5}}, "8301820203820405"},
+		{"t28", map[string]int{}, "a0"},
+		{"t29", map[int]int{3: 4, 1: 2}, "a201020304"},
+		{"t30", map[string]interface{}{"b": []int{2, 3}, "a": 1}, "a26161016162820203"},
+		{"t31", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "c074323031332d30332d32315432303a30343a30305a"},
+		// nil values
+		{"t32", nilPtr, "f6"},
+		{"t33", []int(nil), "f6"},
+		{"t34", map[string]int(nil), "f6"},
+		{"t35", &[]int{1}, "8101"},
+		// structs
+		{"t36", encodeUser{ID: 1, Name: "a", Password: "x", Age: 2}, "a362696401646e616d6561616341676502"},
+		{"t37", encodeUser{Email: "e"}, "a462696400646e616d656065656d61696c61656341676500"},
+		{"t38", encodeAuthor{encodeUser{ID: 1, Name: "x"}, "b"}, "a3646e616d656162626964016341676500"},
+	}
+	for _, test := range tests {
+		data, err := Marshal(test.value)
+		if assert.Nil(t, err, test.tag) {
+			assert.Equal(t, test.expected, hex.EncodeToString(data), test.tag)
+		}
+	}
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.Equal(t, "cbor: unsupported type: chan int", err.Error())
+
+	_, err = Marshal(map[string]interface{}{"a": func() {}})
+	assert.IsType(t, &UnsupportedTypeError{}, err)
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	assert.Nil(t, enc.Encode(1))
+	assert.Nil(t, enc.Encode("a"))
+	assert.Equal(t, "016161", hex.EncodeToString(buf.Bytes()))
+	assert.NotNil(t, enc.Encode(make(chan int)))
+	assert.Equal(t, "016161", hex.EncodeToString(buf.Bytes()))
+}
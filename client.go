@@ -0,0 +1,126 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// RequestIDHeader is the request header carrying the request ID. It is reported as the request ID by SlogAttrs
+// and propagated to outbound requests by Context.HTTPClient.
+const RequestIDHeader = "X-Request-ID"
+
+// PropagatedHeaders lists the request headers that are copied from the current request to the outbound requests
+// made with the client returned by Context.HTTPClient. By default, it includes the request ID and the trace context
+// headers of W3C Trace Context and Zipkin B3. You may modify this variable to propagate other headers.
+var PropagatedHeaders = []string{
+	RequestIDHeader,
+	"traceparent",
+	"tracestate",
+	"baggage",
+	"b3",
+	"X-B3-TraceId",
+	"X-B3-SpanId",
+	"X-B3-ParentSpanId",
+	"X-B3-Sampled",
+	"X-B3-Flags",
+}
+
+// HTTPClient returns an HTTP client for making outbound requests while handling the current request, so that
+// the correlation between the requests is kept. The client is a copy of the given client (or http.DefaultClient
+// if not given) whose transport:
+//
+//   - cancels the outbound requests when the current request is canceled or times out, e.g. when the client
+//     disconnects, so that the downstream services do not keep working for nothing;
+//   - copies the headers listed in PropagatedHeaders from the current request, unless they are already set.
+//
+// Because the outbound requests are canceled when the handling of the current request finishes,
+// the client should not be used by the goroutines that outlive the handlers. Middleware that generates
+// request IDs or starts new trace spans should set the corresponding headers of Context.Request in order
+// to propagate them.
+//
+//     func getUser(c *routing.Context) error {
+//         res, err := c.HTTPClient().Get("http://profile-service/users/" + c.Param("id"))
+//         ...
+//     }
+func (c *Context) HTTPClient(client ...*http.Client) *http.Client {
+	base := http.DefaultClient
+	if len(client) > 0 && client[0] != nil {
+		base = client[0]
+	}
+	copied := *base
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	copied.Transport = &contextTransport{transport, c.Request}
+	return &copied
+}
+
+// contextTransport binds outbound requests to an incoming request.
+type contextTransport struct {
+	http.RoundTripper
+	from *http.Request
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done := t.from.Context().Done()
+	if done == nil {
+		return t.RoundTripper.RoundTrip(t.outbound(req, req.Context()))
+	}
+
+	// cancel the outbound request when the current request is canceled, until the response body is closed
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(stop)
+			cancel()
+		})
+	}
+	res, err := t.RoundTripper.RoundTrip(t.outbound(req, ctx))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	res.Body = &releaseBody{res.Body, release}
+	return res, nil
+}
+
+// outbound returns a copy of the outbound request with the given context and the propagated headers.
+// A RoundTripper should not modify the original request.
+func (t *contextTransport) outbound(req *http.Request, ctx context.Context) *http.Request {
+	out := req.Clone(ctx)
+	for _, name := range PropagatedHeaders {
+		key := http.CanonicalHeaderKey(name)
+		if values := t.from.Header[key]; len(values) > 0 && len(out.Header[key]) == 0 {
+			out.Header[key] = append([]string(nil), values...)
+		}
+	}
+	return out
+}
+
+// releaseBody calls the release function when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripperFunc is an adapter that allows a function to be used as an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestContextHTTPClient(t *testing.T) {
+	var outbound *http.Request
+	base := &http.Client{
+		Timeout: time.Minute,
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			outbound = req
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Request-ID", "abc")
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	req.Header.Set("Authorization", "Bearer secret")
+	c := NewContext(nil, req)
+
+	client := c.HTTPClient(base)
+	assert.Equal(t, time.Minute, client.Timeout)
+	assert.IsType(t, &contextTransport{}, client.Transport)
+
+	res, err := client.Get("http://example.com/profiles")
+	if assert.Nil(t, err) {
+		res.Body.Close()
+		assert.Equal(t, "abc", outbound.Header.Get("X-Request-ID"))
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", outbound.Header.Get("Traceparent"))
+		assert.Equal(t, "", outbound.Header.Get("Authorization"))
+	}
+
+	// the headers and the context that are set explicitly are kept
+	ctx2 := context.WithValue(context.Background(), "key", "value")
+	out, _ := http.NewRequest("GET", "http://example.com/profiles", nil)
+	out = out.WithContext(ctx2)
+	out.Header.Set("X-Request-ID", "xyz")
+	_, err = client.Do(out)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", outbound.Context().Value("key"))
+	assert.Equal(t, "xyz", outbound.Header.Get("X-Request-ID"))
+	assert.Equal(t, "", out.Header.Get("Traceparent"))
+
+	// the outbound requests are canceled with the current request
+	cancel()
+	client = c.HTTPClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})})
+	_, err = client.Get("http://example.com/profiles")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestContextHTTPClientDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request-ID")))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	c := NewContext(nil, req)
+	client := c.HTTPClient()
+	assert.Zero(t, client.Timeout)
+	res, err := client.Get(server.URL)
+	if assert.Nil(t, err) {
+		defer res.Body.Close()
+		buf := make([]byte, 3)
+		res.Body.Read(buf)
+		assert.Equal(t, "abc", string(buf))
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONCodec encodes and decodes JSON data. It is used by JSONDataReader and content.JSONDataWriter, and can be
+// replaced to use a faster JSON library. The configurations of json-iterator (e.g. jsoniter.ConfigFastest) and
+// the sonic APIs (e.g. sonic.ConfigDefault) implement this interface without any adapter.
+type JSONCodec interface {
+	// Marshal returns the JSON encoding of v.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal parses the JSON-encoded data and stores the result in the value pointed to by v.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// DefaultJSONCodec is the JSON codec used by the JSON data readers and writers that do not specify their own codecs.
+// You may modify this variable before serving requests to use another JSON library in the whole application, e.g.
+//
+//     routing.DefaultJSONCodec = jsoniter.ConfigCompatibleWithStandardLibrary
+var DefaultJSONCodec JSONCodec = StdJSONCodec{}
+
+// StdJSONCodec is the JSON codec based on encoding/json. Unlike json.Marshal, it does not escape the HTML characters
+// in strings, which is consistent with how JSON data is written to responses.
+type StdJSONCodec struct{}
+
+// Marshal returns the JSON encoding of v.
+func (StdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	// remove the newline appended by the encoder
+	return buf.Bytes()[:buf.Len()-1], nil
+}
+
+// Unmarshal parses the JSON-encoded data and stores the result in the value pointed to by v.
+func (StdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
@@ -0,0 +1,102 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingCodec is a JSON codec that counts its calls.
+type countingCodec struct {
+	StdJSONCodec
+	marshals, unmarshals int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return c.StdJSONCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return c.StdJSONCodec.Unmarshal(data, v)
+}
+
+func TestStdJSONCodec(t *testing.T) {
+	var codec StdJSONCodec
+	data, err := codec.Marshal(map[string]string{"a": "<b>"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":"<b>"}`, string(data))
+
+	_, err = codec.Marshal(func() {})
+	assert.NotNil(t, err)
+
+	var value map[string]string
+	assert.Nil(t, codec.Unmarshal([]byte(`{"a":"b"}`), &value))
+	assert.Equal(t, map[string]string{"a": "b"}, value)
+	assert.NotNil(t, codec.Unmarshal([]byte(`{`), &value))
+}
+
+func TestJSONDataReaderCodec(t *testing.T) {
+	codec := &countingCodec{}
+	r := &JSONDataReader{Codec: codec}
+	var data FA
+	req, _ := http.NewRequest("POST", "/test", bytes.NewBufferString(`{"A1":"abc","A2":100}`))
+	assert.Nil(t, r.Read(req, &data))
+	assert.Equal(t, FA{"abc", 100}, data)
+	assert.Equal(t, 1, codec.unmarshals)
+
+	req, _ = http.NewRequest("POST", "/test", bytes.NewBufferString(`{"A1":`))
+	assert.NotNil(t, r.Read(req, &data))
+	assert.Equal(t, 2, codec.unmarshals)
+
+	req, _ = http.NewRequest("POST", "/test", errReader{})
+	assert.NotNil(t, r.Read(req, &data))
+	assert.Equal(t, 2, codec.unmarshals)
+
+	// the default codec
+	DefaultJSONCodec = codec
+	defer func() { DefaultJSONCodec = StdJSONCodec{} }()
+	req, _ = http.NewRequest("POST", "/test", bytes.NewBufferString(`{"A1":"xyz","A2":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	c := NewContext(nil, req)
+	assert.Nil(t, c.Read(&data))
+	assert.Equal(t, FA{"xyz", 1}, data)
+	assert.Equal(t, 3, codec.unmarshals)
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func BenchmarkJSONDataReader(b *testing.B) {
+	body, _ := json.Marshal(FB{"abc", true, 1.5})
+	codecs := map[string]JSONCodec{
+		"Stream":    StdJSONCodec{},
+		"Unmarshal": &countingCodec{},
+	}
+	for name, codec := range codecs {
+		b.Run(name, func(b *testing.B) {
+			r := &JSONDataReader{Codec: codec}
+			req, _ := http.NewRequest("POST", "/test", nil)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var data FB
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				r.Read(req, &data)
+			}
+		})
+	}
+}
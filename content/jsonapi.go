@@ -0,0 +1,364 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// JSONAPI is the media type defined by the JSON:API specification (https://jsonapi.org).
+const JSONAPI = "application/vnd.api+json"
+
+type (
+	// JSONAPIResource is implemented by the data that can be written as JSON:API resource objects.
+	// All exported fields of the data, except the one named "id" in JSON, are written as resource attributes.
+	JSONAPIResource interface {
+		// JSONAPIType returns the resource type.
+		JSONAPIType() string
+		// JSONAPIID returns the resource ID.
+		JSONAPIID() string
+	}
+
+	// JSONAPIRelator can be implemented by a JSONAPIResource to declare its relationships.
+	JSONAPIRelator interface {
+		// JSONAPIRelationships returns the related resources indexed by the relationship names.
+		// Each value should be either a JSONAPIResource, a slice of JSONAPIResource, or nil.
+		// The JSON fields named after the relationships are excluded from the resource attributes.
+		JSONAPIRelationships() map[string]interface{}
+	}
+
+	// JSONAPILinker can be implemented by a JSONAPIResource to generate its "self" link using a named route.
+	JSONAPILinker interface {
+		// JSONAPILink returns the route name and the parameters (name1, value1, name2, value2, ...) for building the link.
+		JSONAPILink() (route string, pairs []interface{})
+	}
+
+	// JSONAPIDataWriter sets the "Content-Type" response header as "application/vnd.api+json" and writes the given data
+	// as a JSON:API document to the response. A JSONAPIResource or a slice of them is written as the primary data,
+	// while an error is written as an error object whose status is determined by routing.HTTPError.
+	JSONAPIDataWriter struct {
+		// Router is used to generate the "self" links of the resources implementing JSONAPILinker.
+		// If nil, no link will be generated.
+		Router *routing.Router
+	}
+
+	// JSONAPIDataReader reads a JSON:API document from the request body and populates the primary data
+	// into the given struct (or slice of structs). The resource ID is populated into the field named "id" in JSON,
+	// the attributes into the fields with the same JSON names, and each relationship into the field named after
+	// the relationship as the ID (or a list of IDs) of the related resources. The IDs, which are strings in
+	// JSON:API documents, are converted into numbers for the fields of numeric types, such as int.
+	//
+	// To enable JSONAPIDataReader, register it with routing.DataReaders:
+	//
+	//     routing.DataReaders[content.JSONAPI] = &content.JSONAPIDataReader{}
+	JSONAPIDataReader struct{}
+
+	jsonapiDocument struct {
+		Data   interface{}        `json:"data,omitempty"`
+		Errors []jsonapiErrorItem `json:"errors,omitempty"`
+	}
+
+	jsonapiResource struct {
+		Type          string                         `json:"type"`
+		ID            string                         `json:"id,omitempty"`
+		Attributes    map[string]json.RawMessage     `json:"attributes,omitempty"`
+		Relationships map[string]jsonapiRelationship `json:"relationships,omitempty"`
+		Links         map[string]string              `json:"links,omitempty"`
+	}
+
+	jsonapiRelationship struct {
+		Data json.RawMessage `json:"data"`
+	}
+
+	jsonapiIdentifier struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+
+	jsonapiErrorItem struct {
+		Status string `json:"status"`
+		Code   string `json:"code,omitempty"`
+		Title  string `json:"title"`
+		Detail string `json:"detail,omitempty"`
+	}
+)
+
+var errJSONAPIData = errors.New("jsonapi: data must be a resource or a list of resources")
+
+// SetHeader sets the Content-Type response header.
+func (w *JSONAPIDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", JSONAPI)
+}
+
+func (w *JSONAPIDataWriter) Write(res http.ResponseWriter, data interface{}) error {
+	doc, err := w.buildDocument(data)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(res)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+func (w *JSONAPIDataWriter) buildDocument(data interface{}) (*jsonapiDocument, error) {
+	if err, ok := data.(error); ok {
+		return &jsonapiDocument{Errors: []jsonapiErrorItem{newJSONAPIErrorItem(err)}}, nil
+	}
+	if data == nil {
+		return &jsonapiDocument{Data: json.RawMessage("null")}, nil
+	}
+	if resource, ok := data.(JSONAPIResource); ok {
+		r, err := w.buildResource(resource)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonapiDocument{Data: r}, nil
+	}
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, errJSONAPIData
+	}
+	resources := make([]*jsonapiResource, rv.Len())
+	for i := range resources {
+		resource, ok := rv.Index(i).Interface().(JSONAPIResource)
+		if !ok {
+			return nil, errJSONAPIData
+		}
+		r, err := w.buildResource(resource)
+		if err != nil {
+			return nil, err
+		}
+		resources[i] = r
+	}
+	return &jsonapiDocument{Data: resources}, nil
+}
+
+func (w *JSONAPIDataWriter) buildResource(resource JSONAPIResource) (*jsonapiResource, error) {
+	b, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &attributes); err != nil {
+		return nil, errJSONAPIData
+	}
+	delete(attributes, "id")
+
+	r := &jsonapiResource{
+		Type:       resource.JSONAPIType(),
+		ID:         resource.JSONAPIID(),
+		Attributes: attributes,
+	}
+
+	if relator, ok := resource.(JSONAPIRelator); ok {
+		r.Relationships = map[string]jsonapiRelationship{}
+		for name, related := range relator.JSONAPIRelationships() {
+			delete(attributes, name)
+			data, err := marshalJSONAPIIdentifiers(related)
+			if err != nil {
+				return nil, err
+			}
+			r.Relationships[name] = jsonapiRelationship{Data: data}
+		}
+	}
+
+	if linker, ok := resource.(JSONAPILinker); ok && w.Router != nil {
+		name, pairs := linker.JSONAPILink()
+		if route := w.Router.Route(name); route != nil {
+			r.Links = map[string]string{"self": route.URL(pairs...)}
+		}
+	}
+
+	return r, nil
+}
+
+// marshalJSONAPIIdentifiers builds the resource linkage of a relationship.
+func marshalJSONAPIIdentifiers(related interface{}) (json.RawMessage, error) {
+	if related == nil {
+		return json.RawMessage("null"), nil
+	}
+	if resource, ok := related.(JSONAPIResource); ok {
+		return json.Marshal(jsonapiIdentifier{resource.JSONAPIType(), resource.JSONAPIID()})
+	}
+	rv := reflect.ValueOf(related)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, errJSONAPIData
+	}
+	identifiers := make([]jsonapiIdentifier, rv.Len())
+	for i := range identifiers {
+		resource, ok := rv.Index(i).Interface().(JSONAPIResource)
+		if !ok {
+			return nil, errJSONAPIData
+		}
+		identifiers[i] = jsonapiIdentifier{resource.JSONAPIType(), resource.JSONAPIID()}
+	}
+	return json.Marshal(identifiers)
+}
+
+// newJSONAPIErrorItem converts an error into a JSON:API error object.
+func newJSONAPIErrorItem(err error) jsonapiErrorItem {
+	status := http.StatusInternalServerError
+	if httpError, ok := err.(routing.HTTPError); ok {
+		status = httpError.StatusCode()
+	}
+	item := jsonapiErrorItem{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+	}
+	if codedError, ok := err.(routing.CodedError); ok {
+		item.Code = codedError.ErrorCode()
+	}
+	if detail := err.Error(); detail != item.Title {
+		item.Detail = detail
+	}
+	return item
+}
+
+func (r *JSONAPIDataReader) Read(req *http.Request, data interface{}) error {
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&doc); err != nil {
+		return err
+	}
+	raw := bytes.TrimSpace(doc.Data)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	numeric := jsonapiNumericFields(reflect.TypeOf(data))
+	var flattened interface{}
+	if raw[0] == '[' {
+		var resources []jsonapiResource
+		if err := json.Unmarshal(raw, &resources); err != nil {
+			return err
+		}
+		items := make([]map[string]json.RawMessage, len(resources))
+		for i, resource := range resources {
+			item, err := flattenJSONAPIResource(resource, numeric)
+			if err != nil {
+				return err
+			}
+			items[i] = item
+		}
+		flattened = items
+	} else {
+		var resource jsonapiResource
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			return err
+		}
+		item, err := flattenJSONAPIResource(resource, numeric)
+		if err != nil {
+			return err
+		}
+		flattened = item
+	}
+
+	b, err := json.Marshal(flattened)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, data)
+}
+
+// flattenJSONAPIResource merges the ID, attributes and relationships of a resource object into a single JSON object.
+// The IDs are written as JSON numbers for the fields whose names are in numeric.
+func flattenJSONAPIResource(resource jsonapiResource, numeric map[string]bool) (map[string]json.RawMessage, error) {
+	item := map[string]json.RawMessage{}
+	for name, value := range resource.Attributes {
+		item[name] = value
+	}
+	if resource.ID != "" {
+		item["id"] = marshalJSONAPIID(resource.ID, numeric["id"])
+	}
+	for name, relationship := range resource.Relationships {
+		raw := bytes.TrimSpace(relationship.Data)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			item[name] = json.RawMessage("null")
+			continue
+		}
+		var value interface{}
+		if raw[0] == '[' {
+			var identifiers []jsonapiIdentifier
+			if err := json.Unmarshal(raw, &identifiers); err != nil {
+				return nil, err
+			}
+			ids := make([]json.RawMessage, len(identifiers))
+			for i, identifier := range identifiers {
+				ids[i] = marshalJSONAPIID(identifier.ID, numeric[strings.ToLower(name)])
+			}
+			value = ids
+		} else {
+			var identifier jsonapiIdentifier
+			if err := json.Unmarshal(raw, &identifier); err != nil {
+				return nil, err
+			}
+			value = marshalJSONAPIID(identifier.ID, numeric[strings.ToLower(name)])
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		item[name] = b
+	}
+	return item, nil
+}
+
+// marshalJSONAPIID encodes a resource ID as a JSON number if number is true and the ID is a valid number,
+// or as a JSON string otherwise.
+func marshalJSONAPIID(id string, number bool) json.RawMessage {
+	if number {
+		if _, err := strconv.ParseFloat(id, 64); err == nil && json.Valid([]byte(id)) {
+			return json.RawMessage(id)
+		}
+	}
+	b, _ := json.Marshal(id)
+	return b
+}
+
+// jsonapiNumericFields returns the lower-cased JSON names of the fields of numeric types (or slices of them)
+// of the struct that the given data (a pointer to a struct or to a slice of structs) refers to.
+func jsonapiNumericFields(t reflect.Type) map[string]bool {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	fields := map[string]bool{}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag = strings.Split(tag, ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			fields[strings.ToLower(name)] = true
+		}
+	}
+	return fields
+}
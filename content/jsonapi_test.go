@@ -0,0 +1,127 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package content
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonapiUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (u jsonapiUser) JSONAPIType() string { return "users" }
+func (u jsonapiUser) JSONAPIID() string   { return u.ID }
+func (u jsonapiUser) JSONAPILink() (string, []interface{}) {
+	return "user", []interface{}{"id", u.ID}
+}
+
+type jsonapiPost struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Author   string   `json:"author"`
+	Comments []string `json:"comments"`
+}
+
+func (p jsonapiPost) JSONAPIType() string { return "posts" }
+func (p jsonapiPost) JSONAPIID() string   { return p.ID }
+func (p jsonapiPost) JSONAPIRelationships() map[string]interface{} {
+	return map[string]interface{}{
+		"author":   jsonapiUser{ID: p.Author},
+		"comments": []jsonapiUser{},
+	}
+}
+
+type jsonapiArticle struct {
+	ID     int     `json:"id"`
+	Title  string  `json:"title"`
+	Author int     `json:"author"`
+	Tags   []int64 `json:"tags"`
+}
+
+func (a jsonapiArticle) JSONAPIType() string { return "articles" }
+func (a jsonapiArticle) JSONAPIID() string   { return strconv.Itoa(a.ID) }
+
+func TestJSONAPIIntID(t *testing.T) {
+	// the resources with int IDs can be written and read back
+	articles := []jsonapiArticle{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}}
+	res := httptest.NewRecorder()
+	assert.Nil(t, (&JSONAPIDataWriter{}).Write(res, articles))
+	assert.Contains(t, res.Body.String(), `"id":"1"`)
+
+	req, _ := http.NewRequest("POST", "/articles", strings.NewReader(res.Body.String()))
+	var read []jsonapiArticle
+	assert.Nil(t, (&JSONAPIDataReader{}).Read(req, &read))
+	assert.Equal(t, articles, read)
+}
+
+func TestJSONAPIDataWriter(t *testing.T) {
+	router := routing.New()
+	router.Get("/users/<id>").Name("user")
+
+	res := httptest.NewRecorder()
+	w := &JSONAPIDataWriter{Router: router}
+	w.SetHeader(res)
+	assert.Nil(t, w.Write(res, jsonapiUser{"1", "abc"}))
+	assert.Equal(t, JSONAPI, res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"data":{"type":"users","id":"1","attributes":{"name":"abc"},"links":{"self":"/users/1"}}}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	w = &JSONAPIDataWriter{}
+	assert.Nil(t, w.Write(res, []jsonapiPost{{"1", "hello", "2", nil}}))
+	assert.Equal(t, `{"data":[{"type":"posts","id":"1","attributes":{"title":"hello"},"relationships":{"author":{"data":{"type":"users","id":"2"}},"comments":{"data":[]}}}]}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, w.Write(res, routing.NewHTTPError(http.StatusNotFound, "user not found")))
+	assert.Equal(t, `{"errors":[{"status":"404","title":"Not Found","detail":"user not found"}]}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, w.Write(res, &routing.ErrorCode{Status: http.StatusNotFound, Code: "user_not_found", Message: "Not Found"}))
+	assert.Equal(t, `{"errors":[{"status":"404","code":"user_not_found","title":"Not Found"}]}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, w.Write(res, nil))
+	assert.Equal(t, `{"data":null}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Equal(t, errJSONAPIData, w.Write(res, "abc"))
+}
+
+func TestJSONAPIDataReader(t *testing.T) {
+	r := &JSONAPIDataReader{}
+
+	body := `{"data":{"type":"posts","id":"1","attributes":{"title":"hello"},"relationships":{"author":{"data":{"type":"users","id":"2"}},"comments":{"data":[{"type":"comments","id":"3"},{"type":"comments","id":"4"}]}}}}`
+	req, _ := http.NewRequest("POST", "/posts", strings.NewReader(body))
+	var post jsonapiPost
+	assert.Nil(t, r.Read(req, &post))
+	assert.Equal(t, jsonapiPost{"1", "hello", "2", []string{"3", "4"}}, post)
+
+	body = `{"data":[{"type":"users","id":"1","attributes":{"name":"abc"}},{"type":"users","attributes":{"name":"xyz"}}]}`
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(body))
+	var users []jsonapiUser
+	assert.Nil(t, r.Read(req, &users))
+	assert.Equal(t, []jsonapiUser{{"1", "abc"}, {"", "xyz"}}, users)
+
+	// the IDs are converted for the numeric fields
+	body = `{"data":{"type":"articles","id":"7","attributes":{"title":"hello"},"relationships":{"author":{"data":{"type":"users","id":"2"}},"tags":{"data":[{"type":"tags","id":"3"}]}}}}`
+	req, _ = http.NewRequest("POST", "/articles", strings.NewReader(body))
+	var article jsonapiArticle
+	assert.Nil(t, r.Read(req, &article))
+	assert.Equal(t, jsonapiArticle{7, "hello", 2, []int64{3}}, article)
+
+	req, _ = http.NewRequest("POST", "/articles", strings.NewReader(`{"data":{"type":"articles","id":"abc"}}`))
+	assert.NotNil(t, r.Read(req, &article))
+
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"data":`))
+	assert.NotNil(t, r.Read(req, &users))
+}
@@ -27,6 +27,9 @@ const Language = "Language"
 //     }
 //
 // If you do not specify languages, the negotiator will set the language to be "en-US".
+//
+// The chosen language is sent as the Content-Language response header unless a handler overwrites it,
+// and Accept-Language is added to the Vary response header if more than one language is supported.
 func LanguageNegotiator(languages ...string) routing.Handler {
 	if len(languages) == 0 {
 		languages = []string{"en-US"}
@@ -36,6 +39,11 @@ func LanguageNegotiator(languages ...string) routing.Handler {
 	return func(c *routing.Context) error {
 		language := negotiateLanguage(c.Request, languages, defaultLanguage)
 		c.Set(Language, language)
+		c.Negotiation().Language = language
+		c.Response.Header().Set("Content-Language", language)
+		if len(languages) > 1 {
+			c.Vary("Accept-Language")
+		}
 		return nil
 	}
 }
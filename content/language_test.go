@@ -37,3 +37,21 @@ func TestLanguageNegotiator(t *testing.T) {
 	assert.Nil(t, h(c))
 	assert.Equal(t, "en", c.Get(Language))
 }
+
+func TestLanguageNegotiatorHeaders(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	req.Header.Set("Accept-Language", "zh-CN")
+
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req)
+	assert.Nil(t, LanguageNegotiator("en", "zh-CN")(c))
+	assert.Equal(t, "zh-CN", res.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", res.Header().Get("Vary"))
+	assert.Equal(t, "zh-CN", c.Negotiation().Language)
+
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req)
+	assert.Nil(t, LanguageNegotiator("en")(c))
+	assert.Equal(t, "en", res.Header().Get("Content-Language"))
+	assert.Equal(t, "", res.Header().Get("Vary"))
+}
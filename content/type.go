@@ -9,8 +9,10 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"net/http"
+	"strings"
 
 	routing "github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/cbor"
 )
 
 // MIME types
@@ -19,16 +21,44 @@ const (
 	XML  = routing.MIME_XML
 	XML2 = routing.MIME_XML2
 	HTML = routing.MIME_HTML
+	CBOR = routing.MIME_CBOR
 )
 
 // DataWriters lists all supported content types and the corresponding data writers.
-// By default, JSON, XML, and HTML are supported. You may modify this variable before calling TypeNegotiator
+// By default, JSON, XML, HTML, JSON:API, and CBOR are supported. You may modify this variable before calling TypeNegotiator
 // to customize supported data writers.
 var DataWriters = map[string]routing.DataWriter{
-	JSON: &JSONDataWriter{},
-	XML:  &XMLDataWriter{},
-	XML2: &XMLDataWriter{},
-	HTML: &HTMLDataWriter{},
+	JSON:    &JSONDataWriter{},
+	XML:     &XMLDataWriter{},
+	XML2:    &XMLDataWriter{},
+	HTML:    &HTMLDataWriter{},
+	JSONAPI: &JSONAPIDataWriter{},
+	CBOR:    &CBORDataWriter{},
+}
+
+// FormatNames maps the format names that may be given in the query parameter or the header specified by
+// TypeOptions to the corresponding MIME types. You may modify this variable before calling TypeNegotiatorWithOptions
+// to support other names.
+var FormatNames = map[string]string{
+	"json":    JSON,
+	"xml":     XML,
+	"html":    HTML,
+	"jsonapi": JSONAPI,
+	"cbor":    CBOR,
+}
+
+// TypeOptions specifies how TypeNegotiatorWithOptions lets the clients override the Accept header, which is convenient
+// for testing APIs in browsers that cannot set the Accept header.
+type TypeOptions struct {
+	// QueryParam is the query parameter whose value, such as "json" or "xml", overrides the Accept header, e.g. "format".
+	// If empty, the query parameters are not consulted.
+	QueryParam string
+	// Header is the request header whose value overrides the Accept header, e.g. "X-Response-Format".
+	// QueryParam takes precedence over it. If empty, no header other than Accept is consulted.
+	Header string
+	// Overridable lists the format names in FormatNames that the clients can choose via QueryParam or Header.
+	// The format must also be among those passed to TypeNegotiatorWithOptions. If empty, all of them can be chosen.
+	Overridable []string
 }
 
 // TypeNegotiator returns a content type negotiation handler.
@@ -42,6 +72,24 @@ var DataWriters = map[string]routing.DataWriter{
 //
 // If you do not specify any supported MIME types, the negotiator will use "text/html" as the response MIME type.
 func TypeNegotiator(formats ...string) routing.Handler {
+	return TypeNegotiatorWithOptions(TypeOptions{}, formats...)
+}
+
+// TypeNegotiatorWithOptions returns a content type negotiation handler that works like TypeNegotiator, except that
+// the format named in the query parameter or the header specified by the options takes precedence over the Accept
+// header. The format names are looked up in FormatNames, and the unknown or disallowed names are ignored.
+// For example, the following negotiator responds in XML to "GET /users?format=xml" regardless of the Accept header:
+//
+//     r.Use(content.TypeNegotiatorWithOptions(content.TypeOptions{
+//         QueryParam:  "format",
+//         Header:      "X-Response-Format",
+//         Overridable: []string{"json", "xml"},
+//     }, content.JSON, content.XML))
+//
+// If Header is set, it is added to the Vary response header so that caches store the responses separately.
+// Accept is added as well when the format is negotiated among several formats by the Accept header.
+// The chosen format is recorded in routing.Context.Negotiation.
+func TypeNegotiatorWithOptions(options TypeOptions, formats ...string) routing.Handler {
 	if len(formats) == 0 {
 		formats = []string{HTML}
 	}
@@ -50,16 +98,52 @@ func TypeNegotiator(formats ...string) routing.Handler {
 			panic(format + " is not supported")
 		}
 	}
+	overrides := map[string]string{}
+	if options.QueryParam != "" || options.Header != "" {
+		names := options.Overridable
+		if len(names) == 0 {
+			for name := range FormatNames {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			for _, format := range formats {
+				if FormatNames[name] == format {
+					overrides[strings.ToLower(name)] = format
+				}
+			}
+		}
+	}
 
 	return func(c *routing.Context) error {
-		format := NegotiateContentType(c.Request, formats, formats[0])
+		format := ""
+		if options.QueryParam != "" {
+			format = overrides[strings.ToLower(c.Request.URL.Query().Get(options.QueryParam))]
+		}
+		if options.Header != "" {
+			c.Vary(options.Header)
+			if format == "" {
+				format = overrides[strings.ToLower(strings.TrimSpace(c.Request.Header.Get(options.Header)))]
+			}
+		}
+		if format == "" {
+			format = NegotiateContentType(c.Request, formats, formats[0])
+			if len(formats) > 1 {
+				c.Vary("Accept")
+			}
+		}
+		c.Negotiation().ContentType = format
 		c.SetDataWriter(DataWriters[format])
 		return nil
 	}
 }
 
 // JSONDataWriter sets the "Content-Type" response header as "application/json" and writes the given data in JSON format to the response.
-type JSONDataWriter struct{}
+// A json.RawMessage is written as is without being encoded again.
+type JSONDataWriter struct {
+	// Codec is the JSON codec used to encode the data. If nil, routing.DefaultJSONCodec is used.
+	Codec routing.JSONCodec
+}
 
 // SetHeader sets the Content-Type response header.
 func (w *JSONDataWriter) SetHeader(res http.ResponseWriter) {
@@ -67,9 +151,28 @@ func (w *JSONDataWriter) SetHeader(res http.ResponseWriter) {
 }
 
 func (w *JSONDataWriter) Write(res http.ResponseWriter, data interface{}) (err error) {
-	enc := json.NewEncoder(res)
-	enc.SetEscapeHTML(false)
-	return enc.Encode(data)
+	if raw, ok := data.(json.RawMessage); ok {
+		// the data is already encoded; do not append to it as it may be shared
+		if _, err = res.Write(raw); err == nil {
+			_, err = res.Write([]byte{'\n'})
+		}
+		return
+	}
+	codec := w.Codec
+	if codec == nil {
+		codec = routing.DefaultJSONCodec
+	}
+	if _, ok := codec.(routing.StdJSONCodec); ok {
+		enc := json.NewEncoder(res)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(data)
+	}
+	var bytes []byte
+	if bytes, err = codec.Marshal(data); err != nil {
+		return
+	}
+	_, err = res.Write(append(bytes, '\n'))
+	return
 }
 
 // XMLDataWriter sets the "Content-Type" response header as "application/xml; charset=UTF-8" and writes the given data in XML format to the response.
@@ -100,3 +203,15 @@ func (w *HTMLDataWriter) SetHeader(res http.ResponseWriter) {
 func (w *HTMLDataWriter) Write(res http.ResponseWriter, data interface{}) error {
 	return routing.DefaultDataWriter.Write(res, data)
 }
+
+// CBORDataWriter sets the "Content-Type" response header as "application/cbor" and writes the given data in CBOR format (RFC 8949) to the response.
+type CBORDataWriter struct{}
+
+// SetHeader sets the Content-Type response header.
+func (w *CBORDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/cbor")
+}
+
+func (w *CBORDataWriter) Write(res http.ResponseWriter, data interface{}) error {
+	return cbor.NewEncoder(res).Encode(data)
+}
@@ -5,8 +5,11 @@
 package content
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/go-ozzo/ozzo-routing/v2"
@@ -21,6 +24,111 @@ func TestJSONFormatter(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
 	assert.Equal(t, "\"xyz\"\n", res.Body.String())
+
+	// pre-encoded data is written as is
+	raw := make([]byte, 0, 32)
+	raw = append(raw, `{ "a": 1 }`...)
+	res = httptest.NewRecorder()
+	assert.Nil(t, w.Write(res, json.RawMessage(raw)))
+	assert.Equal(t, "{ \"a\": 1 }\n", res.Body.String())
+	assert.Equal(t, byte(0), raw[:len(raw)+1][len(raw)], "the spare capacity of the data is not written")
+}
+
+// benchItem is the data written in the JSON data writer benchmarks.
+type benchItem struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// itemCodec is a JSON codec with a hand-written encoder for []benchItem, similar to the code generated by
+// easyjson or the JIT encoders of sonic. The other values are encoded with encoding/json.
+type itemCodec struct {
+	routing.StdJSONCodec
+}
+
+func (c itemCodec) Marshal(v interface{}) ([]byte, error) {
+	items, ok := v.([]benchItem)
+	if !ok {
+		return c.StdJSONCodec.Marshal(v)
+	}
+	b := make([]byte, 0, 64*len(items))
+	b = append(b, '[')
+	for i, item := range items {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, `{"id":`...)
+		b = strconv.AppendInt(b, int64(item.ID), 10)
+		b = append(b, `,"name":`...)
+		b = strconv.AppendQuote(b, item.Name)
+		b = append(b, `,"price":`...)
+		b = strconv.AppendFloat(b, item.Price, 'g', -1, 64)
+		b = append(b, '}')
+	}
+	return append(b, ']'), nil
+}
+
+type failingCodec struct {
+	routing.StdJSONCodec
+}
+
+func (failingCodec) Marshal(v interface{}) ([]byte, error) {
+	return nil, errors.New("marshal error")
+}
+
+func TestJSONFormatterCodec(t *testing.T) {
+	items := []benchItem{{1, "a", 1.5}, {2, "b", 2}}
+
+	res := httptest.NewRecorder()
+	w := &JSONDataWriter{Codec: itemCodec{}}
+	assert.Nil(t, w.Write(res, items))
+	assert.Equal(t, `[{"id":1,"name":"a","price":1.5},{"id":2,"name":"b","price":2}]`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, w.Write(res, "<xyz>"))
+	assert.Equal(t, "\"<xyz>\"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	w = &JSONDataWriter{Codec: failingCodec{}}
+	assert.NotNil(t, w.Write(res, items))
+	assert.Equal(t, "", res.Body.String())
+
+	// the default codec
+	routing.DefaultJSONCodec = itemCodec{}
+	defer func() { routing.DefaultJSONCodec = routing.StdJSONCodec{} }()
+	res = httptest.NewRecorder()
+	w = &JSONDataWriter{}
+	assert.Nil(t, w.Write(res, items[:1]))
+	assert.Equal(t, `[{"id":1,"name":"a","price":1.5}]`+"\n", res.Body.String())
+}
+
+// BenchmarkJSONDataWriter compares writing a list of items with encoding/json and with a faster codec.
+// To measure a JSON library (e.g. json-iterator or sonic) for your own data, add its codec to the list.
+func BenchmarkJSONDataWriter(b *testing.B) {
+	items := make([]benchItem, 100)
+	for i := range items {
+		items[i] = benchItem{i, "item " + strconv.Itoa(i), float64(i) * 1.25}
+	}
+	codecs := []struct {
+		name  string
+		codec routing.JSONCodec
+	}{
+		{"Std", routing.StdJSONCodec{}},
+		{"Generated", itemCodec{}},
+	}
+	for _, c := range codecs {
+		b.Run(c.name, func(b *testing.B) {
+			w := &JSONDataWriter{Codec: c.codec}
+			res := httptest.NewRecorder()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res.Body.Reset()
+				w.Write(res, items)
+			}
+		})
+	}
 }
 
 func TestXMLFormatter(t *testing.T) {
@@ -43,6 +151,16 @@ func TestHTMLFormatter(t *testing.T) {
 	assert.Equal(t, "xyz", res.Body.String())
 }
 
+func TestCBORFormatter(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := &CBORDataWriter{}
+	w.SetHeader(res)
+	err := w.Write(res, map[string]int{"a": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "application/cbor", res.Header().Get("Content-Type"))
+	assert.Equal(t, "\xa1\x61a\x01", res.Body.String())
+}
+
 func TestTypeNegotiator(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/users/", nil)
 	req.Header.Set("Accept", "application/xml")
@@ -74,11 +192,85 @@ func TestTypeNegotiator(t *testing.T) {
 	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
 	assert.Equal(t, "\"xyz\"\n", res.Body.String())
 
+	// test CBOR format
+	req.Header.Set("Accept", "application/cbor")
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req)
+	h = TypeNegotiator(JSON, CBOR)
+	assert.Nil(t, h(c))
+	assert.Nil(t, c.Write("xyz"))
+	assert.Equal(t, "application/cbor", res.Header().Get("Content-Type"))
+	assert.Equal(t, "\x63xyz", res.Body.String())
+
 	assert.Panics(t, func() {
 		TypeNegotiator("unknown")
 	})
 }
 
+func TestTypeNegotiatorVary(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req)
+	res.Header().Set("Vary", "Origin")
+	assert.Nil(t, TypeNegotiator(JSON, XML)(c))
+	assert.Nil(t, LanguageNegotiator("en", "de")(c))
+	assert.Equal(t, "Origin, Accept, Accept-Language", res.Header().Get("Vary"))
+	assert.Equal(t, XML, c.Negotiation().ContentType)
+	assert.Equal(t, []string{"Accept", "Accept-Language"}, c.Negotiation().Vary)
+
+	// the response does not vary if there is only one format
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req)
+	assert.Nil(t, TypeNegotiator(JSON)(c))
+	assert.Equal(t, "", res.Header().Get("Vary"))
+	assert.Equal(t, JSON, c.Negotiation().ContentType)
+}
+
+func TestTypeNegotiatorWithOptions(t *testing.T) {
+	h := TypeNegotiatorWithOptions(TypeOptions{
+		QueryParam:  "format",
+		Header:      "X-Response-Format",
+		Overridable: []string{"json", "xml"},
+	}, JSON, XML, CBOR)
+
+	tests := []struct {
+		tag, url, accept, header string
+		contentType, vary        string
+	}{
+		{"t1", "/users", "application/xml", "", "application/xml; charset=UTF-8", "X-Response-Format, Accept"},
+		{"t2", "/users?format=json", "application/xml", "", "application/json", "X-Response-Format"},
+		{"t3", "/users?format=XML", "application/json", "", "application/xml; charset=UTF-8", "X-Response-Format"},
+		{"t4", "/users", "application/xml", "json", "application/json", "X-Response-Format"},
+		{"t5", "/users?format=xml", "", "json", "application/xml; charset=UTF-8", "X-Response-Format"},
+		{"t6", "/users?format=cbor", "application/xml", "", "application/xml; charset=UTF-8", "X-Response-Format, Accept"},
+		{"t7", "/users?format=html", "", "", "application/json", "X-Response-Format, Accept"},
+		{"t8", "/users?format=unknown", "application/cbor", "", "application/cbor", "X-Response-Format, Accept"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		req.Header.Set("Accept", test.accept)
+		req.Header.Set("X-Response-Format", test.header)
+		res := httptest.NewRecorder()
+		c := routing.NewContext(res, req)
+		assert.Nil(t, h(c), test.tag)
+		assert.Nil(t, c.Write("xyz"), test.tag)
+		assert.Equal(t, test.contentType, res.Header().Get("Content-Type"), test.tag)
+		assert.Equal(t, test.vary, res.Header().Get("Vary"), test.tag)
+	}
+
+	// all formats passed to the negotiator are overridable by default
+	h = TypeNegotiatorWithOptions(TypeOptions{QueryParam: "format"}, JSON, CBOR)
+	req, _ := http.NewRequest("GET", "/users?format=cbor", nil)
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req)
+	assert.Nil(t, h(c))
+	assert.Nil(t, c.Write("xyz"))
+	assert.Equal(t, "application/cbor", res.Header().Get("Content-Type"))
+	assert.Equal(t, "", res.Header().Get("Vary"))
+}
+
 var (
 	v1JSON = "application/json;v=1"
 	v2JSON = "application/json;v=2"
@@ -5,7 +5,13 @@
 package routing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 // Context represents the contextual data and environment while processing an incoming HTTP request.
@@ -13,14 +19,35 @@ type Context struct {
 	Request  *http.Request       // the current request
 	Response http.ResponseWriter // the response writer
 	router   *Router
+	route    *Route                 // the route matching the current request
 	pnames   []string               // list of route parameter names
 	pvalues  []string               // list of parameter values corresponding to pnames
 	data     map[string]interface{} // data items managed by Get and Set
+	form     *FormOptions           // the form options overriding those of the router
 	index    int                    // the index of the currently executing handler in handlers
 	handlers []Handler              // the handlers associated with the current route
+	timings  []time.Duration        // the execution times of the handlers, recorded when Router.Timing is true
+	nested   time.Duration          // the time spent in the handlers called via Next by the handler being timed
 	writer   DataWriter
+
+	prerouting bool // whether the handlers registered via Router.Pre are being executed
+	reroutes   int  // the number of times the request has been rerouted
+
+	negotiation *Negotiation // the content negotiation results, created by Negotiation
+
+	sample    float64 // the random number deciding whether the request is sampled, chosen by Sampled
+	hasSample bool    // whether sample has been chosen
+
+	trackExit    bool      // whether to track the last handler invoked via Next (see ChainExit)
+	exit         int       // the index of the last handler invoked via Next
+	exitHandlers []Handler // the handlers containing the last handler invoked via Next
+	exitErr      bool      // whether the last handler invoked via Next returned an error
 }
 
+// MaxReroutes is the maximum number of times a request can be rerouted via Context.Reroute.
+// It prevents rewrite rules from rerouting requests in a loop.
+const MaxReroutes = 10
+
 // NewContext creates a new Context object with the given response, request, and the handlers.
 // This method is primarily provided for writing unit tests for handlers.
 func NewContext(res http.ResponseWriter, req *http.Request, handlers ...Handler) *Context {
@@ -34,6 +61,39 @@ func (c *Context) Router() *Router {
 	return c.router
 }
 
+// BaseContext returns the base context of the router, which is canceled when the router is shut down
+// (see NewWithContext). Unlike the request context, it is not canceled when the request finishes,
+// so it can be used by the work outliving the request. Context.Background() is returned if the context
+// is not created by a router.
+func (c *Context) BaseContext() context.Context {
+	if c.router == nil {
+		return context.Background()
+	}
+	return c.router.ctx
+}
+
+// Route returns the route that matches the current request.
+// Nil is returned if no route matches the request.
+func (c *Context) Route() *Route {
+	return c.route
+}
+
+// RouteFor returns the route that would match the current request if the request used the given HTTP method.
+// Nil is returned if there is no such route. This is useful when handling OPTIONS requests,
+// such as CORS preflight requests, which are about the routes of other HTTP methods.
+func (c *Context) RouteFor(method string) *Route {
+	r := c.router
+	if r == nil {
+		return nil
+	}
+	path := c.Request.URL.Path
+	if r.UseEscapedPath {
+		path = c.Request.URL.EscapedPath()
+	}
+	route, _, _ := r.find(r.requestScheme(c.Request), method, r.normalizeRequestPath(path), make([]string, r.maxParams))
+	return route
+}
+
 // Param returns the named parameter value that is found in the URL path matching the current route.
 // If the named parameter cannot be found, an empty string will be returned.
 func (c *Context) Param(name string) string {
@@ -60,16 +120,36 @@ func (c *Context) SetParam(name, value string) {
 
 // Get returns the named data item previously registered with the context by calling Set.
 // If the named data item cannot be found, nil will be returned.
+//
+// If the data item is not set in the context but the name is registered in Router.ContextKeys,
+// the value associated with the corresponding key in the request context will be returned.
+// Otherwise, the root value of the router with the name (see RouterOptions.Values) will be returned.
 func (c *Context) Get(name string) interface{} {
-	return c.data[name]
+	if value, ok := c.data[name]; ok || c.router == nil {
+		return value
+	}
+	if key, ok := c.router.ContextKeys[name]; ok {
+		if value := c.Request.Context().Value(key); value != nil {
+			return value
+		}
+	}
+	return c.router.values[name]
 }
 
 // Set stores the named data item in the context so that it can be retrieved later.
+// If the name is registered in Router.ContextKeys, the value will also be stored in the request context
+// with the corresponding key, so that it is available to the http.Handler based code processing the request.
 func (c *Context) Set(name string, value interface{}) {
 	if c.data == nil {
 		c.data = make(map[string]interface{})
 	}
 	c.data[name] = value
+	if c.router == nil {
+		return
+	}
+	if key, ok := c.router.ContextKeys[name]; ok {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), key, value))
+	}
 }
 
 // Query returns the first value for the named component of the URL query parameters.
@@ -84,13 +164,39 @@ func (c *Context) Query(name string, defaultValue ...string) string {
 	return ""
 }
 
+// QueryAll returns all values of the named URL query parameter, or nil if the parameter is not present.
+// This is useful for handling repeated parameters, such as "tag=a&tag=b".
+func (c *Context) QueryAll(name string) []string {
+	return c.Request.URL.Query()[name]
+}
+
+// QueryMap returns the URL query parameters named in the format of "prefix[key]" as a map indexed by the keys.
+// For example, given the query "filter[status]=active&filter[type]=user", QueryMap("filter") returns
+// map[string]string{"status": "active", "type": "user"}. Only the first value of each parameter is used.
+func (c *Context) QueryMap(prefix string) map[string]string {
+	m := map[string]string{}
+	for name, values := range c.Request.URL.Query() {
+		if key, ok := mapKey(name, prefix); ok && len(values) > 0 {
+			m[key] = values[0]
+		}
+	}
+	return m
+}
+
+// ReadQuery populates the given struct with the URL query parameters in the same way as ReadFormData.
+// Unlike Read, it ignores the request body, which makes it useful for binding filters and paging parameters
+// of the requests that carry their payload in other formats.
+func (c *Context) ReadQuery(data interface{}) error {
+	return ReadFormData(c.Request.URL.Query(), data)
+}
+
 // Form returns the first value for the named component of the query.
 // Form reads the value from POST and PUT body parameters as well as URL query parameters.
 // The form takes precedence over the latter.
 // If key is not present, it returns the specified default value or an empty string.
 func (c *Context) Form(key string, defaultValue ...string) string {
 	r := c.Request
-	r.ParseMultipartForm(32 << 20)
+	c.parseForm()
 	if vs := r.Form[key]; len(vs) > 0 {
 		return vs[0]
 	}
@@ -105,7 +211,7 @@ func (c *Context) Form(key string, defaultValue ...string) string {
 // If key is not present, it returns the specified default value or an empty string.
 func (c *Context) PostForm(key string, defaultValue ...string) string {
 	r := c.Request
-	r.ParseMultipartForm(32 << 20)
+	c.parseForm()
 	if vs := r.PostForm[key]; len(vs) > 0 {
 		return vs[0]
 	}
@@ -123,7 +229,21 @@ func (c *Context) PostForm(key string, defaultValue ...string) string {
 func (c *Context) Next() error {
 	c.index++
 	for n := len(c.handlers); c.index < n; c.index++ {
-		if err := c.handlers[c.index](c); err != nil {
+		i := c.index
+		if c.trackExit {
+			c.exit, c.exitHandlers, c.exitErr = i, c.handlers, false
+		}
+		var err error
+		if c.timings != nil {
+			err = c.callTimed(i)
+		} else {
+			err = c.handlers[i](c)
+		}
+		if err != nil {
+			if c.trackExit && c.exit == i && &c.exitHandlers[0] == &c.handlers[0] {
+				// the error is returned by the last invoked handler rather than passed on by a middleware
+				c.exitErr = true
+			}
 			return err
 		}
 	}
@@ -149,49 +269,282 @@ func (c *Context) URL(route string, pairs ...interface{}) string {
 	return ""
 }
 
+// Redirect replies to the request with a redirect to the given URL, which may be a path relative to
+// the request path. The status code should be in the 3xx range, such as http.StatusFound. ErrHeaderWritten is
+// returned if the status code of the response has already been sent, as described in WriteHeader().
+func (c *Context) Redirect(url string, status int) error {
+	if c.Status() != 0 {
+		return ErrHeaderWritten
+	}
+	http.Redirect(c.Response, c.Request, url, status)
+	return nil
+}
+
+// Reroute rewrites the request URL to the given one internally and dispatches the request again to the handlers
+// of the route matching the new URL, without responding with a redirect. The URL may be a path relative to
+// the request path, and the query string of the request is kept unless the URL has one. The data set in the context
+// is kept, while the handlers of the current route following the calling handler are skipped. The error returned
+// by the handlers of the new route is returned, and the calling handler usually returns it as is. For example,
+//
+//     router.Get("/old/<id>", func(c *routing.Context) error {
+//         return c.Reroute("/new/" + c.Param("id"))
+//     })
+//
+// When called by the handlers registered via Router.Pre, Reroute only rewrites the request URL, which is then
+// matched against the routes as usual. An error is returned if the URL is invalid, or if the request has been
+// rerouted more than MaxReroutes times.
+func (c *Context) Reroute(target string) error {
+	return c.redispatch("", target, true)
+}
+
+// Forward dispatches the request internally to the route matching the given method and path, without responding
+// with a redirect. The path may be relative to the request path and may contain a query string, which replaces that
+// of the request. Unlike Reroute, the handlers inherited by the route from the router and the groups are not executed
+// again unless Router.ForwardMiddleware is true, because they usually have processed the request already (e.g. logging
+// or authentication). Forward is useful for serving default documents and soft 404 pages. For example,
+//
+//     router.Get("/docs/<page>", func(c *routing.Context) error {
+//         if !exists(c.Param("page")) {
+//             return c.Forward("GET", "/docs/not-found")
+//         }
+//         ...
+//     })
+//
+// Forward reuses the Context, so the route parameters of the current route are replaced by those of the new route,
+// while the data set in the context is kept. The handlers of the current route following the calling handler are
+// skipped. The loop protection of Reroute applies.
+func (c *Context) Forward(method, path string) error {
+	return c.redispatch(strings.ToUpper(method), path, c.router == nil || c.router.ForwardMiddleware)
+}
+
+// sameChain returns whether the two handler chains are the same slice.
+func sameChain(a, b []Handler) bool {
+	return len(a) > 0 && len(a) == len(b) && &a[0] == &b[0]
+}
+
+// redispatch changes the method (if not empty) and the URL of the request and executes the handlers of the route
+// matching the new request. If middleware is false, the handlers inherited from the router and the groups are skipped.
+func (c *Context) redispatch(method, target string, middleware bool) error {
+	if c.reroutes >= MaxReroutes {
+		return fmt.Errorf("the request has been rerouted more than %v times", MaxReroutes)
+	}
+	u, err := c.Request.URL.Parse(target)
+	if err != nil {
+		return err
+	}
+	if u.RawQuery == "" && !strings.Contains(target, "?") {
+		u.RawQuery = c.Request.URL.RawQuery
+	}
+	c.reroutes++
+	req := *c.Request
+	if method != "" {
+		req.Method = method
+	}
+	req.URL = u
+	req.RequestURI = u.RequestURI()
+	c.Request = &req
+	if c.prerouting || c.router == nil {
+		return nil
+	}
+
+	n := len(c.handlers)
+	c.router.match(c)
+	if !middleware {
+		if c.route != nil {
+			c.handlers = c.handlers[c.route.offset:]
+		} else if sameChain(c.handlers, c.router.notFoundHandlers) || sameChain(c.handlers, c.router.invalidPathHandlers) {
+			// the fallback chain does not include the router handlers
+			c.handlers = c.handlers[len(c.router.handlers):]
+		}
+	}
+	c.index = -1
+	if len(c.handlers) > n {
+		n = len(c.handlers)
+	}
+	if c.timings != nil {
+		c.timings = make([]time.Duration, n)
+		for i := range c.timings {
+			c.timings[i] = -1
+		}
+	}
+	err = c.Next()
+	// skip the rest of the original handlers, which may outnumber the new ones
+	c.index = n
+	return err
+}
+
+// RedirectToRoute replies to the request with a redirect to the URL created using the named route.
+// The parameters should be given in the sequence of name1, value1, name2, value2, and so on.
+// The parameters that do not appear in the route path will be added to the URL as query parameters.
+// An error will be returned if the named route cannot be found.
+func (c *Context) RedirectToRoute(route string, status int, pairs ...interface{}) error {
+	r := c.router.namedRoutes[route]
+	if r == nil {
+		return fmt.Errorf("the route %q cannot be found", route)
+	}
+	params := []interface{}{}
+	query := url.Values{}
+	for i := 0; i < len(pairs); i += 2 {
+		name := fmt.Sprint(pairs[i])
+		value := ""
+		if i < len(pairs)-1 {
+			value = fmt.Sprint(pairs[i+1])
+		}
+		if strings.Contains(r.template, "<"+name+">") {
+			params = append(params, name, value)
+		} else {
+			query.Add(name, value)
+		}
+	}
+	u := r.URL(params...)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return c.Redirect(u, status)
+}
+
 // Read populates the given struct variable with the data from the current request.
 // If the request is NOT a GET request, it will check the "Content-Type" header
 // and find a matching reader from DataReaders to read the request data.
 // If there is no match or if the request is a GET request, it will use DefaultFormDataReader
 // to read the request data.
 func (c *Context) Read(data interface{}) error {
+	reader := DefaultFormDataReader
 	if c.Request.Method != "GET" {
 		t := getContentType(c.Request)
-		if reader, ok := DataReaders[t]; ok {
-			return reader.Read(c.Request, data)
+		if r, ok := DataReaders[t]; ok {
+			reader = r
 		}
 	}
+	if _, ok := reader.(*FormDataReader); ok {
+		// parse the form according to the form options before FormDataReader parses it with the defaults
+		c.parseForm()
+	}
+	return reader.Read(c.Request, data)
+}
 
-	return DefaultFormDataReader.Read(c.Request, data)
+// SetFormOptions sets the options for parsing the request body as form data.
+// The options override Router.FormOptions for the current request. They take effect only if the request
+// has not been parsed as form data yet.
+func (c *Context) SetFormOptions(options FormOptions) {
+	c.form = &options
 }
 
 // Write writes the given data of arbitrary type to the response.
-// The method calls the data writer set via SetDataWriter() to do the actual writing.
-// By default, the DefaultDataWriter will be used.
+// The method calls the data writer set via SetDataWriter() to do the actual writing, passing the context to it
+// if it is a ContextDataWriter. By default, the DefaultDataWriter will be used.
 func (c *Context) Write(data interface{}) error {
+	if w, ok := c.writer.(ContextDataWriter); ok {
+		return w.WriteContext(c, data)
+	}
 	return c.writer.Write(c.Response, data)
 }
 
+// WriteRaw writes the given bytes to the response as is, bypassing the data writer set by SetDataWriter, and sets
+// the Content-Type header to the given content type unless it is empty. It is useful for the payloads that are
+// already serialized, such as cached JSON documents, which would otherwise be encoded again by the data writer:
+//
+//     return c.WriteRaw("application/json", cachedUsers)
+func (c *Context) WriteRaw(contentType string, data []byte) error {
+	if contentType != "" {
+		c.Response.Header().Set("Content-Type", contentType)
+	}
+	_, err := c.Response.Write(data)
+	return err
+}
+
 // WriteWithStatus sends the HTTP status code and writes the given data of arbitrary type to the response.
-// See Write() for details on how data is written to response.
+// See Write() for details on how data is written to response. Nothing is written and ErrHeaderWritten is returned
+// if the status code has already been sent, as described in WriteHeader().
 func (c *Context) WriteWithStatus(data interface{}, statusCode int) error {
-	c.Response.WriteHeader(statusCode)
+	if err := c.WriteHeader(statusCode); err != nil {
+		return err
+	}
 	return c.Write(data)
 }
 
+// SetWriteDeadline sets the deadline for writing the response. Writes to the response after the deadline
+// will fail, which allows aborting transfers to the clients that read the response too slowly.
+// A zero value means no deadline.
+//
+// The deadline is set via http.ResponseController, which looks for a SetWriteDeadline method on the response
+// writer, following the chain of the writers that wrap the original one via an Unwrap() http.ResponseWriter method.
+// If no such method is found, http.ErrNotSupported will be returned.
+func (c *Context) SetWriteDeadline(deadline time.Time) error {
+	err := http.NewResponseController(c.Response).SetWriteDeadline(deadline)
+	if errors.Is(err, http.ErrNotSupported) {
+		return http.ErrNotSupported
+	}
+	return err
+}
+
 // SetDataWriter sets the data writer that will be used by Write().
 func (c *Context) SetDataWriter(writer DataWriter) {
 	c.writer = writer
 	writer.SetHeader(c.Response)
 }
 
+// TranslateError translates the message of the given error using Router.Translator.
+// If the error implements TranslatableError, its message key and arguments are used for translation.
+// Otherwise, the error message itself is used as the key. The returned error keeps the HTTP status code
+// and the error code (see CodedError) of the original error. If there is no translator or the translation
+// is not available, the original error will be returned.
+func (c *Context) TranslateError(err error) error {
+	if c.router == nil || c.router.Translator == nil {
+		return err
+	}
+	var (
+		key  string
+		args []interface{}
+	)
+	if e, ok := err.(TranslatableError); ok {
+		key, args = e.MessageKey(), e.MessageArgs()
+	} else {
+		key = err.Error()
+	}
+	message := c.router.Translator(c, key, args...)
+	if message == "" {
+		return err
+	}
+	status := http.StatusInternalServerError
+	if httpError, ok := err.(HTTPError); ok {
+		status = httpError.StatusCode()
+	}
+	translated := &httpError{Status: status, Message: message}
+	if e, ok := err.(CodedError); ok {
+		translated.Code = e.ErrorCode()
+	}
+	return translated
+}
+
 // init sets the request and response of the context and resets all other properties.
 func (c *Context) init(response http.ResponseWriter, request *http.Request) {
 	c.Response = response
 	c.Request = request
+	c.route = nil
 	c.data = nil
+	c.form = nil
+	c.timings = nil
 	c.index = -1
 	c.writer = DefaultDataWriter
+	c.prerouting = false
+	c.reroutes = 0
+	c.negotiation = nil
+	c.hasSample = false
+	c.trackExit = c.router != nil && c.router.Exits != nil
+	c.exit, c.exitHandlers, c.exitErr = -1, nil, false
+}
+
+// parseForm parses the request according to the form options set for the context or the router.
+func (c *Context) parseForm() {
+	options := FormOptions{}
+	if c.form != nil {
+		options = *c.form
+	} else if c.router != nil {
+		options = c.router.FormOptions
+	}
+	// Do not check return result. Otherwise GET request will cause problem.
+	parseForm(c.Request, options)
 }
 
 func getContentType(req *http.Request) string {
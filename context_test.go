@@ -1,12 +1,15 @@
 package routing
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -60,6 +63,149 @@ func TestContextURL(t *testing.T) {
 	assert.Equal(t, "", c.URL("abc", "id", 123, "action", "address"))
 }
 
+func TestContextRedirect(t *testing.T) {
+	router := New()
+	router.Get("/users/<id:\\d+>/<action>").Name("users")
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/old", nil)
+	c := router.AcquireContext(res, req)
+	assert.Nil(t, c.Redirect("/new", http.StatusMovedPermanently))
+	assert.Equal(t, http.StatusMovedPermanently, res.Code)
+	assert.Equal(t, "/new", res.Header().Get("Location"))
+
+	res = httptest.NewRecorder()
+	c = router.AcquireContext(res, req)
+	assert.Nil(t, c.RedirectToRoute("users", http.StatusFound, "id", 123, "action", "a b", "page", 2, "sort", "name"))
+	assert.Equal(t, http.StatusFound, res.Code)
+	assert.Equal(t, "/users/123/a+b?page=2&sort=name", res.Header().Get("Location"))
+
+	res = httptest.NewRecorder()
+	c = router.AcquireContext(res, req)
+	assert.Nil(t, c.RedirectToRoute("users", http.StatusSeeOther, "id", 1, "action", "view"))
+	assert.Equal(t, "/users/1/view", res.Header().Get("Location"))
+
+	assert.NotNil(t, c.RedirectToRoute("unknown", http.StatusFound))
+}
+
+func TestContextReroute(t *testing.T) {
+	router := New()
+	router.Timing = true
+	var calls []string
+	record := func(name string) Handler {
+		return func(c *Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	router.Get("/old/<id>", record("old1"), func(c *Context) error {
+		c.Set("user", "admin")
+		return c.Reroute("/new/" + c.Param("id"))
+	}, record("old2"), record("old3"), record("old4"))
+	router.Get("/new/<id>", func(c *Context) error {
+		return c.Write(fmt.Sprintf("%v %v %v %v", c.Route(), c.Param("id"), c.Get("user"), c.Request.URL.RawQuery))
+	})
+	router.Get("/loop", func(c *Context) error {
+		return c.Reroute("/loop")
+	})
+	router.Get("/bad", func(c *Context) error {
+		return c.Reroute("%zz")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/old/1?a=b", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "GET /new/<id> 1 admin a=b", res.Body.String())
+	assert.Equal(t, []string{"old1"}, calls)
+	assert.Equal(t, "/old/1?a=b", req.URL.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/loop", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/bad", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	// rerouting in pre-handlers only rewrites the URL
+	router = New()
+	router.Pre(func(c *Context) error {
+		if c.Request.URL.Path == "/old" {
+			return c.Reroute("/new?b=c")
+		}
+		return nil
+	})
+	router.Get("/new", func(c *Context) error {
+		return c.Write("new " + c.Request.URL.RawQuery)
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/old?a=b", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "new b=c", res.Body.String())
+}
+
+func TestContextForward(t *testing.T) {
+	router := New()
+	var calls []string
+	record := func(name string) Handler {
+		return func(c *Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	router.Use(record("router"))
+	api := router.Group("/api")
+	api.Use(record("api"))
+	api.Post("/users/<id>", record("users"), func(c *Context) error {
+		return c.Forward("get", "/api/items/"+c.Param("id")+"?x=y")
+	}, record("skipped"))
+	api.Get("/items/<id>", record("items"), func(c *Context) error {
+		return c.Write(fmt.Sprintf("%v %v %v %v", c.Request.Method, c.Route(), c.Param("id"), c.Request.URL.RawQuery))
+	})
+	api.Get("/missing", func(c *Context) error {
+		return c.Forward("GET", "/unknown")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/users/1?a=b", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "GET GET /api/items/<id> 1 x=y", res.Body.String())
+	assert.Equal(t, []string{"router", "api", "users", "items"}, calls)
+
+	calls = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/missing", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, []string{"router", "api"}, calls)
+
+	router.ForwardMiddleware = true
+	calls = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/users/2", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "GET GET /api/items/<id> 2 x=y", res.Body.String())
+	assert.Equal(t, []string{"router", "api", "users", "router", "api", "items"}, calls)
+
+	// forwarding to a path served by the fallback handler
+	router = New()
+	router.Use(record("router1"), record("router2"))
+	router.Get("/missing", func(c *Context) error {
+		return c.Forward("GET", "/legacy")
+	})
+	router.Fallback(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fallback " + r.URL.Path))
+	}))
+	calls = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/missing", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "fallback /legacy", res.Body.String())
+	assert.Equal(t, []string{"router1", "router2"}, calls)
+}
+
 func TestContextGetSet(t *testing.T) {
 	c := NewContext(nil, nil)
 	c.init(nil, nil)
@@ -70,6 +216,30 @@ func TestContextGetSet(t *testing.T) {
 	assert.Equal(t, 123, c.Get("xyz").(int))
 }
 
+func TestContextContextKeys(t *testing.T) {
+	type key int
+	router := New()
+	router.ContextKeys = map[string]interface{}{"user": key(1), "tenant": key(2)}
+	req, _ := http.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), key(1), "john"))
+	c := router.AcquireContext(nil, req)
+
+	assert.Equal(t, "john", c.Get("user"))
+	assert.Nil(t, c.Get("tenant"))
+	assert.Nil(t, c.Get("abc"))
+
+	c.Set("tenant", "acme")
+	c.Set("abc", "xyz")
+	assert.Equal(t, "acme", c.Get("tenant"))
+	assert.Equal(t, "acme", c.Request.Context().Value(key(2)))
+	assert.Equal(t, "xyz", c.Get("abc"))
+	assert.Equal(t, "john", c.Request.Context().Value(key(1)))
+
+	c.Set("user", nil)
+	assert.Nil(t, c.Get("user"))
+	assert.Nil(t, c.Request.Context().Value(key(1)))
+}
+
 func TestContextQueryForm(t *testing.T) {
 	req, _ := http.NewRequest("POST", "http://www.google.com/search?q=foo&q=bar&both=x&prio=1&empty=not",
 		strings.NewReader("z=post&both=y&prio=2&empty="))
@@ -88,6 +258,128 @@ func TestContextQueryForm(t *testing.T) {
 	assert.Equal(t, "123", c.Form("x", "123"))
 }
 
+func TestContextQueryAllMap(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users?tag=a&tag=b&filter[status]=active&filter[type]=user&filter[type]=admin&filter=x&page=2", nil)
+	c := NewContext(nil, req)
+	assert.Equal(t, []string{"a", "b"}, c.QueryAll("tag"))
+	assert.Nil(t, c.QueryAll("x"))
+	assert.Equal(t, map[string]string{"status": "active", "type": "user"}, c.QueryMap("filter"))
+	assert.Equal(t, map[string]string{}, c.QueryMap("sort"))
+
+	var q struct {
+		Tags   []string          `form:"tag"`
+		Filter map[string]string `form:"filter"`
+		Page   int               `form:"page"`
+	}
+	assert.Nil(t, c.ReadQuery(&q))
+	assert.Equal(t, []string{"a", "b"}, q.Tags)
+	assert.Equal(t, map[string]string{"status": "active", "type": "user"}, q.Filter)
+	assert.Equal(t, 2, q.Page)
+}
+
+func TestContextRouteFor(t *testing.T) {
+	router := New()
+	r1 := router.Get("/users/<id>")
+	r2 := router.Scheme("https").Put("/users/<id>")
+
+	req, _ := http.NewRequest("OPTIONS", "https://example.com/users/1", nil)
+	req.TLS = &tls.ConnectionState{}
+	c := NewContext(nil, req)
+	assert.Nil(t, c.RouteFor("GET"))
+
+	c = router.AcquireContext(nil, req)
+	assert.Equal(t, r1, c.RouteFor("GET"))
+	assert.Equal(t, r2, c.RouteFor("PUT"))
+	assert.Nil(t, c.RouteFor("POST"))
+
+	req.TLS = nil
+	assert.Nil(t, c.RouteFor("PUT"))
+}
+
+func TestContextFormOptions(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/search?q=foo", strings.NewReader("z=post"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := NewContext(nil, req)
+	c.SetFormOptions(FormOptions{SkipBody: true})
+	assert.Equal(t, "foo", c.Form("q"))
+	assert.Equal(t, "", c.Form("z"))
+	assert.Equal(t, "", c.PostForm("z"))
+
+	c = NewContext(nil, newMultipartRequest())
+	c.SetFormOptions(FormOptions{SkipMultipart: true})
+	assert.Equal(t, "", c.PostForm("name"))
+	assert.Nil(t, c.Request.MultipartForm)
+
+	router := New()
+	router.FormOptions.MaxMemory = 1
+	c = &Context{router: router}
+	c.init(nil, newMultipartRequest())
+	assert.Equal(t, "abc", c.PostForm("name"))
+	if assert.NotNil(t, c.Request.MultipartForm) {
+		assert.Equal(t, 1, len(c.Request.MultipartForm.File["file"]))
+		c.Request.MultipartForm.RemoveAll()
+	}
+}
+
+func TestContextTranslateError(t *testing.T) {
+	c := NewContext(nil, nil)
+	err := errors.New("abc")
+	assert.Equal(t, err, c.TranslateError(err))
+
+	router := New()
+	router.Translator = func(c *Context, key string, args ...interface{}) string {
+		switch key {
+		case "abc":
+			return "ABC"
+		case "user %v not found":
+			return fmt.Sprintf("utilisateur %v introuvable", args...)
+		}
+		return ""
+	}
+	c = &Context{router: router}
+	assert.Equal(t, NewHTTPError(http.StatusInternalServerError, "ABC"), c.TranslateError(err))
+	assert.Equal(t, NewHTTPError(http.StatusNotFound, "ABC"), c.TranslateError(NewHTTPError(http.StatusNotFound, "abc")))
+	assert.Equal(t, NewHTTPError(http.StatusNotFound, "utilisateur 1 introuvable"), c.TranslateError(NewTranslatableHTTPError(http.StatusNotFound, "user %v not found", 1)))
+	err = errors.New("xyz")
+	assert.Equal(t, err, c.TranslateError(err))
+
+	code := &ErrorCode{Status: http.StatusNotFound, Code: "user_not_found", Message: "abc"}
+	if e, ok := c.TranslateError(code).(CodedError); assert.True(t, ok) {
+		assert.Equal(t, "user_not_found", e.ErrorCode())
+		assert.Equal(t, "ABC", e.Error())
+	}
+}
+
+type deadlineWriter struct {
+	http.ResponseWriter
+	deadline time.Time
+}
+
+func (w *deadlineWriter) SetWriteDeadline(deadline time.Time) error {
+	w.deadline = deadline
+	return nil
+}
+
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestContextSetWriteDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+
+	c := NewContext(httptest.NewRecorder(), nil)
+	assert.Equal(t, http.ErrNotSupported, c.SetWriteDeadline(deadline))
+
+	w := &deadlineWriter{ResponseWriter: httptest.NewRecorder()}
+	c = NewContext(&unwrapWriter{w}, nil)
+	assert.Nil(t, c.SetWriteDeadline(deadline))
+	assert.Equal(t, deadline, w.deadline)
+}
+
 func TestContextNextAbort(t *testing.T) {
 	c, res := testNewContext(
 		testNormalHandler("a"),
@@ -164,3 +456,23 @@ func testNormalHandler(tag string) Handler {
 		return nil
 	}
 }
+
+func TestContextVary(t *testing.T) {
+	res := httptest.NewRecorder()
+	c := NewContext(res, nil)
+	res.Header().Add("Vary", "Origin")
+	res.Header().Add("Vary", "accept")
+	c.Vary("Accept", "accept-encoding", "")
+	c.Vary("Accept-Encoding")
+	assert.Equal(t, []string{"Accept", "Accept-Encoding"}, c.Negotiation().Vary)
+	assert.Equal(t, []string{"Origin, accept, Accept-Encoding"}, res.Header()["Vary"])
+
+	h := http.Header{"Vary": {"*"}}
+	n := &Negotiation{Language: "en", Vary: []string{"Accept"}}
+	n.Apply(h)
+	assert.Equal(t, "*", h.Get("Vary"))
+	assert.Equal(t, "en", h.Get("Content-Language"))
+
+	c.init(res, nil)
+	assert.Nil(t, c.Negotiation().Vary)
+}
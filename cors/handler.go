@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-ozzo/ozzo-routing/v2"
@@ -28,6 +29,10 @@ const (
 	headerMaxAge           = "Access-Control-Max-Age"
 )
 
+// Policy is the name of the route metadata item that specifies the CORS options of a route.
+// The value should be of type Options. It overrides the options given to Handler for the route.
+const Policy = "cors"
+
 // Options specifies how the CORS handler should respond with appropriate CORS headers.
 type Options struct {
 	// the allowed origins (separated by commas). Use an asterisk (*) to indicate allowing all origins, "null" to indicate disallowing any.
@@ -56,9 +61,31 @@ var AllowAll = Options{
 }
 
 // Handler creates a routing handler that adds appropriate CORS headers according to the specified options and the request.
+//
+// Different options can be used for different routes or route groups by associating them with the route metadata
+// named Policy. For preflight requests, the options are taken from the route that the actual request would match.
+// For example,
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/cors"
+//     )
+//
+//     r := routing.New()
+//     r.Use(cors.Handler(cors.AllowAll))
+//     api := r.Group("/api").Set(cors.Policy, cors.Options{
+//         AllowOrigins: "https://example.com",
+//         AllowMethods: "GET,POST",
+//     })
+//     api.Get("/users", getUsers)
+//
+// Note that Handler should be registered before the routes are added so that it also handles the preflight
+// requests for which no OPTIONS route is defined.
 func Handler(opts Options) routing.Handler {
 
 	opts.init()
+	// the initialized options of the routes, indexed by the routes
+	var policies sync.Map
 
 	return func(c *routing.Context) (err error) {
 		origin := c.Request.Header.Get(headerOrigin)
@@ -73,16 +100,35 @@ func Handler(opts Options) routing.Handler {
 				// the request is outside the scope of CORS
 				return
 			}
+			o := routeOptions(c.RouteFor(method), &opts, &policies)
 			headers := c.Request.Header.Get(headerRequestHeaders)
-			opts.setPreflightHeaders(origin, method, headers, c.Response.Header())
+			o.setPreflightHeaders(origin, method, headers, c.Response.Header())
 			c.Abort()
 			return
 		}
-		opts.setActualHeaders(origin, c.Response.Header())
+		routeOptions(c.Route(), &opts, &policies).setActualHeaders(origin, c.Response.Header())
 		return
 	}
 }
 
+// routeOptions returns the options associated with the given route, or the default options if there is none.
+// The options of each route are looked up and initialized once, and then kept in the given policies.
+func routeOptions(route *routing.Route, def *Options, policies *sync.Map) *Options {
+	if route == nil {
+		return def
+	}
+	if o, ok := policies.Load(route); ok {
+		return o.(*Options)
+	}
+	o := def
+	if policy, ok := route.Meta(Policy).(Options); ok {
+		policy.init()
+		o = &policy
+	}
+	actual, _ := policies.LoadOrStore(route, o)
+	return actual.(*Options)
+}
+
 func (o *Options) init() {
 	o.allowHeaderMap = buildAllowMap(o.AllowHeaders, false)
 	o.allowMethodMap = buildAllowMap(o.AllowMethods, true)
@@ -7,6 +7,7 @@ package cors
 import (
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"time"
@@ -227,3 +228,44 @@ func TestHandlers(t *testing.T) {
 	assert.Nil(t, h(c))
 	assert.Equal(t, "", res.Header().Get(headerAllowOrigin))
 }
+
+func TestHandlerPolicy(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(Options{AllowOrigins: "https://example.com", AllowMethods: "GET"}))
+	router.Get("/users", func(c *routing.Context) error { return nil })
+	api := router.Group("/api").Set(Policy, Options{AllowOrigins: "https://foo.com", AllowMethods: "PUT"})
+	api.Put("/users", func(c *routing.Context) error { return nil })
+
+	tests := []struct {
+		method, path, origin, requestMethod, allowOrigin, allowMethods string
+	}{
+		{"GET", "/users", "https://example.com", "", "https://example.com", ""},
+		{"GET", "/users", "https://foo.com", "", "", ""},
+		{"PUT", "/api/users", "https://foo.com", "", "https://foo.com", ""},
+		{"PUT", "/api/users", "https://example.com", "", "", ""},
+		{"OPTIONS", "/users", "https://example.com", "GET", "https://example.com", "GET"},
+		{"OPTIONS", "/api/users", "https://foo.com", "PUT", "https://foo.com", "PUT"},
+		{"OPTIONS", "/api/users", "https://example.com", "PUT", "", ""},
+		{"OPTIONS", "/api/users", "https://example.com", "GET", "https://example.com", "GET"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		req.Header.Set("Origin", test.origin)
+		if test.requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", test.requestMethod)
+		}
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.allowOrigin, res.Header().Get(headerAllowOrigin), test.method+" "+test.path+" "+test.origin)
+		assert.Equal(t, test.allowMethods, res.Header().Get(headerAllowMethods), test.method+" "+test.path+" "+test.origin)
+	}
+
+	// the options of a route are initialized only once
+	var policies sync.Map
+	def := &Options{}
+	route := router.Routes()[1]
+	o := routeOptions(route, def, &policies)
+	assert.Equal(t, "PUT", o.AllowMethods)
+	assert.True(t, o == routeOptions(route, def, &policies))
+	assert.True(t, def == routeOptions(router.Routes()[0], def, &policies))
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decompress
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// DefaultMaxFormSize is the default maximum size of a form body that Charset transcodes, which is the same as
+// the limit of http.Request.ParseForm.
+const DefaultMaxFormSize = 10 << 20
+
+// CharsetOptions specifies how Charset transcodes the request bodies.
+type CharsetOptions struct {
+	// The maximum number of bytes of a URL-encoded form body, which is read into memory to be transcoded.
+	// A larger body results in ErrTooLarge. Defaults to DefaultMaxFormSize.
+	MaxFormSize int64
+}
+
+// Charset returns a handler that transcodes the bodies of the text requests declaring a non-UTF-8 charset in
+// the Content-Type header to UTF-8, so that the handlers following this one (and Context.Read) always see
+// UTF-8 text. The charset parameter of the Content-Type header is changed to "utf-8" accordingly.
+//
+// The bodies of the text/*, JSON (including +json), and URL-encoded form requests are transcoded. Multipart
+// forms are not transcoded because each part declares its own charset. The charsets are looked up by their
+// names and labels defined in the WHATWG Encoding Standard, such as "iso-8859-1", "windows-1252", and "shift_jis".
+// If the charset is unknown, the handler will return a 415 HTTP error.
+//
+// Charset should be used after Handler if the request bodies may also be compressed:
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/decompress"
+//     )
+//
+//     r := routing.New()
+//     r.Use(decompress.Handler(10<<20), decompress.Charset())
+func Charset(opts ...CharsetOptions) routing.Handler {
+	var options CharsetOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.MaxFormSize <= 0 {
+		options.MaxFormSize = DefaultMaxFormSize
+	}
+	return func(c *routing.Context) error {
+		req := c.Request
+		if req.Body == nil || req.Body == http.NoBody {
+			return nil
+		}
+		mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil || !isText(mediaType) {
+			return nil
+		}
+		charset := strings.ToLower(strings.TrimSpace(params["charset"]))
+		if charset == "" || charset == "utf-8" || charset == "utf8" || charset == "us-ascii" {
+			return nil
+		}
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return routing.NewHTTPError(http.StatusUnsupportedMediaType, "unsupported charset: "+charset)
+		}
+		if name, _ := htmlindex.Name(enc); name == "utf-8" {
+			return nil
+		}
+
+		if mediaType == "application/x-www-form-urlencoded" {
+			body, err := transcodeForm(req.Body, enc, options.MaxFormSize)
+			req.Body.Close()
+			if err == ErrTooLarge {
+				return err
+			} else if err != nil {
+				return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			req.Body = ioutil.NopCloser(strings.NewReader(body))
+		} else {
+			req.Body = &charsetReader{
+				Reader: transform.NewReader(req.Body, enc.NewDecoder()),
+				Closer: req.Body,
+			}
+		}
+
+		params["charset"] = "utf-8"
+		req.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		req.Header.Del("Content-Length")
+		req.ContentLength = -1
+		return nil
+	}
+}
+
+// charsetReader reads the transcoded request body and closes the original one.
+type charsetReader struct {
+	io.Reader
+	io.Closer
+}
+
+// isText returns whether the body of the given media type is text that can be transcoded.
+func isText(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// transcodeForm reads a URL-encoded form and returns it re-encoded with the names and values transcoded to UTF-8.
+// The form must be decoded before being transcoded because the non-ASCII bytes are percent-encoded.
+func transcodeForm(r io.Reader, enc encoding.Encoding, maxSize int64) (string, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxSize {
+		return "", ErrTooLarge
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return "", err
+	}
+	decoder := enc.NewDecoder()
+	result := url.Values{}
+	for name, vs := range values {
+		if name, err = decoder.String(name); err != nil {
+			return "", err
+		}
+		for _, v := range vs {
+			if v, err = decoder.String(v); err != nil {
+				return "", err
+			}
+			result.Add(name, v)
+		}
+	}
+	return result.Encode(), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decompress
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharset(t *testing.T) {
+	h := Charset()
+	run := func(contentType string, body []byte) (*http.Request, error) {
+		req, _ := http.NewRequest("POST", "/users", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req, h(routing.NewContext(httptest.NewRecorder(), req))
+	}
+	read := func(req *http.Request) string {
+		body, err := ioutil.ReadAll(req.Body)
+		assert.Nil(t, err)
+		assert.Nil(t, req.Body.Close())
+		return string(body)
+	}
+
+	// "café" in latin1
+	req, err := run("application/json; charset=ISO-8859-1", []byte("{\"name\":\"caf\xe9\"}"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"café"}`, read(req))
+	assert.Equal(t, "application/json; charset=utf-8", req.Header.Get("Content-Type"))
+	assert.Equal(t, int64(-1), req.ContentLength)
+
+	// "日本" in shift_jis
+	req, err = run("text/plain; charset=shift_jis", []byte("\x93\xfa\x96\x7b"))
+	assert.Nil(t, err)
+	assert.Equal(t, "日本", read(req))
+
+	req, err = run("application/x-www-form-urlencoded; charset=windows-1252", []byte("name=caf%E9&tag=a&tag=%80"))
+	assert.Nil(t, err)
+	assert.Nil(t, req.ParseForm())
+	assert.Equal(t, "café", req.PostForm.Get("name"))
+	assert.Equal(t, []string{"a", "€"}, req.PostForm["tag"])
+
+	// not transcoded
+	req, err = run("application/json; charset=utf-8", []byte("{\"name\":\"caf\xe9\"}"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"name\":\"caf\xe9\"}", read(req))
+	req, err = run("application/octet-stream; charset=iso-8859-1", []byte("caf\xe9"))
+	assert.Nil(t, err)
+	assert.Equal(t, "caf\xe9", read(req))
+	assert.Equal(t, "application/octet-stream; charset=iso-8859-1", req.Header.Get("Content-Type"))
+	req, err = run("text/plain", []byte("caf\xe9"))
+	assert.Nil(t, err)
+	assert.Equal(t, "caf\xe9", read(req))
+
+	_, err = run("text/plain; charset=unknown", []byte("abc"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, err.(routing.HTTPError).StatusCode())
+	}
+
+	_, err = run("application/x-www-form-urlencoded; charset=iso-8859-1", []byte(strings.Repeat("a", DefaultMaxFormSize+1)))
+	assert.Equal(t, ErrTooLarge, err)
+
+	h = Charset(CharsetOptions{MaxFormSize: 5})
+	_, err = run("application/x-www-form-urlencoded; charset=iso-8859-1", []byte("a=123"))
+	assert.Nil(t, err)
+	_, err = run("application/x-www-form-urlencoded; charset=iso-8859-1", []byte("a=1234"))
+	assert.Equal(t, ErrTooLarge, err)
+}
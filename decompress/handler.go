@@ -0,0 +1,131 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package decompress provides a request body decompression handler for the ozzo routing package.
+package decompress
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// DecoderFunc creates a reader that decodes the data read from the given reader.
+type DecoderFunc func(io.Reader) (io.ReadCloser, error)
+
+// Decoders lists all supported content encodings and the corresponding decoders.
+// By default, gzip, deflate, and br (Brotli) are supported. You may modify this variable to add new supported
+// content encodings, such as "zstd".
+var Decoders = map[string]DecoderFunc{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+	"br": func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	},
+}
+
+// ErrTooLarge is returned when reading a decompressed request body whose size exceeds the limit.
+var ErrTooLarge = routing.NewHTTPError(http.StatusRequestEntityTooLarge)
+
+// Handler returns a handler that decompresses the request body according to the Content-Encoding request header,
+// so that the handlers following this one (and Context.Read) can read the body as if it was not compressed.
+//
+// The maxSize parameter specifies the maximum number of bytes allowed in a decompressed body. Reading beyond
+// this limit results in ErrTooLarge. If maxSize is not positive, the decompressed body size is not limited.
+//
+// If the content encoding is not listed in Decoders, the handler will return a 415 HTTP error.
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/decompress"
+//     )
+//
+//     r := routing.New()
+//     r.Use(decompress.Handler(10 << 20))
+func Handler(maxSize int64) routing.Handler {
+	return func(c *routing.Context) error {
+		req := c.Request
+		encodings := req.Header.Get("Content-Encoding")
+		if encodings == "" || req.Body == nil || req.Body == http.NoBody {
+			return nil
+		}
+
+		var body io.ReadCloser = req.Body
+		closers := []io.Closer{req.Body}
+		// the encodings are listed in the order in which they were applied
+		ee := strings.Split(encodings, ",")
+		for i := len(ee) - 1; i >= 0; i-- {
+			encoding := strings.ToLower(strings.TrimSpace(ee[i]))
+			if encoding == "" || encoding == "identity" {
+				continue
+			}
+			decoder, ok := Decoders[encoding]
+			if !ok {
+				return routing.NewHTTPError(http.StatusUnsupportedMediaType, "unsupported content encoding: "+encoding)
+			}
+			r, err := decoder(body)
+			if err != nil {
+				return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			body = r
+			closers = append(closers, r)
+		}
+
+		req.Body = &reader{body: body, closers: closers, remaining: maxSize, limited: maxSize > 0}
+		req.Header.Del("Content-Encoding")
+		req.Header.Del("Content-Length")
+		req.ContentLength = -1
+		return nil
+	}
+}
+
+// reader reads the decompressed request body and enforces the size limit.
+type reader struct {
+	body      io.Reader
+	closers   []io.Closer
+	remaining int64
+	limited   bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if !r.limited {
+		return r.body.Read(p)
+	}
+	if r.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > r.remaining+1 {
+		// read one more byte to detect if the limit is exceeded
+		p = p[:r.remaining+1]
+	}
+	n, err := r.body.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), ErrTooLarge
+	}
+	return n, err
+}
+
+// Close closes the decoders and the original request body.
+func (r *reader) Close() (err error) {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if e := r.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
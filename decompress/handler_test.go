@@ -0,0 +1,120 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler(100)
+
+	// gzip
+	req, _ := http.NewRequest("POST", "/users", bytes.NewReader(gzipData("abc")))
+	req.Header.Set("Content-Encoding", "gzip")
+	c := routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(body))
+	assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+	assert.Nil(t, req.Body.Close())
+
+	// deflate
+	req, _ = http.NewRequest("POST", "/users", bytes.NewReader(deflateData("xyz")))
+	req.Header.Set("Content-Encoding", "deflate")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, err = ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "xyz", string(body))
+
+	// br
+	req, _ = http.NewRequest("POST", "/users", bytes.NewReader(brotliData("uvw")))
+	req.Header.Set("Content-Encoding", "br")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, err = ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "uvw", string(body))
+
+	// no encoding
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader("abc"))
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, _ = ioutil.ReadAll(req.Body)
+	assert.Equal(t, "abc", string(body))
+
+	// unsupported encoding
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader("abc"))
+	req.Header.Set("Content-Encoding", "zstd")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	err = h(c)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusUnsupportedMediaType, err.(routing.HTTPError).StatusCode())
+	}
+
+	// invalid data
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader("abc"))
+	req.Header.Set("Content-Encoding", "gzip")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	err = h(c)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(routing.HTTPError).StatusCode())
+	}
+
+	// exceeding the size limit
+	req, _ = http.NewRequest("POST", "/users", bytes.NewReader(gzipData(strings.Repeat("a", 101))))
+	req.Header.Set("Content-Encoding", "gzip")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, err = ioutil.ReadAll(req.Body)
+	assert.Equal(t, ErrTooLarge, err)
+	assert.Equal(t, 100, len(body))
+
+	// exactly at the size limit
+	req, _ = http.NewRequest("POST", "/users", bytes.NewReader(gzipData(strings.Repeat("a", 100))))
+	req.Header.Set("Content-Encoding", "gzip")
+	c = routing.NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, h(c))
+	body, err = ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, len(body))
+}
+
+func gzipData(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func deflateData(s string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func brotliData(s string) []byte {
+	var buf bytes.Buffer
+	w := brotli.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
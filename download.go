@@ -0,0 +1,151 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Attachment sends the specified file as an attachment, which prompts the client to download it and save it
+// with the given file name. If the file name is empty, the base name of the file path will be used.
+// Range and conditional requests are supported. A 404 HTTP error is returned if the file cannot be found.
+func (c *Context) Attachment(path, filename string) error {
+	return c.sendFile(path, "attachment", filename)
+}
+
+// Inline sends the specified file to be displayed inline by the client (e.g. a PDF document in the browser).
+// Range and conditional requests are supported. A 404 HTTP error is returned if the file cannot be found.
+func (c *Context) Inline(path string) error {
+	return c.sendFile(path, "inline", "")
+}
+
+// sendFile sends the file with the given Content-Disposition type.
+func (c *Context) sendFile(path, disposition, filename string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	defer file.Close()
+	fstat, err := file.Stat()
+	if err != nil {
+		return NewHTTPError(http.StatusNotFound, err.Error())
+	} else if fstat.IsDir() {
+		return NewHTTPError(http.StatusNotFound)
+	}
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	c.Response.Header().Set("Content-Disposition", ContentDisposition(disposition, filename))
+	return c.ServeContent(filename, fstat.ModTime(), file)
+}
+
+// ServeContent sends the content read from the given io.ReadSeeker to the response like http.ServeContent does.
+// It supports range requests and conditional requests (If-Match, If-None-Match, If-Modified-Since, etc.),
+// and sets the Content-Type header based on the extension of the given name or the content if the header
+// is not set yet. Unlike http.ServeContent, the errors (e.g. 416 for an unsatisfiable range) are returned as
+// HTTP errors instead of being written to the response, so that they can be handled like other errors.
+// This allows serving data from any seekable source, such as a cloud storage object.
+func (c *Context) ServeContent(name string, modtime time.Time, content io.ReadSeeker) error {
+	w := &contentWriter{ResponseWriter: c.Response}
+	http.ServeContent(w, c.Request, name, modtime, content)
+	if w.status >= http.StatusBadRequest {
+		return NewHTTPError(w.status, strings.TrimSpace(w.message.String()))
+	}
+	return nil
+}
+
+// contentWriter captures the error response written by http.ServeContent.
+type contentWriter struct {
+	http.ResponseWriter
+	status  int
+	message strings.Builder
+}
+
+func (w *contentWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *contentWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.status >= http.StatusBadRequest {
+		return w.message.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports flushing.
+// Nothing is flushed if an error response is being captured.
+func (w *contentWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.status >= http.StatusBadRequest {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *contentWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ContentDisposition returns the value of a Content-Disposition header with the given disposition type
+// (e.g. "attachment") and file name as specified by RFC 6266. If the file name contains non-ASCII characters,
+// it is encoded in the "filename*" parameter, while the "filename" parameter contains an ASCII fallback
+// for the clients that do not support RFC 5987 encoding.
+func ContentDisposition(disposition, filename string) string {
+	if filename == "" {
+		return disposition
+	}
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		case r < 0x20 || r >= 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	s := disposition + `; filename="` + fallback.String() + `"`
+	if ascii {
+		return s
+	}
+	var encoded strings.Builder
+	for _, b := range []byte(filename) {
+		if isAttrChar(b) {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return s + "; filename*=UTF-8''" + encoded.String()
+}
+
+// isAttrChar checks if the given byte can be used without encoding in an RFC 5987 parameter value.
+func isAttrChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
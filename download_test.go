@@ -0,0 +1,126 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		disposition, filename, expected string
+	}{
+		{"inline", "", "inline"},
+		{"attachment", "report.pdf", `attachment; filename="report.pdf"`},
+		{"attachment", `my "best" report.pdf`, `attachment; filename="my \"best\" report.pdf"`},
+		{"attachment", "résumé.pdf", `attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`},
+		{"attachment", "报告 1.txt", `attachment; filename="__ 1.txt"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%201.txt`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ContentDisposition(test.disposition, test.filename), test.filename)
+	}
+}
+
+func TestContextAttachment(t *testing.T) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := NewContext(res, req)
+	assert.Nil(t, c.Attachment("file/testdata/css/main.css", "样式.css"))
+	assert.Equal(t, `attachment; filename="__.css"; filename*=UTF-8''%E6%A0%B7%E5%BC%8F.css`, res.Header().Get("Content-Disposition"))
+	assert.Equal(t, "text/css; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "body {}\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	c = NewContext(res, req)
+	assert.Nil(t, c.Attachment("file/testdata/css/main.css", ""))
+	assert.Equal(t, `attachment; filename="main.css"`, res.Header().Get("Content-Disposition"))
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, "body", res.Body.String())
+
+	res = httptest.NewRecorder()
+	c = NewContext(res, req)
+	err := c.Attachment("file/testdata/missing.css", "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(HTTPError).StatusCode())
+	}
+	err = c.Attachment("file/testdata", "")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(HTTPError).StatusCode())
+	}
+}
+
+func TestContextInline(t *testing.T) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := NewContext(res, req)
+	assert.Nil(t, c.Inline("file/testdata/index.html"))
+	assert.Equal(t, `inline; filename="index.html"`, res.Header().Get("Content-Disposition"))
+	assert.Equal(t, "hello\n", res.Body.String())
+}
+
+func TestContextServeContent(t *testing.T) {
+	modtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	c := NewContext(res, req)
+	assert.Nil(t, c.ServeContent("data.txt", modtime, strings.NewReader("abcdefg")))
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, "cde", res.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", modtime.Format(http.TimeFormat))
+	c = NewContext(res, req)
+	assert.Nil(t, c.ServeContent("data.txt", modtime, strings.NewReader("abcdefg")))
+	assert.Equal(t, http.StatusNotModified, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=100-200")
+	c = NewContext(res, req)
+	err := c.ServeContent("data.txt", modtime, strings.NewReader("abcdefg"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, err.(HTTPError).StatusCode())
+		assert.NotEmpty(t, err.Error())
+	}
+	assert.Equal(t, "", res.Body.String())
+	assert.Equal(t, "bytes */7", res.Header().Get("Content-Range"))
+
+	// errors flow through the router
+	router := New()
+	router.Get("/data", func(c *Context) error {
+		return c.ServeContent("data.txt", modtime, strings.NewReader("abcdefg"))
+	})
+	req.URL.Path = "/data"
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, res.Code)
+}
+
+func TestContentWriterFlush(t *testing.T) {
+	res := httptest.NewRecorder()
+	w := &contentWriter{ResponseWriter: res}
+	w.Flush()
+	assert.True(t, res.Flushed)
+	assert.Equal(t, http.StatusOK, w.status)
+
+	// the captured error responses are not flushed
+	res = httptest.NewRecorder()
+	w = &contentWriter{ResponseWriter: res}
+	w.WriteHeader(http.StatusNotFound)
+	w.Flush()
+	assert.False(t, res.Flushed)
+}
@@ -4,7 +4,10 @@
 
 package routing
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+)
 
 // HTTPError represents an HTTP error with HTTP status code and error message
 type HTTPError interface {
@@ -13,9 +16,32 @@ type HTTPError interface {
 	StatusCode() int
 }
 
+// TranslatableError is implemented by errors whose messages can be translated by a Translator.
+type TranslatableError interface {
+	error
+	// MessageKey returns the key identifying the error message
+	MessageKey() string
+	// MessageArgs returns the arguments used to build the translated message
+	MessageArgs() []interface{}
+}
+
+// CodedError is implemented by errors carrying a stable, machine-readable code, such as "user_not_found",
+// which the API clients can rely on instead of the error messages.
+type CodedError interface {
+	error
+	// ErrorCode returns the code of the error, or an empty string if the error has no code
+	ErrorCode() string
+}
+
+// Translator translates the message identified by the given key and arguments for the current request.
+// The target language is typically determined from the context (e.g. the one chosen by content.LanguageNegotiator).
+// It should return an empty string if the translation is not available.
+type Translator func(c *Context, key string, args ...interface{}) string
+
 // Error contains the error information reported by calling Context.Error().
 type httpError struct {
 	Status  int    `json:"status" xml:"status"`
+	Code    string `json:"code,omitempty" xml:"code,omitempty"`
 	Message string `json:"message" xml:"message"`
 }
 
@@ -24,9 +50,9 @@ type httpError struct {
 // to generate the message based on the status code.
 func NewHTTPError(status int, message ...string) HTTPError {
 	if len(message) > 0 {
-		return &httpError{status, message[0]}
+		return &httpError{Status: status, Message: message[0]}
 	}
-	return &httpError{status, http.StatusText(status)}
+	return &httpError{Status: status, Message: http.StatusText(status)}
 }
 
 // Error returns the error message.
@@ -38,3 +64,103 @@ func (e *httpError) Error() string {
 func (e *httpError) StatusCode() int {
 	return e.Status
 }
+
+// ErrorCode returns the error code, or an empty string if the error has no code.
+func (e *httpError) ErrorCode() string {
+	return e.Code
+}
+
+// translatableHTTPError is an HTTPError whose message can be translated.
+type translatableHTTPError struct {
+	httpError
+	key  string
+	args []interface{}
+}
+
+// NewTranslatableHTTPError creates a new HTTPError whose message is identified by the given key and arguments.
+// The untranslated error message is built by calling fmt.Sprintf() with the key as the format.
+// See Context.TranslateError() for how the message is translated.
+func NewTranslatableHTTPError(status int, key string, args ...interface{}) HTTPError {
+	message := key
+	if len(args) > 0 {
+		message = fmt.Sprintf(key, args...)
+	}
+	return &translatableHTTPError{httpError{Status: status, Message: message}, key, args}
+}
+
+// MessageKey returns the key identifying the error message.
+func (e *translatableHTTPError) MessageKey() string {
+	return e.key
+}
+
+// MessageArgs returns the arguments used to build the translated message.
+func (e *translatableHTTPError) MessageArgs() []interface{} {
+	return e.args
+}
+
+// ErrorCode declares a stable error code together with the HTTP status and the default message of the errors
+// of the code, so that the codes of an API are declared in one place. An ErrorCode is an HTTPError itself, and is
+// serialized with the "status", "code", and "message" members by the data writers. It is usually created by
+// RegisterErrorCode:
+//
+//     var ErrUserNotFound = routing.RegisterErrorCode("user_not_found", http.StatusNotFound, "The user was not found.")
+//
+//     func getUser(c *routing.Context) error {
+//         ...
+//         if user == nil {
+//             return ErrUserNotFound
+//         }
+//         ...
+//     }
+type ErrorCode struct {
+	Status  int    `json:"status" xml:"status"`
+	Code    string `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+}
+
+// ErrorCodes lists the error codes registered by RegisterErrorCode, indexed by the codes.
+// It can be used to document the error codes of an API. It should not be modified while serving requests.
+var ErrorCodes = map[string]*ErrorCode{}
+
+// RegisterErrorCode declares an error code with the given HTTP status and default message, and registers it
+// in ErrorCodes. If the message is empty, http.StatusText() of the status is used. RegisterErrorCode panics
+// if the code is empty or has already been registered.
+func RegisterErrorCode(code string, status int, message string) *ErrorCode {
+	if code == "" {
+		panic("the error code must not be empty")
+	}
+	if _, ok := ErrorCodes[code]; ok {
+		panic("the error code " + code + " is already registered")
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	e := &ErrorCode{Status: status, Code: code, Message: message}
+	ErrorCodes[code] = e
+	return e
+}
+
+// Error returns the default message of the error code.
+func (e *ErrorCode) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code of the error code.
+func (e *ErrorCode) StatusCode() int {
+	return e.Status
+}
+
+// ErrorCode returns the error code.
+func (e *ErrorCode) ErrorCode() string {
+	return e.Code
+}
+
+// Newf creates an HTTPError of the error code with a message that replaces the default one. The message is built
+// and translated in the same way as NewTranslatableHTTPError does with the format as the key. For example,
+//
+//     return ErrUserNotFound.Newf("The user %v was not found.", id)
+func (e *ErrorCode) Newf(format string, args ...interface{}) HTTPError {
+	err := NewTranslatableHTTPError(e.Status, format, args...).(*translatableHTTPError)
+	err.Code = e.Code
+	return err
+}
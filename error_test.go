@@ -24,3 +24,49 @@ func TestNewHttpError(t *testing.T) {
 	s, _ := json.Marshal(e)
 	assert.Equal(t, `{"status":404,"message":"abc"}`, string(s))
 }
+
+func TestNewTranslatableHTTPError(t *testing.T) {
+	e := NewTranslatableHTTPError(http.StatusBadRequest, "user %v not found", 123)
+	assert.Equal(t, http.StatusBadRequest, e.StatusCode())
+	assert.Equal(t, "user 123 not found", e.Error())
+	if te, ok := e.(TranslatableError); assert.True(t, ok) {
+		assert.Equal(t, "user %v not found", te.MessageKey())
+		assert.Equal(t, []interface{}{123}, te.MessageArgs())
+	}
+
+	e = NewTranslatableHTTPError(http.StatusBadRequest, "invalid")
+	assert.Equal(t, "invalid", e.Error())
+
+	s, _ := json.Marshal(e)
+	assert.Equal(t, `{"status":400,"message":"invalid"}`, string(s))
+}
+
+func TestErrorCode(t *testing.T) {
+	e := RegisterErrorCode("test_user_not_found", http.StatusNotFound, "The user was not found.")
+	defer delete(ErrorCodes, "test_user_not_found")
+	assert.Equal(t, e, ErrorCodes["test_user_not_found"])
+	assert.Equal(t, http.StatusNotFound, e.StatusCode())
+	assert.Equal(t, "test_user_not_found", e.ErrorCode())
+	assert.Equal(t, "The user was not found.", e.Error())
+	s, _ := json.Marshal(e)
+	assert.Equal(t, `{"status":404,"code":"test_user_not_found","message":"The user was not found."}`, string(s))
+
+	err := e.Newf("The user %v was not found.", 123)
+	assert.Equal(t, http.StatusNotFound, err.StatusCode())
+	assert.Equal(t, "The user 123 was not found.", err.Error())
+	if ce, ok := err.(CodedError); assert.True(t, ok) {
+		assert.Equal(t, "test_user_not_found", ce.ErrorCode())
+	}
+	if te, ok := err.(TranslatableError); assert.True(t, ok) {
+		assert.Equal(t, "The user %v was not found.", te.MessageKey())
+	}
+	s, _ = json.Marshal(err)
+	assert.Equal(t, `{"status":404,"code":"test_user_not_found","message":"The user 123 was not found."}`, string(s))
+
+	e2 := RegisterErrorCode("test_conflict", http.StatusConflict, "")
+	defer delete(ErrorCodes, "test_conflict")
+	assert.Equal(t, "Conflict", e2.Error())
+
+	assert.Panics(t, func() { RegisterErrorCode("test_conflict", http.StatusConflict, "") })
+	assert.Panics(t, func() { RegisterErrorCode("", http.StatusConflict, "") })
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Command fullapp is an example application built with the kit package. It serves a small user API
+// authenticated by JWT, the static files under the "public" directory, and the health and metrics endpoints.
+//
+// Run it and try the following requests:
+//
+//     curl -X POST -d '{"username":"demo","password":"pass"}' http://localhost:8080/api/login
+//     curl -H "Authorization: Bearer <token>" http://localhost:8080/api/users
+//     curl http://localhost:8080/health/ready
+//     curl http://localhost:8080/metrics
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/auth"
+	"github.com/go-ozzo/ozzo-routing/v2/cors"
+	"github.com/go-ozzo/ozzo-routing/v2/file"
+	"github.com/go-ozzo/ozzo-routing/v2/health"
+	"github.com/go-ozzo/ozzo-routing/v2/kit"
+	"github.com/golang-jwt/jwt"
+)
+
+// signingKey signs and verifies the JWT tokens. A real application should load it from its configuration.
+const signingKey = "change-me"
+
+type (
+	// User is a user managed by the API.
+	User struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	// userStore stores the users in memory.
+	userStore struct {
+		mu     sync.RWMutex
+		users  map[int]User
+		nextID int
+	}
+)
+
+func main() {
+	users := &userStore{users: map[int]User{}, nextID: 1}
+	health.Register("users", func(ctx context.Context) error {
+		// a real application would ping its database here
+		return nil
+	})
+
+	app := kit.New(kit.Options{
+		CORS:          &cors.AllowAll,
+		Auth:          auth.JWT(signingKey),
+		Static:        file.PathMap{"/": "/public/"},
+		StaticOptions: file.ServerOptions{IndexFile: "index.html"},
+	})
+	// the login route is not in the API group, so that it is not authenticated
+	app.Post("/api/login", login)
+
+	app.API.Get("/users", users.list)
+	app.API.Post("/users", users.create)
+	app.API.Get("/users/<id:\\d+>", users.get)
+
+	server := &http.Server{Addr: ":8080", Handler: app}
+	log.Printf("listening on %v", server.Addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// login issues a JWT token for valid credentials.
+func login(c *routing.Context) error {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.Read(&credentials); err != nil {
+		return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	// a real application would check the credentials against its user store
+	if credentials.Username != "demo" || credentials.Password != "pass" {
+		return routing.NewHTTPError(http.StatusUnauthorized)
+	}
+	token, err := auth.NewJWT(jwt.MapClaims{
+		"sub": credentials.Username,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, signingKey)
+	if err != nil {
+		return err
+	}
+	return c.Write(map[string]string{"token": token})
+}
+
+func (s *userStore) list(c *routing.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return c.Write(users)
+}
+
+func (s *userStore) get(c *routing.Context) error {
+	id, _ := strconv.Atoi(c.Param("id"))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[id]
+	if !ok {
+		return routing.NewHTTPError(http.StatusNotFound)
+	}
+	return c.Write(user)
+}
+
+func (s *userStore) create(c *routing.Context) error {
+	var user User
+	if err := c.Read(&user); err != nil {
+		return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if user.Name == "" {
+		return routing.NewHTTPError(http.StatusBadRequest, "the name is required")
+	}
+	s.mu.Lock()
+	user.ID = s.nextID
+	s.nextID++
+	s.users[user.ID] = user
+	s.mu.Unlock()
+	return c.WriteWithStatus(user, http.StatusCreated)
+}
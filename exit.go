@@ -0,0 +1,130 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// The reasons for which a handler ends the handler chain of a request.
+const (
+	ExitError = "error" // the handler returned an error, e.g. an authentication or rate limit handler rejecting the request
+	ExitAbort = "abort" // the handler called Context.Abort to skip the rest of the handlers, e.g. a cache hit
+	ExitEnd   = "end"   // the handler is the last one in the chain, usually the route handler
+)
+
+type (
+	// ChainExit describes the handler that ended the handler chain of a request, which is the last handler
+	// invoked via Context.Next.
+	ChainExit struct {
+		// Index is the index of the handler in the chain. It is -1 if no handler has been invoked.
+		Index int `json:"index"`
+		// Handler is the name of the handler (see HandlerNames).
+		Handler string `json:"handler"`
+		// Reason is why the handler ended the chain: ExitError, ExitAbort, or ExitEnd.
+		Reason string `json:"reason"`
+	}
+
+	// ExitStats counts the requests of each route by the handlers that ended their handler chains, so that
+	// the operators can see why the requests end where they do, e.g. how many are rejected by authentication
+	// or rate limiting, served from cache, or served by the route handlers. Set Router.Exits to an ExitStats
+	// to enable counting. ExitStats implements expvar.Var, so the counts can be published via expvar:
+	//
+	//     router.Exits = &routing.ExitStats{}
+	//     expvar.Publish("exits", router.Exits)
+	//
+	// ExitStats is safe for concurrent use.
+	ExitStats struct {
+		mu     sync.Mutex
+		routes map[string]map[ChainExit]int64
+	}
+
+	// ExitCount is the number of the requests ended by a handler for a reason.
+	ExitCount struct {
+		ChainExit
+		Count int64 `json:"count"`
+	}
+)
+
+// HandlerNames maps the names of the handler functions, e.g. "auth.Bearer.func1", to the names reported
+// by Context.HandlerName, Context.HandlerTimings, and Context.ChainExit, e.g. "auth". It should be set up
+// before the router starts serving requests.
+var HandlerNames = map[string]string{}
+
+// TrackChainExit enables tracking the handler that ended the handler chain of the current request, which is
+// reported by ChainExit. It should be called before the handlers are executed, e.g. before a logging handler
+// calls Context.Next. Tracking is always enabled when Router.Exits is set.
+func (c *Context) TrackChainExit() {
+	c.trackExit = true
+}
+
+// ChainExit returns the handler that ended the handler chain of the current request. It should be called after
+// the handlers are executed, e.g. after Context.Next returns in a logging handler. The result has the Index -1
+// unless tracking is enabled via TrackChainExit or Router.Exits.
+func (c *Context) ChainExit() ChainExit {
+	if c.exit < 0 || c.exit >= len(c.exitHandlers) {
+		return ChainExit{Index: -1}
+	}
+	reason := ExitEnd
+	if c.exitErr {
+		reason = ExitError
+	} else if c.exit < len(c.exitHandlers)-1 {
+		reason = ExitAbort
+	}
+	return ChainExit{c.exit, handlerName(c.exitHandlers[c.exit]), reason}
+}
+
+// Add counts a request of the given route ended by the given handler. The router calls this method
+// for every request when Router.Exits is set.
+func (s *ExitStats) Add(route *Route, exit ChainExit) {
+	key := ""
+	if route != nil {
+		key = route.String()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.routes == nil {
+		s.routes = map[string]map[ChainExit]int64{}
+	}
+	counts := s.routes[key]
+	if counts == nil {
+		counts = map[ChainExit]int64{}
+		s.routes[key] = counts
+	}
+	counts[exit]++
+}
+
+// Snapshot returns the current counts indexed by the routes, e.g. "GET /users". The requests matching no route
+// are indexed by an empty string. The counts of each route are sorted by the handler indexes and the reasons.
+func (s *ExitStats) Snapshot() map[string][]ExitCount {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string][]ExitCount, len(s.routes))
+	for key, counts := range s.routes {
+		list := make([]ExitCount, 0, len(counts))
+		for exit, n := range counts {
+			list = append(list, ExitCount{exit, n})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Index != list[j].Index {
+				return list[i].Index < list[j].Index
+			}
+			if list[i].Reason != list[j].Reason {
+				return list[i].Reason < list[j].Reason
+			}
+			return list[i].Handler < list[j].Handler
+		})
+		result[key] = list
+	}
+	return result
+}
+
+// String returns the current counts in JSON. It implements expvar.Var.
+func (s *ExitStats) String() string {
+	b, _ := json.Marshal(s.Snapshot())
+	return string(b)
+}
@@ -0,0 +1,124 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exitAuth(c *Context) error {
+	if c.Request.Header.Get("Authorization") == "" {
+		return NewHTTPError(http.StatusUnauthorized)
+	}
+	return nil
+}
+
+func exitCache(c *Context) error {
+	if c.Query("cached") != "" {
+		c.Abort()
+		return c.Write("cached")
+	}
+	return nil
+}
+
+func exitWrap(c *Context) error {
+	return c.Next()
+}
+
+func exitUser(c *Context) error {
+	if c.Query("fail") != "" {
+		return errors.New("fail")
+	}
+	return c.Write("user")
+}
+
+func TestContextChainExit(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.Equal(t, ChainExit{Index: -1}, NewContext(nil, req).ChainExit())
+
+	// no tracking unless enabled
+	c := NewContext(httptest.NewRecorder(), req, exitUser)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, ChainExit{Index: -1}, c.ChainExit())
+
+	// the error of a handler running another chain of the same length is not taken as that of the other chain
+	c = NewContext(nil, req, exitWrap, func(c *Context) error {
+		handlers, index := c.handlers, c.index
+		c.handlers, c.index = []Handler{exitWrap, exitCache}, -1
+		c.Next()
+		c.handlers, c.index = handlers, index
+		return errors.New("fail")
+	})
+	c.TrackChainExit()
+	assert.NotNil(t, c.Next())
+	assert.Equal(t, ChainExit{1, "v2.exitCache", ExitEnd}, c.ChainExit())
+
+	var exit ChainExit
+	router := New()
+	router.Use(func(c *Context) error {
+		c.TrackChainExit()
+		err := c.Next()
+		exit = c.ChainExit()
+		return err
+	}, exitWrap)
+	router.Get("/users", exitAuth, exitCache, exitUser)
+
+	tests := []struct {
+		id, url string
+		auth    bool
+		exit    ChainExit
+	}{
+		{"t1", "/users", true, ChainExit{4, "v2.exitUser", ExitEnd}},
+		{"t2", "/users", false, ChainExit{2, "v2.exitAuth", ExitError}},
+		{"t3", "/users?cached=1", true, ChainExit{3, "v2.exitCache", ExitAbort}},
+		{"t4", "/users?fail=1", true, ChainExit{4, "v2.exitUser", ExitError}},
+		{"t5", "/missing", true, ChainExit{3, "v2.NotFoundHandler", ExitError}},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		if test.auth {
+			req.Header.Set("Authorization", "secret")
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, test.exit, exit, test.id)
+	}
+
+	HandlerNames["v2.exitAuth"] = "auth"
+	defer delete(HandlerNames, "v2.exitAuth")
+	req, _ = http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, ChainExit{2, "auth", ExitError}, exit)
+}
+
+func TestExitStats(t *testing.T) {
+	router := New()
+	router.Exits = &ExitStats{}
+	router.Get("/users", exitAuth, exitCache, exitUser)
+
+	for _, url := range []string{"/users", "/users", "/users?cached=1", "/missing"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "secret")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, map[string][]ExitCount{
+		"GET /users": {
+			{ChainExit{0, "v2.exitAuth", ExitError}, 1},
+			{ChainExit{1, "v2.exitCache", ExitAbort}, 1},
+			{ChainExit{2, "v2.exitUser", ExitEnd}, 2},
+		},
+		"": {
+			{ChainExit{1, "v2.NotFoundHandler", ExitError}, 1},
+		},
+	}, router.Exits.Snapshot())
+	assert.Contains(t, router.Exits.String(), `"GET /users":[{"index":0,"handler":"v2.exitAuth","reason":"error","count":1}`)
+}
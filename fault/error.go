@@ -16,6 +16,11 @@ import (
 // Otherwise the HTTP status is set as http.StatusInternalServerError. The handler will also write the error
 // as the response body.
 //
+// If the handlers have already written a part of the response when the error occurs, the partial response
+// is discarded and replaced with the error if it is still held by a routing.BufferedResponseWriter. Otherwise,
+// the error is only logged, unless AppendPartial is true, so that the client does not receive a corrupted
+// response mixed with the error.
+//
 // A log function can be provided to log a message whenever an error is handled. If nil, no message will be logged.
 //
 // An optional error conversion function can also be provided to convert an error into a normalized one
@@ -32,34 +37,55 @@ import (
 //     r.Use(fault.PanicHandler(log.Printf))
 func ErrorHandler(logf LogFunc, errorf ...ConvertErrorFunc) routing.Handler {
 	return func(c *routing.Context) error {
+		c.TrackResponse()
 		err := c.Next()
 		if err == nil {
 			return nil
 		}
 
 		if logf != nil {
-			logf("%v", err)
+			logf("%v", annotate(c, err))
 		}
 
 		if len(errorf) > 0 {
 			err = errorf[0](c, err)
 		}
 
-		writeError(c, err)
+		if !writeError(c, err) && logf != nil {
+			logPartial(c, logf)
+		}
 		c.Abort()
 
 		return nil
 	}
 }
 
+// AppendPartial specifies whether the error handlers should write an error after the response that has been
+// partially sent to the client. When false (the default), such errors are only logged.
+var AppendPartial = false
+
 // writeError writes the error to the response.
+// The error message will be translated if a translator is registered with the router.
 // If the error implements HTTPError, it will set the HTTP status as the result of the StatusCode() call of the error.
 // Otherwise, the HTTP status will be set as http.StatusInternalServerError.
-func writeError(c *routing.Context, err error) {
+// If a part of the response has been written, it is discarded if possible. writeError returns false without
+// writing the error if the partial response cannot be discarded and AppendPartial is false.
+func writeError(c *routing.Context, err error) bool {
+	if status, _ := c.Written(); status != 0 && !c.DiscardResponse() && !AppendPartial {
+		return false
+	}
+	err = c.TranslateError(err)
 	if httpError, ok := err.(routing.HTTPError); ok {
 		c.Response.WriteHeader(httpError.StatusCode())
 	} else {
 		c.Response.WriteHeader(http.StatusInternalServerError)
 	}
 	c.Write(err)
+	return true
+}
+
+// logPartial logs that the error is not written because the response has been partially sent to the client.
+func logPartial(c *routing.Context, logf LogFunc) {
+	status, size := c.Written()
+	logf("the error is not written because the response has been partially written (status %v, %v bytes)", status, size)
 }
@@ -69,6 +69,68 @@ func Test_writeError(t *testing.T) {
 	assert.Equal(t, "xyz", res.Body.String())
 }
 
+func Test_writeErrorTranslated(t *testing.T) {
+	router := routing.New()
+	router.Translator = func(c *routing.Context, key string, args ...interface{}) string {
+		if c.Get("Language") == "fr" && key == "xyz" {
+			return "XYZ"
+		}
+		return ""
+	}
+	router.Use(ErrorHandler(nil))
+	router.Get("/users", func(c *routing.Context) error {
+		c.Set("Language", "fr")
+		return routing.NewHTTPError(http.StatusNotFound, "xyz")
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "XYZ", res.Body.String())
+}
+
 func convertError(c *routing.Context, err error) error {
 	return errors.New("123")
 }
+
+func TestErrorHandlerPartial(t *testing.T) {
+	var buf bytes.Buffer
+	h := ErrorHandler(getLogger(&buf))
+	partial := func(c *routing.Context) error {
+		c.Response.Write([]byte("partial"))
+		return errors.New("abc")
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	c := routing.NewContext(res, req, h, partial)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "partial", res.Body.String())
+	assert.Equal(t, "abcthe error is not written because the response has been partially written (status 200, 7 bytes)", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/", nil)
+	c = routing.NewContext(res, req, func(c *routing.Context) error {
+		rw := routing.NewBufferedResponseWriter(c.Response, 0)
+		c.Response = rw
+		err := c.Next()
+		rw.Commit()
+		return err
+	}, h, partial)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "abc", res.Body.String())
+	assert.Equal(t, "abc", buf.String())
+
+	AppendPartial = true
+	defer func() { AppendPartial = false }()
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/", nil)
+	c = routing.NewContext(res, req, h, partial)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, "partialabc", res.Body.String())
+	assert.Equal(t, "abc", buf.String())
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fault
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/content"
+)
+
+type (
+	// ErrorPage writes an error page with the given data as the response, including the HTTP status code.
+	ErrorPage func(c *routing.Context, data PageData) error
+
+	// PageMap maps HTTP status codes to error pages. The page of the zero status code, if any,
+	// is used for the status codes that are not in the map.
+	PageMap map[int]ErrorPage
+
+	// PageData describes the error shown by an error page.
+	PageData struct {
+		Status  int           // the HTTP status code, e.g. 404
+		Code    string        // the error code if the error implements routing.CodedError, e.g. "user_not_found"
+		Title   string        // the HTTP status text, e.g. "Not Found"
+		Message string        // the error message, translated if a translator is registered with the router
+		Error   error         // the error being handled
+		Request *http.Request // the current request
+	}
+
+	// Template renders the data into w, such as *html/template.Template.
+	Template interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+)
+
+// ErrorPages returns a handler that renders the errors returned by the handlers following this one as HTML pages
+// when the client accepts HTML (i.e. lists "text/html" in the Accept header), so that websites do not show
+// plain-text errors to their visitors. The page is chosen according to the HTTP status code of the error, which is
+// the result of StatusCode() if the error implements routing.HTTPError, or http.StatusInternalServerError otherwise.
+//
+// If the client does not accept HTML, there is no page for the status code, the response has been partially sent
+// to the client, or the page fails to render, the error is returned to the parent handlers so that it can be handled
+// as usual. ErrorPages should therefore be used after an error handler, such as Recovery. To render the pages
+// for panics, add PanicHandler after ErrorPages.
+//
+//     import (
+//         "html/template"
+//         "log"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/fault"
+//         "github.com/go-ozzo/ozzo-routing/v2/file"
+//     )
+//
+//     r := routing.New()
+//     r.Use(fault.Recovery(log.Printf))
+//     r.Use(fault.ErrorPages(fault.PageMap{
+//         404: file.ErrorPage("ui/404.html"),
+//         0:   fault.TemplatePage(template.Must(template.ParseFiles("ui/error.html"))),
+//     }))
+//     r.Use(fault.PanicHandler(log.Printf))
+func ErrorPages(pages PageMap) routing.Handler {
+	return func(c *routing.Context) error {
+		err := c.Next()
+		if err == nil {
+			return nil
+		}
+
+		status := http.StatusInternalServerError
+		if httpError, ok := err.(routing.HTTPError); ok {
+			status = httpError.StatusCode()
+		}
+		page, ok := pages[status]
+		if !ok {
+			page, ok = pages[0]
+		}
+		if !ok || !acceptsHTML(c.Request) {
+			return err
+		}
+		if status, _ := c.Written(); status != 0 && !c.DiscardResponse() {
+			return err
+		}
+
+		data := PageData{
+			Status:  status,
+			Code:    errorCode(err),
+			Title:   http.StatusText(status),
+			Message: c.TranslateError(err).Error(),
+			Error:   err,
+			Request: c.Request,
+		}
+		if page(c, data) != nil {
+			return err
+		}
+		c.Abort()
+		return nil
+	}
+}
+
+// TemplatePage returns an error page that renders the given template with PageData.
+// The page is sent with the "text/html; charset=UTF-8" content type.
+func TemplatePage(t Template) ErrorPage {
+	return func(c *routing.Context, data PageData) error {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return err
+		}
+		return WritePage(c, data.Status, "text/html; charset=UTF-8", buf.Bytes())
+	}
+}
+
+// WritePage writes an error page with the given status code, content type, and body as the response.
+// It can be used to implement error pages.
+func WritePage(c *routing.Context, status int, contentType string, body []byte) error {
+	header := c.Response.Header()
+	header.Set("Content-Type", contentType)
+	header.Del("Content-Length")
+	header.Set("X-Content-Type-Options", "nosniff")
+	c.Response.WriteHeader(status)
+	_, err := c.Response.Write(body)
+	return err
+}
+
+// errorCode returns the code of the given error if it implements routing.CodedError.
+func errorCode(err error) string {
+	if codedError, ok := err.(routing.CodedError); ok {
+		return codedError.ErrorCode()
+	}
+	return ""
+}
+
+// acceptsHTML checks if the client explicitly accepts HTML responses.
+func acceptsHTML(req *http.Request) bool {
+	for _, accept := range content.AcceptMediaTypes(req) {
+		if accept.Weight > 0 && (accept.Type == "text" && accept.Subtype == "html" || accept.Type == "application" && accept.Subtype == "xhtml+xml") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fault
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPages(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`<h1>{{.Status}} {{.Title}}</h1><p>{{.Message}}</p>`))
+	badTmpl := template.Must(template.New("error").Parse(`{{.Unknown}}`))
+	router := routing.New()
+	router.Use(ErrorPages(PageMap{
+		http.StatusNotFound:   TemplatePage(tmpl),
+		http.StatusBadRequest: TemplatePage(badTmpl),
+		0: func(c *routing.Context, data PageData) error {
+			return WritePage(c, data.Status, "text/plain", []byte("error page"))
+		},
+	}))
+	router.Get("/users/<id>", func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusNotFound, "user <"+c.Param("id")+"> not found")
+	})
+	router.Get("/bad", func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusBadRequest)
+	})
+	router.Get("/fail", func(c *routing.Context) error {
+		return errors.New("failed")
+	})
+	router.Get("/ok", func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+
+	tests := []struct {
+		tag         string
+		path        string
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"t1", "/users/1", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusNotFound, "text/html; charset=UTF-8", "<h1>404 Not Found</h1><p>user &lt;1&gt; not found</p>"},
+		{"t2", "/users/1", "application/xhtml+xml", http.StatusNotFound, "text/html; charset=UTF-8", "<h1>404 Not Found</h1><p>user &lt;1&gt; not found</p>"},
+		{"t3", "/users/1", "application/json", http.StatusNotFound, "text/plain; charset=utf-8", "user <1> not found\n"},
+		{"t4", "/users/1", "*/*", http.StatusNotFound, "text/plain; charset=utf-8", "user <1> not found\n"},
+		{"t5", "/users/1", "text/html;q=0", http.StatusNotFound, "text/plain; charset=utf-8", "user <1> not found\n"},
+		{"t6", "/bad", "text/html", http.StatusBadRequest, "text/plain; charset=utf-8", "Bad Request\n"},
+		{"t7", "/fail", "text/html", http.StatusInternalServerError, "text/plain", "error page"},
+		{"t8", "/missing", "text/html", http.StatusNotFound, "text/html; charset=UTF-8", "<h1>404 Not Found</h1><p>Not Found</p>"},
+		{"t9", "/ok", "text/html", http.StatusOK, "text/plain; charset=utf-8", "ok"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		req.Header.Set("Accept", test.accept)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.contentType, res.Header().Get("Content-Type"), test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+	}
+}
+
+func TestErrorPagesWithoutDefault(t *testing.T) {
+	router := routing.New()
+	router.Use(ErrorPages(PageMap{
+		http.StatusNotFound: func(c *routing.Context, data PageData) error {
+			return WritePage(c, data.Status, "text/html", []byte("not found"))
+		},
+	}))
+	router.Get("/fail", func(c *routing.Context) error {
+		return errors.New("failed")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/fail", nil)
+	req.Header.Set("Accept", "text/html")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "failed\n", res.Body.String())
+}
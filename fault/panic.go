@@ -8,8 +8,39 @@ import (
 	"github.com/go-ozzo/ozzo-routing/v2"
 )
 
+type (
+	// PanicClassifier converts a value recovered from a panic into an error, e.g. an HTTPError with an appropriate
+	// status for a domain-specific panic raised by an ORM. It returns nil if it does not recognize the value.
+	PanicClassifier func(c *routing.Context, value interface{}) error
+
+	// PanicError is the error converted from a panic value that is not an error, keeping the value so that
+	// an error conversion function can inspect it.
+	PanicError struct {
+		Value interface{} // the value recovered from the panic
+	}
+)
+
+// PanicClassifiers are called in order by PanicHandler, and thus by Recovery, to convert the values recovered
+// from panics into errors before they are returned to the parent handlers. The first non-nil error is used.
+// If no classifier recognizes a value, the value is used as the error if it is an error, or wrapped in a PanicError
+// otherwise. You may modify this variable before serving requests, e.g.
+//
+//     fault.PanicClassifiers = append(fault.PanicClassifiers, func(c *routing.Context, v interface{}) error {
+//         if e, ok := v.(*orm.RecordNotFound); ok {
+//             return routing.NewHTTPError(http.StatusNotFound, e.Table+" not found")
+//         }
+//         return nil
+//     })
+var PanicClassifiers []PanicClassifier
+
+// Error returns the string representation of the panic value.
+func (e *PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
 // PanicHandler returns a handler that recovers from panics happened in the handlers following this one.
-// When a panic is recovered, it will be converted into an error and returned to the parent handlers.
+// When a panic is recovered, it will be converted into an error (see PanicClassifiers) and returned to
+// the parent handlers.
 //
 // A log function can be provided to log the panic call stack information. If the log function is nil,
 // no message will be logged.
@@ -27,13 +58,17 @@ func PanicHandler(logf LogFunc) routing.Handler {
 	return func(c *routing.Context) (err error) {
 		defer func() {
 			if e := recover(); e != nil {
-				if logf != nil {
-					logf("recovered from panic:%v", getCallStack(4))
+				if router := c.Router(); router != nil && router.Stats != nil {
+					router.Stats.AddPanic()
 				}
-				var ok bool
-				if err, ok = e.(error); !ok {
-					err = fmt.Errorf("%v", e)
+				if logf != nil {
+					if route := c.Route(); route != nil {
+						logf("recovered from panic [route %v, handler %v]:%v", route, c.HandlerName(), getCallStack(4))
+					} else {
+						logf("recovered from panic:%v", getCallStack(4))
+					}
 				}
+				err = classifyPanic(c, e)
 			}
 		}()
 
@@ -41,6 +76,19 @@ func PanicHandler(logf LogFunc) routing.Handler {
 	}
 }
 
+// classifyPanic converts the value recovered from a panic into an error.
+func classifyPanic(c *routing.Context, value interface{}) error {
+	for _, classify := range PanicClassifiers {
+		if err := classify(c, value); err != nil {
+			return err
+		}
+	}
+	if err, ok := value.(error); ok {
+		return err
+	}
+	return &PanicError{value}
+}
+
 // getCallStack returns the current call stack information as a string.
 // The skip parameter specifies how many top frames should be skipped.
 func getCallStack(skip int) string {
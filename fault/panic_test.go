@@ -41,3 +41,64 @@ func TestPanicHandler(t *testing.T) {
 	assert.Contains(t, buf.String(), "panic_test.go")
 	assert.Contains(t, buf.String(), "xyz")
 }
+
+func TestPanicHandlerStats(t *testing.T) {
+	router := routing.New()
+	router.Stats = &routing.Stats{}
+	router.Use(ErrorHandler(nil), PanicHandler(nil))
+	router.Get("/panic", func(c *routing.Context) error {
+		panic("xyz")
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	s := router.Stats.Snapshot()
+	assert.Equal(t, int64(1), s.Panics)
+	assert.Equal(t, int64(1), s.Status5xx)
+}
+
+type recordNotFound struct {
+	Table string
+}
+
+func TestPanicClassifiers(t *testing.T) {
+	PanicClassifiers = []PanicClassifier{
+		func(c *routing.Context, v interface{}) error {
+			if e, ok := v.(recordNotFound); ok {
+				return routing.NewHTTPError(http.StatusNotFound, e.Table+" not found")
+			}
+			return nil
+		},
+	}
+	defer func() { PanicClassifiers = nil }()
+
+	var value interface{}
+	router := routing.New()
+	router.Use(Recovery(nil, func(c *routing.Context, err error) error {
+		if e, ok := err.(*PanicError); ok {
+			value = e.Value
+		}
+		return err
+	}))
+	router.Get("/users", func(c *routing.Context) error {
+		panic(recordNotFound{"users"})
+	})
+	router.Get("/orders", func(c *routing.Context) error {
+		panic(123)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "users not found", res.Body.String())
+	assert.Nil(t, value)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/orders", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "123", res.Body.String())
+	assert.Equal(t, 123, value)
+}
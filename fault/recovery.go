@@ -5,7 +5,12 @@
 // Package fault provides a panic and error handler for the ozzo routing package.
 package fault
 
-import "github.com/go-ozzo/ozzo-routing/v2"
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
 
 type (
 	// LogFunc logs a message using the given format and optional arguments.
@@ -15,15 +20,73 @@ type (
 
 	// ConvertErrorFunc converts an error into a different format so that it is more appropriate for rendering purpose.
 	ConvertErrorFunc func(*routing.Context, error) error
+
+	// handlerError is an error logged with the route and the handler where it occurred.
+	handlerError struct {
+		error
+		route, handler string
+	}
 )
 
+// Error returns the error message followed by the route and the handler.
+func (e *handlerError) Error() string {
+	return fmt.Sprintf("%v [route %v, handler %v]", e.error, e.route, e.handler)
+}
+
+// Unwrap returns the original error.
+func (e *handlerError) Unwrap() error {
+	return e.error
+}
+
+// annotate adds the matched route and the name of the handler that returned the error to the given error
+// for logging. The error is returned as is if the request matches no route.
+func annotate(c *routing.Context, err error) error {
+	route := c.Route()
+	if route == nil {
+		return err
+	}
+	return &handlerError{err, route.String(), c.HandlerName()}
+}
+
+// LoggerFunc returns a LogFunc that sends the messages to the given leveled logger, so that it can be used with
+// Recovery, ErrorHandler, and PanicHandler. A message about an error implementing routing.HTTPError with a 4xx
+// status is logged at the warning level together with the status ("status"). Other messages, including those about
+// panics, are logged at the error level.
+//
+//     r.Use(fault.Recovery(fault.LoggerFunc(slog.Default())))
+func LoggerFunc(logger routing.Logger) LogFunc {
+	return func(format string, a ...interface{}) {
+		msg := fmt.Sprintf(format, a...)
+		if len(a) == 1 {
+			kv := []interface{}{}
+			err, _ := a[0].(error)
+			if e, ok := err.(*handlerError); ok {
+				msg = e.error.Error()
+				kv = append(kv, "route", e.route, "handler", e.handler)
+				err = e.error
+			}
+			if httpError, ok := err.(routing.HTTPError); ok {
+				if status := httpError.StatusCode(); status < http.StatusInternalServerError {
+					logger.Warn(msg, append(kv, "status", status)...)
+				} else {
+					logger.Error(msg, append(kv, "status", status)...)
+				}
+				return
+			}
+			logger.Error(msg, kv...)
+			return
+		}
+		logger.Error(msg)
+	}
+}
+
 // Recovery returns a handler that handles both panics and errors occurred while servicing an HTTP request.
 // Recovery can be considered as a combination of ErrorHandler and PanicHandler.
 //
 // The handler will recover from panics and render the recovered error or the error returned by a handler.
 // If the error implements routing.HTTPError, the handler will set the HTTP status code accordingly.
 // Otherwise the HTTP status is set as http.StatusInternalServerError. The handler will also write the error
-// as the response body.
+// as the response body. A partially written response is handled in the same way as ErrorHandler does.
 //
 // A log function can be provided to log a message whenever an error is handled. If nil, no message will be logged.
 //
@@ -41,14 +104,17 @@ type (
 func Recovery(logf LogFunc, errorf ...ConvertErrorFunc) routing.Handler {
 	handlePanic := PanicHandler(logf)
 	return func(c *routing.Context) error {
+		c.TrackResponse()
 		if err := handlePanic(c); err != nil {
 			if logf != nil {
-				logf("%v", err)
+				logf("%v", annotate(c, err))
 			}
 			if len(errorf) > 0 {
 				err = errorf[0](c, err)
 			}
-			writeError(c, err)
+			if !writeError(c, err) && logf != nil {
+				logPartial(c, logf)
+			}
 			c.Abort()
 		}
 		return nil
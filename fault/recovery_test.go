@@ -101,3 +101,70 @@ func handler3(c *routing.Context) error {
 func handler4(c *routing.Context) error {
 	panic(routing.NewHTTPError(http.StatusBadRequest, "123"))
 }
+
+type testLogger struct {
+	bytes.Buffer
+}
+
+func (l *testLogger) Debug(msg string, kv ...interface{}) { fmt.Fprintln(l, "D", msg, kv) }
+func (l *testLogger) Info(msg string, kv ...interface{})  { fmt.Fprintln(l, "I", msg, kv) }
+func (l *testLogger) Warn(msg string, kv ...interface{})  { fmt.Fprintln(l, "W", msg, kv) }
+func (l *testLogger) Error(msg string, kv ...interface{}) { fmt.Fprintln(l, "E", msg, kv) }
+
+func TestLoggerFunc(t *testing.T) {
+	logger := &testLogger{}
+	logf := LoggerFunc(logger)
+	logf("%v", routing.NewHTTPError(http.StatusNotFound))
+	logf("%v", routing.NewHTTPError(http.StatusBadGateway))
+	logf("%v", errors.New("abc"))
+	logf("recovered from panic:%v", "stack")
+	assert.Equal(t, "W Not Found [status 404]\nE Bad Gateway [status 502]\nE abc []\nE recovered from panic:stack []\n", logger.String())
+
+	logger.Reset()
+	h := Recovery(LoggerFunc(logger))
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	c := routing.NewContext(res, req, h, handler3)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Contains(t, logger.String(), "E recovered from panic:")
+	assert.Contains(t, logger.String(), "E xyz []\n")
+}
+
+func TestRecoveryHandlerName(t *testing.T) {
+	var buf bytes.Buffer
+	router := routing.New()
+	router.Use(Recovery(getLogger(&buf)))
+	router.Get("/users/<id>", handler2Middleware, handler1)
+	router.Get("/panic", handler3)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "abc", res.Body.String())
+	assert.Equal(t, "abc [route GET /users/<id>, handler fault.handler1]", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/panic", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "xyz", res.Body.String())
+	assert.Contains(t, buf.String(), "recovered from panic [route GET /panic, handler fault.handler3]:\n")
+	assert.Contains(t, buf.String(), "xyz [route GET /panic, handler fault.handler3]")
+
+	logger := &testLogger{}
+	router = routing.New()
+	router.Use(ErrorHandler(LoggerFunc(logger)))
+	router.Get("/users", func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusConflict)
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.Equal(t, "W Conflict [route GET /users handler fault.TestRecoveryHandlerName.func1 status 409]\n", logger.String())
+}
+
+func handler2Middleware(c *routing.Context) error {
+	return c.Next()
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fault
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// RecoveryWithSlog returns a handler that works like Recovery but logs the panics and errors using the given
+// slog logger. The messages are enriched with the attributes returned by routing.SlogAttrs. A panic is logged at
+// the error level with its call stack ("stack"). An error is logged with its HTTP status ("status") at the warning
+// level if it implements routing.HTTPError with a 4xx status, and at the error level otherwise.
+//
+//     r := routing.New()
+//     r.Use(fault.RecoveryWithSlog(slog.Default()))
+func RecoveryWithSlog(logger *slog.Logger, errorf ...ConvertErrorFunc) routing.Handler {
+	return func(c *routing.Context) error {
+		c.TrackResponse()
+		handlePanic := PanicHandler(func(format string, a ...interface{}) {
+			attrs := append(handlerAttrs(c), slog.String("stack", fmt.Sprint(a[len(a)-1])))
+			logger.LogAttrs(c.Request.Context(), slog.LevelError, "recovered from panic", attrs...)
+		})
+		if err := handlePanic(c); err != nil {
+			level, status := slog.LevelError, http.StatusInternalServerError
+			if httpError, ok := err.(routing.HTTPError); ok {
+				status = httpError.StatusCode()
+				if status < http.StatusInternalServerError {
+					level = slog.LevelWarn
+				}
+			}
+			attrs := append(handlerAttrs(c), slog.Int("status", status))
+			logger.LogAttrs(c.Request.Context(), level, err.Error(), attrs...)
+			if len(errorf) > 0 {
+				err = errorf[0](c, err)
+			}
+			if !writeError(c, err) {
+				status, size := c.Written()
+				logger.LogAttrs(c.Request.Context(), slog.LevelWarn, "the error is not written because the response has been partially written",
+					append(handlerAttrs(c), slog.Int("status", status), slog.Int64("size", size))...)
+			}
+			c.Abort()
+		}
+		return nil
+	}
+}
+
+// handlerAttrs returns the attributes returned by routing.SlogAttrs, followed by the name of the handler
+// that returned the error or panicked ("handler") if the request matches a route.
+func handlerAttrs(c *routing.Context) []slog.Attr {
+	attrs := routing.SlogAttrs(c)
+	if c.Route() != nil {
+		attrs = append(attrs, slog.String("handler", c.HandlerName()))
+	}
+	return attrs
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fault
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryWithSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	h := RecoveryWithSlog(logger, convertError)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/", nil)
+	c := routing.NewContext(res, req, h, handler1, handler2)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, "123", res.Body.String())
+	assert.Equal(t, "level=ERROR msg=abc method=GET path=/users/ status=500\n", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req, RecoveryWithSlog(logger), func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusNotFound)
+	})
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "level=WARN msg=\"Not Found\" method=GET path=/users/ status=404\n", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req, RecoveryWithSlog(logger), handler3)
+	assert.Nil(t, c.Next())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Contains(t, buf.String(), `level=ERROR msg="recovered from panic" method=GET path=/users/ stack=`)
+	assert.Contains(t, buf.String(), "level=ERROR msg=xyz method=GET path=/users/ status=500\n")
+
+	buf.Reset()
+	router := routing.New()
+	router.Use(RecoveryWithSlog(logger))
+	router.Get("/users/<id>", handler1)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "level=ERROR msg=abc method=GET path=/users/1 route=/users/<id> handler=fault.handler1 status=500\n", buf.String())
+}
@@ -6,13 +6,19 @@
 package file
 
 import (
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/fault"
 )
 
 // ServerOptions defines the possible options for the Server handler.
@@ -20,7 +26,12 @@ type ServerOptions struct {
 	// The path that all files to be served should be located within. The path map passed to the Server method
 	// are all relative to this path. This property can be specified as an absolute file path or a path relative
 	// to the current working path. If not set, this property defaults to the current working path.
+	// It is ignored if Filesystem is set.
 	RootPath string
+	// The file system that the files are served from, such as http.Dir, a zip-backed file system, or a wrapper
+	// of afero.Fs. If set, the file paths in the path map, IndexFile, and CatchAllFile are slash-separated paths
+	// within this file system. If not set, the files are served from the directory RootPath.
+	Filesystem http.FileSystem
 	// The file (e.g. index.html) to be served when the current request corresponds to a directory.
 	// If not set, the handler will return a 404 HTTP error when the request corresponds to a directory.
 	// This should only be a file name without the directory part.
@@ -34,6 +45,20 @@ type ServerOptions struct {
 	// The function should return a boolean indicating whether the file should be served or not.
 	// If false, a 404 HTTP error will be returned by the handler.
 	Allow func(*routing.Context, string) bool
+	// A function that is called with the path and the information of a file right before the file is served,
+	// including the index file and the catch-all file. The function may set response headers for the file
+	// (e.g. Content-Disposition, Cache-Control), or return an error (e.g. a 403 or 404 HTTP error) to prevent
+	// the file from being served. It may also return a non-empty path (relative to RootPath) to serve
+	// a different file instead, such as a localized version of the file.
+	OnFile func(c *routing.Context, path string, info os.FileInfo) (string, error)
+	// The maximum duration allowed for each write when sending a file to the client. A transfer to a client
+	// that reads the file too slowly will be aborted. If not set, the transfer is not limited.
+	// This option requires the response writer to support write deadlines (see routing.Context.SetWriteDeadline).
+	WriteTimeout time.Duration
+	// The URL path prefix to be removed from the request path before it is matched against the path map,
+	// e.g. the prefix of the route group that the handler is registered with. If set, the requests whose paths
+	// are not under the prefix are answered with a 404 HTTP error.
+	Prefix string
 }
 
 // PathMap specifies the mapping between URL paths (keys) and file paths (keys).
@@ -53,7 +78,12 @@ func init() {
 // "/css/main.css", the file "<working dir>/www/css/main.css" will be served.
 // If a URL path matches multiple prefixes in the path map, the most specific prefix will take precedence.
 // For example, if the path map contains both "/css" and "/css/img", and the URL path is "/css/img/logo.gif",
-// then the path mapped by "/css/img" will be used.
+// then the path mapped by "/css/img" will be used. If ServerOptions.Prefix is set, it is removed from the URL path
+// before matching.
+//
+// Like the router, the handler answers the requests of the methods other than GET and HEAD with
+// a 405 HTTP error and an Allow header listing the allowed methods, except that an OPTIONS request
+// is answered with the Allow header only.
 //
 //     import (
 //         "log"
@@ -66,24 +96,48 @@ func init() {
 //          "/css": "/ui/dist/css",
 //          "/js": "/ui/dist/js",
 //     }))
+//
+// The files can be served from any http.FileSystem, such as an embedded or a zip-backed file system,
+// by setting ServerOptions.Filesystem:
+//
+//     r.Get("/*", file.Server(file.PathMap{"/": "/dist"}, file.ServerOptions{
+//         Filesystem: statikFS,
+//         IndexFile:  "index.html",
+//     }))
+//
+// When the handler is registered with a route group, the path map can be relative to the group prefix:
+//
+//     g := r.Group("/static")
+//     g.Get("/*", file.Server(file.PathMap{"/": "/ui/dist/"}, file.ServerOptions{Prefix: "/static"}))
 func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 	var options ServerOptions
 	if len(opts) > 0 {
 		options = opts[0]
 	}
-	if !filepath.IsAbs(options.RootPath) {
-		options.RootPath = filepath.Join(RootPath, options.RootPath)
-	}
 	from, to := parsePathMap(pathMap)
 
-	// security measure: limit the files within options.RootPath
-	dir := http.Dir(options.RootPath)
+	dir := options.Filesystem
+	if dir == nil {
+		if !filepath.IsAbs(options.RootPath) {
+			options.RootPath = filepath.Join(RootPath, options.RootPath)
+		}
+		// security measure: limit the files within options.RootPath
+		dir = http.Dir(options.RootPath)
+	}
 
 	return func(c *routing.Context) error {
-		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
-			return routing.NewHTTPError(http.StatusMethodNotAllowed)
+		if !allowMethod(c) {
+			return methodNotAllowed(c)
 		}
-		path, found := matchPath(c.Request.URL.Path, from, to)
+		urlPath := c.Request.URL.Path
+		if options.Prefix != "" {
+			p, ok := trimPrefix(urlPath, options.Prefix)
+			if !ok {
+				return routing.NewHTTPError(http.StatusNotFound)
+			}
+			urlPath = p
+		}
+		path, found := matchPath(urlPath, from, to)
 		if !found || options.Allow != nil && !options.Allow(c, path) {
 			return routing.NewHTTPError(http.StatusNotFound)
 		}
@@ -94,9 +148,9 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 			err   error
 		)
 
-		if file, err = dir.Open(path); err != nil {
+		if file, err = openFile(dir, path); err != nil {
 			if options.CatchAllFile != "" {
-				return serveFile(c, dir, options.CatchAllFile)
+				return serveFile(c, dir, options.CatchAllFile, &options)
 			}
 			return routing.NewHTTPError(http.StatusNotFound, err.Error())
 		}
@@ -110,17 +164,51 @@ func Server(pathMap PathMap, opts ...ServerOptions) routing.Handler {
 			if options.IndexFile == "" {
 				return routing.NewHTTPError(http.StatusNotFound)
 			}
-			return serveFile(c, dir, filepath.Join(path, options.IndexFile))
+			return serveFile(c, dir, filepath.ToSlash(filepath.Join(path, options.IndexFile)), &options)
 		}
 
-		c.Response.Header().Del("Content-Type")
-		http.ServeContent(c.Response, c.Request, path, fstat.ModTime(), file)
+		return sendFile(c, dir, path, file, fstat, &options)
+	}
+}
+
+// allowedMethods is the value of the Allow header sent by the handlers in this package.
+const allowedMethods = "GET, HEAD, OPTIONS"
+
+// allowMethod checks if the files can be served for the method of the current request.
+func allowMethod(c *routing.Context) bool {
+	return c.Request.Method == "GET" || c.Request.Method == "HEAD"
+}
+
+// methodNotAllowed responds to a request whose method is not allowed in the same way as
+// routing.MethodNotAllowedHandler: it sets the Allow header, and returns a 405 HTTP error unless
+// the request is an OPTIONS request.
+func methodNotAllowed(c *routing.Context) error {
+	c.Response.Header().Set("Allow", allowedMethods)
+	if c.Request.Method == "OPTIONS" {
+		c.Abort()
 		return nil
 	}
+	return routing.NewHTTPError(http.StatusMethodNotAllowed)
+}
+
+// trimPrefix removes the given prefix from the URL path. It returns false if the path is not under the prefix.
+func trimPrefix(path, prefix string) (string, bool) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	path = path[len(prefix):]
+	if path == "" {
+		return "/", true
+	}
+	if path[0] != '/' {
+		return "", false
+	}
+	return path, true
 }
 
-func serveFile(c *routing.Context, dir http.Dir, path string) error {
-	file, err := dir.Open(path)
+func serveFile(c *routing.Context, dir http.FileSystem, path string, options *ServerOptions) error {
+	file, err := openFile(dir, path)
 	if err != nil {
 		return routing.NewHTTPError(http.StatusNotFound, err.Error())
 	}
@@ -131,24 +219,178 @@ func serveFile(c *routing.Context, dir http.Dir, path string) error {
 	} else if fstat.IsDir() {
 		return routing.NewHTTPError(http.StatusNotFound)
 	}
+	return sendFile(c, dir, path, file, fstat, options)
+}
+
+// sendFile calls ServerOptions.OnFile for the opened file and then sends the file content (or that of
+// the file substituted by OnFile) to the response.
+func sendFile(c *routing.Context, dir http.FileSystem, path string, file http.File, fstat os.FileInfo, options *ServerOptions) error {
 	c.Response.Header().Del("Content-Type")
-	http.ServeContent(c.Response, c.Request, path, fstat.ModTime(), file)
+	if options.OnFile != nil {
+		p, err := options.OnFile(c, path, fstat)
+		if err != nil {
+			return err
+		}
+		if p != "" && p != path {
+			if file, err = openFile(dir, p); err != nil {
+				return routing.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			defer file.Close()
+			if fstat, err = file.Stat(); err != nil {
+				return routing.NewHTTPError(http.StatusNotFound, err.Error())
+			} else if fstat.IsDir() {
+				return routing.NewHTTPError(http.StatusNotFound)
+			}
+			path = p
+		}
+	}
+	serveContent(c, path, fstat.ModTime(), file, options.WriteTimeout)
 	return nil
 }
 
+// openFile opens the named file in the file system. The name is converted into a slash-separated rooted path
+// as required by http.FileSystem.
+func openFile(fs http.FileSystem, name string) (http.File, error) {
+	name = filepath.ToSlash(name)
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return fs.Open(name)
+}
+
+// serveContent sends the file content to the response.
+// If writeTimeout is positive, the write deadline will be extended by writeTimeout before each write.
+func serveContent(c *routing.Context, name string, modtime time.Time, content io.ReadSeeker, writeTimeout time.Duration) {
+	if writeTimeout <= 0 {
+		http.ServeContent(c.Response, c.Request, name, modtime, content)
+		return
+	}
+	w := &deadlineWriter{c.Response, c, writeTimeout}
+	http.ServeContent(w, c.Request, name, modtime, content)
+	c.SetWriteDeadline(time.Time{})
+}
+
+// deadlineChunkSize is the number of bytes sent by each ReadFrom call of the wrapped writer, which is the same as
+// the size of the writes made by io.Copy, so that WriteTimeout applies to the same amount of data either way.
+const deadlineChunkSize = 32 << 10
+
+// deadlineWriter extends the write deadline of the response before each write.
+type deadlineWriter struct {
+	http.ResponseWriter
+	c       *routing.Context
+	timeout time.Duration
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	w.c.SetWriteDeadline(time.Now().Add(w.timeout))
+	return w.ResponseWriter.Write(p)
+}
+
+// ReadFrom sends the data read from src in chunks, extending the write deadline before each chunk.
+// If the wrapped writer implements io.ReaderFrom, e.g. to send files with sendfile, the chunks are sent
+// by its ReadFrom method.
+func (w *deadlineWriter) ReadFrom(src io.Reader) (n int64, err error) {
+	rf, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(struct{ io.Writer }{w}, src)
+	}
+	// unwrap the limited reader so that the wrapped writer sees the original reader (e.g. *os.File)
+	limit := int64(-1)
+	if lr, ok := src.(*io.LimitedReader); ok {
+		src, limit = lr.R, lr.N
+		defer func() { lr.N -= n }()
+	}
+	for limit != 0 {
+		size := int64(deadlineChunkSize)
+		if limit > 0 && limit < size {
+			size = limit
+		}
+		w.c.SetWriteDeadline(time.Now().Add(w.timeout))
+		m, err := rf.ReadFrom(io.LimitReader(src, size))
+		n += m
+		if limit > 0 {
+			limit -= m
+		}
+		if err != nil || m < size {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Flush extends the write deadline and then sends the buffered data to the client if the wrapped writer
+// supports flushing.
+func (w *deadlineWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.c.SetWriteDeadline(time.Now().Add(w.timeout))
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ContentOptions defines the possible options for the Content handler.
+type ContentOptions struct {
+	// The content type of the file. If not set, the content type is determined by the file extension,
+	// or by the file content if the extension is unknown.
+	ContentType string
+	// The Content-Disposition type, i.e., "attachment" to prompt the client to download the file, or "inline".
+	// If not set, the Content-Disposition header is not sent.
+	Disposition string
+	// The file name sent in the Content-Disposition header. Defaults to the base name of the file path.
+	Filename string
+	// Whether to skip sending the ETag header, which is computed from the size and the modification time of the file.
+	NoETag bool
+	// The file system that the file is read from. If set, the file path is a slash-separated path within this
+	// file system. If not set, the file is read from the OS file system.
+	Filesystem http.FileSystem
+}
+
 // Content returns a handler that serves the content of the specified file as the response.
 // The file to be served can be specified as an absolute file path or a path relative to RootPath (which
 // defaults to the current working path).
 // If the specified file does not exist, the handler will pass the control to the next available handler.
-func Content(path string) routing.Handler {
-	if !filepath.IsAbs(path) {
+//
+// The handler sends an ETag header computed from the size and the modification time of the file, unless
+// ContentOptions.NoETag is true or the header is already set, and it supports the conditional requests
+// (If-None-Match, If-Modified-Since, etc.) and the range requests. The content type and the Content-Disposition
+// header can be set via ContentOptions. The requests of the methods other than GET and HEAD are answered
+// in the same way as Server does. For example,
+//
+//     r.Get("/favicon.ico", file.Content("ui/favicon.ico"))
+//     r.Get("/report", file.Content("data/report.csv", file.ContentOptions{
+//         ContentType: "text/csv; charset=utf-8",
+//         Disposition: "attachment",
+//         Filename:    "report.csv",
+//     }))
+func Content(path string, opts ...ContentOptions) routing.Handler {
+	var options ContentOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Filesystem == nil && !filepath.IsAbs(path) {
 		path = filepath.Join(RootPath, path)
 	}
+	filename := options.Filename
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
 	return func(c *routing.Context) error {
-		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
-			return routing.NewHTTPError(http.StatusMethodNotAllowed)
+		if !allowMethod(c) {
+			return methodNotAllowed(c)
+		}
+		var (
+			file http.File
+			err  error
+		)
+		if options.Filesystem != nil {
+			file, err = openFile(options.Filesystem, path)
+		} else {
+			file, err = os.Open(path)
 		}
-		file, err := os.Open(path)
 		if err != nil {
 			return routing.NewHTTPError(http.StatusNotFound, err.Error())
 		}
@@ -159,12 +401,49 @@ func Content(path string) routing.Handler {
 		} else if fstat.IsDir() {
 			return routing.NewHTTPError(http.StatusNotFound)
 		}
-		c.Response.Header().Del("Content-Type")
+		header := c.Response.Header()
+		if options.ContentType != "" {
+			header.Set("Content-Type", options.ContentType)
+		} else {
+			header.Del("Content-Type")
+		}
+		if !options.NoETag && header.Get("ETag") == "" {
+			header.Set("ETag", fileETag(fstat))
+		}
+		if options.Disposition != "" {
+			header.Set("Content-Disposition", routing.ContentDisposition(options.Disposition, filename))
+		}
 		http.ServeContent(c.Response, c.Request, path, fstat.ModTime(), file)
 		return nil
 	}
 }
 
+// fileETag returns a strong ETag derived from the size and the modification time of a file.
+func fileETag(fstat os.FileInfo) string {
+	return `"` + strconv.FormatInt(fstat.ModTime().UnixNano(), 16) + "-" + strconv.FormatInt(fstat.Size(), 16) + `"`
+}
+
+// ErrorPage returns an error page for fault.ErrorPages that sends the content of the specified file, such as
+// a static "404.html" page. The file can be specified as an absolute file path or a path relative to RootPath.
+// The content type is determined by the file extension, and defaults to HTML. The file is read whenever
+// the page is sent, so it can be updated without restarting the application.
+func ErrorPage(path string) fault.ErrorPage {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(RootPath, path)
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "text/html; charset=UTF-8"
+	}
+	return func(c *routing.Context, data fault.PageData) error {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return fault.WritePage(c, data.Status, contentType, body)
+	}
+}
+
 func parsePathMap(pathMap PathMap) (from, to []string) {
 	from = make([]string, len(pathMap))
 	to = make([]string, len(pathMap))
@@ -5,12 +5,16 @@
 package file
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/fault"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,6 +108,52 @@ func TestContent(t *testing.T) {
 	}
 }
 
+func TestContentOptions(t *testing.T) {
+	h := Content("testdata/index.html")
+	req, _ := http.NewRequest("GET", "/index.html", nil)
+	res := httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	etag := res.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]+-6"$`, etag)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "", res.Header().Get("Content-Disposition"))
+
+	req, _ = http.NewRequest("GET", "/index.html", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Equal(t, "", res.Body.String())
+
+	req, _ = http.NewRequest("GET", "/index.html", nil)
+	req.Header.Set("If-None-Match", `"other"`)
+	res = httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "hello\n", res.Body.String())
+
+	h = Content("testdata/index.html", ContentOptions{
+		ContentType: "text/plain",
+		Disposition: "attachment",
+		Filename:    "hello.txt",
+		NoETag:      true,
+	})
+	req, _ = http.NewRequest("GET", "/index.html", nil)
+	res = httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, "text/plain", res.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="hello.txt"`, res.Header().Get("Content-Disposition"))
+	assert.Equal(t, "", res.Header().Get("ETag"))
+
+	h = Content("testdata/index.html", ContentOptions{Disposition: "inline"})
+	req, _ = http.NewRequest("GET", "/index.html", nil)
+	res = httptest.NewRecorder()
+	res.Header().Set("ETag", `"custom"`)
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, `inline; filename="index.html"`, res.Header().Get("Content-Disposition"))
+	assert.Equal(t, `"custom"`, res.Header().Get("ETag"))
+}
+
 func TestServer(t *testing.T) {
 	h := Server(PathMap{"/css": "/testdata/css"})
 	tests := []struct {
@@ -185,3 +235,271 @@ func TestServer(t *testing.T) {
 		assert.Equal(t, "hello\n", res.Body.String())
 	}
 }
+
+func TestServerPrefix(t *testing.T) {
+	h := Server(PathMap{"/": "/testdata/css/"}, ServerOptions{Prefix: "/static/", IndexFile: "index.html"})
+	tests := []struct {
+		id     string
+		url    string
+		status int
+		body   string
+	}{
+		{"t1", "/static/main.css", 0, "body {}\n"},
+		{"t2", "/static", 0, "css.html\n"},
+		{"t3", "/staticx/main.css", http.StatusNotFound, ""},
+		{"t4", "/css/main.css", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.url, nil)
+		res := httptest.NewRecorder()
+		err := h(routing.NewContext(res, req))
+		if test.status == 0 {
+			assert.Nil(t, err, test.id)
+			assert.Equal(t, test.body, res.Body.String(), test.id)
+		} else if assert.NotNil(t, err, test.id) {
+			assert.Equal(t, test.status, err.(routing.HTTPError).StatusCode(), test.id)
+		}
+	}
+}
+
+func TestServerMethods(t *testing.T) {
+	router := routing.New()
+	router.Get("/css/*", Server(PathMap{"/css": "/testdata/css"}))
+	router.Get("/index", Content("testdata/index.html"))
+	router.To("POST,OPTIONS", "/static/*", Server(PathMap{"/static": "/testdata/css"}))
+
+	tests := []struct {
+		id          string
+		method, url string
+		status      int
+		allow       string
+	}{
+		// answered by the router
+		{"t1", "POST", "/css/main.css", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		{"t2", "OPTIONS", "/css/main.css", http.StatusOK, "GET, OPTIONS"},
+		{"t3", "POST", "/index", http.StatusMethodNotAllowed, "GET, OPTIONS"},
+		// answered by the handlers in the same way
+		{"t4", "POST", "/static/main.css", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS"},
+		{"t5", "OPTIONS", "/static/main.css", http.StatusOK, "GET, HEAD, OPTIONS"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.id)
+		assert.Equal(t, test.allow, res.Header().Get("Allow"), test.id)
+	}
+}
+
+func TestServerFallback(t *testing.T) {
+	router := routing.New()
+	router.Get("/app/*", Server(PathMap{"/app": "/testdata"})).
+		Fallback(Content("testdata/index.html"))
+	router.Get("/assets/*", Server(PathMap{"/assets": "/testdata"}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/app/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "hello\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/assets/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestServerOnFile(t *testing.T) {
+	var infos []string
+	h := Server(PathMap{"/css": "/testdata/css"}, ServerOptions{
+		IndexFile: "index.html",
+		OnFile: func(c *routing.Context, path string, info os.FileInfo) (string, error) {
+			infos = append(infos, path+":"+info.Name())
+			switch path {
+			case "/testdata/css/main.css":
+				c.Response.Header().Set("Content-Disposition", "attachment")
+				c.Response.Header().Set("Content-Type", "text/plain")
+			case "/testdata/css/index.html":
+				if c.Query("lang") == "fr" {
+					return "/testdata/index.html", nil
+				}
+			}
+			if c.Query("deny") != "" {
+				return "", routing.NewHTTPError(http.StatusForbidden)
+			}
+			if c.Query("missing") != "" {
+				return "/testdata/missing.html", nil
+			}
+			return "", nil
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/css/main.css", nil)
+	res := httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, "body {}\n", res.Body.String())
+	assert.Equal(t, "attachment", res.Header().Get("Content-Disposition"))
+	assert.Equal(t, "text/plain", res.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"/testdata/css/main.css:main.css"}, infos)
+
+	req, _ = http.NewRequest("GET", "/css?lang=fr", nil)
+	res = httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, "hello\n", res.Body.String())
+	assert.Equal(t, "/testdata/css/index.html:index.html", infos[1])
+
+	req, _ = http.NewRequest("GET", "/css/main.css?deny=1", nil)
+	res = httptest.NewRecorder()
+	err := h(routing.NewContext(res, req))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusForbidden, err.(routing.HTTPError).StatusCode())
+	}
+
+	req, _ = http.NewRequest("GET", "/css/main.css?missing=1", nil)
+	res = httptest.NewRecorder()
+	err = h(routing.NewContext(res, req))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(routing.HTTPError).StatusCode())
+	}
+}
+
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadlines []time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	r.deadlines = append(r.deadlines, deadline)
+	return nil
+}
+
+func TestServerWriteTimeout(t *testing.T) {
+	h := Server(PathMap{"/css": "/testdata/css"}, ServerOptions{WriteTimeout: time.Minute})
+	req, _ := http.NewRequest("GET", "/css/main.css", nil)
+	res := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	c := routing.NewContext(res, req)
+	assert.Nil(t, h(c))
+	assert.Equal(t, "body {}\n", res.Body.String())
+	if assert.Equal(t, 2, len(res.deadlines)) {
+		assert.True(t, res.deadlines[0].After(time.Now()))
+		assert.True(t, res.deadlines[1].IsZero())
+	}
+}
+
+type readerFromRecorder struct {
+	*deadlineRecorder
+	reads []int64
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(r.ResponseRecorder, src)
+	r.reads = append(r.reads, n)
+	return n, err
+}
+
+func TestDeadlineWriterReadFrom(t *testing.T) {
+	res := &readerFromRecorder{deadlineRecorder: &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}}
+	w := &deadlineWriter{res, routing.NewContext(res, nil), time.Minute}
+	assert.Equal(t, res, w.Unwrap())
+
+	data := strings.Repeat("a", deadlineChunkSize*2+10)
+	src := io.LimitReader(strings.NewReader(data), deadlineChunkSize+10)
+	n, err := io.Copy(w, src)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(deadlineChunkSize+10), n)
+	assert.Equal(t, []int64{deadlineChunkSize, 10}, res.reads)
+	assert.Equal(t, 2, len(res.deadlines))
+	assert.Equal(t, int64(0), src.(*io.LimitedReader).N)
+
+	n, err = w.ReadFrom(strings.NewReader("abc"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, deadlineChunkSize+13, res.Body.Len())
+
+	w.Flush()
+	assert.True(t, res.Flushed)
+	assert.Equal(t, 4, len(res.deadlines))
+}
+
+func TestErrorPage(t *testing.T) {
+	page := ErrorPage("testdata/index.html")
+	req, _ := http.NewRequest("GET", "/missing", nil)
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req)
+	assert.Nil(t, page(c, fault.PageData{Status: http.StatusNotFound}))
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, "hello\n", res.Body.String())
+
+	page = ErrorPage("testdata/404.html")
+	res = httptest.NewRecorder()
+	c = routing.NewContext(res, req)
+	assert.NotNil(t, page(c, fault.PageData{Status: http.StatusNotFound}))
+	assert.Equal(t, "", res.Body.String())
+}
+
+// recordingFS is an http.FileSystem other than http.Dir that records the names of the opened files.
+type recordingFS struct {
+	fs    http.FileSystem
+	names []string
+}
+
+func (f *recordingFS) Open(name string) (http.File, error) {
+	f.names = append(f.names, name)
+	return f.fs.Open(name)
+}
+
+func TestServerFilesystem(t *testing.T) {
+	fs := &recordingFS{fs: http.Dir("testdata")}
+	h := Server(PathMap{"/css": "css", "/": ""}, ServerOptions{
+		Filesystem:   fs,
+		RootPath:     "unused",
+		IndexFile:    "index.html",
+		CatchAllFile: "index.html",
+		Allow: func(c *routing.Context, path string) bool {
+			return path != "css/index.html"
+		},
+	})
+
+	tests := []struct {
+		id, url, body, name string
+		status              int
+	}{
+		{"t1", "/css/main.css", "body {}\n", "/css/main.css", 0},
+		{"t2", "/css", "css.html\n", "/css/index.html", 0},
+		{"t3", "/", "hello\n", "/index.html", 0},
+		{"t4", "/css/main2.css", "hello\n", "/index.html", 0},
+		{"t5", "/css/index.html", "", "", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		fs.names = nil
+		req, _ := http.NewRequest("GET", test.url, nil)
+		res := httptest.NewRecorder()
+		c := routing.NewContext(res, req)
+		err := h(c)
+		if test.status == 0 {
+			assert.Nil(t, err, test.id)
+			assert.Equal(t, test.body, res.Body.String(), test.id)
+			if assert.NotEmpty(t, fs.names, test.id) {
+				assert.Equal(t, test.name, fs.names[len(fs.names)-1], test.id)
+			}
+		} else if assert.NotNil(t, err, test.id) {
+			assert.Equal(t, test.status, err.(routing.HTTPError).StatusCode(), test.id)
+			assert.Empty(t, fs.names, test.id)
+		}
+	}
+
+	h = Content("css/main.css", ContentOptions{Filesystem: fs})
+	req, _ := http.NewRequest("GET", "/main.css", nil)
+	res := httptest.NewRecorder()
+	assert.Nil(t, h(routing.NewContext(res, req)))
+	assert.Equal(t, "body {}\n", res.Body.String())
+	assert.Equal(t, "text/css; charset=utf-8", res.Header().Get("Content-Type"))
+
+	h = Content("missing.css", ContentOptions{Filesystem: fs})
+	res = httptest.NewRecorder()
+	err := h(routing.NewContext(res, req))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(routing.HTTPError).StatusCode())
+	}
+}
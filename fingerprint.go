@@ -0,0 +1,120 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RequestFingerprint is the key used to store and retrieve the request fingerprint computed by FingerprintHandler in Context.
+const RequestFingerprint = "Fingerprint"
+
+// FingerprintOptions specifies how FingerprintHandler derives the request fingerprint.
+type FingerprintOptions struct {
+	// The request headers contributing to the fingerprint. Defaults to DefaultFingerprintHeaders.
+	Headers []string
+	// The number of leading bits of an IPv4 client address contributing to the fingerprint. Defaults to 24.
+	// A negative value excludes IPv4 addresses from the fingerprint.
+	IPv4Prefix int
+	// The number of leading bits of an IPv6 client address contributing to the fingerprint. Defaults to 64.
+	// A negative value excludes IPv6 addresses from the fingerprint.
+	IPv6Prefix int
+	// ClientIP returns the client IP address of a request. Defaults to the host part of http.Request.RemoteAddr.
+	ClientIP func(*http.Request) string
+	// TLSFingerprint returns the TLS client fingerprint (e.g. JA3) of a request, if available.
+	// The net/http package does not expose the TLS client hello, so this must be provided by the server setup.
+	TLSFingerprint func(*http.Request) string
+	// Hash hashes the components of the fingerprint. Defaults to the hex-encoded SHA-256 of the components.
+	Hash func(components []string) string
+}
+
+// DefaultFingerprintHeaders lists the request headers used by FingerprintHandler by default.
+var DefaultFingerprintHeaders = []string{"User-Agent", "Accept", "Accept-Language", "Accept-Encoding"}
+
+// FingerprintHandler returns a handler that derives a stable fingerprint of the client sending the request,
+// which can be used by fraud detection or rate limiting policies. The fingerprint is a hash of the selected
+// request headers, the TLS client fingerprint (if available), and the network the client IP address belongs to.
+// It can be retrieved by the following handlers via Context.Fingerprint. For example,
+//
+//     r := routing.New()
+//     r.Use(routing.FingerprintHandler(routing.FingerprintOptions{}))
+func FingerprintHandler(opts FingerprintOptions) Handler {
+	if opts.Headers == nil {
+		opts.Headers = DefaultFingerprintHeaders
+	}
+	if opts.IPv4Prefix == 0 {
+		opts.IPv4Prefix = 24
+	}
+	if opts.IPv6Prefix == 0 {
+		opts.IPv6Prefix = 64
+	}
+	if opts.ClientIP == nil {
+		opts.ClientIP = remoteIP
+	}
+	if opts.Hash == nil {
+		opts.Hash = hashFingerprint
+	}
+	return func(c *Context) error {
+		req := c.Request
+		components := make([]string, 0, len(opts.Headers)+2)
+		for _, name := range opts.Headers {
+			components = append(components, strings.Join(req.Header[http.CanonicalHeaderKey(name)], ","))
+		}
+		if opts.TLSFingerprint != nil {
+			components = append(components, opts.TLSFingerprint(req))
+		}
+		components = append(components, ipNetwork(opts.ClientIP(req), opts.IPv4Prefix, opts.IPv6Prefix))
+		c.Set(RequestFingerprint, opts.Hash(components))
+		return nil
+	}
+}
+
+// Fingerprint returns the fingerprint of the client sending the current request as computed by FingerprintHandler.
+// An empty string is returned if the fingerprint is not computed.
+func (c *Context) Fingerprint() string {
+	fingerprint, _ := c.Get(RequestFingerprint).(string)
+	return fingerprint
+}
+
+// remoteIP returns the host part of the remote address of the given request.
+func remoteIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// ipNetwork masks the given IP address with the prefix length according to its version.
+// If the IP address is invalid or excluded, an empty string is returned.
+func ipNetwork(s string, ipv4Prefix, ipv6Prefix int) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		if ipv4Prefix < 0 {
+			return ""
+		}
+		return ip4.Mask(net.CIDRMask(ipv4Prefix, 32)).String()
+	}
+	if ipv6Prefix < 0 {
+		return ""
+	}
+	return ip.Mask(net.CIDRMask(ipv6Prefix, 128)).String()
+}
+
+// hashFingerprint returns the hex-encoded SHA-256 hash of the given components.
+func hashFingerprint(components []string) string {
+	h := sha256.New()
+	for _, component := range components {
+		h.Write([]byte(component))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
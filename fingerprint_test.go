@@ -0,0 +1,58 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintHandler(t *testing.T) {
+	fingerprint := func(h Handler, remoteAddr, userAgent string) string {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("User-Agent", userAgent)
+		c := NewContext(nil, req)
+		assert.Equal(t, "", c.Fingerprint())
+		assert.Nil(t, h(c))
+		return c.Fingerprint()
+	}
+
+	h := FingerprintHandler(FingerprintOptions{})
+	f1 := fingerprint(h, "192.168.1.10:1234", "curl")
+	assert.Len(t, f1, 64)
+	assert.Equal(t, f1, fingerprint(h, "192.168.1.20:5678", "curl"))
+	assert.NotEqual(t, f1, fingerprint(h, "192.168.2.10:1234", "curl"))
+	assert.NotEqual(t, f1, fingerprint(h, "192.168.1.10:1234", "firefox"))
+	f2 := fingerprint(h, "[2001:db8::1]:1234", "curl")
+	assert.Equal(t, f2, fingerprint(h, "[2001:db8::2]:1234", "curl"))
+	assert.NotEqual(t, f1, f2)
+
+	var components []string
+	h = FingerprintHandler(FingerprintOptions{
+		Headers:        []string{"user-agent"},
+		IPv4Prefix:     -1,
+		TLSFingerprint: func(*http.Request) string { return "ja3" },
+		Hash: func(c []string) string {
+			components = c
+			return strings.Join(c, "|")
+		},
+	})
+	assert.Equal(t, "curl|ja3|", fingerprint(h, "192.168.1.10:1234", "curl"))
+	assert.Equal(t, []string{"curl", "ja3", ""}, components)
+	assert.Equal(t, "curl|ja3|2001:db8::", fingerprint(h, "[2001:db8::1]:1234", "curl"))
+}
+
+func TestIPNetwork(t *testing.T) {
+	assert.Equal(t, "10.1.2.0", ipNetwork("10.1.2.3", 24, 64))
+	assert.Equal(t, "10.1.2.3", ipNetwork("10.1.2.3", 32, 64))
+	assert.Equal(t, "", ipNetwork("10.1.2.3", -1, 64))
+	assert.Equal(t, "2001:db8:1:2::", ipNetwork("2001:db8:1:2:3:4:5:6", 24, 64))
+	assert.Equal(t, "", ipNetwork("2001:db8::1", 24, -1))
+	assert.Equal(t, "", ipNetwork("unknown", 24, 64))
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir returns a recorder that writes the fixtures as indented JSON files under the given directory, which is
+// created if it does not exist. The files are named after the routes and numbered in sequence, e.g.
+// "GET_users_id_1.json" for the route "GET /users/<id>", or after the request methods and paths if no route
+// matches the requests. The existing files are never overwritten.
+func Dir(path string) Recorder {
+	return func(f *Fixture) error {
+		data, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+		name := fileName(f)
+		for i := 1; ; i++ {
+			file, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%v_%v.json", name, i)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if os.IsExist(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			_, err = file.Write(append(data, '\n'))
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			return err
+		}
+	}
+}
+
+// fileName returns the base file name of a fixture, containing only letters, digits, and underscores.
+func fileName(f *Fixture) string {
+	name := f.Route
+	if name == "" {
+		name = f.Request.Method + " " + strings.SplitN(f.Request.URL, "?", 2)[0]
+	}
+	var b strings.Builder
+	underscore := false
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
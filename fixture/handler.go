@@ -0,0 +1,234 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package fixture provides a handler that records requests and responses as fixtures for the ozzo routing package.
+package fixture
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+type (
+	// Fixture is a recorded pair of a request and the response to it.
+	Fixture struct {
+		// Route is the method and the path template of the route matching the request, e.g. "GET /users/<id>".
+		// It is empty if no route matches the request.
+		Route    string    `json:"route,omitempty"`
+		Time     time.Time `json:"time"`
+		Request  Request   `json:"request"`
+		Response Response  `json:"response"`
+		// Error is the error returned by the handlers, which is handled after the response is recorded.
+		Error string `json:"error,omitempty"`
+	}
+
+	// Request is a recorded request.
+	Request struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header,omitempty"`
+		Body   Body        `json:"body,omitempty"`
+	}
+
+	// Response is a recorded response.
+	Response struct {
+		Status int         `json:"status"`
+		Header http.Header `json:"header,omitempty"`
+		Body   Body        `json:"body,omitempty"`
+	}
+
+	// Body is a recorded request or response body.
+	Body struct {
+		// Data is the body if it is valid UTF-8 text, or the base64 encoding of the body otherwise.
+		Data string `json:"data,omitempty"`
+		// Base64 indicates whether Data is base64-encoded.
+		Base64 bool `json:"base64,omitempty"`
+		// Truncated indicates whether the body is not fully recorded because it exceeds Options.MaxBodySize
+		// or is flushed. Data then holds the beginning of a request body, or nothing of a response body.
+		Truncated bool `json:"truncated,omitempty"`
+	}
+
+	// Recorder stores a fixture, e.g. as a file. Recorder should be thread safe.
+	Recorder func(f *Fixture) error
+
+	// Redactor removes the sensitive data, such as credentials, from a fixture before it is recorded.
+	Redactor func(f *Fixture)
+
+	// LogFunc logs a message using the given format and optional arguments.
+	LogFunc func(format string, a ...interface{})
+
+	// Options specifies how fixtures are recorded.
+	Options struct {
+		// Enabled reports whether the current request should be recorded. If nil, all requests are recorded.
+		Enabled func(c *routing.Context) bool
+		// Redactors sanitize the fixtures before they are recorded. If nil, DefaultRedactors is used.
+		Redactors []Redactor
+		// MaxBodySize is the maximum number of bytes of a body to be recorded. If not positive, DefaultMaxBodySize is used.
+		MaxBodySize int64
+		// LogFunc logs the errors of recording fixtures. If nil, the errors are ignored.
+		LogFunc LogFunc
+	}
+)
+
+// DefaultMaxBodySize is the default maximum number of bytes of a request or response body to be recorded.
+const DefaultMaxBodySize = 1 << 20
+
+// DefaultRedactors are the redactors used when Options.Redactors is nil. They redact the credentials
+// carried by the common HTTP headers.
+var DefaultRedactors = []Redactor{
+	RedactHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"),
+}
+
+// Handler returns a handler that records the requests and the responses as fixtures, e.g. for contract tests
+// or for replaying the traffic against another version of the application. The fixtures are sanitized by
+// the redactors in Options and then passed to the given recorder, such as the one returned by Dir.
+// For example, the following code records the API traffic as JSON files when the RECORD_FIXTURES
+// environment variable is set:
+//
+//     import (
+//         "log"
+//         "os"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/fault"
+//         "github.com/go-ozzo/ozzo-routing/v2/fixture"
+//     )
+//
+//     r := routing.New()
+//     api := r.Group("/api")
+//     if os.Getenv("RECORD_FIXTURES") != "" {
+//         api.Use(fixture.Handler(fixture.Dir("testdata/fixtures"), fixture.Options{LogFunc: log.Printf}))
+//     }
+//     api.Use(fault.Recovery(log.Printf))
+//
+// The responses are buffered by routing.BufferedResponseWriter until the handlers finish. If a response body
+// exceeds Options.MaxBodySize or is flushed, it is sent without being buffered and is not recorded. The errors
+// returned by the handlers are handled after the responses are recorded, so an error handler, such as
+// fault.Recovery, should be placed after this handler if the error responses should be recorded.
+//
+// The requests matching the routes marked with the routing.Sensitive metadata are not recorded if their
+// Sensitivity.Skip is true. Otherwise, their query parameters, headers, and bodies are redacted as specified
+// by the Sensitivity before the redactors in Options are applied.
+func Handler(record Recorder, opts ...Options) routing.Handler {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Redactors == nil {
+		options.Redactors = DefaultRedactors
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = DefaultMaxBodySize
+	}
+	return func(c *routing.Context) error {
+		if options.Enabled != nil && !options.Enabled(c) {
+			return nil
+		}
+		sensitivity := c.Sensitivity()
+		if sensitivity != nil && sensitivity.Skip {
+			return nil
+		}
+
+		req := c.Request
+		redacted := sensitivity.Redact(req)
+		f := &Fixture{
+			Time: time.Now(),
+			Request: Request{
+				Method: redacted.Method,
+				URL:    redacted.URL.String(),
+				Header: redacted.Header.Clone(),
+			},
+		}
+		if route := c.Route(); route != nil {
+			f.Route = route.Method() + " " + route.Path()
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := captureRequestBody(req, options.MaxBodySize)
+			if err != nil {
+				return err
+			}
+			f.Request.Body = body
+		}
+
+		rw := routing.NewBufferedResponseWriter(c.Response, options.MaxBodySize)
+		c.Response = rw
+		err := c.Next()
+
+		f.Response.Status = rw.Status
+		if f.Response.Status == 0 {
+			f.Response.Status = http.StatusOK
+		}
+		f.Response.Header = rw.Header().Clone()
+		f.Response.Body = newBody(rw.Body(), rw.Committed())
+		if err != nil {
+			f.Error = err.Error()
+		}
+		if cerr := rw.Commit(); cerr != nil && err == nil {
+			err = cerr
+		}
+
+		if sensitivity != nil && sensitivity.RedactBody {
+			redactBody(&f.Request.Body)
+			redactBody(&f.Response.Body)
+		}
+		for _, redact := range options.Redactors {
+			redact(f)
+		}
+		if rerr := record(f); rerr != nil && options.LogFunc != nil {
+			options.LogFunc("fixture: failed to record %v %v: %v", f.Request.Method, f.Request.URL, rerr)
+		}
+		return err
+	}
+}
+
+// Bytes returns the recorded body data, decoding it if it is base64-encoded.
+func (b Body) Bytes() []byte {
+	if !b.Base64 {
+		return []byte(b.Data)
+	}
+	data, _ := base64.StdEncoding.DecodeString(b.Data)
+	return data
+}
+
+// redactBody replaces the recorded body with Redacted.
+func redactBody(body *Body) {
+	if body.Data != "" {
+		*body = Body{Data: Redacted, Truncated: body.Truncated}
+	}
+}
+
+// newBody creates a Body holding the given data.
+func newBody(data []byte, truncated bool) Body {
+	if utf8.Valid(data) {
+		return Body{Data: string(data), Truncated: truncated}
+	}
+	return Body{Data: base64.StdEncoding.EncodeToString(data), Base64: true, Truncated: truncated}
+}
+
+// captureRequestBody reads at most maxSize bytes of the request body and restores the body so that
+// it can still be read by the following handlers.
+func captureRequestBody(req *http.Request, maxSize int64) (Body, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(req.Body, maxSize+1))
+	if err != nil {
+		return Body{}, err
+	}
+	body := req.Body
+	req.Body = readCloser{io.MultiReader(bytes.NewReader(data), body), body}
+	if int64(len(data)) > maxSize {
+		return newBody(data[:maxSize], true), nil
+	}
+	return newBody(data, false), nil
+}
+
+// readCloser reads the captured part of the request body followed by the rest of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,246 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var fixtures []*Fixture
+	record := func(f *Fixture) error {
+		fixtures = append(fixtures, f)
+		return nil
+	}
+	router := routing.New()
+	router.Use(Handler(record), fault.ErrorHandler(nil))
+	router.Post("/users/<id>", func(c *routing.Context) error {
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		c.Response.Header().Set("Set-Cookie", "sid=abc")
+		c.Response.WriteHeader(http.StatusCreated)
+		return c.Write("got " + string(body))
+	})
+	router.Get("/binary", func(c *routing.Context) error {
+		return c.Write([]byte{0xff, 0xfe})
+	})
+	router.Get("/error", func(c *routing.Context) error {
+		return routing.NewHTTPError(http.StatusBadRequest, "invalid")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users/1?x=y", strings.NewReader("abc"))
+	req.Header.Set("Authorization", "Bearer token")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "got abc", res.Body.String())
+	if assert.Len(t, fixtures, 1) {
+		f := fixtures[0]
+		assert.Equal(t, "POST /users/<id>", f.Route)
+		assert.False(t, f.Time.IsZero())
+		assert.Equal(t, "POST", f.Request.Method)
+		assert.Equal(t, "/users/1?x=y", f.Request.URL)
+		assert.Equal(t, Redacted, f.Request.Header.Get("Authorization"))
+		assert.Equal(t, Body{Data: "abc"}, f.Request.Body)
+		assert.Equal(t, http.StatusCreated, f.Response.Status)
+		assert.Equal(t, Redacted, f.Response.Header.Get("Set-Cookie"))
+		assert.Equal(t, Body{Data: "got abc"}, f.Response.Body)
+		assert.Equal(t, "", f.Error)
+	}
+	// the response headers are not redacted
+	assert.Equal(t, "sid=abc", res.Header().Get("Set-Cookie"))
+
+	fixtures = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/binary", nil)
+	router.ServeHTTP(res, req)
+	if assert.Len(t, fixtures, 1) {
+		assert.Equal(t, Body{Data: "//4=", Base64: true}, fixtures[0].Response.Body)
+		assert.Equal(t, []byte{0xff, 0xfe}, fixtures[0].Response.Body.Bytes())
+		assert.Equal(t, http.StatusOK, fixtures[0].Response.Status)
+	}
+
+	fixtures = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	router.ServeHTTP(res, req)
+	if assert.Len(t, fixtures, 1) {
+		assert.Equal(t, http.StatusBadRequest, fixtures[0].Response.Status)
+		assert.Equal(t, "invalid", fixtures[0].Response.Body.Data)
+	}
+
+	fixtures = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/missing", nil)
+	router.ServeHTTP(res, req)
+	if assert.Len(t, fixtures, 1) {
+		assert.Equal(t, "", fixtures[0].Route)
+		assert.Equal(t, http.StatusNotFound, fixtures[0].Response.Status)
+	}
+}
+
+func TestHandlerOptions(t *testing.T) {
+	var fixtures []*Fixture
+	var logs bytes.Buffer
+	record := func(f *Fixture) error {
+		fixtures = append(fixtures, f)
+		return errors.New("disk full")
+	}
+	h := Handler(record, Options{
+		Enabled: func(c *routing.Context) bool {
+			return c.Request.Method == "POST"
+		},
+		Redactors:   []Redactor{},
+		MaxBodySize: 3,
+		LogFunc: func(format string, a ...interface{}) {
+			fmt.Fprintf(&logs, format, a...)
+		},
+	})
+	var body []byte
+	handler := func(c *routing.Context) error {
+		if c.Request.Body != nil {
+			body, _ = ioutil.ReadAll(c.Request.Body)
+		}
+		c.Write("12345")
+		return errors.New("abc")
+	}
+
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader("abcdef"))
+	req.Header.Set("Authorization", "Bearer token")
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req, h, handler)
+	err := c.Next()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "abc", err.Error())
+	}
+	assert.Equal(t, "abcdef", string(body))
+	assert.Equal(t, "12345", res.Body.String())
+	if assert.Len(t, fixtures, 1) {
+		f := fixtures[0]
+		assert.Equal(t, "Bearer token", f.Request.Header.Get("Authorization"))
+		assert.Equal(t, Body{Data: "abc", Truncated: true}, f.Request.Body)
+		assert.Equal(t, Body{Truncated: true}, f.Response.Body)
+		assert.Equal(t, "abc", f.Error)
+	}
+	assert.Equal(t, "fixture: failed to record POST /users: disk full", logs.String())
+
+	fixtures = nil
+	req, _ = http.NewRequest("GET", "/users", nil)
+	c = routing.NewContext(httptest.NewRecorder(), req, h, handler)
+	c.Next()
+	assert.Nil(t, fixtures)
+}
+
+func TestHandlerSensitive(t *testing.T) {
+	var fixtures []*Fixture
+	record := func(f *Fixture) error {
+		fixtures = append(fixtures, f)
+		return nil
+	}
+	router := routing.New()
+	router.Use(Handler(record))
+	handler := func(c *routing.Context) error {
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		return c.Write("got " + string(body))
+	}
+	router.Post("/login", handler).Set(routing.Sensitive, routing.Sensitivity{
+		RedactQuery:   true,
+		RedactHeaders: []string{"X-Otp"},
+		RedactBody:    true,
+	})
+	router.Post("/token", handler).Set(routing.Sensitive, routing.Sensitivity{Skip: true})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/login?user=a", strings.NewReader("secret"))
+	req.Header.Set("X-Otp", "123")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "got secret", res.Body.String())
+	assert.Equal(t, "123", req.Header.Get("X-Otp"))
+	if assert.Len(t, fixtures, 1) {
+		f := fixtures[0]
+		assert.Equal(t, "/login?user=REDACTED", f.Request.URL)
+		assert.Equal(t, Redacted, f.Request.Header.Get("X-Otp"))
+		assert.Equal(t, Body{Data: Redacted}, f.Request.Body)
+		assert.Equal(t, Body{Data: Redacted}, f.Response.Body)
+	}
+
+	fixtures = nil
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/token", strings.NewReader("code"))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "got code", res.Body.String())
+	assert.Nil(t, fixtures)
+}
+
+func TestRedactors(t *testing.T) {
+	f := &Fixture{
+		Request: Request{
+			URL:    "/users?token=abc&page=1",
+			Header: http.Header{"Content-Type": {"application/json"}, "X-Api-Key": {"k1", "k2"}},
+			Body:   Body{Data: `{"name":"a","Password":"x","items":[{"secret":1}]}`},
+		},
+		Response: Response{
+			Header: http.Header{"Content-Type": {"application/problem+json"}},
+			Body:   Body{Data: `{"secret":"y"}`},
+		},
+	}
+	RedactHeaders("x-api-key")(f)
+	RedactQuery("token", "missing")(f)
+	RedactJSONFields("password", "secret")(f)
+	assert.Equal(t, []string{Redacted, Redacted}, f.Request.Header["X-Api-Key"])
+	assert.Equal(t, "/users?page=1&token=REDACTED", f.Request.URL)
+	assert.Equal(t, `{"Password":"REDACTED","items":[{"secret":"REDACTED"}],"name":"a"}`, f.Request.Body.Data)
+	assert.Equal(t, `{"secret":"REDACTED"}`, f.Response.Body.Data)
+
+	// non-JSON and truncated bodies are not changed
+	f = &Fixture{
+		Request:  Request{Header: http.Header{}, Body: Body{Data: `{"secret":"y"}`}},
+		Response: Response{Header: http.Header{"Content-Type": {"application/json"}}, Body: Body{Data: `{"secret":"y"`, Truncated: true}},
+	}
+	RedactJSONFields("secret")(f)
+	assert.Equal(t, `{"secret":"y"}`, f.Request.Body.Data)
+	assert.Equal(t, `{"secret":"y"`, f.Response.Body.Data)
+}
+
+func TestDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixture")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	record := Dir(filepath.Join(dir, "fixtures"))
+	f := &Fixture{Route: "GET /users/<id>", Request: Request{Method: "GET", URL: "/users/1"}, Response: Response{Status: 200}}
+	assert.Nil(t, record(f))
+	assert.Nil(t, record(f))
+	assert.Nil(t, record(&Fixture{Request: Request{Method: "GET", URL: "/a/b.txt?x=1"}}))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "fixtures", "*.json"))
+	for i, file := range files {
+		files[i] = filepath.Base(file)
+	}
+	assert.Equal(t, []string{"GET_a_b_txt_1.json", "GET_users_id_1.json", "GET_users_id_2.json"}, files)
+
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "fixtures", "GET_users_id_1.json"))
+	var result Fixture
+	if assert.Nil(t, json.Unmarshal(data, &result)) {
+		assert.Equal(t, "GET /users/<id>", result.Route)
+		assert.Equal(t, "/users/1", result.Request.URL)
+		assert.Equal(t, 200, result.Response.Status)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package fixture
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Redacted is the value replacing the redacted data in fixtures.
+const Redacted = routing.Redacted
+
+// RedactHeaders returns a redactor that replaces the values of the named request and response headers with Redacted.
+func RedactHeaders(names ...string) Redactor {
+	return func(f *Fixture) {
+		for _, name := range names {
+			redactHeader(f.Request.Header, name)
+			redactHeader(f.Response.Header, name)
+		}
+	}
+}
+
+// RedactQuery returns a redactor that replaces the values of the named URL query parameters with Redacted.
+func RedactQuery(names ...string) Redactor {
+	return func(f *Fixture) {
+		u, err := url.Parse(f.Request.URL)
+		if err != nil {
+			return
+		}
+		query := u.Query()
+		redacted := false
+		for _, name := range names {
+			if values, ok := query[name]; ok {
+				for i := range values {
+					values[i] = Redacted
+				}
+				redacted = true
+			}
+		}
+		if redacted {
+			u.RawQuery = query.Encode()
+			f.Request.URL = u.String()
+		}
+	}
+}
+
+// RedactJSONFields returns a redactor that replaces the values of the named fields (case-insensitive) at any level
+// of the JSON request and response bodies with Redacted. The bodies that are not JSON or are truncated are left
+// unchanged. Note that the redacted JSON bodies are re-encoded with their object keys sorted.
+func RedactJSONFields(names ...string) Redactor {
+	fields := make(map[string]bool, len(names))
+	for _, name := range names {
+		fields[strings.ToLower(name)] = true
+	}
+	return func(f *Fixture) {
+		redactJSON(&f.Request.Body, f.Request.Header, fields)
+		redactJSON(&f.Response.Body, f.Response.Header, fields)
+	}
+}
+
+func redactHeader(header http.Header, name string) {
+	values := header[http.CanonicalHeaderKey(name)]
+	for i := range values {
+		values[i] = Redacted
+	}
+}
+
+// redactJSON redacts the fields of a JSON body.
+func redactJSON(body *Body, header http.Header, fields map[string]bool) {
+	if body.Data == "" || body.Base64 || body.Truncated {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(body.Data), &value); err != nil {
+		return
+	}
+	if !redactValue(value, fields) {
+		return
+	}
+	if data, err := json.Marshal(value); err == nil {
+		body.Data = string(data)
+	}
+}
+
+// redactValue redacts the fields of a decoded JSON value and returns whether any field is redacted.
+func redactValue(value interface{}, fields map[string]bool) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if fields[strings.ToLower(key)] {
+				v[key] = Redacted
+				redacted = true
+			} else if redactValue(item, fields) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactValue(item, fields) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import "net/http"
+
+// FlagSet is the key used to store and retrieve the feature flags of the current request in Context.
+// It is set by the Flags handler.
+const FlagSet = "FlagSet"
+
+type (
+	// FlagProvider evaluates feature flags, e.g. by calling a feature flag service such as LaunchDarkly or Unleash.
+	FlagProvider interface {
+		// FlagEnabled reports whether the named flag is enabled for the current request. The provider may
+		// identify the user or the tenant making the request from the context, e.g. via c.Get("User").
+		FlagEnabled(c *Context, name string) bool
+	}
+
+	// FlagProviderFunc is a function that implements FlagProvider.
+	FlagProviderFunc func(c *Context, name string) bool
+
+	// FlagMap is a FlagProvider that enables the flags mapped to true for all requests.
+	// It is useful for tests and for the flags set by configuration.
+	FlagMap map[string]bool
+
+	// flagSet caches the flags evaluated for a request.
+	flagSet struct {
+		provider FlagProvider
+		flags    map[string]bool
+	}
+)
+
+// FlagEnabled calls f(c, name).
+func (f FlagProviderFunc) FlagEnabled(c *Context, name string) bool {
+	return f(c, name)
+}
+
+// FlagEnabled returns the value mapped to the name.
+func (m FlagMap) FlagEnabled(c *Context, name string) bool {
+	return m[name]
+}
+
+// Flags returns a handler that makes the feature flags evaluated by the given provider available to the handlers
+// following it via Context.FlagEnabled and RequireFlag. It should be placed after the handlers identifying
+// the users or the tenants, such as those in the auth and tenant packages, so that the provider can evaluate
+// the flags for them.
+//
+//     r := routing.New()
+//     r.Use(auth.Bearer(validateToken))
+//     r.Use(routing.Flags(routing.FlagProviderFunc(func(c *routing.Context, name string) bool {
+//         return flagClient.BoolVariation(name, userKey(c), false)
+//     })))
+//     r.Get("/checkout", routing.RequireFlag("new-checkout"), newCheckout)
+func Flags(provider FlagProvider) Handler {
+	return func(c *Context) error {
+		c.Set(FlagSet, &flagSet{provider: provider})
+		return nil
+	}
+}
+
+// FlagEnabled reports whether the named feature flag is enabled for the current request. Each flag is evaluated
+// at most once per request, so that it stays the same while the request is being handled. False is returned
+// if the Flags handler is not used.
+func (c *Context) FlagEnabled(name string) bool {
+	fs, ok := c.Get(FlagSet).(*flagSet)
+	if !ok {
+		return false
+	}
+	enabled, ok := fs.flags[name]
+	if !ok {
+		enabled = fs.provider.FlagEnabled(c, name)
+		if fs.flags == nil {
+			fs.flags = map[string]bool{}
+		}
+		fs.flags[name] = enabled
+	}
+	return enabled
+}
+
+// RequireFlag returns a handler that responds with 404 (Not Found) unless the named feature flag is enabled for
+// the current request, so that the features being rolled out are hidden from the other users.
+func RequireFlag(name string) Handler {
+	return func(c *Context) error {
+		if !c.FlagEnabled(name) {
+			return NewHTTPError(http.StatusNotFound)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	calls := 0
+	provider := FlagProviderFunc(func(c *Context, name string) bool {
+		calls++
+		return name == "beta" && c.Get("User") == "alice"
+	})
+	router := New()
+	router.Use(func(c *Context) error {
+		c.Set("User", c.Request.Header.Get("X-User"))
+		return nil
+	}, Flags(provider))
+	router.Get("/checkout", RequireFlag("beta"), func(c *Context) error {
+		assert.True(t, c.FlagEnabled("beta"))
+		assert.False(t, c.FlagEnabled("other"))
+		return c.Write("new")
+	})
+
+	tests := []struct {
+		tag, user string
+		status    int
+		body      string
+		calls     int
+	}{
+		{"t1", "alice", http.StatusOK, "new", 2},
+		{"t2", "bob", http.StatusNotFound, "Not Found\n", 1},
+	}
+	for _, test := range tests {
+		calls = 0
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/checkout", nil)
+		req.Header.Set("X-User", test.user)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+		assert.Equal(t, test.calls, calls, test.tag)
+	}
+}
+
+func TestFlagMap(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := NewContext(httptest.NewRecorder(), req, Flags(FlagMap{"a": true, "b": false}))
+	assert.False(t, c.FlagEnabled("a"))
+	assert.Nil(t, c.Next())
+	assert.True(t, c.FlagEnabled("a"))
+	assert.False(t, c.FlagEnabled("b"))
+	assert.False(t, c.FlagEnabled("c"))
+	assert.Nil(t, RequireFlag("a")(c))
+	assert.NotNil(t, RequireFlag("b")(c))
+}
@@ -1,10 +1,18 @@
 module github.com/go-ozzo/ozzo-routing/v2
 
-go 1.13
+go 1.21
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/golang/gddo v0.0.0-20190904175337-72a348e765d2
-	github.com/google/go-cmp v0.3.1 // indirect
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/text v0.3.6
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/google/go-cmp v0.3.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
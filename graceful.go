@@ -14,13 +14,10 @@ import (
 )
 
 // GracefulShutdown shuts down the given HTTP server gracefully when receiving an os.Interrupt or syscall.SIGTERM signal.
-// It will wait for the specified timeout to stop hanging HTTP handlers.
+// It will wait for the specified timeout to stop hanging HTTP handlers. If the handler of the server is a Router,
+// its background tasks will be stopped as well (see Router.Shutdown).
 func GracefulShutdown(hs *http.Server, timeout time.Duration, logFunc func(format string, args ...interface{})) {
-	stop := make(chan os.Signal, 1)
-
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	<-stop
+	waitForSignal()
 
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -32,4 +29,17 @@ func GracefulShutdown(hs *http.Server, timeout time.Duration, logFunc func(forma
 	} else {
 		logFunc("server was shut down gracefully")
 	}
+	if router, ok := hs.Handler.(*Router); ok {
+		if err := router.Shutdown(ctx); err != nil {
+			logFunc("error while stopping background tasks: %v", err)
+		}
+	}
+}
+
+// waitForSignal blocks until receiving an os.Interrupt or syscall.SIGTERM signal.
+func waitForSignal() {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	signal.Stop(stop)
 }
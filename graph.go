@@ -0,0 +1,182 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphFormat is the text format of the graph exported by Router.ExportGraph.
+type GraphFormat string
+
+// The formats supported by Router.ExportGraph.
+const (
+	GraphDOT     GraphFormat = "dot"     // Graphviz DOT, which can be rendered by "dot -Tsvg"
+	GraphMermaid GraphFormat = "mermaid" // Mermaid flowchart, which can be embedded in Markdown documents
+)
+
+type (
+	// graphNode is a node of the graph exported by Router.ExportGraph.
+	graphNode struct {
+		id, label string
+		kind      string // "router", "path", "route", "middleware", or "handler"
+	}
+
+	// graphBuilder collects the nodes and the edges of the graph exported by Router.ExportGraph.
+	graphBuilder struct {
+		nodes []graphNode
+		edges [][2]string
+	}
+)
+
+// ExportGraph writes the graph of the routing table in the given format, which is GraphDOT if not specified.
+// The graph shows the handlers registered via Router.Pre, the tree of the route path segments, and the chain
+// of the handlers of each route in the order that they are executed. The handlers inherited from the router and
+// the groups are distinguished from those registered with the routes, and the route metadata names are listed
+// in the route labels. This allows reviewing what runs where after the groups and the middleware are combined:
+//
+//     router.ExportGraph(os.Stdout)
+//     router.ExportGraph(file, routing.GraphMermaid)
+//
+// The handlers are named after their functions, e.g. "access.Logger.func1".
+func (r *Router) ExportGraph(w io.Writer, format ...GraphFormat) error {
+	f := GraphDOT
+	if len(format) > 0 {
+		f = format[0]
+	}
+	if f != GraphDOT && f != GraphMermaid {
+		return fmt.Errorf("routing: unsupported graph format %q", f)
+	}
+
+	g := &graphBuilder{}
+	g.node("router", "router", "router")
+	prev := "router"
+	for i, h := range r.pre {
+		id := "pre" + strconv.Itoa(i)
+		g.node(id, handlerName(h), "middleware")
+		g.edge(prev, id)
+		prev = id
+	}
+
+	paths := map[string]bool{}
+	for i, route := range r.routes {
+		parent := prev
+		path := ""
+		for _, segment := range strings.Split(strings.Trim(route.Path(), "/"), "/") {
+			path += "/" + segment
+			id := "path:" + path
+			if !paths[path] {
+				paths[path] = true
+				g.node(id, "/"+segment, "path")
+				g.edge(parent, id)
+			}
+			parent = id
+		}
+
+		id := "route" + strconv.Itoa(i)
+		g.node(id, routeLabel(route), "route")
+		g.edge(parent, id)
+		parent = id
+		for j, h := range route.handlers {
+			hid := id + "h" + strconv.Itoa(j)
+			kind := "handler"
+			if j < route.offset {
+				kind = "middleware"
+			}
+			g.node(hid, handlerName(h), kind)
+			g.edge(parent, hid)
+			parent = hid
+		}
+	}
+
+	var s string
+	if f == GraphMermaid {
+		s = g.mermaid()
+	} else {
+		s = g.dot()
+	}
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// routeLabel returns the label of the route node, which consists of the method, the scheme, the path,
+// and the metadata names of the route.
+func routeLabel(route *Route) string {
+	s := route.method + " "
+	if route.group.scheme != "" {
+		s += route.group.scheme + "://"
+	}
+	s += route.Path()
+	if len(route.meta) > 0 {
+		names := make([]string, 0, len(route.meta))
+		for name := range route.meta {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s += " [" + strings.Join(names, ", ") + "]"
+	}
+	return s
+}
+
+// node adds a node of the given kind.
+func (g *graphBuilder) node(id, label, kind string) {
+	g.nodes = append(g.nodes, graphNode{id, label, kind})
+}
+
+// edge adds an edge between the given nodes.
+func (g *graphBuilder) edge(from, to string) {
+	g.edges = append(g.edges, [2]string{from, to})
+}
+
+// dot returns the graph in the Graphviz DOT format.
+func (g *graphBuilder) dot() string {
+	shapes := map[string]string{
+		"router":     "shape=doublecircle",
+		"path":       "shape=plaintext",
+		"route":      "shape=box, style=bold",
+		"middleware": "shape=box, style=dashed",
+		"handler":    "shape=box",
+	}
+	var b strings.Builder
+	b.WriteString("digraph routes {\n  rankdir=LR;\n")
+	for _, n := range g.nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", strconv.Quote(n.id), strconv.Quote(n.label), shapes[n.kind])
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(e[0]), strconv.Quote(e[1]))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaid returns the graph as a Mermaid flowchart.
+func (g *graphBuilder) mermaid() string {
+	ids := make(map[string]string, len(g.nodes))
+	for i, n := range g.nodes {
+		ids[n.id] = "n" + strconv.Itoa(i)
+	}
+	shapes := map[string]string{
+		"router":     "((%s))",
+		"path":       ">%s]",
+		"route":      "([%s])",
+		"middleware": "[/%s/]",
+		"handler":    "[%s]",
+	}
+	escaper := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range g.nodes {
+		label := `"` + escaper.Replace(n.label) + `"`
+		fmt.Fprintf(&b, "  %s%s\n", ids[n.id], fmt.Sprintf(shapes[n.kind], label))
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e[0]], ids[e[1]])
+	}
+	return b.String()
+}
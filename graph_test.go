@@ -0,0 +1,70 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterExportGraph(t *testing.T) {
+	router := New()
+	router.Pre(AllowedHosts("example.com"))
+	router.Use(NotFoundHandler)
+	api := router.Group("/api")
+	api.Use(MethodNotAllowedHandler)
+	api.Get("/users/<id>", InvalidPathHandler).Set(Sensitive, true)
+	api.Post("/users", InvalidPathHandler)
+
+	var buf bytes.Buffer
+	assert.Nil(t, router.ExportGraph(&buf))
+	assert.Equal(t, `digraph routes {
+  rankdir=LR;
+  "router" [label="router", shape=doublecircle];
+  "pre0" [label="v2.AllowedHosts.func1", shape=box, style=dashed];
+  "path:/api" [label="/api", shape=plaintext];
+  "path:/api/users" [label="/users", shape=plaintext];
+  "path:/api/users/<id>" [label="/<id>", shape=plaintext];
+  "route0" [label="GET /api/users/<id> [Sensitive]", shape=box, style=bold];
+  "route0h0" [label="v2.NotFoundHandler", shape=box, style=dashed];
+  "route0h1" [label="v2.MethodNotAllowedHandler", shape=box, style=dashed];
+  "route0h2" [label="v2.InvalidPathHandler", shape=box];
+  "route1" [label="POST /api/users", shape=box, style=bold];
+  "route1h0" [label="v2.NotFoundHandler", shape=box, style=dashed];
+  "route1h1" [label="v2.MethodNotAllowedHandler", shape=box, style=dashed];
+  "route1h2" [label="v2.InvalidPathHandler", shape=box];
+  "router" -> "pre0";
+  "pre0" -> "path:/api";
+  "path:/api" -> "path:/api/users";
+  "path:/api/users" -> "path:/api/users/<id>";
+  "path:/api/users/<id>" -> "route0";
+  "route0" -> "route0h0";
+  "route0h0" -> "route0h1";
+  "route0h1" -> "route0h2";
+  "path:/api/users" -> "route1";
+  "route1" -> "route1h0";
+  "route1h0" -> "route1h1";
+  "route1h1" -> "route1h2";
+}
+`, buf.String())
+
+	router = New()
+	router.Get("/", NotFoundHandler)
+	buf.Reset()
+	assert.Nil(t, router.ExportGraph(&buf, GraphMermaid))
+	assert.Equal(t, `flowchart LR
+  n0(("router"))
+  n1>"/"]
+  n2(["GET /"])
+  n3["v2.NotFoundHandler"]
+  n0 --> n1
+  n1 --> n2
+  n2 --> n3
+`, buf.String())
+
+	assert.NotNil(t, router.ExportGraph(&buf, "svg"))
+}
@@ -4,13 +4,21 @@
 
 package routing
 
-import "strings"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // RouteGroup represents a group of routes that share the same path prefix.
 type RouteGroup struct {
 	prefix   string
+	scheme   string
 	router   *Router
 	handlers []Handler
+	finally  []FinallyHandler
+	meta     map[string]interface{}
+	resource string
 }
 
 // newRouteGroup creates a new RouteGroup with the given path prefix, router, and handlers.
@@ -90,13 +98,21 @@ func (rg *RouteGroup) To(methods, path string, handlers ...Handler) *Route {
 // Group creates a RouteGroup with the given route path prefix and handlers.
 // The new group will combine the existing path prefix with the new one.
 // If no handler is provided, the new group will inherit the handlers registered
-// with the current group.
+// with the current group. The new group always inherits the finally handlers and the metadata of the current group.
 func (rg *RouteGroup) Group(prefix string, handlers ...Handler) *RouteGroup {
 	if len(handlers) == 0 {
 		handlers = make([]Handler, len(rg.handlers))
 		copy(handlers, rg.handlers)
 	}
-	return newRouteGroup(rg.prefix+prefix, rg.router, handlers)
+	g := newRouteGroup(rg.prefix+prefix, rg.router, handlers)
+	g.scheme = rg.scheme
+	g.resource = rg.resource
+	g.finally = make([]FinallyHandler, len(rg.finally))
+	copy(g.finally, rg.finally)
+	for name, value := range rg.meta {
+		g.Set(name, value)
+	}
+	return g
 }
 
 // Use registers one or multiple handlers to the current route group.
@@ -105,8 +121,76 @@ func (rg *RouteGroup) Use(handlers ...Handler) {
 	rg.handlers = append(rg.handlers, handlers...)
 }
 
+// Finally registers one or multiple finally handlers to the current route group.
+// These handlers will be called for all routes belong to this group and its subgroups after the rest of
+// the handlers are executed, even if a handler returns an error or calls Context.Abort. Each finally handler
+// receives the error returned by the handlers (or by the previous finally handler) and returns the error
+// that should be handled by the router. The finally handlers registered with a subgroup are called before
+// those inherited from its parent group.
+func (rg *RouteGroup) Finally(handlers ...FinallyHandler) {
+	rg.finally = append(append([]FinallyHandler{}, handlers...), rg.finally...)
+}
+
+// Set associates a named metadata item with the current route group.
+// The metadata item will be associated with the routes and subgroups subsequently added to this group,
+// unless they override it by calling Route.Set or RouteGroup.Set with the same name.
+func (rg *RouteGroup) Set(name string, value interface{}) *RouteGroup {
+	if rg.meta == nil {
+		rg.meta = make(map[string]interface{})
+	}
+	rg.meta[name] = value
+	return rg
+}
+
+// LoaderFunc loads the data item identified by the given parameter value, e.g. a tenant identified by its ID.
+// It should return a nil item if the data item does not exist.
+type LoaderFunc func(c *Context, value string) (interface{}, error)
+
+// Load registers a handler with the current route group that resolves the named parameter in the group prefix
+// using the given loader. The loaded item is stored in the context under the parameter name, so the handlers
+// registered after it can retrieve the item by calling Context.Get. The loader is called once per request.
+// If the parameter value is empty or the loader returns a nil item, an http.StatusNotFound error will be returned.
+// For example,
+//
+//     tenant := router.Group("/tenants/<tenant>")
+//     tenant.Load("tenant", func(c *routing.Context, id string) (interface{}, error) {
+//         return findTenant(id)
+//     })
+//     tenant.Get("/users", func(c *routing.Context) error {
+//         t := c.Get("tenant").(*Tenant)
+//         ...
+//     })
+//
+// Load panics if the group prefix does not declare the named parameter.
+func (rg *RouteGroup) Load(name string, loader LoaderFunc) *RouteGroup {
+	if !strings.Contains(buildURLTemplate(rg.prefix), "<"+name+">") {
+		panic(fmt.Sprintf("the prefix %q of the route group does not declare the parameter %q", rg.prefix, name))
+	}
+	rg.Use(func(c *Context) error {
+		value := c.Param(name)
+		if value == "" {
+			return NewHTTPError(http.StatusNotFound)
+		}
+		item, err := loader(c, value)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return NewHTTPError(http.StatusNotFound)
+		}
+		c.Set(name, item)
+		return nil
+	})
+	return rg
+}
+
 func (rg *RouteGroup) add(method, path string, handlers []Handler) *Route {
 	r := rg.newRoute(method, path)
+	r.finally = rg.finally
+	r.offset = len(rg.handlers)
+	for name, value := range rg.meta {
+		r.Set(name, value)
+	}
 	rg.router.addRoute(r, combineHandlers(rg.handlers, handlers))
 	return r
 }
@@ -6,6 +6,10 @@ package routing
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -91,3 +95,151 @@ func TestRouteGroupUse(t *testing.T) {
 	group2.Use(newHandler("3", &buf))
 	assert.Equal(t, 3, len(group2.handlers), "len(group2.handlers) =")
 }
+
+func TestRouteGroupSet(t *testing.T) {
+	router := New()
+	group := router.Group("/admin").Set("a", 1).Set("b", 2)
+	r1 := group.Get("/users").Set("b", 3)
+	sub := group.Group("/posts").Set("c", 4)
+	r2 := sub.To("GET,POST", "")
+	group.Set("d", 5)
+
+	assert.Equal(t, 1, r1.Meta("a"))
+	assert.Equal(t, 3, r1.Meta("b"))
+	assert.Nil(t, r1.Meta("c"))
+	assert.Nil(t, r1.Meta("d"))
+	for _, route := range r2.routes {
+		assert.Equal(t, 1, route.Meta("a"))
+		assert.Equal(t, 2, route.Meta("b"))
+		assert.Equal(t, 4, route.Meta("c"))
+	}
+	assert.Nil(t, group.Get("/x").Meta("c"))
+	assert.Equal(t, 5, group.Get("/y").Meta("d"))
+}
+
+func TestRouteGroupParamPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	router := New()
+	tenants := router.Group("/tenants/<tenant:[a-z]+>")
+	tenants.Use(func(c *Context) error {
+		fmt.Fprintf(&buf, "m(%v).", c.Param("tenant"))
+		return nil
+	})
+	users := tenants.Group("/users")
+	users.Get("/<id>", func(c *Context) error {
+		fmt.Fprintf(&buf, "h(%v,%v).", c.Param("tenant"), c.Param("id"))
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenants/acme/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "m(acme).h(acme,1).", buf.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenants/123/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouteGroupLoad(t *testing.T) {
+	calls := 0
+	router := New()
+	tenants := router.Group("/tenants/<tenant>").Load("tenant", func(c *Context, id string) (interface{}, error) {
+		calls++
+		switch id {
+		case "acme":
+			return "Acme Inc.", nil
+		case "broken":
+			return nil, errors.New("db error")
+		}
+		return nil, nil
+	})
+	tenants.Group("/users").Get("/<id>", func(c *Context) error {
+		return c.Write(fmt.Sprintf("%v:%v", c.Get("tenant"), c.Param("id")))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tenants/acme/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "Acme Inc.:1", res.Body.String())
+	assert.Equal(t, 1, calls)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenants/other/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenants/broken/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/tenants//users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, 3, calls)
+
+	assert.Panics(t, func() {
+		router.Group("/users").Load("tenant", nil)
+	})
+}
+
+func TestRouteGroupFinally(t *testing.T) {
+	var buf bytes.Buffer
+	newFinally := func(tag string) FinallyHandler {
+		return func(c *Context, err error) error {
+			fmt.Fprintf(&buf, "%v(%v).", tag, err)
+			return err
+		}
+	}
+	router := New()
+	router.Finally(newFinally("r"))
+	api := router.Group("/api")
+	api.Finally(newFinally("a1"), newFinally("a2"))
+	api.Get("/users", newHandler("1.", &buf), func(c *Context) error {
+		c.Abort()
+		return nil
+	}, newHandler("2.", &buf))
+	api.Get("/posts", func(c *Context) error {
+		return errors.New("abc")
+	})
+	api.Get("/comments", func(c *Context) error {
+		return errors.New("abc")
+	})
+	api.Finally(func(c *Context, err error) error {
+		return nil
+	})
+	api.Get("/orders", func(c *Context) error {
+		return errors.New("abc")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "1.a1(<nil>).a2(<nil>).r(<nil>).", buf.String())
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/posts", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "a1(abc).a2(abc).r(abc).", buf.String())
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/orders", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "a1(<nil>).a2(<nil>).r(<nil>).", buf.String())
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	buf.Reset()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/unknown", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "r(Not Found).", buf.String())
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
@@ -0,0 +1,166 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package health provides a registry of health checks and the handlers reporting them for the ozzo routing package.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+type (
+	// CheckFunc checks whether a dependency, such as a cache store or a key fetcher, is working.
+	// It returns a non-nil error describing the problem if the dependency is not working. It should return
+	// as soon as possible when the context is done.
+	CheckFunc func(ctx context.Context) error
+
+	// Registry is a set of named health checks. It is safe for concurrent use.
+	Registry struct {
+		mu     sync.RWMutex
+		checks map[string]CheckFunc
+	}
+
+	// Report is the result of running the health checks in a registry.
+	Report struct {
+		// Status is "ok" if all checks pass, or "unavailable" otherwise.
+		Status string `json:"status"`
+		// Checks are the results of the individual checks, indexed by the check names.
+		Checks map[string]CheckResult `json:"checks,omitempty"`
+	}
+
+	// CheckResult is the result of a health check.
+	CheckResult struct {
+		// Status is "ok" if the check passes, or "error" otherwise.
+		Status string `json:"status"`
+		// Error is the error message of a failed check.
+		Error string `json:"error,omitempty"`
+	}
+)
+
+// DefaultRegistry is the registry used by Register and the handlers that are not given a registry.
+// The handlers and stores in other packages register their health checks in this registry, so that
+// the readiness endpoint reflects the state of the dependencies without manual wiring.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{checks: map[string]CheckFunc{}}
+}
+
+// Register adds a health check with the given name to the registry, replacing the existing check with the same name.
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+// Unregister removes the health check with the given name from the registry.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+}
+
+// Names returns the names of the health checks in the registry in alphabetical order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check runs all health checks in the registry concurrently and reports their results.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]CheckFunc, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: "ok", Checks: make(map[string]CheckResult, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			result := CheckResult{Status: "ok"}
+			if err := check(ctx); err != nil {
+				result = CheckResult{Status: "error", Error: err.Error()}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != "ok" {
+				report.Status = "unavailable"
+			}
+		}(name, check)
+	}
+	wg.Wait()
+	return report
+}
+
+// Register adds a health check with the given name to DefaultRegistry.
+func Register(name string, check CheckFunc) {
+	DefaultRegistry.Register(name, check)
+}
+
+// Live returns a handler for the liveness endpoint (e.g. "/healthz"), which always responds with
+// the "ok" status, indicating that the application is running and able to serve requests.
+func Live() routing.Handler {
+	return func(c *routing.Context) error {
+		return writeReport(c, http.StatusOK, Report{Status: "ok"})
+	}
+}
+
+// Ready returns a handler for the readiness endpoint (e.g. "/readyz"), which runs the health checks in
+// the given registry (DefaultRegistry if not given) and responds with the report in JSON. The response status
+// is http.StatusOK if all checks pass, or http.StatusServiceUnavailable otherwise.
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/health"
+//     )
+//
+//     health.Register("database", func(ctx context.Context) error {
+//         return db.PingContext(ctx)
+//     })
+//     r := routing.New()
+//     r.Get("/healthz", health.Live())
+//     r.Get("/readyz", health.Ready())
+func Ready(registry ...*Registry) routing.Handler {
+	reg := DefaultRegistry
+	if len(registry) > 0 {
+		reg = registry[0]
+	}
+	return func(c *routing.Context) error {
+		report := reg.Check(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		return writeReport(c, status, report)
+	}
+}
+
+// writeReport writes the report as the JSON response with the given status.
+func writeReport(c *routing.Context, status int, report Report) error {
+	c.Response.Header().Set("Content-Type", "application/json")
+	c.Response.Header().Set("Cache-Control", "no-store")
+	c.Response.WriteHeader(status)
+	return json.NewEncoder(c.Response).Encode(report)
+}
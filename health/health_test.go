@@ -0,0 +1,90 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	assert.Equal(t, Report{Status: "ok", Checks: map[string]CheckResult{}}, r.Check(context.Background()))
+
+	r.Register("db", func(ctx context.Context) error {
+		return nil
+	})
+	r.Register("cache", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	assert.Equal(t, []string{"cache", "db"}, r.Names())
+	assert.Equal(t, Report{
+		Status: "unavailable",
+		Checks: map[string]CheckResult{
+			"db":    {Status: "ok"},
+			"cache": {Status: "error", Error: "connection refused"},
+		},
+	}, r.Check(context.Background()))
+
+	r.Register("cache", func(ctx context.Context) error {
+		return ctx.Err()
+	})
+	assert.Equal(t, "ok", r.Check(context.Background()).Status)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, CheckResult{"error", "context canceled"}, r.Check(ctx).Checks["cache"])
+
+	r.Unregister("cache")
+	assert.Equal(t, []string{"db"}, r.Names())
+}
+
+func TestHandlers(t *testing.T) {
+	defer func(r *Registry) { DefaultRegistry = r }(DefaultRegistry)
+	DefaultRegistry = NewRegistry()
+	healthy := true
+	Register("jwks", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("keys expired")
+		}
+		return nil
+	})
+
+	router := routing.New()
+	router.Get("/healthz", Live())
+	router.Get("/readyz", Ready())
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"status":"ok"}`+"\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "no-store", res.Header().Get("Cache-Control"))
+	assert.Equal(t, `{"status":"ok","checks":{"jwks":{"status":"ok"}}}`+"\n", res.Body.String())
+
+	healthy = false
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, `{"status":"unavailable","checks":{"jwks":{"status":"error","error":"keys expired"}}}`+"\n", res.Body.String())
+
+	// a custom registry
+	router.Get("/ready", Ready(NewRegistry()))
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/ready", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+}
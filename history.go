@@ -0,0 +1,191 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// History keeps the last requests served by each route in memory, which allows inspecting the recent traffic
+	// of a production server without a metrics infrastructure. Set Router.History to a History created by
+	// NewHistory to enable recording, and serve History.Handler on an admin route:
+	//
+	//     router.History = routing.NewHistory(100)
+	//     admin := router.Group("/admin", auth.Basic(checkAdmin))
+	//     admin.Any("/history", router.History.Handler())
+	//
+	// Recording can be turned on and off at runtime via SetEnabled or the handler. History is safe for
+	// concurrent use.
+	History struct {
+		size     int
+		disabled int32
+		mu       sync.Mutex
+		rings    map[string]*historyRing
+	}
+
+	// RequestRecord describes a request recorded by History.
+	RequestRecord struct {
+		Time    time.Time `json:"time"`       // the time when the request was received
+		Path    string    `json:"path"`       // the request path, without the query string
+		Status  int       `json:"status"`     // the response status
+		Size    int64     `json:"size"`       // the number of bytes in the response body
+		Latency float64   `json:"latency_ms"` // the time used to serve the request in milliseconds
+		Client  string    `json:"client"`     // the client IP address
+	}
+
+	// RouteHistory holds the requests recorded for a route, and the histograms of their statuses and response sizes.
+	RouteHistory struct {
+		Route    string          `json:"route"`    // the route, e.g. "GET /users/<id>", or "" for the unmatched requests
+		Requests []RequestRecord `json:"requests"` // the recorded requests, from the oldest to the newest
+		Statuses map[int]int     `json:"statuses"` // the numbers of the recorded requests by response status
+		Sizes    []int           `json:"sizes"`    // the numbers of the recorded requests by HistorySizeBuckets
+	}
+
+	// historyRing is a ring buffer of the requests recorded for a route.
+	historyRing struct {
+		records []RequestRecord
+		next    int
+	}
+)
+
+// HistorySizeBuckets lists the upper bounds (exclusive) of the response size buckets in RouteHistory.Sizes.
+// The last bucket of RouteHistory.Sizes counts the responses exceeding all the bounds.
+var HistorySizeBuckets = []int64{1 << 10, 10 << 10, 100 << 10, 1 << 20}
+
+// NewHistory creates a History that keeps the last size requests of each route. Recording is enabled.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = 1
+	}
+	return &History{size: size, rings: map[string]*historyRing{}}
+}
+
+// Enabled reports whether the requests are being recorded.
+func (h *History) Enabled() bool {
+	return atomic.LoadInt32(&h.disabled) == 0
+}
+
+// SetEnabled turns recording on or off. The recorded requests are kept when recording is turned off.
+func (h *History) SetEnabled(enabled bool) {
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreInt32(&h.disabled, disabled)
+}
+
+// Reset removes all recorded requests.
+func (h *History) Reset() {
+	h.mu.Lock()
+	h.rings = map[string]*historyRing{}
+	h.mu.Unlock()
+}
+
+// Add records a request served by the given route, which is nil if the request matches no route.
+// It is called by the router when Router.History is set.
+func (h *History) Add(route *Route, record RequestRecord) {
+	if !h.Enabled() {
+		return
+	}
+	key := ""
+	if route != nil {
+		key = route.String()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ring := h.rings[key]
+	if ring == nil {
+		ring = &historyRing{records: make([]RequestRecord, 0, h.size)}
+		h.rings[key] = ring
+	}
+	if len(ring.records) < h.size {
+		ring.records = append(ring.records, record)
+	} else {
+		ring.records[ring.next] = record
+	}
+	ring.next = (ring.next + 1) % h.size
+}
+
+// Route returns the history of the given route, e.g. "GET /users/<id>", or of the unmatched requests if the route
+// is "". The history is empty if no request of the route has been recorded.
+func (h *History) Route(route string) RouteHistory {
+	rh := RouteHistory{
+		Route:    route,
+		Requests: []RequestRecord{},
+		Statuses: map[int]int{},
+		Sizes:    make([]int, len(HistorySizeBuckets)+1),
+	}
+	h.mu.Lock()
+	if ring := h.rings[route]; ring != nil {
+		if len(ring.records) < h.size {
+			rh.Requests = append(rh.Requests, ring.records...)
+		} else {
+			rh.Requests = append(append(rh.Requests, ring.records[ring.next:]...), ring.records[:ring.next]...)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, record := range rh.Requests {
+		rh.Statuses[record.Status]++
+		i := sort.Search(len(HistorySizeBuckets), func(i int) bool {
+			return record.Size < HistorySizeBuckets[i]
+		})
+		rh.Sizes[i]++
+	}
+	return rh
+}
+
+// Routes returns the histories of the given routes, followed by that of the unmatched requests.
+// The routes are usually obtained from Router.Routes.
+func (h *History) Routes(routes []*Route) []RouteHistory {
+	histories := make([]RouteHistory, 0, len(routes)+1)
+	for _, route := range routes {
+		histories = append(histories, h.Route(route.String()))
+	}
+	return append(histories, h.Route(""))
+}
+
+// Handler returns a handler that serves the recorded requests in JSON. A GET request responds with the histories
+// of all routes of the router, in the order that the routes are added, or the history of the route specified
+// by the "route" query parameter, e.g. "?route=GET /users/<id>". A POST request with the "enabled" query parameter
+// set to "true" or "false" turns recording on or off, and a DELETE request removes the recorded requests.
+// Other methods result in an http.StatusMethodNotAllowed error.
+func (h *History) Handler() Handler {
+	return func(c *Context) error {
+		switch c.Request.Method {
+		case "GET", "HEAD":
+		case "POST":
+			enabled, err := strconv.ParseBool(c.Query("enabled"))
+			if err != nil {
+				return NewHTTPError(http.StatusBadRequest, "the enabled parameter must be true or false")
+			}
+			h.SetEnabled(enabled)
+		case "DELETE":
+			h.Reset()
+		default:
+			c.Response.Header().Set("Allow", "GET, HEAD, POST, DELETE")
+			return NewHTTPError(http.StatusMethodNotAllowed)
+		}
+
+		result := struct {
+			Enabled bool           `json:"enabled"`
+			Routes  []RouteHistory `json:"routes"`
+		}{Enabled: h.Enabled()}
+		if route, ok := c.Request.URL.Query()["route"]; ok {
+			result.Routes = []RouteHistory{h.Route(route[0])}
+		} else {
+			result.Routes = h.Routes(c.Router().Routes())
+		}
+		c.Response.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(c.Response).Encode(result)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	router := New()
+	router.History = NewHistory(2)
+	router.Get("/users/<id>", func(c *Context) error {
+		if c.Param("id") == "0" {
+			return NewHTTPError(http.StatusNotFound)
+		}
+		return c.Write(strings.Repeat("x", 2000))
+	})
+	router.Post("/users", func(c *Context) error {
+		c.Response.WriteHeader(http.StatusCreated)
+		return nil
+	})
+
+	serve := func(method, path string) {
+		req, _ := http.NewRequest(method, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve("GET", "/users/1")
+	serve("GET", "/users/0")
+	serve("GET", "/users/2?x=y")
+	serve("GET", "/missing")
+
+	h := router.History.Route("GET /users/<id>")
+	assert.Equal(t, "GET /users/<id>", h.Route)
+	if assert.Len(t, h.Requests, 2) {
+		assert.Equal(t, "/users/0", h.Requests[0].Path)
+		assert.Equal(t, http.StatusNotFound, h.Requests[0].Status)
+		assert.Equal(t, "/users/2", h.Requests[1].Path)
+		assert.Equal(t, http.StatusOK, h.Requests[1].Status)
+		assert.Equal(t, int64(2000), h.Requests[1].Size)
+		assert.Equal(t, "10.0.0.1", h.Requests[1].Client)
+		assert.False(t, h.Requests[1].Time.IsZero())
+		assert.True(t, h.Requests[1].Latency >= 0)
+	}
+	assert.Equal(t, map[int]int{200: 1, 404: 1}, h.Statuses)
+	assert.Equal(t, []int{1, 1, 0, 0, 0}, h.Sizes)
+
+	h = router.History.Route("POST /users")
+	assert.Equal(t, []RequestRecord{}, h.Requests)
+	assert.Equal(t, []int{0, 0, 0, 0, 0}, h.Sizes)
+
+	h = router.History.Route("")
+	if assert.Len(t, h.Requests, 1) {
+		assert.Equal(t, "/missing", h.Requests[0].Path)
+	}
+
+	histories := router.History.Routes(router.Routes())
+	if assert.Len(t, histories, 3) {
+		assert.Equal(t, "GET /users/<id>", histories[0].Route)
+		assert.Equal(t, "POST /users", histories[1].Route)
+		assert.Equal(t, "", histories[2].Route)
+	}
+
+	router.History.SetEnabled(false)
+	assert.False(t, router.History.Enabled())
+	serve("POST", "/users")
+	assert.Len(t, router.History.Route("POST /users").Requests, 0)
+	router.History.SetEnabled(true)
+	serve("POST", "/users")
+	assert.Len(t, router.History.Route("POST /users").Requests, 1)
+
+	router.History.Reset()
+	assert.Len(t, router.History.Route("POST /users").Requests, 0)
+}
+
+func TestHistoryHandler(t *testing.T) {
+	router := New()
+	router.History = NewHistory(10)
+	router.Any("/admin/history", router.History.Handler())
+	router.Get("/users", func(c *Context) error {
+		return c.Write("ok")
+	})
+
+	type result struct {
+		Enabled bool
+		Routes  []RouteHistory
+	}
+	call := func(method, url string) (*httptest.ResponseRecorder, result) {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		router.ServeHTTP(res, req)
+		var r result
+		json.Unmarshal(res.Body.Bytes(), &r)
+		return res, r
+	}
+
+	call("GET", "/users")
+	res, r := call("GET", "/admin/history?route=GET%20/users")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.True(t, r.Enabled)
+	if assert.Len(t, r.Routes, 1) && assert.Len(t, r.Routes[0].Requests, 1) {
+		assert.Equal(t, int64(2), r.Routes[0].Requests[0].Size)
+	}
+
+	_, r = call("GET", "/admin/history")
+	assert.Len(t, r.Routes, len(router.Routes())+1)
+
+	_, r = call("POST", "/admin/history?enabled=false")
+	assert.False(t, r.Enabled)
+	call("GET", "/users")
+	_, r = call("GET", "/admin/history?route=GET%20/users")
+	assert.Len(t, r.Routes[0].Requests, 1)
+
+	res, _ = call("POST", "/admin/history?enabled=maybe")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	_, r = call("DELETE", "/admin/history?route=GET%20/users")
+	assert.Len(t, r.Routes[0].Requests, 0)
+
+	res, _ = call("PUT", "/admin/history")
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	assert.Equal(t, "GET, HEAD, POST, DELETE", res.Header().Get("Allow"))
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// hostPattern is a parsed pattern of AllowedHosts.
+type hostPattern struct {
+	name     string // the host name, or the domain of a wildcard pattern
+	port     string // the port, or "" to match any port
+	wildcard bool   // whether to match the subdomains of the domain
+}
+
+// AllowedHosts returns a handler that rejects the requests whose Host headers do not match any of the given patterns,
+// which defends against host header injection, e.g. the password reset links pointing to an attacker's host, and
+// web cache poisoning. A pattern is either a host name (e.g. "example.com"), which matches the host exactly,
+// or a wildcard pattern (e.g. "*.example.com"), which matches any subdomain but not the domain itself.
+// The pattern "*" matches any valid host. The port in the Host header is ignored unless the pattern contains a port
+// (e.g. "localhost:8080"). Host names are compared case-insensitively.
+//
+// If the Host header is missing or malformed, an http.StatusBadRequest error will be returned; if it matches no
+// pattern, an http.StatusMisdirectedRequest error will be returned. The handler should be registered via Router.Pre
+// so that the requests are checked before being matched against the routes:
+//
+//     r := routing.New()
+//     r.Pre(routing.AllowedHosts("example.com", "*.example.com", "localhost:8080"))
+func AllowedHosts(patterns ...string) Handler {
+	allowed := make([]hostPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		name, port := splitHost(pattern)
+		if strings.HasPrefix(name, "*.") {
+			allowed = append(allowed, hostPattern{name[2:], port, true})
+		} else {
+			allowed = append(allowed, hostPattern{name, port, false})
+		}
+	}
+	return func(c *Context) error {
+		name, port := splitHost(c.Request.Host)
+		if !isValidHost(name, port) {
+			return NewHTTPError(http.StatusBadRequest, "invalid host")
+		}
+		for _, p := range allowed {
+			if p.port != "" && p.port != port {
+				continue
+			}
+			if p.name == "*" || !p.wildcard && p.name == name || p.wildcard && strings.HasSuffix(name, "."+p.name) {
+				return nil
+			}
+		}
+		return NewHTTPError(http.StatusMisdirectedRequest)
+	}
+}
+
+// splitHost splits a host into the lowercase host name without the trailing dot and the port.
+func splitHost(host string) (name, port string) {
+	name = strings.ToLower(host)
+	if h, p, err := net.SplitHostPort(name); err == nil {
+		name, port = h, p
+	} else if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		name = name[1 : len(name)-1]
+	}
+	return strings.TrimSuffix(name, "."), port
+}
+
+// isValidHost checks if the host name and the port consist of the characters allowed in host names,
+// IP addresses, and ports.
+func isValidHost(name, port string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return false
+		}
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !(ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '.' || ch == '_' || ch == ':') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedHosts(t *testing.T) {
+	router := New()
+	router.Pre(AllowedHosts("Example.com", "*.example.org", "localhost:8080", "::1"))
+	router.Get("/", func(c *Context) error {
+		return c.Write("ok")
+	})
+
+	tests := []struct {
+		tag, host string
+		status    int
+	}{
+		{"t1", "example.com", http.StatusOK},
+		{"t2", "EXAMPLE.com:443", http.StatusOK},
+		{"t3", "example.com.", http.StatusOK},
+		{"t4", "www.example.com", http.StatusMisdirectedRequest},
+		{"t5", "api.example.org", http.StatusOK},
+		{"t6", "a.b.example.org", http.StatusOK},
+		{"t7", "example.org", http.StatusMisdirectedRequest},
+		{"t8", "evilexample.org", http.StatusMisdirectedRequest},
+		{"t9", "localhost:8080", http.StatusOK},
+		{"t10", "localhost", http.StatusMisdirectedRequest},
+		{"t11", "localhost:9090", http.StatusMisdirectedRequest},
+		{"t12", "[::1]:80", http.StatusOK},
+		{"t13", "[::1]", http.StatusOK},
+		{"t14", "", http.StatusBadRequest},
+		{"t15", "evil.com:80.example.org", http.StatusBadRequest},
+		{"t16", "evil.com/x", http.StatusBadRequest},
+		{"t17", "attacker.com", http.StatusMisdirectedRequest},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Host = test.host
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+	}
+
+	router = New()
+	router.Pre(AllowedHosts("*"))
+	router.Get("/", func(c *Context) error {
+		return c.Write("ok")
+	})
+	for _, host := range []string{"example.com", "1.2.3.4:80"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Host = host
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code, host)
+	}
+}
+
+func TestRouterPre(t *testing.T) {
+	router := New()
+	var calls []string
+	router.Use(func(c *Context) error {
+		calls = append(calls, "use")
+		return nil
+	})
+	router.Pre(func(c *Context) error {
+		calls = append(calls, "pre1")
+		if c.Request.URL.Path == "/old" {
+			c.Request.URL.Path = "/new"
+		}
+		return nil
+	}, func(c *Context) error {
+		calls = append(calls, "pre2")
+		switch c.Request.URL.Path {
+		case "/blocked":
+			return NewHTTPError(http.StatusForbidden)
+		case "/done":
+			c.Abort()
+			return c.Write("done")
+		}
+		return nil
+	})
+	router.Get("/new", func(c *Context) error {
+		return c.Write("new")
+	})
+
+	tests := []struct {
+		tag, path string
+		status    int
+		body      string
+		calls     []string
+	}{
+		{"t1", "/new", http.StatusOK, "new", []string{"pre1", "pre2", "use"}},
+		{"t2", "/old", http.StatusOK, "new", []string{"pre1", "pre2", "use"}},
+		{"t3", "/blocked", http.StatusForbidden, "Forbidden\n", []string{"pre1", "pre2"}},
+		{"t4", "/done", http.StatusOK, "done", []string{"pre1", "pre2"}},
+		{"t5", "/missing", http.StatusNotFound, "Not Found\n", []string{"pre1", "pre2", "use"}},
+	}
+	for _, test := range tests {
+		calls = nil
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+		assert.Equal(t, test.calls, calls, test.tag)
+	}
+
+	router.Freeze()
+	assert.Panics(t, func() { router.Pre(nil) })
+}
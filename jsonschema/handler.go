@@ -0,0 +1,97 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Error is the HTTP error returned when a request body does not conform to the JSON schema.
+type Error struct {
+	Status  int               `json:"status" xml:"status"`
+	Message string            `json:"message" xml:"message"`
+	Errors  []ValidationError `json:"errors" xml:"errors>error"`
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code.
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+// Handler returns a handler that validates the JSON request body against the given schema before
+// the rest of the handlers are executed. If the body is not valid JSON, an http.StatusBadRequest error
+// will be returned. If the body does not conform to the schema, an Error with the http.StatusUnprocessableEntity
+// status will be returned, which lists the locations of the invalid values as JSON pointers. Otherwise, the body
+// is restored so that it can be read by the following handlers, e.g. via routing.Context.Read.
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/jsonschema"
+//     )
+//
+//     userSchema := jsonschema.MustCompile([]byte(`{
+//         "type": "object",
+//         "properties": {"name": {"type": "string", "minLength": 1}},
+//         "required": ["name"]
+//     }`))
+//     r := routing.New()
+//     r.Post("/users", jsonschema.Handler(userSchema), createUser)
+func Handler(schema *Schema) routing.Handler {
+	return func(c *routing.Context) error {
+		return validate(c, schema)
+	}
+}
+
+// RouteHandler returns a handler that validates the JSON request body against the schema generated by FromType
+// from the request schema of the matching route (see routing.Route.Request). The schema of each route is generated
+// once and then cached. The requests to the routes without request schemas are not validated. Like Handler,
+// the handler returns an Error with the http.StatusUnprocessableEntity status if the body does not conform to the schema.
+//
+//     r := routing.New()
+//     r.Use(jsonschema.RouteHandler())
+//     r.Post("/users", createUser).Request(CreateUser{})
+func RouteHandler() routing.Handler {
+	var schemas sync.Map
+	return func(c *routing.Context) error {
+		route := c.Route()
+		if route == nil || route.RequestSchema() == nil {
+			return nil
+		}
+		schema, ok := schemas.Load(route)
+		if !ok {
+			schema, _ = schemas.LoadOrStore(route, FromType(route.RequestSchema()))
+		}
+		return validate(c, schema.(*Schema))
+	}
+}
+
+// validate validates the request body against the schema and restores the body.
+func validate(c *routing.Context, schema *Schema) error {
+	body, err := ioutil.ReadAll(c.Request.Body)
+	c.Request.Body.Close()
+	if err != nil {
+		return err
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if errs := schema.Validate(value); len(errs) > 0 {
+		return &Error{http.StatusUnprocessableEntity, http.StatusText(http.StatusUnprocessableEntity), errs}
+	}
+	return nil
+}
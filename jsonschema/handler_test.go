@@ -0,0 +1,85 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	router := routing.New()
+	router.Post("/users", Handler(MustCompile([]byte(userSchema))), func(c *routing.Context) error {
+		var data struct{ Name string }
+		if err := c.Read(&data); err != nil {
+			return err
+		}
+		return c.Write(data.Name)
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"john","age":30}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "john", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"name":"john"`))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"name":"j"}`))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+
+	err := &Error{http.StatusUnprocessableEntity, "Unprocessable Entity", []ValidationError{{"/age", "is required"}}}
+	assert.Equal(t, "Unprocessable Entity", err.Error())
+	assert.Equal(t, http.StatusUnprocessableEntity, err.StatusCode())
+	b, _ := json.Marshal(err)
+	assert.Equal(t, `{"status":422,"message":"Unprocessable Entity","errors":[{"pointer":"/age","message":"is required"}]}`, string(b))
+}
+
+func TestRouteHandler(t *testing.T) {
+	var validated error
+	router := routing.New()
+	router.Use(func(c *routing.Context) error {
+		validated = c.Next()
+		return validated
+	}, RouteHandler())
+	router.Post("/users", func(c *routing.Context) error {
+		return c.Write("ok")
+	}).Request(address{})
+	router.Post("/posts", func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"city":"Paris"}`))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "ok", res.Body.String())
+
+	for i := 0; i < 2; i++ {
+		res = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"city":1}`))
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+		if e, ok := validated.(*Error); assert.True(t, ok) {
+			assert.Equal(t, []ValidationError{{"/city", "must be of type string"}}, e.Errors)
+		}
+	}
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/posts", strings.NewReader(`not json`))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "ok", res.Body.String())
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FromType generates the JSON schema of the JSON documents that can be decoded into the type of the given value,
+// such as the request schema declared via routing.Route.Request. The struct fields are mapped to the object properties
+// according to their "json" tags. A field is required unless it is a pointer or its tag has the "omitempty" option.
+// Types implementing json.Marshaler are not constrained, except time.Time which is a string in the date-time format.
+// If the value is a pointer, the schema describes the value it points to.
+func FromType(value interface{}) *Schema {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return fromType(t, map[reflect.Type]bool{})
+}
+
+func fromType(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := fromType(t.Elem(), visiting)
+		if len(s.Type) > 0 {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	case t == rawMessageType || t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: Types{"string"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min := 0.0
+		return &Schema{Type: Types{"integer"}, Minimum: &min}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// []byte is encoded as a base64 string
+			return &Schema{Type: Types{"string"}}
+		}
+		s := &Schema{Type: Types{"array"}, Items: fromType(t.Elem(), visiting)}
+		if t.Kind() == reflect.Slice {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	case reflect.Map:
+		return &Schema{Type: Types{"object", "null"}}
+	case reflect.Struct:
+		if visiting[t] {
+			// a recursive type
+			return &Schema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		s := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+		addFields(s, t, visiting)
+		return s
+	}
+	return &Schema{}
+}
+
+// addFields adds the exported fields of the struct type as the properties of the schema.
+func addFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name, opts = tag[:j], tag[j:]
+		}
+		ft := field.Type
+		if field.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// the fields of an embedded struct are promoted
+				addFields(s, ft, visiting)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = fromType(field.Type, visiting)
+		if !strings.Contains(opts, ",omitempty") && field.Type.Kind() != reflect.Ptr {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
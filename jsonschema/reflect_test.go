@@ -0,0 +1,72 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	address struct {
+		City string `json:"city"`
+	}
+
+	node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children,omitempty"`
+	}
+
+	createUser struct {
+		address
+		Name     string            `json:"name"`
+		Age      uint              `json:"age,omitempty"`
+		Email    *string           `json:"email"`
+		Score    float64           `json:"-"`
+		Tags     []string          `json:"tags,omitempty"`
+		Data     []byte            `json:"data,omitempty"`
+		Meta     map[string]string `json:"meta,omitempty"`
+		Birthday time.Time         `json:"birthday,omitempty"`
+		Raw      json.RawMessage   `json:"raw,omitempty"`
+		Tree     node              `json:"tree,omitempty"`
+		Active   bool
+		secret   string
+	}
+)
+
+func TestFromType(t *testing.T) {
+	b, _ := json.Marshal(FromType(createUser{}))
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"city": {"type": "string"},
+			"name": {"type": "string"},
+			"age": {"type": "integer", "minimum": 0},
+			"email": {"type": ["string", "null"]},
+			"tags": {"type": ["array", "null"], "items": {"type": "string"}},
+			"data": {"type": "string"},
+			"meta": {"type": ["object", "null"]},
+			"birthday": {"type": "string", "format": "date-time"},
+			"raw": {},
+			"tree": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": ["array", "null"], "items": {}}
+				},
+				"required": ["name"]
+			},
+			"Active": {"type": "boolean"}
+		},
+		"required": ["city", "name", "Active"]
+	}`, string(b))
+
+	assert.Equal(t, &Schema{Type: Types{"object"}, Properties: map[string]*Schema{"city": {Type: Types{"string"}}},
+		Required: []string{"city"}}, FromType(&address{}))
+	assert.Equal(t, &Schema{}, FromType(nil))
+}
@@ -0,0 +1,270 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package jsonschema provides a handler that validates request bodies against JSON schemas for the ozzo routing package.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Schema is a JSON schema. It supports a commonly used subset of the JSON Schema keywords
+	// for describing the request bodies of APIs. The unsupported keywords are ignored.
+	Schema struct {
+		Type                 Types              `json:"type,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Format               string             `json:"format,omitempty"`
+
+		pattern *regexp.Regexp
+	}
+
+	// Types lists the allowed JSON types of a value, such as "object", "integer", or "null".
+	// It is encoded as a string if there is only one type.
+	Types []string
+
+	// ValidationError describes a value that does not conform to the schema.
+	ValidationError struct {
+		// Pointer is the JSON pointer (RFC 6901) locating the value in the document, e.g. "/items/0/name".
+		Pointer string `json:"pointer" xml:"pointer"`
+		// Message describes why the value is invalid.
+		Message string `json:"message" xml:"message"`
+	}
+)
+
+// UnmarshalJSON decodes the types from either a string or an array of strings.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = Types{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return fmt.Errorf("jsonschema: type must be a string or an array of strings")
+	}
+	*t = ss
+	return nil
+}
+
+// MarshalJSON encodes the types as a string if there is only one type.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Compile parses the given JSON schema document and prepares it for validation.
+func Compile(data []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.compile(""); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// MustCompile is like Compile but panics if the schema cannot be compiled.
+// It simplifies the initialization of the schemas stored in global variables or embedded files.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Load reads and compiles the JSON schema in the given file.
+func Load(filename string) (*Schema, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(data)
+}
+
+// compile compiles the regular expressions in the schema and its subschemas.
+func (s *Schema) compile(pointer string) (err error) {
+	if s.Pattern != "" {
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("jsonschema: invalid pattern at %q: %v", pointer+"/pattern", err)
+		}
+	}
+	for name, p := range s.Properties {
+		if err := p.compile(pointer + "/properties/" + escapePointer(name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(pointer + "/items")
+	}
+	return nil
+}
+
+// Validate validates the given value decoded from a JSON document (e.g. by json.Unmarshal into an interface{}).
+// It returns the validation errors ordered by their locations, or nil if the value conforms to the schema.
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	errs := s.validate(value, "", nil)
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Pointer < errs[j].Pointer
+	})
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, pointer string, errs []ValidationError) []ValidationError {
+	addError := func(format string, args ...interface{}) {
+		errs = append(errs, ValidationError{pointer, fmt.Sprintf(format, args...)})
+	}
+	if len(s.Type) > 0 && !s.hasType(value) {
+		addError("must be of type %v", strings.Join(s.Type, " or "))
+		return errs
+	}
+	if len(s.Enum) > 0 && !s.inEnum(value) {
+		addError("must be one of the allowed values")
+	}
+	switch v := value.(type) {
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			addError("must be at least %v characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			addError("must be at most %v characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			addError("must match the pattern %q", s.Pattern)
+		}
+		if msg := checkFormat(s.Format, v); msg != "" {
+			addError("%s", msg)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			addError("must be no less than %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			addError("must be no greater than %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			addError("must have at least %v items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			addError("must have at most %v items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				errs = s.Items.validate(item, fmt.Sprintf("%v/%v", pointer, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, ValidationError{pointer + "/" + escapePointer(name), "is required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p, ok := s.Properties[name]; ok {
+				errs = p.validate(v[name], pointer+"/"+escapePointer(name), errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, ValidationError{pointer + "/" + escapePointer(name), "is not allowed"})
+			}
+		}
+	}
+	return errs
+}
+
+// hasType checks if the value is of one of the types allowed by the schema.
+func (s *Schema) hasType(value interface{}) bool {
+	for _, t := range s.Type {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || t == "integer" && v == math.Trunc(v) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum checks if the value equals one of the enumerated values.
+func (s *Schema) inEnum(value interface{}) bool {
+	for _, e := range s.Enum {
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFormat checks the string against the named format and returns an error message if it does not conform.
+// Unknown formats are not checked.
+func checkFormat(format, value string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be a date-time in RFC 3339 format"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date in the format of YYYY-MM-DD"
+		}
+	case "email":
+		if i := strings.LastIndex(value, "@"); i <= 0 || i == len(value)-1 {
+			return "must be an email address"
+		}
+	}
+	return ""
+}
+
+// escapePointer escapes a JSON object member name as a JSON pointer reference token.
+func escapePointer(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
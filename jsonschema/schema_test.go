@@ -0,0 +1,103 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 5, "pattern": "^[a-z]+$"},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"email": {"type": ["string", "null"], "format": "email"},
+		"role": {"enum": ["admin", "user"]},
+		"a/b": {"type": "boolean"},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2}
+	},
+	"required": ["name", "age"],
+	"additionalProperties": false
+}`
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := Compile([]byte(userSchema))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	tests := []struct {
+		tag, data string
+		errs      []ValidationError
+	}{
+		{"t1", `{"name":"john","age":30}`, nil},
+		{"t2", `{"name":"john","age":30,"email":null,"role":"admin","a/b":true,"tags":["x"]}`, nil},
+		{"t3", `{}`, []ValidationError{{"/age", "is required"}, {"/name", "is required"}}},
+		{"t4", `[]`, []ValidationError{{"", "must be of type object"}}},
+		{"t5", `{"name":"j","age":1.5}`, []ValidationError{
+			{"/age", "must be of type integer"},
+			{"/name", "must be at least 2 characters long"},
+		}},
+		{"t6", `{"name":"John123","age":-1}`, []ValidationError{
+			{"/age", "must be no less than 0"},
+			{"/name", "must be at most 5 characters long"},
+			{"/name", `must match the pattern "^[a-z]+$"`},
+		}},
+		{"t7", `{"name":"john","age":30,"email":"john","role":"guest","a/b":1,"x":1}`, []ValidationError{
+			{"/a~1b", "must be of type boolean"},
+			{"/email", "must be an email address"},
+			{"/role", "must be one of the allowed values"},
+			{"/x", "is not allowed"},
+		}},
+		{"t8", `{"name":"john","age":30,"tags":[]}`, []ValidationError{{"/tags", "must have at least 1 items"}}},
+		{"t9", `{"name":"john","age":30,"tags":["a",1,2]}`, []ValidationError{
+			{"/tags", "must have at most 2 items"},
+			{"/tags/1", "must be of type string"},
+			{"/tags/2", "must be of type string"},
+		}},
+	}
+	for _, test := range tests {
+		var value interface{}
+		assert.Nil(t, json.Unmarshal([]byte(test.data), &value), test.tag)
+		assert.Equal(t, test.errs, s.Validate(value), test.tag)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	_, err := Compile([]byte(`{"properties": {"name": {"pattern": "("}}}`))
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `invalid pattern at "/properties/name/pattern"`)
+	}
+	_, err = Compile([]byte(`{"type": 1}`))
+	assert.NotNil(t, err)
+	assert.Panics(t, func() { MustCompile([]byte(`{`)) })
+
+	s := MustCompile([]byte(`{"type": "string", "format": "date-time"}`))
+	assert.Nil(t, s.Validate("2006-01-02T15:04:05Z"))
+	assert.Equal(t, []ValidationError{{"", "must be a date-time in RFC 3339 format"}}, s.Validate("2006-01-02"))
+
+	b, _ := json.Marshal(&Schema{Type: Types{"string"}, Items: &Schema{Type: Types{"integer", "null"}}})
+	assert.Equal(t, `{"type":"string","items":{"type":["integer","null"]}}`, string(b))
+}
+
+func TestLoad(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "jsonschema")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "user.json")
+	ioutil.WriteFile(filename, []byte(userSchema), 0644)
+
+	s, err := Load(filename)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"name", "age"}, s.Required)
+	}
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package kit assembles the recommended stack of the handlers in the ozzo routing package into a router,
+// so that a new application starts with a production-shaped baseline in a few lines.
+package kit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/access"
+	"github.com/go-ozzo/ozzo-routing/v2/content"
+	"github.com/go-ozzo/ozzo-routing/v2/cors"
+	"github.com/go-ozzo/ozzo-routing/v2/fault"
+	"github.com/go-ozzo/ozzo-routing/v2/file"
+	"github.com/go-ozzo/ozzo-routing/v2/health"
+)
+
+type (
+	// Options specifies the handlers assembled by New. The zero value gives a router with request IDs,
+	// access logging, panic recovery, error handling, JSON responses, and the health and metrics endpoints.
+	Options struct {
+		// Logger logs the requests and the errors. Defaults to the standard logger of the log package.
+		Logger routing.Logger
+		// CORS specifies the CORS policy of the router. Nil disables CORS.
+		CORS *cors.Options
+		// Formats are the response formats supported by the content negotiation. Defaults to JSON.
+		Formats []string
+		// APIPrefix is the path prefix of the API route group. Defaults to "/api".
+		APIPrefix string
+		// Auth authenticates the requests of the API route group, e.g. auth.JWT(key). Nil disables authentication.
+		Auth routing.Handler
+		// Static maps the URL paths to the directories of the static files, e.g. file.PathMap{"/": "/ui/dist/"}.
+		// The static files are served for the GET and HEAD requests matching no route. Nil disables serving static files.
+		Static file.PathMap
+		// StaticOptions specifies how the static files are served.
+		StaticOptions file.ServerOptions
+		// Health is the registry of the readiness checks. Defaults to health.DefaultRegistry.
+		Health *health.Registry
+		// HealthPath is the path prefix of the liveness ("/live") and readiness ("/ready") endpoints.
+		// Defaults to "/health". Use "-" to disable the endpoints.
+		HealthPath string
+		// MetricsPath is the path of the endpoint reporting the counters of the served requests (see routing.Stats).
+		// Defaults to "/metrics". Use "-" to disable counting and the endpoint.
+		MetricsPath string
+	}
+
+	// App is a router assembled by New, with a route group for the API routes.
+	App struct {
+		*routing.Router
+		// API is the route group of the API routes, whose requests are authenticated by Options.Auth.
+		API *routing.RouteGroup
+	}
+)
+
+// New creates an App whose router uses the recommended stack of handlers in the following order:
+// RequestID, the access logger, fault.Recovery, cors.Handler, and content.TypeNegotiator. It also adds
+// the health and metrics endpoints, and the static file server if Options.Static is set. For example,
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2/auth"
+//         "github.com/go-ozzo/ozzo-routing/v2/file"
+//         "github.com/go-ozzo/ozzo-routing/v2/kit"
+//     )
+//
+//     app := kit.New(kit.Options{
+//         Auth:   auth.JWT(verificationKey),
+//         Static: file.PathMap{"/": "/ui/dist/"},
+//     })
+//     app.API.Get("/users", listUsers)
+//     http.ListenAndServe(":8080", app)
+func New(opts ...Options) *App {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if len(options.Formats) == 0 {
+		options.Formats = []string{content.JSON}
+	}
+	if options.APIPrefix == "" {
+		options.APIPrefix = "/api"
+	}
+	if options.HealthPath == "" {
+		options.HealthPath = "/health"
+	}
+	if options.MetricsPath == "" {
+		options.MetricsPath = "/metrics"
+	}
+
+	router := routing.New()
+	if options.Logger != nil {
+		router.Use(
+			RequestID(),
+			access.LeveledLogger(options.Logger),
+			fault.Recovery(fault.LoggerFunc(options.Logger)),
+		)
+	} else {
+		router.Use(
+			RequestID(),
+			access.Logger(log.Printf),
+			fault.Recovery(log.Printf),
+		)
+	}
+	if options.CORS != nil {
+		router.Use(cors.Handler(*options.CORS))
+	}
+	router.Use(content.TypeNegotiator(options.Formats...))
+
+	if options.HealthPath != "-" {
+		router.Get(options.HealthPath+"/live", health.Live())
+		if options.Health != nil {
+			router.Get(options.HealthPath+"/ready", health.Ready(options.Health))
+		} else {
+			router.Get(options.HealthPath+"/ready", health.Ready())
+		}
+	}
+	if options.MetricsPath != "-" {
+		stats := &routing.Stats{}
+		router.Stats = stats
+		router.Get(options.MetricsPath, func(c *routing.Context) error {
+			return c.Write(stats.Snapshot())
+		})
+	}
+	if options.Static != nil {
+		// serve the static files as the NotFound handler, so that they do not shadow the routes added later
+		static := file.Server(options.Static, options.StaticOptions)
+		router.NotFound(routing.MethodNotAllowedHandler, func(c *routing.Context) error {
+			if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+				return routing.NotFoundHandler(c)
+			}
+			return static(c)
+		})
+	}
+
+	api := router.Group(options.APIPrefix)
+	if options.Auth != nil {
+		api.Use(options.Auth)
+	}
+	return &App{Router: router, API: api}
+}
+
+// RequestID returns a handler that assigns a random ID to each request without the routing.RequestIDHeader
+// header, and sends the request ID back in the same response header. The request ID is then logged with
+// routing.SlogAttrs and propagated by the client returned by routing.Context.HTTPClient.
+func RequestID() routing.Handler {
+	return func(c *routing.Context) error {
+		id := c.Request.Header.Get(routing.RequestIDHeader)
+		if id == "" {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return err
+			}
+			id = hex.EncodeToString(b)
+			c.Request.Header.Set(routing.RequestIDHeader, id)
+		}
+		c.Response.Header().Set(routing.RequestIDHeader, id)
+		return nil
+	}
+}
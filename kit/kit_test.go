@@ -0,0 +1,133 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package kit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/cors"
+	"github.com/go-ozzo/ozzo-routing/v2/file"
+	"github.com/go-ozzo/ozzo-routing/v2/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	registry := health.NewRegistry()
+	app := New(Options{
+		Auth: func(c *routing.Context) error {
+			if c.Request.Header.Get("Authorization") != "secret" {
+				return routing.NewHTTPError(http.StatusUnauthorized)
+			}
+			return nil
+		},
+		CORS:   &cors.AllowAll,
+		Health: registry,
+	})
+	app.API.Get("/users", func(c *routing.Context) error {
+		return c.Write([]string{"alice"})
+	})
+	app.Get("/panic", func(c *routing.Context) error {
+		panic("boom")
+	})
+	run := func(path string, header ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Origin", "http://example.com")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		res := httptest.NewRecorder()
+		app.ServeHTTP(res, req)
+		return res
+	}
+
+	res := run("/api/users", "Authorization", "secret")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `["alice"]`, strings.TrimSpace(res.Body.String()))
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Len(t, res.Header().Get(routing.RequestIDHeader), 32)
+	assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+
+	res = run("/api/users", routing.RequestIDHeader, "abc")
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Equal(t, "abc", res.Header().Get(routing.RequestIDHeader))
+
+	res = run("/panic")
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	res = run("/health/live")
+	assert.Equal(t, http.StatusOK, res.Code)
+	registry.Register("db", func(ctx context.Context) error {
+		return errors.New("down")
+	})
+	res = run("/health/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	res = run("/metrics")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), `"status_5xx":2`)
+
+	res = run("/missing")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestNewDisabled(t *testing.T) {
+	app := New(Options{HealthPath: "-", MetricsPath: "-", APIPrefix: "/v1"})
+	app.API.Get("/users", func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+	assert.Nil(t, app.Stats)
+	for path, status := range map[string]int{
+		"/v1/users":    http.StatusOK,
+		"/health/live": http.StatusNotFound,
+		"/metrics":     http.StatusNotFound,
+	} {
+		req, _ := http.NewRequest("GET", path, nil)
+		res := httptest.NewRecorder()
+		app.ServeHTTP(res, req)
+		assert.Equal(t, status, res.Code, path)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	c := routing.NewContext(res, req)
+	assert.Nil(t, RequestID()(c))
+	id := req.Header.Get(routing.RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, res.Header().Get(routing.RequestIDHeader))
+}
+
+func TestNewStatic(t *testing.T) {
+	app := New(Options{Static: file.PathMap{"/": "/testdata/"}, StaticOptions: file.ServerOptions{RootPath: "."}})
+	// the static files do not shadow the routes added after New
+	app.Get("/index.html", func(c *routing.Context) error {
+		return c.Write("route")
+	})
+	for _, test := range []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/hello.txt", http.StatusOK, "hello"},
+		{"GET", "/index.html", http.StatusOK, `"route"`},
+		{"GET", "/missing.txt", http.StatusNotFound, ""},
+		{"POST", "/hello.txt", http.StatusNotFound, ""},
+	} {
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		res := httptest.NewRecorder()
+		app.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.path)
+		if test.body != "" {
+			assert.Equal(t, test.body, strings.TrimSpace(res.Body.String()), test.path)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package limit
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// MaxConcurrent is the name of the route metadata item that specifies the maximum number of the requests of a route
+// that can be served concurrently. The value should be an int. It overrides Concurrency.Max.
+const MaxConcurrent = "limit.concurrent"
+
+type (
+	// Concurrency limits the number of the requests of each route that are served concurrently, so that a heavy
+	// route, such as a report export, cannot occupy all the workers and database connections and starve the other
+	// routes. The requests exceeding the limit of a route wait in a FIFO queue of the route, and are served in
+	// the order of their arrival. For example,
+	//
+	//     limiter := &limit.Concurrency{Max: 100, MaxQueue: 1000, Timeout: 10 * time.Second}
+	//     expvar.Publish("concurrency", limiter)
+	//
+	//     r := routing.New()
+	//     r.Use(limiter.Handler())
+	//     r.Get("/reports/export", exportReports).Set(limit.MaxConcurrent, 2)
+	//
+	// Concurrency implements expvar.Var, so the queue depths of the routes can be published via expvar.
+	// Concurrency is safe for concurrent use, but its fields should not be modified after the router starts
+	// serving requests.
+	Concurrency struct {
+		// Max is the maximum number of the requests of a route that can be served concurrently. It can be
+		// overridden per route using the route metadata named MaxConcurrent. Zero means no limit.
+		Max int
+		// MaxQueue is the maximum number of the requests of a route that can wait in the queue. The requests
+		// exceeding the queue are rejected with a 503 HTTP error. Zero means no limit.
+		MaxQueue int
+		// Timeout is the maximum time that a request can wait in the queue before it is rejected with
+		// a 503 HTTP error. Zero means waiting until the request is canceled.
+		Timeout time.Duration
+		// If set, it is called to log the rejected requests.
+		LogFunc LogFunc
+
+		mu     sync.Mutex
+		routes map[string]*routeQueue
+	}
+
+	// ConcurrencySnapshot holds the state of the concurrency limit of a route at some point.
+	ConcurrencySnapshot struct {
+		Active    int   `json:"active"`     // the number of the requests being served
+		Queued    int   `json:"queued"`     // the number of the requests waiting in the queue
+		MaxQueued int   `json:"max_queued"` // the maximum number of the requests that have waited in the queue at once
+		Rejected  int64 `json:"rejected"`   // the number of the requests rejected because the queue is full or timed out
+	}
+
+	// routeQueue tracks the requests of a route being served and waiting.
+	routeQueue struct {
+		active    int
+		waiting   []chan struct{}
+		maxQueued int
+		rejected  int64
+	}
+)
+
+// Handler returns a handler that limits the concurrent requests of the route matching the current request.
+// The requests matching no route are not limited. The upgrade requests, such as WebSocket handshakes, are not
+// limited either, unless the route sets the routing.UpgradePolicy metadata to routing.UpgradeLifetime, in which case
+// an upgraded connection holds its slot until it is closed.
+func (l *Concurrency) Handler() routing.Handler {
+	return func(c *routing.Context) error {
+		route := c.Route()
+		if route == nil || c.UpgradeExempted() {
+			return nil
+		}
+		max := l.Max
+		if n, ok := route.Meta(MaxConcurrent).(int); ok {
+			max = n
+		}
+		if max <= 0 {
+			return nil
+		}
+		key := route.String()
+		if err := l.acquire(c, key, max); err != nil {
+			return err
+		}
+		defer l.release(key)
+		return c.Next()
+	}
+}
+
+// Snapshot returns the current state of the concurrency limits of the routes that have served requests,
+// indexed by the routes, e.g. "GET /reports/export".
+func (l *Concurrency) Snapshot() map[string]ConcurrencySnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := make(map[string]ConcurrencySnapshot, len(l.routes))
+	for key, q := range l.routes {
+		result[key] = ConcurrencySnapshot{
+			Active:    q.active,
+			Queued:    len(q.waiting),
+			MaxQueued: q.maxQueued,
+			Rejected:  q.rejected,
+		}
+	}
+	return result
+}
+
+// String returns the current state of the concurrency limits in JSON. It implements expvar.Var.
+func (l *Concurrency) String() string {
+	b, _ := json.Marshal(l.Snapshot())
+	return string(b)
+}
+
+// acquire waits until the request can be served by the route of the given key. It returns an error
+// if the request is rejected or canceled while waiting.
+func (l *Concurrency) acquire(c *routing.Context, key string, max int) error {
+	l.mu.Lock()
+	if l.routes == nil {
+		l.routes = map[string]*routeQueue{}
+	}
+	q := l.routes[key]
+	if q == nil {
+		q = &routeQueue{}
+		l.routes[key] = q
+	}
+	if q.active < max && len(q.waiting) == 0 {
+		q.active++
+		l.mu.Unlock()
+		return nil
+	}
+	if l.MaxQueue > 0 && len(q.waiting) >= l.MaxQueue {
+		q.rejected++
+		l.mu.Unlock()
+		l.log(c, "the queue of route %v is full", key)
+		return routing.NewHTTPError(http.StatusServiceUnavailable)
+	}
+	ready := make(chan struct{})
+	q.waiting = append(q.waiting, ready)
+	if len(q.waiting) > q.maxQueued {
+		q.maxQueued = len(q.waiting)
+	}
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if l.Timeout > 0 {
+		timer := time.NewTimer(l.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	timedOut := false
+	select {
+	case <-ready:
+		return nil
+	case <-timeout:
+		err, timedOut = routing.NewHTTPError(http.StatusServiceUnavailable), true
+	case <-c.Request.Context().Done():
+		err = routing.NewHTTPError(routing.StatusClientClosedRequest, c.Request.Context().Err().Error())
+	}
+
+	l.mu.Lock()
+	for i, ch := range q.waiting {
+		if ch == ready {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			if timedOut {
+				q.rejected++
+			}
+			l.mu.Unlock()
+			if timedOut {
+				l.log(c, "the request timed out in the queue of route %v", key)
+			}
+			return err
+		}
+	}
+	l.mu.Unlock()
+	// the request has been granted a slot just before it gives up waiting
+	l.release(key)
+	return err
+}
+
+// release frees the slot taken by a request of the route of the given key, and hands it over to
+// the first request waiting in the queue, if any.
+func (l *Concurrency) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q := l.routes[key]
+	if len(q.waiting) > 0 {
+		close(q.waiting[0])
+		q.waiting = q.waiting[1:]
+		return
+	}
+	q.active--
+}
+
+// log logs a rejected request if LogFunc is set.
+func (l *Concurrency) log(c *routing.Context, format string, key string) {
+	if l.LogFunc != nil {
+		l.LogFunc("limit: %v %v rejected: "+format, c.Request.Method, c.Request.URL.Path, key)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package limit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrency(t *testing.T) {
+	limiter := &Concurrency{MaxQueue: 3}
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	router := routing.New()
+	router.Use(limiter.Handler())
+	router.Get("/export", func(c *routing.Context) error {
+		mu.Lock()
+		order = append(order, c.Query("id"))
+		mu.Unlock()
+		<-release
+		return c.Write("ok")
+	}).Set(MaxConcurrent, 1)
+	router.Get("/users", func(c *routing.Context) error {
+		return c.Write("users")
+	})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 4)
+	serve := func(i int, id string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/export?id="+id, nil)
+			router.ServeHTTP(res, req)
+			codes[i] = res.Code
+		}()
+	}
+	waitQueued := func(n int) {
+		for i := 0; i < 100 && limiter.Snapshot()["GET /export"].Queued != n; i++ {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	serve(0, "a")
+	for limiter.Snapshot()["GET /export"].Active != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for i, id := range []string{"b", "c", "d"} {
+		serve(i+1, id)
+		waitQueued(i + 1)
+	}
+	assert.Equal(t, ConcurrencySnapshot{Active: 1, Queued: 3, MaxQueued: 3}, limiter.Snapshot()["GET /export"])
+
+	// the queue is full
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export?id=e", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	// other routes are not limited
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "users", res.Body.String())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []string{"a", "b", "c", "d"}, order)
+	assert.Equal(t, []int{200, 200, 200, 200}, codes)
+	assert.Equal(t, ConcurrencySnapshot{MaxQueued: 3, Rejected: 1}, limiter.Snapshot()["GET /export"])
+	assert.Equal(t, `{"GET /export":{"active":0,"queued":0,"max_queued":3,"rejected":1}}`, limiter.String())
+}
+
+func TestConcurrencyTimeout(t *testing.T) {
+	var logs []string
+	limiter := &Concurrency{Max: 1, Timeout: 10 * time.Millisecond, LogFunc: func(format string, a ...interface{}) {
+		logs = append(logs, format)
+	}}
+	release := make(chan struct{})
+	router := routing.New()
+	router.Use(limiter.Handler())
+	router.Get("/export", func(c *routing.Context) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/export", nil)
+		router.ServeHTTP(res, req)
+		close(done)
+	}()
+	for limiter.Snapshot()["GET /export"].Active != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/export", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, 1, len(logs))
+	assert.Equal(t, ConcurrencySnapshot{Active: 1, MaxQueued: 1, Rejected: 1}, limiter.Snapshot()["GET /export"])
+
+	close(release)
+	<-done
+	assert.Equal(t, 0, limiter.Snapshot()["GET /export"].Active)
+}
+
+func TestConcurrencyUpgrade(t *testing.T) {
+	limiter := &Concurrency{Max: 1}
+	var active int
+	router := routing.New()
+	router.Use(limiter.Handler())
+	handler := func(c *routing.Context) error {
+		active = limiter.Snapshot()[c.Route().String()].Active
+		return nil
+	}
+	router.Get("/ws", handler)
+	router.Get("/ws/capped", handler).Set(routing.UpgradePolicy, routing.UpgradeLifetime)
+
+	for path, expected := range map[string]int{"/ws": 0, "/ws/capped": 1} {
+		active = -1
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, expected, active, path)
+	}
+}
+
+func TestConcurrencyUpgradeBody(t *testing.T) {
+	limiter := &Concurrency{Max: 1, Timeout: 10 * time.Millisecond}
+	release := make(chan struct{})
+	router := routing.New()
+	router.Use(limiter.Handler())
+	router.Post("/ws", func(c *routing.Context) error {
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		req, _ := http.NewRequest("POST", "/ws", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	for limiter.Snapshot()["POST /ws"].Active != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// a request with a body and the upgrade headers still waits in the queue
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/ws", strings.NewReader("abc"))
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "x")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, ConcurrencySnapshot{Active: 1, MaxQueued: 1, Rejected: 1}, limiter.Snapshot()["POST /ws"])
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,270 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package limit provides handlers that limit the size and the bandwidth of responses, the concurrent requests
+// of routes, and the request headers for the ozzo routing package.
+package limit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// MaxSize is the name of the route metadata item that specifies the maximum response body size (in bytes) of a route.
+// The value should be an int or int64. It overrides Options.MaxSize.
+const MaxSize = "limit.size"
+
+// ErrTooLarge is returned when writing a response body whose size exceeds the limit.
+var ErrTooLarge = errors.New("limit: response body too large")
+
+// LogFunc logs a message using the given format and optional arguments.
+// The usage of format and arguments is similar to that for fmt.Printf().
+// LogFunc should be thread safe.
+type LogFunc func(format string, a ...interface{})
+
+// Options specifies how the responses should be limited.
+type Options struct {
+	// The maximum number of bytes allowed in a response body. Zero means no limit.
+	MaxSize int64
+	// The maximum number of bytes per second that can be written for a response. Zero means no throttling.
+	Rate int64
+	// The maximum number of bytes that can be written at once when throttling. Defaults to Rate.
+	Burst int64
+	// If set, it is called to log the requests whose responses exceed the size limit.
+	LogFunc LogFunc
+}
+
+// Handler returns a handler that limits the size of the response body and optionally throttles
+// the bandwidth used to write the response.
+//
+// When writing the response body would exceed the size limit, the write is discarded and fails with ErrTooLarge,
+// and so do the subsequent writes. If nothing has been sent yet, the handler returns ErrTooLarge so that
+// a 500 HTTP error can be sent instead; otherwise the response is truncated. The size limit can be set per route
+// using the route metadata named MaxSize, like the following:
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/limit"
+//     )
+//
+//     r := routing.New()
+//     r.Use(limit.Handler(limit.Options{MaxSize: 1 << 20, Rate: 512 << 10, LogFunc: log.Printf}))
+//     r.Get("/reports", getReports).Set(limit.MaxSize, 100<<20)
+//
+// Throttling uses a token bucket that allows writing Burst bytes at once and refills at Rate bytes per second.
+// It stops waiting when the request is canceled. The bucket is shared by all responses sent over the same connection,
+// including the keep-alive requests and the HTTP/2 streams, if the server sets up the connections via ConnContext:
+//
+//     srv := &http.Server{Addr: ":8080", Handler: r, ConnContext: limit.ConnContext}
+//
+// Otherwise, each response is throttled with its own bucket.
+//
+// The upgrade requests, such as WebSocket handshakes, are not limited, because the upgraded connections are taken
+// over from the response writer.
+func Handler(opts Options) routing.Handler {
+	if opts.Burst <= 0 {
+		opts.Burst = opts.Rate
+	}
+	// key identifies the buckets of this handler among those of the connections
+	key := &opts
+	return func(c *routing.Context) error {
+		if c.IsUpgrade() {
+			return nil
+		}
+		maxSize := opts.MaxSize
+		if route := c.Route(); route != nil {
+			switch size := route.Meta(MaxSize).(type) {
+			case int:
+				maxSize = int64(size)
+			case int64:
+				maxSize = size
+			}
+		}
+		if maxSize <= 0 && opts.Rate <= 0 {
+			return nil
+		}
+
+		rw := &responseWriter{
+			ResponseWriter: c.Response,
+			req:            c.Request,
+			maxSize:        maxSize,
+			burst:          opts.Burst,
+		}
+		if opts.Rate > 0 {
+			rw.bucket = connBucket(c.Request.Context(), key)
+			if rw.bucket == nil {
+				rw.bucket = newBucket(opts.Rate, opts.Burst)
+			}
+		}
+		c.Response = rw
+		err := c.Next()
+		// errors should be written without the limits
+		c.Response = rw.ResponseWriter
+		if rw.exceeded {
+			if opts.LogFunc != nil {
+				opts.LogFunc("limit: response of %v %v exceeds %v bytes", c.Request.Method, c.Request.URL.Path, maxSize)
+			}
+			if err == nil && !rw.wroteHeader {
+				err = ErrTooLarge
+			}
+		}
+		return err
+	}
+}
+
+// connKey is the context key of the token buckets of a connection.
+type connKey struct{}
+
+// connBuckets holds the token buckets of a connection, indexed by the handlers throttling the responses.
+type connBuckets struct {
+	mu      sync.Mutex
+	buckets map[*Options]*bucket
+}
+
+// ConnContext returns a context of a new connection that makes Handler throttle all responses sent over
+// the connection with a shared token bucket. It should be set as http.Server.ConnContext.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, &connBuckets{})
+}
+
+// connBucket returns the token bucket of the given handler for the connection of the request context,
+// or nil if the connection is not set up via ConnContext.
+func connBucket(ctx context.Context, key *Options) *bucket {
+	cb, _ := ctx.Value(connKey{}).(*connBuckets)
+	if cb == nil {
+		return nil
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b := cb.buckets[key]
+	if b == nil {
+		if cb.buckets == nil {
+			cb.buckets = map[*Options]*bucket{}
+		}
+		b = newBucket(key.Rate, key.Burst)
+		cb.buckets[key] = b
+	}
+	return b
+}
+
+// bucket is a token bucket that refills at rate bytes per second up to burst bytes.
+// It is safe for concurrent use, e.g. by the HTTP/2 streams of a connection.
+type bucket struct {
+	mu     sync.Mutex
+	rate   int64
+	burst  int64
+	tokens int64
+	last   time.Time
+}
+
+func newBucket(rate, burst int64) *bucket {
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve takes the given number of tokens from the bucket and returns how long to wait before using them.
+func (b *bucket) reserve(size int64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		// the bucket is full after this long, which also keeps the multiplication below from overflowing
+		if full := time.Duration((b.burst - b.tokens) * int64(time.Second) / b.rate); elapsed > full {
+			elapsed = full
+		}
+		b.tokens += int64(elapsed) * b.rate / int64(time.Second)
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	b.tokens -= size
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * int64(time.Second) / b.rate)
+}
+
+// responseWriter wraps http.ResponseWriter in order to limit the size and the bandwidth of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	maxSize     int64
+	written     int64
+	exceeded    bool
+	wroteHeader bool
+	burst       int64
+	bucket      *bucket // nil if the response is not throttled
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.exceeded {
+		return 0, ErrTooLarge
+	}
+	if w.maxSize > 0 && w.written+int64(len(p)) > w.maxSize {
+		w.exceeded = true
+		return 0, ErrTooLarge
+	}
+	w.wroteHeader = true
+
+	n := 0
+	for len(p) > 0 {
+		chunk := p
+		if w.bucket != nil {
+			if int64(len(chunk)) > w.burst {
+				chunk = chunk[:w.burst]
+			}
+			if e := w.wait(int64(len(chunk))); e != nil {
+				return n, e
+			}
+		}
+		m, e := w.ResponseWriter.Write(chunk)
+		n += m
+		w.written += int64(m)
+		if e != nil {
+			return n, e
+		}
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// wait blocks until the token bucket has enough tokens for writing the given number of bytes.
+func (w *responseWriter) wait(size int64) error {
+	d := w.bucket.reserve(size)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-w.req.Context().Done():
+		return w.req.Context().Err()
+	}
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports flushing.
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package limit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var logs []string
+	logf := func(format string, a ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, a...))
+	}
+	router := routing.New()
+	router.Use(Handler(Options{MaxSize: 5, LogFunc: logf}))
+	router.Get("/small", func(c *routing.Context) error {
+		return c.Write("abcde")
+	})
+	router.Get("/large", func(c *routing.Context) error {
+		return c.Write("abcdef")
+	})
+	router.Get("/truncated", func(c *routing.Context) error {
+		c.Response.Write([]byte("abc"))
+		c.Response.Write([]byte("def"))
+		return nil
+	})
+	router.Get("/big", func(c *routing.Context) error {
+		return c.Write("abcdef")
+	}).Set(MaxSize, 10)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/small", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "abcde", res.Body.String())
+	assert.Empty(t, logs)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/large", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Equal(t, ErrTooLarge.Error()+"\n", res.Body.String())
+	assert.Equal(t, []string{"limit: response of GET /large exceeds 5 bytes"}, logs)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/truncated", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "abc", res.Body.String())
+	assert.Len(t, logs, 2)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/big", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "abcdef", res.Body.String())
+}
+
+func TestHandlerRate(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(Options{Rate: 1000, Burst: 100}))
+	router.Get("/", func(c *routing.Context) error {
+		return c.Write(strings.Repeat("a", 300))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	start := time.Now()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, 300, res.Body.Len())
+	// the first 100 bytes are sent immediately, the rest take about 200ms
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(res, req.WithContext(ctx))
+	assert.Equal(t, strings.Repeat("a", 100)+context.Canceled.Error()+"\n", res.Body.String())
+}
+
+func TestHandlerConn(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(Options{Rate: 1000, Burst: 100}))
+	router.Get("/", func(c *routing.Context) error {
+		c.Response.(http.Flusher).Flush()
+		return c.Write(strings.Repeat("a", 100))
+	})
+
+	// the requests over the same connection share the bucket
+	ctx := ConnContext(context.Background(), nil)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		router.ServeHTTP(res, req.WithContext(ctx))
+		assert.Equal(t, 100, res.Body.Len())
+		assert.True(t, res.Flushed)
+	}
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+}
+
+func TestBucket(t *testing.T) {
+	b := newBucket(512<<10, 512<<10)
+	assert.Equal(t, time.Duration(0), b.reserve(512<<10))
+	// a long idle gap refills the bucket without overflowing
+	b.last = time.Now().Add(-5 * time.Hour)
+	assert.Equal(t, time.Duration(0), b.reserve(512<<10))
+	assert.Equal(t, int64(0), b.tokens)
+	d := b.reserve(256 << 10)
+	assert.True(t, d > 400*time.Millisecond && d <= 500*time.Millisecond, d)
+}
+
+func TestHandlerUpgrade(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(Options{MaxSize: 1}))
+	router.Get("/ws", func(c *routing.Context) error {
+		_, ok := c.Response.(*responseWriter)
+		assert.False(t, ok)
+		return c.Write("upgraded")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "upgraded", res.Body.String())
+}
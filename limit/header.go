@@ -0,0 +1,70 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package limit
+
+import (
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// HeaderOptions specifies the limits of the request headers. The size of a header field is the length of its name
+// plus the length of its value. Each value of a header with multiple values counts as a separate field.
+type HeaderOptions struct {
+	// The maximum number of the header fields in a request. Zero means no limit.
+	MaxCount int
+	// The maximum size of a header field in bytes. Zero means no limit.
+	MaxSize int
+	// The maximum total size of the header fields in bytes. Zero means no limit.
+	MaxTotal int
+	// If set, it is called to log the rejected requests.
+	LogFunc LogFunc
+}
+
+// Headers returns a handler that rejects the requests whose headers exceed the given limits with
+// a 431 (Request Header Fields Too Large) HTTP error. While http.Server.MaxHeaderBytes limits the total size of
+// the request line and the headers, this handler also limits the number and the size of the individual fields,
+// which protects the handlers that allocate memory or do work per header field, such as those parsing cookies.
+//
+// The handler should be registered via Router.Pre, so that it checks the headers before the route matching
+// and any handlers registered via Use:
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/limit"
+//     )
+//
+//     r := routing.New()
+//     r.Pre(limit.Headers(limit.HeaderOptions{MaxCount: 100, MaxSize: 8 << 10, MaxTotal: 32 << 10}))
+func Headers(opts HeaderOptions) routing.Handler {
+	return func(c *routing.Context) error {
+		count, total := 0, 0
+		for name, values := range c.Request.Header {
+			for _, value := range values {
+				size := len(name) + len(value)
+				count++
+				total += size
+				if opts.MaxSize > 0 && size > opts.MaxSize {
+					return rejectHeaders(c, opts.LogFunc, "the header %v is too large", name)
+				}
+			}
+		}
+		if opts.MaxCount > 0 && count > opts.MaxCount {
+			return rejectHeaders(c, opts.LogFunc, "too many header fields: %v", count)
+		}
+		if opts.MaxTotal > 0 && total > opts.MaxTotal {
+			return rejectHeaders(c, opts.LogFunc, "the headers are too large: %v bytes", total)
+		}
+		return nil
+	}
+}
+
+// rejectHeaders logs a request rejected by Headers if logf is set, and returns the 431 HTTP error.
+func rejectHeaders(c *routing.Context, logf LogFunc, format string, arg interface{}) error {
+	if logf != nil {
+		logf("limit: %v %v rejected: "+format, c.Request.Method, c.Request.URL.Path, arg)
+	}
+	return routing.NewHTTPError(http.StatusRequestHeaderFieldsTooLarge)
+}
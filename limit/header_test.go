@@ -0,0 +1,63 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package limit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaders(t *testing.T) {
+	var logs []string
+	logf := func(format string, a ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, a...))
+	}
+	called := false
+	router := routing.New()
+	router.Pre(Headers(HeaderOptions{MaxCount: 3, MaxSize: 20, MaxTotal: 40, LogFunc: logf}))
+	router.Use(func(c *routing.Context) error {
+		called = true
+		return nil
+	})
+	router.Get("/", func(c *routing.Context) error {
+		return c.Write("ok")
+	})
+
+	tests := []struct {
+		id      string
+		headers [][2]string
+		status  int
+		log     string
+	}{
+		{"t1", nil, http.StatusOK, ""},
+		{"t2", [][2]string{{"A", "1"}, {"B", "2"}, {"C", "3"}}, http.StatusOK, ""},
+		{"t3", [][2]string{{"A", "1"}, {"A", "2"}, {"B", "3"}, {"C", "4"}}, http.StatusRequestHeaderFieldsTooLarge, "limit: GET / rejected: too many header fields: 4"},
+		{"t4", [][2]string{{"Cookie", strings.Repeat("x", 15)}}, http.StatusRequestHeaderFieldsTooLarge, "limit: GET / rejected: the header Cookie is too large"},
+		{"t5", [][2]string{{"A", strings.Repeat("x", 19)}, {"B", strings.Repeat("x", 19)}, {"C", "x"}}, http.StatusRequestHeaderFieldsTooLarge, "limit: GET / rejected: the headers are too large: 42 bytes"},
+	}
+	for _, test := range tests {
+		logs, called = nil, false
+		req, _ := http.NewRequest("GET", "/", nil)
+		for _, h := range test.headers {
+			req.Header.Add(h[0], h[1])
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.id)
+		if test.log == "" {
+			assert.Empty(t, logs, test.id)
+			assert.True(t, called, test.id)
+		} else {
+			assert.Equal(t, []string{test.log}, logs, test.id)
+			assert.False(t, called, test.id)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Logger is a minimal leveled logger shared by the handlers that log messages, such as those in the access and
+// fault packages. Each message may be followed by alternating keys and values describing the message, e.g.
+// Info("request served", "status", 200). The method set is compatible with *slog.Logger, which can be used as
+// a Logger directly. Use NewKeyValueLogger or NewLevelfLogger to adapt other logging libraries.
+// Logger should be thread safe.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// KeyValueLogger is implemented by the loggers that accept a message followed by alternating keys and values,
+// such as *zap.SugaredLogger.
+type KeyValueLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// LevelfLogger is implemented by the loggers that provide a printf-style method for each level,
+// such as *logrus.Logger, *logrus.Entry, and *zap.SugaredLogger.
+type LevelfLogger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+type keyValueLogger struct {
+	l KeyValueLogger
+}
+
+// NewKeyValueLogger returns a Logger which sends the messages and the key-value pairs to the given logger.
+//
+//     logger := routing.NewKeyValueLogger(zapLogger.Sugar())
+func NewKeyValueLogger(l KeyValueLogger) Logger {
+	return &keyValueLogger{l}
+}
+
+func (l *keyValueLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.l.Debugw(msg, keysAndValues...)
+}
+
+func (l *keyValueLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.l.Infow(msg, keysAndValues...)
+}
+
+func (l *keyValueLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.l.Warnw(msg, keysAndValues...)
+}
+
+func (l *keyValueLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.l.Errorw(msg, keysAndValues...)
+}
+
+type levelfLogger struct {
+	l LevelfLogger
+}
+
+// NewLevelfLogger returns a Logger which sends the messages to the given logger. The key-value pairs are appended
+// to the messages in the format of "key=value".
+//
+//     logger := routing.NewLevelfLogger(logrus.StandardLogger())
+func NewLevelfLogger(l LevelfLogger) Logger {
+	return &levelfLogger{l}
+}
+
+func (l *levelfLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.l.Debugf("%s", formatKeyValues(msg, keysAndValues))
+}
+
+func (l *levelfLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.l.Infof("%s", formatKeyValues(msg, keysAndValues))
+}
+
+func (l *levelfLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.l.Warnf("%s", formatKeyValues(msg, keysAndValues))
+}
+
+func (l *levelfLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.l.Errorf("%s", formatKeyValues(msg, keysAndValues))
+}
+
+// formatKeyValues appends the key-value pairs to the message in the format of "key=value".
+// Values containing spaces or quotes are quoted.
+func formatKeyValues(msg string, keysAndValues []interface{}) string {
+	var s strings.Builder
+	s.WriteString(msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{} = "MISSING"
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		v := fmt.Sprint(value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&s, " %v=%s", keysAndValues[i], v)
+	}
+	return s.String()
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bufferLogger struct {
+	buf bytes.Buffer
+}
+
+func (l *bufferLogger) log(level, msg string, keysAndValues []interface{}) {
+	fmt.Fprintf(&l.buf, "%v %v %v\n", level, msg, keysAndValues)
+}
+
+func (l *bufferLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.log("D", msg, keysAndValues)
+}
+
+func (l *bufferLogger) Infow(msg string, keysAndValues ...interface{}) {
+	l.log("I", msg, keysAndValues)
+}
+
+func (l *bufferLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.log("W", msg, keysAndValues)
+}
+
+func (l *bufferLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.log("E", msg, keysAndValues)
+}
+
+func (l *bufferLogger) Debugf(format string, args ...interface{}) {
+	l.log("D", fmt.Sprintf(format, args...), nil)
+}
+
+func (l *bufferLogger) Infof(format string, args ...interface{}) {
+	l.log("I", fmt.Sprintf(format, args...), nil)
+}
+
+func (l *bufferLogger) Warnf(format string, args ...interface{}) {
+	l.log("W", fmt.Sprintf(format, args...), nil)
+}
+
+func (l *bufferLogger) Errorf(format string, args ...interface{}) {
+	l.log("E", fmt.Sprintf(format, args...), nil)
+}
+
+func TestNewKeyValueLogger(t *testing.T) {
+	l := &bufferLogger{}
+	logger := NewKeyValueLogger(l)
+	logger.Debug("a", "k", 1)
+	logger.Info("b")
+	logger.Warn("c", "k", "v")
+	logger.Error("d", "k", 2, "x", true)
+	assert.Equal(t, "D a [k 1]\nI b []\nW c [k v]\nE d [k 2 x true]\n", l.buf.String())
+}
+
+func TestNewLevelfLogger(t *testing.T) {
+	l := &bufferLogger{}
+	logger := NewLevelfLogger(l)
+	logger.Debug("a", "k", 1)
+	logger.Info("b %v")
+	logger.Warn("c", "k", "a b", "e", "")
+	logger.Error("d", "k")
+	assert.Equal(t, "D a k=1 []\nI b %v []\nW c k=\"a b\" e=\"\" []\nE d k=MISSING []\n", l.buf.String())
+}
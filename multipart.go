@@ -0,0 +1,118 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+// MultipartOptions specifies the limits applied by Context.ReadParts when streaming a multipart request body.
+type MultipartOptions struct {
+	// The maximum number of bytes that can be read from a single part. Zero means no limit.
+	MaxPartSize int64
+	// The maximum number of parts allowed in the request body. Zero means no limit.
+	MaxParts int
+	// The media types (e.g. "image/png") allowed for the file parts. If empty, all media types are allowed.
+	AllowedTypes []string
+}
+
+// Part represents a single part in a multipart request body being streamed by Context.ReadParts.
+// Reading from a Part fails with a 413 HTTP error once MultipartOptions.MaxPartSize bytes have been read.
+type Part struct {
+	*multipart.Part
+	remaining int64
+	limited   bool
+}
+
+// Read reads the body of the part.
+func (p *Part) Read(b []byte) (int, error) {
+	if !p.limited {
+		return p.Part.Read(b)
+	}
+	if p.remaining < 0 {
+		return 0, errPartTooLarge
+	}
+	if int64(len(b)) > p.remaining+1 {
+		// read one more byte to detect if the limit is exceeded
+		b = b[:p.remaining+1]
+	}
+	n, err := p.Part.Read(b)
+	p.remaining -= int64(n)
+	if p.remaining < 0 {
+		return n + int(p.remaining), errPartTooLarge
+	}
+	return n, err
+}
+
+var (
+	errPartTooLarge  = NewHTTPError(http.StatusRequestEntityTooLarge, "multipart: part too large")
+	errTooManyParts  = NewHTTPError(http.StatusRequestEntityTooLarge, "multipart: too many parts")
+	errNotMultipart  = NewHTTPError(http.StatusUnsupportedMediaType, "request Content-Type isn't multipart")
+	errPartForbidden = NewHTTPError(http.StatusUnsupportedMediaType, "multipart: part media type not allowed")
+)
+
+// MultipartReader returns a MIME multipart reader if the current request is a multipart/form-data
+// or a multipart/mixed POST request. Use this method instead of Form or PostForm to process
+// the request body as a stream.
+func (c *Context) MultipartReader() (*multipart.Reader, error) {
+	return c.Request.MultipartReader()
+}
+
+// ReadParts streams the parts of a multipart request body and calls the given function for each part in order.
+// Unlike Form and PostForm, the parts are not buffered in memory or on disk, which makes ReadParts suitable
+// for handling very large uploads. The function is responsible for consuming the part body;
+// any unread data is discarded when moving to the next part.
+//
+// Optional MultipartOptions can be given to limit the number of parts, the size of each part, and
+// the media types of file parts. Violating these limits results in a 413 or 415 HTTP error.
+// If the function returns an error, ReadParts stops and returns the error.
+func (c *Context) ReadParts(fn func(*Part) error, opts ...MultipartOptions) error {
+	var options MultipartOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	mr, err := c.MultipartReader()
+	if err != nil {
+		return errNotMultipart
+	}
+	for n := 1; ; n++ {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if options.MaxParts > 0 && n > options.MaxParts {
+			p.Close()
+			return errTooManyParts
+		}
+		if p.FileName() != "" && !isPartTypeAllowed(p, options.AllowedTypes) {
+			p.Close()
+			return errPartForbidden
+		}
+		err = fn(&Part{Part: p, remaining: options.MaxPartSize, limited: options.MaxPartSize > 0})
+		p.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isPartTypeAllowed checks if the media type of the given part is in the allowed list.
+func isPartTypeAllowed(p *multipart.Part, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	t, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+	for _, allowed := range types {
+		if t == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMultipartRequest() *http.Request {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("name", "abc")
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="a.txt"`)
+	h.Set("Content-Type", "text/plain")
+	p, _ := w.CreatePart(h)
+	p.Write([]byte("0123456789"))
+	w.Close()
+	req, _ := http.NewRequest("POST", "/upload", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestContextReadParts(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), newMultipartRequest())
+	var names, contents []string
+	err := c.ReadParts(func(p *Part) error {
+		data, err := ioutil.ReadAll(p)
+		names = append(names, p.FormName())
+		contents = append(contents, string(data))
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"name", "file"}, names)
+	assert.Equal(t, []string{"abc", "0123456789"}, contents)
+
+	// part size limit
+	c = NewContext(httptest.NewRecorder(), newMultipartRequest())
+	err = c.ReadParts(func(p *Part) error {
+		_, err := ioutil.ReadAll(p)
+		return err
+	}, MultipartOptions{MaxPartSize: 5})
+	assert.Equal(t, errPartTooLarge, err)
+
+	// part count limit
+	c = NewContext(httptest.NewRecorder(), newMultipartRequest())
+	err = c.ReadParts(func(p *Part) error { return nil }, MultipartOptions{MaxParts: 1})
+	assert.Equal(t, errTooManyParts, err)
+
+	// allowed media types
+	c = NewContext(httptest.NewRecorder(), newMultipartRequest())
+	err = c.ReadParts(func(p *Part) error { return nil }, MultipartOptions{AllowedTypes: []string{"image/png"}})
+	assert.Equal(t, errPartForbidden, err)
+	c = NewContext(httptest.NewRecorder(), newMultipartRequest())
+	err = c.ReadParts(func(p *Part) error { return nil }, MultipartOptions{AllowedTypes: []string{"text/plain"}})
+	assert.Nil(t, err)
+
+	// not a multipart request
+	req, _ := http.NewRequest("POST", "/upload", strings.NewReader("abc"))
+	req.Header.Set("Content-Type", MIME_FORM)
+	c = NewContext(httptest.NewRecorder(), req)
+	assert.Equal(t, errNotMultipart, c.ReadParts(func(p *Part) error { return nil }))
+}
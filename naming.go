@@ -0,0 +1,262 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+type (
+	// JSONNaming converts between the names of the Go struct fields and the names of the JSON object members.
+	JSONNaming interface {
+		// Member returns the JSON member name of the given Go field name, e.g. "user_name" for "UserName".
+		Member(field string) string
+		// Field returns the Go field name of the given JSON member name, e.g. "UserName" for "user_name".
+		// The field name is matched with the struct fields case-insensitively, so "UserId" matches "UserID".
+		Field(member string) string
+	}
+
+	// NamingJSONCodec is a JSON codec that renames the JSON object members according to a naming strategy,
+	// so that the Go structs can be exchanged in a wire format such as snake_case without json tags on every field.
+	// The members whose names are given by json tags are kept as they are. For example, the following code makes
+	// all JSON data readers and writers use snake_case:
+	//
+	//     routing.DefaultJSONCodec = routing.NamingJSONCodec{Naming: routing.SnakeCase}
+	//
+	// Note that the keys of the maps are renamed as well, because they cannot be told from the struct fields
+	// in the JSON data.
+	NamingJSONCodec struct {
+		// Codec encodes and decodes the JSON data before the members are renamed. Defaults to StdJSONCodec.
+		Codec JSONCodec
+		// Naming is the naming strategy of the JSON object members, such as SnakeCase.
+		Naming JSONNaming
+		// Strict specifies whether Unmarshal should return an error if a member name in the JSON data
+		// does not follow the naming strategy, e.g. "userName" when using SnakeCase.
+		Strict bool
+	}
+
+	snakeCase struct{}
+	camelCase struct{}
+)
+
+var (
+	// SnakeCase names the JSON object members in snake_case, e.g. "user_id" for the field "UserID".
+	SnakeCase JSONNaming = snakeCase{}
+	// CamelCase names the JSON object members in lower camelCase, e.g. "userID" for the field "UserID".
+	CamelCase JSONNaming = camelCase{}
+)
+
+// Marshal returns the JSON encoding of v with the object members renamed according to the naming strategy.
+func (c NamingJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := c.codec().Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	tags := jsonTagNames(reflect.TypeOf(v))
+	return renameMembers(data, func(name string) (string, error) {
+		if tags[name] {
+			return name, nil
+		}
+		return c.Naming.Member(name), nil
+	})
+}
+
+// Unmarshal renames the object members in the JSON data to the Go field names according to the naming strategy,
+// and stores the result in the value pointed to by v.
+func (c NamingJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	tags := jsonTagNames(reflect.TypeOf(v))
+	data, err := renameMembers(data, func(name string) (string, error) {
+		if tags[name] {
+			return name, nil
+		}
+		field := c.Naming.Field(name)
+		if c.Strict && c.Naming.Member(field) != name {
+			return "", fmt.Errorf("the JSON member %q does not follow the naming strategy", name)
+		}
+		return field, nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.codec().Unmarshal(data, v)
+}
+
+func (c NamingJSONCodec) codec() JSONCodec {
+	if c.Codec == nil {
+		return StdJSONCodec{}
+	}
+	return c.Codec
+}
+
+func (snakeCase) Member(field string) string {
+	return joinWords(splitWords(field), "_")
+}
+
+func (snakeCase) Field(member string) string {
+	return titleWords(strings.Split(member, "_"))
+}
+
+func (camelCase) Member(field string) string {
+	words := splitWords(field)
+	if len(words) == 0 {
+		return field
+	}
+	return strings.ToLower(words[0]) + strings.Join(words[1:], "")
+}
+
+func (camelCase) Field(member string) string {
+	return titleWords([]string{member})
+}
+
+// splitWords splits a Go identifier into words, treating an acronym as a single word,
+// e.g. "HTTPServerID" into "HTTP", "Server", and "ID".
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		r, prev := runes[i], runes[i-1]
+		if r == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if !unicode.IsUpper(r) || prev == '_' {
+			continue
+		}
+		if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// joinWords joins the words in lower case with the separator.
+func joinWords(words []string, sep string) string {
+	return strings.ToLower(strings.Join(words, sep))
+}
+
+// titleWords concatenates the words with their first letters in upper case.
+func titleWords(words []string) string {
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// jsonTagNames returns the member names given by the json tags of the struct fields reachable from the type.
+func jsonTagNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	visited := map[reflect.Type]bool{}
+	var walk func(reflect.Type)
+	walk = func(t reflect.Type) {
+		if t == nil || visited[t] {
+			return
+		}
+		visited[t] = true
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			walk(t.Elem())
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+					names[name] = true
+				}
+				walk(field.Type)
+			}
+		}
+	}
+	walk(t)
+	return names
+}
+
+// renameMembers rewrites the JSON data with the object members renamed by the given function,
+// keeping the order of the members and the values as they are.
+func renameMembers(data []byte, rename func(string) (string, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	writeString := func(s string) {
+		enc.Encode(s)
+		// remove the newline appended by the encoder
+		buf.Truncate(buf.Len() - 1)
+	}
+
+	// each level records whether it is an object and the number of the tokens written in it
+	type level struct {
+		object bool
+		n      int
+	}
+	var levels []level
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		} else if err != nil {
+			return nil, err
+		}
+		if d, ok := token.(json.Delim); ok && (d == '}' || d == ']') {
+			buf.WriteByte(byte(d))
+			levels = levels[:len(levels)-1]
+			continue
+		}
+		if len(levels) > 0 {
+			l := &levels[len(levels)-1]
+			if l.object && l.n%2 == 0 {
+				if l.n > 0 {
+					buf.WriteByte(',')
+				}
+				name, err := rename(token.(string))
+				if err != nil {
+					return nil, err
+				}
+				writeString(name)
+				buf.WriteByte(':')
+				l.n++
+				continue
+			}
+			if !l.object && l.n > 0 {
+				buf.WriteByte(',')
+			}
+			l.n++
+		}
+		switch t := token.(type) {
+		case json.Delim:
+			buf.WriteByte(byte(t))
+			levels = append(levels, level{object: t == '{'})
+		case string:
+			writeString(t)
+		case json.Number:
+			buf.WriteString(string(t))
+		case bool:
+			fmt.Fprint(&buf, t)
+		case nil:
+			buf.WriteString("null")
+		}
+	}
+}
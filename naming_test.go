@@ -0,0 +1,88 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONNaming(t *testing.T) {
+	tests := []struct {
+		id, field, snake, camel string
+	}{
+		{"t1", "Name", "name", "name"},
+		{"t2", "UserName", "user_name", "userName"},
+		{"t3", "UserID", "user_id", "userID"},
+		{"t4", "ID", "id", "id"},
+		{"t5", "HTTPServerURL", "http_server_url", "httpServerURL"},
+		{"t6", "Address2Line", "address2_line", "address2Line"},
+		{"t7", "user_name", "user_name", "username"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.snake, SnakeCase.Member(test.field), test.id)
+		assert.Equal(t, test.camel, CamelCase.Member(test.field), test.id)
+	}
+	assert.Equal(t, "UserId", SnakeCase.Field("user_id"))
+	assert.Equal(t, "UserID", CamelCase.Field("userID"))
+}
+
+type namingAddress struct {
+	StreetName string
+	ZIPCode    string `json:"zip"`
+}
+
+type namingUser struct {
+	UserID    int
+	FirstName string
+	Addresses []namingAddress
+	Extra     map[string]int
+	Note      string `json:"NoteText"`
+}
+
+func TestNamingJSONCodec(t *testing.T) {
+	codec := NamingJSONCodec{Naming: SnakeCase}
+	user := namingUser{
+		UserID:    1,
+		FirstName: "<Tom>",
+		Addresses: []namingAddress{{"Main St", "12345"}},
+		Extra:     map[string]int{"LoginCount": 2},
+		Note:      "abc",
+	}
+	data, err := codec.Marshal(user)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"user_id":1,"first_name":"<Tom>","addresses":[{"street_name":"Main St","zip":"12345"}],"extra":{"login_count":2},"NoteText":"abc"}`, string(data))
+
+	var u namingUser
+	assert.Nil(t, codec.Unmarshal(data, &u))
+	user.Extra = map[string]int{"LoginCount": 2}
+	assert.Equal(t, user, u)
+
+	u = namingUser{}
+	assert.Nil(t, codec.Unmarshal([]byte(`{"firstName":"Tom","user_id":25}`), &u))
+	assert.Equal(t, "Tom", u.FirstName)
+	assert.Equal(t, 25, u.UserID)
+
+	codec.Strict = true
+	assert.NotNil(t, codec.Unmarshal([]byte(`{"firstName":"Tom"}`), &u))
+	assert.Nil(t, codec.Unmarshal([]byte(`{"first_name":"Tom","NoteText":null,"zip":"1"}`), &u))
+	assert.NotNil(t, codec.Unmarshal([]byte(`{"first_name":`), &u))
+
+	codec = NamingJSONCodec{Codec: StdJSONCodec{}, Naming: CamelCase}
+	data, err = codec.Marshal([]interface{}{namingAddress{"Main St", "1"}, nil, true, "x"})
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"streetName":"Main St","zip":"1"},null,true,"x"]`, string(data))
+
+	_, err = codec.Marshal(func() {})
+	assert.NotNil(t, err)
+
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"user_id":3,"first_name":"Tom"}`))
+	u = namingUser{}
+	assert.Nil(t, (&JSONDataReader{Codec: NamingJSONCodec{Naming: SnakeCase}}).Read(req, &u))
+	assert.Equal(t, namingUser{UserID: 3, FirstName: "Tom"}, u)
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Negotiation records the results of the content negotiation of a request. It is shared by the handlers that
+// negotiate different aspects of the response, such as the content type, the language, and the content coding,
+// so that the response carries the Vary and Content-Language headers covering all of them, and shared caches
+// do not serve a variant to the clients that asked for another one.
+//
+// The negotiating handlers record their results via Context.Negotiation and Context.Vary. For example,
+// a compression handler would do the following after choosing gzip based on the Accept-Encoding header:
+//
+//     c.Negotiation().Encoding = "gzip"
+//     c.Vary("Accept-Encoding")
+type Negotiation struct {
+	ContentType string   // the negotiated MIME type of the response, e.g. "application/json"
+	Language    string   // the negotiated language, which is sent as the Content-Language response header
+	Encoding    string   // the negotiated content coding of the response, e.g. "gzip"
+	Vary        []string // the request headers that the negotiated results depend on
+}
+
+// Negotiation returns the content negotiation results of the current request. It never returns nil.
+func (c *Context) Negotiation() *Negotiation {
+	if c.negotiation == nil {
+		c.negotiation = &Negotiation{}
+	}
+	return c.negotiation
+}
+
+// Vary records that the response depends on the given request headers. The headers are added to the
+// Vary response header unless they are already listed in it.
+func (c *Context) Vary(headers ...string) {
+	n := c.Negotiation()
+	for _, name := range headers {
+		if name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name)); name != "" && !containsFold(n.Vary, name) {
+			n.Vary = append(n.Vary, name)
+		}
+	}
+	n.Apply(c.Response.Header())
+}
+
+// Apply adds the recorded Vary headers to the given response headers, and sets the Content-Language header
+// to the negotiated language if it is not set. It is called by Context.Vary, and can be called again right before
+// the response is sent, in case a handler has overwritten the headers.
+func (n *Negotiation) Apply(header http.Header) {
+	if n.Language != "" && header.Get("Content-Language") == "" {
+		header.Set("Content-Language", n.Language)
+	}
+	if len(n.Vary) == 0 {
+		return
+	}
+	var values []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "*" {
+				return
+			} else if name != "" && !containsFold(values, name) {
+				values = append(values, name)
+			}
+		}
+	}
+	n0 := len(values)
+	for _, name := range n.Vary {
+		if !containsFold(values, name) {
+			values = append(values, name)
+		}
+	}
+	if len(values) > n0 || len(header["Vary"]) > 1 {
+		header.Set("Vary", strings.Join(values, ", "))
+	}
+}
+
+// containsFold reports whether the list contains the given string under case-insensitive comparison.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
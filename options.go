@@ -0,0 +1,113 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+)
+
+// RouterOptions specifies the options for creating a Router by NewWithOptions.
+type RouterOptions struct {
+	IgnoreTrailingSlash bool   // whether to ignore trailing slashes in the end of the request URL
+	UseEscapedPath      bool   // whether to use encoded URL instead of decoded URL to match routes
+	TrustForwardedProto bool   // whether to use the X-Forwarded-Proto header to determine the request scheme
+	StrictPath          bool   // whether to reject the request paths containing empty segments, NUL, or control characters
+	AllowUnnamedParams  bool   // whether to allow parameter tokens without names in route paths (e.g. "<:\d+>")
+	Timing              bool   // whether to record the execution time of each handler (see Context.HandlerTimings)
+	DebugHeader         string // the request header which, when present, enables recording the route matching trace
+	// DisableAutoOptions disables responding to the OPTIONS requests and the requests with unsupported methods
+	// with the Allow header listing the methods of the routes matching the request paths (see MethodNotAllowedHandler).
+	DisableAutoOptions bool
+	// AutoHead indicates whether a HEAD request matching no HEAD route should be handled by the GET route
+	// matching it. The HTTP server discards the response body written by the GET route.
+	AutoHead bool
+	// MaxBodySize is the maximum number of bytes allowed in a request body. The requests whose Content-Length
+	// exceeds the limit are rejected with 413 (Request Entity Too Large), and reading beyond the limit fails.
+	// The upgrade requests, such as WebSocket handshakes, are not limited. Zero means no limit.
+	MaxBodySize int64
+	// Context is the base context of the router, which is passed to the background tasks (see Router.Every)
+	// and returned by Context.BaseContext for every request. It is canceled by Router.Shutdown.
+	// Defaults to context.Background().
+	Context context.Context
+	// Values are the root data items which can be retrieved by Context.Get in every request unless they are
+	// overridden by Context.Set. They are usually shared dependencies, such as database connections or loggers.
+	Values map[string]interface{}
+}
+
+// NewWithOptions creates a new Router configured with the given options. Unlike setting the fields of a Router
+// created by New, the options are validated and cannot be changed while the router is serving requests. An error
+// is returned if any option is invalid. For example,
+//
+//     router, err := routing.NewWithOptions(routing.RouterOptions{
+//         IgnoreTrailingSlash: true,
+//         StrictPath:          true,
+//         AutoHead:            true,
+//         MaxBodySize:         10 << 20,
+//     })
+func NewWithOptions(opts RouterOptions) (*Router, error) {
+	if opts.MaxBodySize < 0 {
+		return nil, errors.New("routing: MaxBodySize must not be negative")
+	}
+	if opts.DebugHeader != "" && strings.ContainsAny(opts.DebugHeader, " :\t\r\n") {
+		return nil, errors.New("routing: invalid DebugHeader " + opts.DebugHeader)
+	}
+
+	r := New()
+	if opts.Context != nil {
+		r.cancel()
+		r.ctx, r.cancel = context.WithCancel(opts.Context)
+	}
+	r.IgnoreTrailingSlash = opts.IgnoreTrailingSlash
+	r.UseEscapedPath = opts.UseEscapedPath
+	r.TrustForwardedProto = opts.TrustForwardedProto
+	r.StrictPath = opts.StrictPath
+	r.AllowUnnamedParams = opts.AllowUnnamedParams
+	r.Timing = opts.Timing
+	if opts.DebugHeader != "" {
+		r.DebugHeader = textproto.CanonicalMIMEHeaderKey(opts.DebugHeader)
+	}
+	if opts.DisableAutoOptions {
+		r.NotFound(NotFoundHandler)
+	}
+	if len(opts.Values) > 0 {
+		r.values = make(map[string]interface{}, len(opts.Values))
+		for name, value := range opts.Values {
+			r.values[name] = value
+		}
+	}
+	r.autoHead = opts.AutoHead
+	r.maxBodySize = opts.MaxBodySize
+	return r, nil
+}
+
+// NewWithContext creates a new Router whose base context derives from the given context. The base context can be
+// obtained by the handlers via Context.BaseContext, and it is canceled by Router.Shutdown or when the given context
+// is canceled. This allows the long-running handlers, such as those streaming events, to stop when the server shuts
+// down. Root values can be optionally given, which every request can retrieve via Context.Get. For example,
+//
+//     router := routing.NewWithContext(ctx, map[string]interface{}{"db": db})
+//     router.Get("/events", func(c *routing.Context) error {
+//         db := c.Get("db").(*sql.DB)
+//         select {
+//         case <-c.BaseContext().Done():
+//             ...
+//         }
+//     })
+func NewWithContext(ctx context.Context, values ...map[string]interface{}) *Router {
+	if ctx == nil {
+		panic("routing: nil context")
+	}
+	opts := RouterOptions{Context: ctx, Values: map[string]interface{}{}}
+	for _, v := range values {
+		for name, value := range v {
+			opts.Values[name] = value
+		}
+	}
+	r, _ := NewWithOptions(opts)
+	return r
+}
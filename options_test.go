@@ -0,0 +1,161 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	router, err := NewWithOptions(RouterOptions{
+		IgnoreTrailingSlash: true,
+		StrictPath:          true,
+		Timing:              true,
+		DebugHeader:         "x-debug-route",
+	})
+	if assert.Nil(t, err) {
+		assert.True(t, router.IgnoreTrailingSlash)
+		assert.True(t, router.StrictPath)
+		assert.True(t, router.Timing)
+		assert.False(t, router.UseEscapedPath)
+		assert.Equal(t, "X-Debug-Route", router.DebugHeader)
+	}
+
+	_, err = NewWithOptions(RouterOptions{MaxBodySize: -1})
+	assert.NotNil(t, err)
+	_, err = NewWithOptions(RouterOptions{DebugHeader: "X-Debug: 1"})
+	assert.NotNil(t, err)
+}
+
+func TestRouterAutoHead(t *testing.T) {
+	router, _ := NewWithOptions(RouterOptions{AutoHead: true})
+	router.Get("/users", func(c *Context) error {
+		return c.Write("get " + c.Request.Method)
+	})
+	router.Head("/items", func(c *Context) error {
+		return c.Write("head")
+	})
+	router.Get("/items", func(c *Context) error {
+		return c.Write("get")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "get HEAD", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/items", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "head", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("OPTIONS", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "GET, HEAD, OPTIONS", res.Header().Get("Allow"))
+
+	// HEAD is not routed to GET by default
+	router = New()
+	router.Get("/users", func(c *Context) error {
+		return c.Write("get")
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("HEAD", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+}
+
+func TestRouterMaxBodySize(t *testing.T) {
+	router, _ := NewWithOptions(RouterOptions{MaxBodySize: 5})
+	router.Post("/users", func(c *Context) error {
+		data, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			return NewHTTPError(http.StatusRequestEntityTooLarge)
+		}
+		return c.Write(string(data))
+	})
+
+	tests := []struct {
+		tag, body string
+		length    int64
+		status    int
+	}{
+		{"t1", "abc", 3, http.StatusOK},
+		{"t2", "abcdef", 6, http.StatusRequestEntityTooLarge},
+		{"t3", "abcdef", -1, http.StatusRequestEntityTooLarge},
+		{"t4", "abcde", -1, http.StatusOK},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/users", strings.NewReader(test.body))
+		req.ContentLength = test.length
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+	}
+}
+
+func TestRouterDisableAutoOptions(t *testing.T) {
+	router, _ := NewWithOptions(RouterOptions{DisableAutoOptions: true})
+	router.Get("/users", func(c *Context) error {
+		return c.Write("get")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "", res.Header().Get("Allow"))
+}
+
+func TestRouterOptionsContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	router, _ := NewWithOptions(RouterOptions{Context: parent})
+	stopped := make(chan bool, 1)
+	go func() {
+		<-router.ctx.Done()
+		stopped <- true
+	}()
+	cancel()
+	assert.True(t, <-stopped)
+	assert.Nil(t, router.Shutdown(context.Background()))
+}
+
+func TestNewWithContext(t *testing.T) {
+	type dbKey struct{}
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router := NewWithContext(parent, map[string]interface{}{"db": "users-db", "user": "nobody"})
+	router.ContextKeys = map[string]interface{}{"db": dbKey{}}
+	var base context.Context
+	router.Get("/users", func(c *Context) error {
+		base = c.BaseContext()
+		c.Set("user", "admin")
+		return c.Write(fmt.Sprintf("%v %v %v", c.Get("db"), c.Get("user"), c.Get("missing")))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "users-db admin <nil>", res.Body.String())
+	if assert.NotNil(t, base) {
+		assert.Nil(t, base.Err())
+		assert.Nil(t, router.Shutdown(context.Background()))
+		assert.Equal(t, context.Canceled, base.Err())
+	}
+
+	assert.Equal(t, context.Background(), NewContext(nil, nil).BaseContext())
+	assert.Panics(t, func() {
+		NewWithContext(nil)
+	})
+}
@@ -0,0 +1,432 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// MergePatch is a JSON Merge Patch document (RFC 7386). The members of the patch replace those of the target
+	// document, except that the null members remove them. For example,
+	//
+	//     router.Patch("/users/<id>", func(c *routing.Context) error {
+	//         var patch routing.MergePatch
+	//         if err := c.Read(&patch); err != nil {
+	//             return routing.NewHTTPError(http.StatusBadRequest, err.Error())
+	//         }
+	//         user := loadUser(c.Param("id"))
+	//         if err := patch.ApplyTo(&user); err != nil {
+	//             return err
+	//         }
+	//         ...
+	//     })
+	MergePatch json.RawMessage
+
+	// JSONPatch is a JSON Patch document (RFC 6902), which is a list of operations applied to the target document
+	// in order. The operations are applied either all or none.
+	JSONPatch []PatchOperation
+
+	// PatchOperation is an operation of a JSON Patch document.
+	PatchOperation struct {
+		Op    string          `json:"op"`              // "add", "remove", "replace", "move", "copy", or "test"
+		Path  string          `json:"path"`            // the JSON pointer (RFC 6901) of the target location
+		From  string          `json:"from,omitempty"`  // the JSON pointer of the source location of "move" and "copy"
+		Value json.RawMessage `json:"value,omitempty"` // the value of "add", "replace", and "test"
+	}
+
+	// MergePatchDataReader reads a JSON Merge Patch request body. The body is stored as is if it is read into
+	// a MergePatch. Otherwise it is decoded into the given data, whose existing members are kept unless they are
+	// in the patch. Note that a null member sets the corresponding map, pointer, or slice to nil, and has no effect
+	// on other types.
+	MergePatchDataReader struct{}
+
+	// JSONPatchDataReader reads a JSON Patch request body. When it is read into a JSONPatch, the operations are
+	// validated and an error is returned if any of them is malformed.
+	JSONPatchDataReader struct{}
+)
+
+func (r *MergePatchDataReader) Read(req *http.Request, data interface{}) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if !json.Valid(body) {
+		return errors.New("invalid JSON merge patch")
+	}
+	if patch, ok := data.(*MergePatch); ok {
+		*patch = MergePatch(body)
+		return nil
+	}
+	return json.Unmarshal(body, data)
+}
+
+func (r *JSONPatchDataReader) Read(req *http.Request, data interface{}) error {
+	if err := json.NewDecoder(req.Body).Decode(data); err != nil {
+		return err
+	}
+	if patch, ok := data.(*JSONPatch); ok {
+		return patch.Validate()
+	}
+	return nil
+}
+
+// Apply applies the patch to the given JSON document and returns the patched document.
+func (p MergePatch) Apply(doc []byte) ([]byte, error) {
+	var target, patch interface{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := decodePatchJSON(doc, &target); err != nil {
+			return nil, err
+		}
+	}
+	if err := decodePatchJSON(p, &patch); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergePatch(target, patch))
+}
+
+// ApplyTo applies the patch to the given value, which must be a non-nil pointer. The value is encoded as JSON,
+// patched, and decoded back into the value after it is reset to its zero value, so the removed members are cleared.
+func (p MergePatch) ApplyTo(v interface{}) error {
+	return applyPatchTo(v, p.Apply)
+}
+
+// mergePatch returns the result of applying the merge patch to the target as described by RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+		} else {
+			t[name] = mergePatch(t[name], value)
+		}
+	}
+	return t
+}
+
+// Validate checks that every operation of the patch is well-formed.
+func (p JSONPatch) Validate() error {
+	for i, op := range p {
+		if err := op.validate(); err != nil {
+			return errors.New("operation " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Apply applies the operations of the patch to the given JSON document in order and returns the patched document.
+// A 409 (Conflict) HTTP error is returned if a "test" operation fails, and a 422 (Unprocessable Entity) HTTP error
+// is returned if an operation cannot be applied, e.g. because its path does not exist.
+func (p JSONPatch) Apply(doc []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	var target interface{}
+	if err := decodePatchJSON(doc, &target); err != nil {
+		return nil, err
+	}
+	for i, op := range p {
+		var err error
+		if target, err = op.apply(target); err != nil {
+			if httpErr, ok := err.(HTTPError); ok {
+				return nil, httpErr
+			}
+			return nil, NewHTTPError(http.StatusUnprocessableEntity, "operation "+strconv.Itoa(i)+": "+err.Error())
+		}
+	}
+	return json.Marshal(target)
+}
+
+// ApplyTo applies the patch to the given value, which must be a non-nil pointer. The value is encoded as JSON,
+// patched, and decoded back into the value after it is reset to its zero value, so the removed members are cleared.
+func (p JSONPatch) ApplyTo(v interface{}) error {
+	return applyPatchTo(v, p.Apply)
+}
+
+func (op PatchOperation) validate() error {
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return errors.New(`"value" is required by "` + op.Op + `"`)
+		}
+	case "move", "copy":
+		if _, err := parsePointer(op.From); err != nil {
+			return err
+		}
+		if op.Op == "move" && strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return errors.New("cannot move a value into its own child")
+		}
+	case "remove":
+	default:
+		return errors.New(`unknown operation "` + op.Op + `"`)
+	}
+	_, err := parsePointer(op.Path)
+	return err
+}
+
+// apply applies the operation to the document and returns the resulting document.
+func (op PatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, _ := parsePointer(op.Path)
+	var value interface{}
+	if len(op.Value) > 0 {
+		if err := decodePatchJSON(op.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+	switch op.Op {
+	case "test":
+		current, err := pointerValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, NewHTTPError(http.StatusConflict, "test failed at "+op.Path)
+		}
+		return doc, nil
+	case "move", "copy":
+		from, _ := parsePointer(op.From)
+		current, err := pointerValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = patchPointer(doc, from, "remove", nil); err != nil {
+				return nil, err
+			}
+			return patchPointer(doc, path, "add", current)
+		}
+		// copy the value so that the source and the target do not share the maps and slices
+		data, _ := json.Marshal(current)
+		decodePatchJSON(data, &value)
+		return patchPointer(doc, path, "add", value)
+	}
+	return patchPointer(doc, path, op.Op, value)
+}
+
+// parsePointer parses a JSON pointer (RFC 6901) into the reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.New(`invalid JSON pointer "` + pointer + `"`)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// pointerValue returns the value at the location referenced by the tokens.
+func pointerValue(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			value, ok := d[token]
+			if !ok {
+				return nil, errors.New(`member "` + token + `" not found`)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(d)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, errors.New(`cannot reference "` + token + `" in a scalar value`)
+		}
+	}
+	return doc, nil
+}
+
+// patchPointer adds, removes, or replaces the value at the location referenced by the tokens,
+// and returns the resulting document.
+func patchPointer(doc interface{}, tokens []string, op string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		if op == "remove" {
+			return nil, nil
+		}
+		return value, nil
+	}
+	token, rest := tokens[0], tokens[1:]
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		child, ok := d[token]
+		if !ok && (len(rest) > 0 || op != "add") {
+			return nil, errors.New(`member "` + token + `" not found`)
+		}
+		if len(rest) > 0 {
+			child, err := patchPointer(child, rest, op, value)
+			if err != nil {
+				return nil, err
+			}
+			d[token] = child
+		} else if op == "remove" {
+			delete(d, token)
+		} else {
+			d[token] = value
+		}
+		return d, nil
+	case []interface{}:
+		if len(rest) == 0 && op == "add" {
+			i := len(d)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(d)); err != nil {
+					return nil, err
+				}
+			}
+			d = append(d, nil)
+			copy(d[i+1:], d[i:])
+			d[i] = value
+			return d, nil
+		}
+		i, err := arrayIndex(token, len(d)-1)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(rest) > 0:
+			if d[i], err = patchPointer(d[i], rest, op, value); err != nil {
+				return nil, err
+			}
+		case op == "remove":
+			d = append(d[:i], d[i+1:]...)
+		default:
+			d[i] = value
+		}
+		return d, nil
+	}
+	return nil, errors.New(`cannot reference "` + token + `" in a scalar value`)
+}
+
+// arrayIndex parses an array index token which must not exceed max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || token != strconv.Itoa(i) {
+		return 0, errors.New(`invalid array index "` + token + `"`)
+	}
+	return i, nil
+}
+
+// jsonEqual reports whether two decoded JSON values are equal. Numbers are compared by their values.
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, err1 := x.Float64()
+		fy, err2 := y.Float64()
+		return err1 == nil && err2 == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for name, value := range x {
+			if other, ok := y[name]; !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// decodePatchJSON decodes the JSON data while keeping the numbers as json.Number to avoid losing precision.
+func decodePatchJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// applyPatchTo applies a patch function to the JSON encoding of the value pointed to by v.
+func applyPatchTo(v interface{}, apply func([]byte) ([]byte, error)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("the value to be patched must be a non-nil pointer")
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if doc, err = apply(doc); err != nil {
+		return err
+	}
+	rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	if err = json.Unmarshal(doc, v); err != nil {
+		return NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	return nil
+}
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since request headers (RFC 7232) against the current
+// ETag and the modification time of the resource, and returns a 412 (Precondition Failed) HTTP error if they are
+// not met. An empty etag means that the resource does not exist, and a zero modtime is ignored. The handlers
+// performing conditional updates call it before modifying the resource, so that the clients do not overwrite
+// the changes made by others:
+//
+//     router.Patch("/users/<id>", func(c *routing.Context) error {
+//         user := loadUser(c.Param("id"))
+//         if err := c.CheckPreconditions(user.ETag(), user.Updated); err != nil {
+//             return err
+//         }
+//         ...
+//     })
+func (c *Context) CheckPreconditions(etag string, modtime time.Time) error {
+	if im := c.Request.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag) {
+			return NewHTTPError(http.StatusPreconditionFailed)
+		}
+	} else if ius := c.Request.Header.Get("If-Unmodified-Since"); ius != "" && !modtime.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && modtime.Truncate(time.Second).After(t) {
+			return NewHTTPError(http.StatusPreconditionFailed)
+		}
+	}
+	return nil
+}
+
+// matchETag reports whether the If-Match header value matches the given ETag using the strong comparison.
+func matchETag(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
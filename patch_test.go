@@ -0,0 +1,146 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		tag, doc, patch, expected string
+	}{
+		{"t1", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"t2", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"t3", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"t4", `{"a":{"b":"c","d":1}}`, `{"a":{"b":null,"e":2}}`, `{"a":{"d":1,"e":2}}`},
+		{"t5", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"t6", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"t7", ``, `{"a":{"b":null}}`, `{"a":{}}`},
+	}
+	for _, test := range tests {
+		result, err := MergePatch(test.patch).Apply([]byte(test.doc))
+		if assert.Nil(t, err, test.tag) {
+			assert.JSONEq(t, test.expected, string(result), test.tag)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := `{"name":"a","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`
+	tests := []struct {
+		tag, patch, expected string
+		status               int
+	}{
+		{"t1", `[{"op":"replace","path":"/name","value":"b"}]`, `{"name":"b","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`, 0},
+		{"t2", `[{"op":"add","path":"/tags/1","value":"z"},{"op":"add","path":"/tags/-","value":"w"}]`, `{"name":"a","tags":["x","z","y","w"],"meta":{"a/b":1,"m~n":2}}`, 0},
+		{"t3", `[{"op":"remove","path":"/meta/a~1b"},{"op":"remove","path":"/tags/0"}]`, `{"name":"a","tags":["y"],"meta":{"m~n":2}}`, 0},
+		{"t4", `[{"op":"move","from":"/meta/m~0n","path":"/count"}]`, `{"name":"a","tags":["x","y"],"meta":{"a/b":1},"count":2}`, 0},
+		{"t5", `[{"op":"copy","from":"/tags","path":"/labels"},{"op":"add","path":"/labels/-","value":"z"}]`, `{"name":"a","tags":["x","y"],"labels":["x","y","z"],"meta":{"a/b":1,"m~n":2}}`, 0},
+		{"t6", `[{"op":"test","path":"/meta/a~1b","value":1.0},{"op":"replace","path":"/name","value":"c"}]`, `{"name":"c","tags":["x","y"],"meta":{"a/b":1,"m~n":2}}`, 0},
+		{"t7", `[{"op":"test","path":"/name","value":"b"}]`, ``, http.StatusConflict},
+		{"t8", `[{"op":"replace","path":"/missing","value":1}]`, ``, http.StatusUnprocessableEntity},
+		{"t9", `[{"op":"remove","path":"/tags/2"}]`, ``, http.StatusUnprocessableEntity},
+		{"t10", `[{"op":"add","path":"/tags/01","value":1}]`, ``, http.StatusUnprocessableEntity},
+		{"t11", `[{"op":"move","from":"/meta","path":"/meta/x"}]`, ``, http.StatusUnprocessableEntity},
+		{"t12", `[{"op":"unknown","path":"/name"}]`, ``, http.StatusUnprocessableEntity},
+		{"t13", `[{"op":"add","path":"/name"}]`, ``, http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("PATCH", "/users/1", strings.NewReader(test.patch))
+		req.Header.Set("Content-Type", MIME_JSON_PATCH)
+		c := NewContext(httptest.NewRecorder(), req)
+		var patch JSONPatch
+		err := c.Read(&patch)
+		if err == nil {
+			var result []byte
+			if result, err = patch.Apply([]byte(doc)); err == nil {
+				assert.Equal(t, 0, test.status, test.tag)
+				assert.JSONEq(t, test.expected, string(result), test.tag)
+				continue
+			}
+			if assert.Implements(t, (*HTTPError)(nil), err, test.tag) {
+				assert.Equal(t, test.status, err.(HTTPError).StatusCode(), test.tag)
+			}
+		} else {
+			assert.Equal(t, http.StatusUnprocessableEntity, test.status, test.tag)
+		}
+	}
+}
+
+func TestPatchApplyTo(t *testing.T) {
+	type user struct {
+		Name  string   `json:"name"`
+		Email *string  `json:"email,omitempty"`
+		Tags  []string `json:"tags"`
+	}
+	email := "a@example.com"
+	u := user{Name: "a", Email: &email, Tags: []string{"x"}}
+
+	req, _ := http.NewRequest("PATCH", "/users/1", strings.NewReader(`{"name":"b","email":null}`))
+	req.Header.Set("Content-Type", MIME_MERGE_PATCH+"; charset=utf-8")
+	c := NewContext(httptest.NewRecorder(), req)
+	var merge MergePatch
+	if assert.Nil(t, c.Read(&merge)) && assert.Nil(t, merge.ApplyTo(&u)) {
+		assert.Equal(t, user{Name: "b", Tags: []string{"x"}}, u)
+	}
+
+	patch := JSONPatch{{Op: "add", Path: "/tags/0", Value: []byte(`"y"`)}}
+	if assert.Nil(t, patch.ApplyTo(&u)) {
+		assert.Equal(t, []string{"y", "x"}, u.Tags)
+	}
+	assert.NotNil(t, patch.ApplyTo(u))
+
+	// a merge patch can be read into a struct directly
+	req, _ = http.NewRequest("PATCH", "/users/1", strings.NewReader(`{"tags":null}`))
+	req.Header.Set("Content-Type", MIME_MERGE_PATCH)
+	c = NewContext(httptest.NewRecorder(), req)
+	if assert.Nil(t, c.Read(&u)) {
+		assert.Equal(t, user{Name: "b"}, u)
+	}
+
+	req, _ = http.NewRequest("PATCH", "/users/1", strings.NewReader(`{"tags":`))
+	req.Header.Set("Content-Type", MIME_MERGE_PATCH)
+	c = NewContext(httptest.NewRecorder(), req)
+	assert.NotNil(t, c.Read(&merge))
+}
+
+func TestContextCheckPreconditions(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		tag, header, value, etag string
+		status                   int
+	}{
+		{"t1", "", "", `"v1"`, 0},
+		{"t2", "If-Match", `"v1"`, `"v1"`, 0},
+		{"t3", "If-Match", `"v0", "v1"`, `"v1"`, 0},
+		{"t4", "If-Match", `"v0"`, `"v1"`, http.StatusPreconditionFailed},
+		{"t5", "If-Match", `*`, `"v1"`, 0},
+		{"t6", "If-Match", `*`, ``, http.StatusPreconditionFailed},
+		{"t7", "If-Match", `W/"v1"`, `W/"v1"`, http.StatusPreconditionFailed},
+		{"t8", "If-Match", `*`, `W/"v1"`, 0},
+		{"t9", "If-Unmodified-Since", modified.Format(http.TimeFormat), `"v1"`, 0},
+		{"t10", "If-Unmodified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), `"v1"`, http.StatusPreconditionFailed},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("PUT", "/users/1", nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		c := NewContext(httptest.NewRecorder(), req)
+		err := c.CheckPreconditions(test.etag, modified.Add(300*time.Millisecond))
+		if test.status == 0 {
+			assert.Nil(t, err, test.tag)
+		} else if assert.NotNil(t, err, test.tag) {
+			assert.Equal(t, test.status, err.(HTTPError).StatusCode(), test.tag)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package proxy provides a handler that forwards requests to upstream servers for the ozzo routing package.
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/auth"
+	"github.com/golang-jwt/jwt"
+)
+
+type (
+	// TokenSource supplies the service-to-service tokens sent to the upstream servers. Token is called for every
+	// forwarded request, so it should cache the token until it expires. TokenSource should be thread safe.
+	TokenSource interface {
+		Token() (string, error)
+	}
+
+	// TokenSourceFunc is a function that implements TokenSource.
+	TokenSourceFunc func() (string, error)
+
+	// StaticToken is a TokenSource that always returns the same token.
+	StaticToken string
+
+	// LogFunc logs a message using the given format and optional arguments.
+	// The usage of format and arguments is similar to that for fmt.Printf().
+	// LogFunc should be thread safe.
+	LogFunc func(format string, a ...interface{})
+
+	// UserFunc returns the name of the authenticated user of the current request. It returns an empty string
+	// if the request is not authenticated.
+	UserFunc func(c *routing.Context) string
+
+	// Options specifies how requests are forwarded.
+	Options struct {
+		// Transport sends the requests to the upstream servers. If nil, a clone of http.DefaultTransport is used.
+		Transport http.RoundTripper
+		// Certificates are the client certificates presented to the upstream servers over TLS.
+		// They are ignored if Transport is set.
+		Certificates []tls.Certificate
+		// StripAuthorization removes the Authorization header of the incoming requests, so that the credentials
+		// of the clients are not leaked to the upstream servers. It is implied if TokenSource is set.
+		StripAuthorization bool
+		// TokenSource supplies the bearer tokens sent in the Authorization header instead of those of the clients.
+		TokenSource TokenSource
+		// UserHeader is the header carrying the authenticated user to the upstream servers as a JWT whose subject
+		// is the user name. The header of the incoming requests is always removed to prevent spoofing.
+		// If empty, the user is not forwarded.
+		UserHeader string
+		// UserSigningKey is the HS256 key used to sign the JWTs in UserHeader. It is required if UserHeader is set.
+		UserSigningKey string
+		// UserTTL is the lifetime of the JWTs in UserHeader. Defaults to DefaultUserTTL.
+		UserTTL time.Duration
+		// User returns the name of the authenticated user. Defaults to DefaultUserFunc.
+		User UserFunc
+		// If set, it is called to log the errors of forwarding the requests.
+		LogFunc LogFunc
+	}
+)
+
+// DefaultUserTTL is the default lifetime of the JWTs carrying the authenticated users.
+const DefaultUserTTL = time.Minute
+
+// Token returns the token.
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// Token calls f().
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+// DefaultUserFunc returns the name of the user identity set by the auth handlers. The identity is used
+// as is if it is a string, or its String method is called if it is a fmt.Stringer. For a client certificate
+// set by auth.ClientCert, the subject common name is used, or the first URI (e.g. a SPIFFE ID) in the subject
+// alternative names if the common name is empty.
+func DefaultUserFunc(c *routing.Context) string {
+	switch identity := c.Get(auth.User).(type) {
+	case string:
+		return identity
+	case *x509.Certificate:
+		if identity.Subject.CommonName == "" && len(identity.URIs) > 0 {
+			return identity.URIs[0].String()
+		}
+		return identity.Subject.CommonName
+	case fmt.Stringer:
+		return identity.String()
+	}
+	return ""
+}
+
+// errorKey is the context key of the error returned by the reverse proxy.
+type errorKey struct{}
+
+// Handler returns a handler that forwards the requests to the given upstream server using httputil.ReverseProxy.
+// The path of the target URL is prepended to the request paths, and the query parameters of the target URL
+// are merged with those of the requests. The handler translates the credentials of the requests as specified
+// by Options, which is needed by the API gateways sitting between the clients and the internal services:
+// the client credentials are replaced with a service-to-service token, and the user authenticated by
+// the gateway is forwarded as a signed header that the services can verify with auth.JWT. For example,
+//
+//     import (
+//         "net/url"
+//         "os"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/auth"
+//         "github.com/go-ozzo/ozzo-routing/v2/proxy"
+//     )
+//
+//     target, _ := url.Parse("https://orders.internal")
+//     r := routing.New()
+//     r.Any("/orders/*", auth.Bearer(validateToken), proxy.Handler(target, proxy.Options{
+//         TokenSource:    proxy.StaticToken(os.Getenv("ORDERS_TOKEN")),
+//         UserHeader:     "X-Forwarded-User",
+//         UserSigningKey: os.Getenv("USER_SIGNING_KEY"),
+//     }))
+//
+// If the upstream server cannot be reached, an http.StatusBadGateway error will be returned, and the cause
+// will be logged by Options.LogFunc without being exposed to the client.
+func Handler(target *url.URL, opts ...Options) routing.Handler {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.UserHeader != "" && options.UserSigningKey == "" {
+		panic("proxy: UserSigningKey is required to forward the user")
+	}
+	if options.UserTTL <= 0 {
+		options.UserTTL = DefaultUserTTL
+	}
+	if options.User == nil {
+		options.User = DefaultUserFunc
+	}
+	transport := options.Transport
+	if transport == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if len(options.Certificates) > 0 {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			t.TLSClientConfig.Certificates = options.Certificates
+		}
+		transport = t
+	}
+
+	rp := httputil.NewSingleHostReverseProxy(target)
+	rp.Transport = transport
+	rp.ErrorHandler = func(res http.ResponseWriter, req *http.Request, err error) {
+		if p, ok := req.Context().Value(errorKey{}).(*error); ok {
+			*p = err
+		}
+	}
+
+	return func(c *routing.Context) error {
+		var proxyErr error
+		req := c.Request.Clone(context.WithValue(c.Request.Context(), errorKey{}, &proxyErr))
+
+		if options.StripAuthorization || options.TokenSource != nil {
+			req.Header.Del("Authorization")
+		}
+		if options.TokenSource != nil {
+			token, err := options.TokenSource.Token()
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if options.UserHeader != "" {
+			req.Header.Del(options.UserHeader)
+			if user := options.User(c); user != "" {
+				now := time.Now()
+				token, err := auth.NewJWT(jwt.MapClaims{
+					"sub": user,
+					"iat": now.Unix(),
+					"exp": now.Add(options.UserTTL).Unix(),
+				}, options.UserSigningKey)
+				if err != nil {
+					return err
+				}
+				req.Header.Set(options.UserHeader, token)
+			}
+		}
+
+		rp.ServeHTTP(c.Response, req)
+		if proxyErr != nil {
+			if options.LogFunc != nil {
+				options.LogFunc("proxy: failed to forward %v %v: %v", c.Request.Method, c.Request.URL, proxyErr)
+			}
+			return routing.NewHTTPError(http.StatusBadGateway)
+		}
+		return nil
+	}
+}
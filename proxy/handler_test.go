@@ -0,0 +1,141 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	var upstream *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		upstream = req
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, "path "+req.URL.Path)
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL + "/v1")
+
+	router := routing.New()
+	router.Use(func(c *routing.Context) error {
+		if user := c.Request.Header.Get("X-Test-User"); user != "" {
+			c.Set(auth.User, user)
+		}
+		return nil
+	})
+	router.Any("/orders/*", Handler(target, Options{
+		TokenSource:    StaticToken("service-token"),
+		UserHeader:     "X-Forwarded-User",
+		UserSigningKey: "secret",
+	}))
+	router.Any("/plain/*", Handler(target, Options{StripAuthorization: true}))
+	router.Any("/raw/*", Handler(target))
+
+	tests := []struct {
+		tag, url, user string
+		authorization  string
+		forwardedUser  string
+		path           string
+	}{
+		{"t1", "/orders/1", "alice", "Bearer service-token", "alice", "/v1/orders/1"},
+		{"t2", "/orders/2", "", "Bearer service-token", "", "/v1/orders/2"},
+		{"t3", "/plain/3", "alice", "", "spoofed", "/v1/plain/3"},
+		{"t4", "/raw/4", "", "Bearer client-token", "spoofed", "/v1/raw/4"},
+	}
+	for _, test := range tests {
+		upstream = nil
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.url, nil)
+		req.Header.Set("Authorization", "Bearer client-token")
+		req.Header.Set("X-Forwarded-User", "spoofed")
+		req.Header.Set("X-Test-User", test.user)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusAccepted, res.Code, test.tag)
+		assert.Equal(t, "yes", res.Header().Get("X-Upstream"), test.tag)
+		assert.Equal(t, "path "+test.path, res.Body.String(), test.tag)
+		if assert.NotNil(t, upstream, test.tag) {
+			assert.Equal(t, test.authorization, upstream.Header.Get("Authorization"), test.tag)
+			forwarded := upstream.Header.Get("X-Forwarded-User")
+			if test.user != "" && test.url[:7] == "/orders" {
+				token, err := jwt.Parse(forwarded, func(*jwt.Token) (interface{}, error) {
+					return []byte("secret"), nil
+				})
+				if assert.Nil(t, err, test.tag) {
+					assert.Equal(t, test.forwardedUser, token.Claims.(jwt.MapClaims)["sub"], test.tag)
+				}
+			} else {
+				assert.Equal(t, test.forwardedUser, forwarded, test.tag)
+			}
+		}
+		// the incoming request is not changed
+		assert.Equal(t, "Bearer client-token", req.Header.Get("Authorization"), test.tag)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logFunc := func(format string, a ...interface{}) {
+		fmt.Fprintf(&buf, format, a...)
+	}
+	target, _ := url.Parse("http://127.0.0.1:1")
+	router := routing.New()
+	router.Get("/down", Handler(target, Options{LogFunc: logFunc}))
+	router.Get("/token", Handler(target, Options{
+		TokenSource: TokenSourceFunc(func() (string, error) {
+			return "", errors.New("token unavailable")
+		}),
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/down", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadGateway, res.Code)
+	assert.Equal(t, "Bad Gateway\n", res.Body.String())
+	assert.Contains(t, buf.String(), "proxy: failed to forward GET /down: ")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/token", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	assert.Panics(t, func() {
+		Handler(target, Options{UserHeader: "X-User"})
+	})
+}
+
+func TestDefaultUserFunc(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	tests := []struct {
+		tag      string
+		identity interface{}
+		expected string
+	}{
+		{"t1", nil, ""},
+		{"t2", "alice", "alice"},
+		{"t3", &x509.Certificate{Subject: pkix.Name{CommonName: "billing.internal"}}, "billing.internal"},
+		{"t4", &x509.Certificate{URIs: []*url.URL{spiffe}}, "spiffe://example.org/billing"},
+		{"t5", spiffe, "spiffe://example.org/billing"},
+		{"t6", 123, ""},
+	}
+	for _, test := range tests {
+		c := routing.NewContext(nil, nil)
+		c.Set(auth.User, test.identity)
+		assert.Equal(t, test.expected, DefaultUserFunc(c), test.tag)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rbac
+
+import (
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Enforcer enforces access control policies. It is implemented by the casbin enforcers, such as *casbin.Enforcer,
+// *casbin.CachedEnforcer, and *casbin.SyncedEnforcer.
+type Enforcer interface {
+	Enforce(rvals ...interface{}) (bool, error)
+}
+
+// Casbin returns a PolicyChecker that makes decisions using a casbin enforcer. The enforcer is called with
+// the subject, the object, and the action of each access request, which matches the common casbin models
+// with the request definition "r = sub, obj, act". A nil subject (i.e. an unauthenticated request) is passed
+// as an empty string. Use Options.Subject to pass a different subject, such as the user name of the identity.
+func Casbin(e Enforcer) PolicyChecker {
+	return PolicyCheckerFunc(func(c *routing.Context, req Request) (bool, error) {
+		subject := req.Subject
+		if subject == nil {
+			subject = ""
+		}
+		return e.Enforce(subject, req.Object, req.Action)
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package rbac provides an access control handler that enforces the decisions of policy engines for the ozzo routing package.
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/auth"
+)
+
+// Object is the name of the route metadata item that specifies the object of the access requests to a route.
+// It overrides the route path, which is used as the object by default.
+const Object = "rbac.object"
+
+type (
+	// Request is a request to access a resource.
+	Request struct {
+		Subject interface{} // the identity stored under auth.User, or the result of Options.Subject
+		Object  string      // the route metadata named Object, or the path of the matching route (e.g. "/users/<id>")
+		Action  string      // the HTTP method of the request
+	}
+
+	// PolicyChecker decides whether access requests are allowed according to a policy.
+	// A non-nil error should be returned if the decision cannot be made.
+	PolicyChecker interface {
+		Check(c *routing.Context, req Request) (bool, error)
+	}
+
+	// PolicyCheckerFunc is an adapter that allows a function to be used as a PolicyChecker.
+	PolicyCheckerFunc func(c *routing.Context, req Request) (bool, error)
+
+	// LogFunc logs a message using the given format and optional arguments.
+	// The usage of format and arguments is similar to that for fmt.Printf().
+	// LogFunc should be thread safe.
+	LogFunc func(format string, a ...interface{})
+
+	// Options specifies how the access requests are built and the decisions are logged.
+	Options struct {
+		// Subject returns the subject of the current request. If not set, the identity stored in the context
+		// under auth.User is used, which is nil if the request is not authenticated.
+		Subject func(c *routing.Context) interface{}
+		// If set, it is called to log the denied requests together with the access requests.
+		LogFunc LogFunc
+	}
+
+	// Error is the HTTP error returned when an access request is denied. Its message does not reveal the request,
+	// which is available in the Request field for logging purpose.
+	Error struct {
+		Status  int     `json:"status" xml:"status"`
+		Message string  `json:"message" xml:"message"`
+		Request Request `json:"-" xml:"-"`
+	}
+)
+
+// Check calls f(c, req).
+func (f PolicyCheckerFunc) Check(c *routing.Context, req Request) (bool, error) {
+	return f(c, req)
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code.
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+// String returns the string representation of the access request.
+func (r Request) String() string {
+	return fmt.Sprintf("subject=%v object=%v action=%v", r.Subject, r.Object, r.Action)
+}
+
+// Handler returns a handler that checks whether the current request is allowed by the given policy checker before
+// the rest of the handlers are executed. The access request consists of the subject (by default, the identity set by
+// the auth handlers), the object (by default, the path of the matching route), and the action (the HTTP method).
+// If the request is denied, an Error with the http.StatusForbidden status is returned. If the checker fails,
+// its error is returned. The requests not matching any route are not checked.
+//
+//     import (
+//         "log"
+//         "github.com/casbin/casbin/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/auth"
+//         "github.com/go-ozzo/ozzo-routing/v2/rbac"
+//     )
+//
+//     enforcer, _ := casbin.NewEnforcer("model.conf", "policy.csv")
+//     r := routing.New()
+//     r.Use(auth.Bearer(authenticate))
+//     r.Use(rbac.Handler(rbac.Casbin(enforcer), rbac.Options{LogFunc: log.Printf}))
+//     r.Get("/users/<id>", getUser)
+//     r.Get("/reports", getReports).Set(rbac.Object, "reports")
+func Handler(checker PolicyChecker, opts ...Options) routing.Handler {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	return func(c *routing.Context) error {
+		route := c.Route()
+		if route == nil {
+			return nil
+		}
+		req := Request{Object: route.Path(), Action: c.Request.Method}
+		if object, ok := route.Meta(Object).(string); ok {
+			req.Object = object
+		}
+		if options.Subject != nil {
+			req.Subject = options.Subject(c)
+		} else {
+			req.Subject = c.Get(auth.User)
+		}
+
+		allowed, err := checker.Check(c, req)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			if options.LogFunc != nil {
+				options.LogFunc("rbac: access denied: %v", req)
+			}
+			return &Error{http.StatusForbidden, http.StatusText(http.StatusForbidden), req}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+// policyEnforcer is an Enforcer allowing the requests listed in its policy.
+type policyEnforcer map[string]bool
+
+func (e policyEnforcer) Enforce(rvals ...interface{}) (bool, error) {
+	if rvals[1] == "broken" {
+		return false, errors.New("policy not loaded")
+	}
+	return e[fmt.Sprintf("%v %v %v", rvals...)], nil
+}
+
+func TestHandler(t *testing.T) {
+	var logs []string
+	logf := func(format string, a ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, a...))
+	}
+	enforcer := policyEnforcer{
+		"alice /users/<id> GET":    true,
+		"alice reports GET":        true,
+		"bob /users/<id> GET":      true,
+		"bob /users/<id> DELETE":   true,
+		" /public GET":             true,
+		"alice /users/<id> DELETE": false,
+	}
+	router := routing.New()
+	router.Use(func(c *routing.Context) error {
+		if user := c.Request.Header.Get("User"); user != "" {
+			c.Set(auth.User, user)
+		}
+		return nil
+	})
+	router.Use(Handler(Casbin(enforcer), Options{LogFunc: logf}))
+	router.To("GET,DELETE", "/users/<id>", func(c *routing.Context) error {
+		return c.Write("user " + c.Param("id"))
+	})
+	router.Get("/reports", func(c *routing.Context) error {
+		return c.Write("reports")
+	}).Set(Object, "reports")
+	router.Get("/public", func(c *routing.Context) error {
+		return c.Write("public")
+	})
+	router.Get("/broken", func(c *routing.Context) error {
+		return c.Write("broken")
+	}).Set(Object, "broken")
+
+	tests := []struct {
+		tag    string
+		method string
+		path   string
+		user   string
+		status int
+		body   string
+	}{
+		{"t1", "GET", "/users/1", "alice", http.StatusOK, "user 1"},
+		{"t2", "DELETE", "/users/1", "alice", http.StatusForbidden, "Forbidden\n"},
+		{"t3", "DELETE", "/users/1", "bob", http.StatusOK, "user 1"},
+		{"t4", "GET", "/reports", "alice", http.StatusOK, "reports"},
+		{"t5", "GET", "/reports", "bob", http.StatusForbidden, "Forbidden\n"},
+		{"t6", "GET", "/public", "", http.StatusOK, "public"},
+		{"t7", "GET", "/users/1", "", http.StatusForbidden, "Forbidden\n"},
+		{"t8", "GET", "/broken", "alice", http.StatusInternalServerError, "policy not loaded\n"},
+		{"t9", "GET", "/missing", "alice", http.StatusNotFound, "Not Found\n"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		req.Header.Set("User", test.user)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+	}
+	assert.Equal(t, []string{
+		"rbac: access denied: subject=alice object=/users/<id> action=DELETE",
+		"rbac: access denied: subject=bob object=reports action=GET",
+		"rbac: access denied: subject=<nil> object=/users/<id> action=GET",
+	}, logs)
+}
+
+func TestHandlerSubject(t *testing.T) {
+	var request Request
+	checker := PolicyCheckerFunc(func(c *routing.Context, req Request) (bool, error) {
+		request = req
+		return false, nil
+	})
+	h := Handler(checker, Options{
+		Subject: func(c *routing.Context) interface{} {
+			return "role:admin"
+		},
+	})
+	router := routing.New()
+	router.Post("/users", h)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, Request{"role:admin", "/users", "POST"}, request)
+
+	// the requests not matching any route are not checked
+	c := routing.NewContext(res, req)
+	assert.Nil(t, Handler(checker)(c))
+
+	// the access request is kept in the error
+	router.Get("/items/<id>", func(c *routing.Context) error {
+		err := h(c)
+		if assert.IsType(t, &Error{}, err) {
+			assert.Equal(t, http.StatusForbidden, err.(*Error).StatusCode())
+			assert.Equal(t, "Forbidden", err.Error())
+			assert.Equal(t, "subject=role:admin object=/items/<id> action=GET", err.(*Error).Request.String())
+		}
+		return nil
+	})
+	req, _ = http.NewRequest("GET", "/items/1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -5,23 +5,33 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2/cbor"
 )
 
 // MIME types used when doing request data reading and response data writing.
 const (
 	MIME_JSON           = "application/json"
+	MIME_CBOR           = "application/cbor"
 	MIME_XML            = "application/xml"
 	MIME_XML2           = "text/xml"
 	MIME_HTML           = "text/html"
 	MIME_FORM           = "application/x-www-form-urlencoded"
 	MIME_MULTIPART_FORM = "multipart/form-data"
+	MIME_MERGE_PATCH    = "application/merge-patch+json" // JSON Merge Patch (RFC 7386)
+	MIME_JSON_PATCH     = "application/json-patch+json"  // JSON Patch (RFC 6902)
 )
 
 var (
 	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
 )
 
 // DataReader is used by Context.Read() to read data from an HTTP request.
@@ -39,8 +49,11 @@ var (
 		MIME_FORM:           &FormDataReader{},
 		MIME_MULTIPART_FORM: &FormDataReader{},
 		MIME_JSON:           &JSONDataReader{},
+		MIME_CBOR:           &CBORDataReader{},
 		MIME_XML:            &XMLDataReader{},
 		MIME_XML2:           &XMLDataReader{},
+		MIME_MERGE_PATCH:    &MergePatchDataReader{},
+		MIME_JSON_PATCH:     &JSONPatchDataReader{},
 	}
 	// DefaultFormDataReader is the reader used when there is no matching reader in DataReaders
 	// or if the current request is a GET request.
@@ -48,10 +61,32 @@ var (
 )
 
 // JSONDataReader reads the request body as JSON-formatted data.
-type JSONDataReader struct{}
+type JSONDataReader struct {
+	// Codec is the JSON codec used to decode the body. If nil, DefaultJSONCodec is used.
+	Codec JSONCodec
+}
 
 func (r *JSONDataReader) Read(req *http.Request, data interface{}) error {
-	return json.NewDecoder(req.Body).Decode(data)
+	codec := r.Codec
+	if codec == nil {
+		codec = DefaultJSONCodec
+	}
+	if _, ok := codec.(StdJSONCodec); ok {
+		// decode the body as a stream without reading it into memory first
+		return json.NewDecoder(req.Body).Decode(data)
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(body, data)
+}
+
+// CBORDataReader reads the request body as CBOR-encoded data (RFC 8949).
+type CBORDataReader struct{}
+
+func (r *CBORDataReader) Read(req *http.Request, data interface{}) error {
+	return cbor.NewDecoder(req.Body).Decode(data)
 }
 
 // XMLDataReader reads the request body as XML-formatted data.
@@ -61,18 +96,102 @@ func (r *XMLDataReader) Read(req *http.Request, data interface{}) error {
 	return xml.NewDecoder(req.Body).Decode(data)
 }
 
+// DefaultMaxMemory is the default maximum number of bytes of a multipart request body that are stored in memory
+// when the body is parsed as form data. The rest of the file parts are stored on disk in temporary files.
+const DefaultMaxMemory = 32 << 20
+
+// FormOptions specifies how the request body is parsed as form data by Context.Form, Context.PostForm,
+// and Context.Read.
+type FormOptions struct {
+	// The maximum number of bytes of a multipart body to be stored in memory. If not positive, DefaultMaxMemory is used.
+	MaxMemory int64
+	// Whether to skip parsing multipart bodies. URL-encoded bodies will still be parsed.
+	SkipMultipart bool
+	// Whether to skip parsing the request body. Only the URL query parameters will be used as form data.
+	SkipBody bool
+}
+
 // FormDataReader reads the query parameters and request body as form data.
+// If the request has not been parsed yet, it will be parsed with the default FormOptions.
+// The files uploaded with a multipart body are populated as described in ReadFormFiles.
 type FormDataReader struct{}
 
 func (r *FormDataReader) Read(req *http.Request, data interface{}) error {
-	// Do not check return result. Otherwise GET request will cause problem.
-	req.ParseMultipartForm(32 << 20)
-	return ReadFormData(req.Form, data)
+	if req.Form == nil {
+		// Do not check return result. Otherwise GET request will cause problem.
+		parseForm(req, FormOptions{})
+	}
+	if err := ReadFormData(req.Form, data); err != nil {
+		return err
+	}
+	if req.MultipartForm != nil {
+		return ReadFormFiles(req.MultipartForm.File, data)
+	}
+	return nil
+}
+
+// parseForm parses the query parameters and request body of the given request according to the form options.
+func parseForm(req *http.Request, options FormOptions) error {
+	if options.SkipBody {
+		if req.PostForm == nil {
+			// an empty PostForm prevents ParseForm from reading the body
+			req.PostForm = make(url.Values)
+		}
+		return req.ParseForm()
+	}
+	if options.SkipMultipart {
+		return req.ParseForm()
+	}
+	maxMemory := options.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+	return req.ParseMultipartForm(maxMemory)
 }
 
 const formTag = "form"
 
+// FormFactory creates the value to be populated with the form data for a struct field of an interface type.
+// The name is the form name of the field, e.g. "payment", which can be used to choose the concrete type according to
+// the form values, e.g. those of "payment.type". The returned value must implement the interface, and is usually
+// a pointer to a struct whose fields are populated with the form values prefixed with the name, e.g. "payment.number".
+// If nil is returned, the field is left unchanged.
+type FormFactory func(form map[string][]string, name string) (interface{}, error)
+
+// FormFactories maps interface types to the factories used by ReadFormData to create the values of the struct fields
+// of these types. The fields of the interface types without factories are left unchanged. You may modify this
+// variable, usually via RegisterFormFactory, before serving requests.
+var FormFactories = map[reflect.Type]FormFactory{}
+
+// RegisterFormFactory registers the factory creating the values of the struct fields of the interface type
+// that iface points to, e.g.
+//
+//     routing.RegisterFormFactory((*PaymentMethod)(nil), func(form map[string][]string, name string) (interface{}, error) {
+//         if url.Values(form).Get(name+".type") == "card" {
+//             return &Card{}, nil
+//         }
+//         return &BankTransfer{}, nil
+//     })
+//
+// RegisterFormFactory panics if iface is not a pointer to an interface.
+func RegisterFormFactory(iface interface{}, factory FormFactory) {
+	rt := reflect.TypeOf(iface)
+	if rt == nil || rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Interface {
+		panic("iface must be a pointer to an interface")
+	}
+	FormFactories[rt.Elem()] = factory
+}
+
 // ReadFormData populates the data variable with the data from the given form values.
+//
+// The struct fields are populated with the form values of the same names, or of the names specified by
+// the "form" tags. The fields of nested structs are populated with the values named like "field.subfield",
+// and the fields of maps with the values named like "field[key]". The fields of interface types are populated
+// with the values created by FormFactories. The map[string][]string or url.Values fields tagged with `form:"*"`
+// receive all form values, e.g. in order to pass them through to another service. In a nested struct,
+// such a field receives the values whose names start with the name of the struct, with the prefix removed.
+// The *multipart.FileHeader and []*multipart.FileHeader fields are left unchanged, as they are populated
+// by ReadFormFiles.
 func ReadFormData(form map[string][]string, data interface{}) error {
 	rv := reflect.ValueOf(data)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
@@ -86,6 +205,100 @@ func ReadFormData(form map[string][]string, data interface{}) error {
 	return readForm(form, "", rv)
 }
 
+// ReadFormFiles populates the *multipart.FileHeader and []*multipart.FileHeader fields of the data variable
+// with the files uploaded with a multipart form, which are usually those in http.Request.MultipartForm.File.
+// The fields are matched with the file names in the same way as ReadFormData matches the fields with the form
+// values, so that a struct can receive both the regular form values and the files of an upload form:
+//
+//     type Upload struct {
+//         Title       string
+//         Photo       *multipart.FileHeader   `form:"photo"`
+//         Attachments []*multipart.FileHeader `form:"attachments"`
+//     }
+//
+// A *multipart.FileHeader field receives the first file of the name. The uploaded files can be opened with
+// multipart.FileHeader.Open.
+func ReadFormFiles(files map[string][]*multipart.FileHeader, data interface{}) error {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("data must be a pointer")
+	}
+	rv = indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return errors.New("data must be a pointer to a struct")
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	readFormFiles(files, "", rv)
+	return nil
+}
+
+// readFormFiles populates the file fields of the struct and its nested structs with the files of the matching names.
+func readFormFiles(files map[string][]*multipart.FileHeader, prefix string, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get(formTag)
+		if !field.Anonymous && field.PkgPath != "" || tag == "-" || tag == "*" {
+			continue
+		}
+
+		name := tag
+		if name == "" && !field.Anonymous {
+			name = field.Name
+		}
+		if name != "" && prefix != "" {
+			name = prefix + "." + name
+		}
+
+		switch ft := field.Type; {
+		case ft == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				rv.Field(i).Set(reflect.ValueOf(fhs[0]))
+			}
+		case ft.Kind() == reflect.Slice && ft.Elem() == fileHeaderType:
+			if fhs, ok := files[name]; ok {
+				rv.Field(i).Set(reflect.ValueOf(fhs))
+			}
+		default:
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() != reflect.Struct {
+				continue
+			}
+			if name == "" {
+				name = prefix
+			}
+			// avoid allocating the nil struct pointers that would receive no files
+			if hasFilePrefix(files, name) {
+				readFormFiles(files, name, indirect(rv.Field(i)))
+			}
+		}
+	}
+}
+
+// hasFilePrefix returns whether any file name starts with the given prefix followed by ".".
+// It returns true if the prefix is empty.
+func hasFilePrefix(files map[string][]*multipart.FileHeader, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	for name := range files {
+		if strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isFileField returns whether the given type is populated by ReadFormFiles.
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || t.Kind() == reflect.Slice && t.Elem() == fileHeaderType
+}
+
 func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 	rv = indirect(rv)
 	rt := rv.Type()
@@ -99,6 +312,17 @@ func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 			continue
 		}
 
+		if tag == "*" {
+			if err := readFormAll(form, prefix, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isFileField(field.Type) {
+			continue
+		}
+
 		ft := field.Type
 		if ft.Kind() == reflect.Ptr {
 			ft = ft.Elem()
@@ -112,6 +336,16 @@ func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 			name = prefix + "." + name
 		}
 
+		if ft.Kind() == reflect.Interface {
+			if name == "" {
+				name = prefix
+			}
+			if err := readFormInterface(form, name, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// check if type implements a known type, like encoding.TextUnmarshaler
 		if ok, err := readFormFieldKnownType(form, name, rv.Field(i)); err != nil {
 			return err
@@ -119,6 +353,13 @@ func readForm(form map[string][]string, prefix string, rv reflect.Value) error {
 			continue
 		}
 
+		if ft.Kind() == reflect.Map {
+			if err := readFormMap(form, name, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if ft.Kind() != reflect.Struct {
 			if err := readFormField(form, name, rv.Field(i)); err != nil {
 				return err
@@ -166,7 +407,7 @@ func readFormField(form map[string][]string, name string, rv reflect.Value) erro
 	n := len(value)
 	slice := reflect.MakeSlice(rv.Type(), n, n)
 	for i := 0; i < n; i++ {
-		if err := setFormFieldValue(slice.Index(i), value[i]); err != nil {
+		if err := setFormElemValue(slice.Index(i), value[i]); err != nil {
 			return err
 		}
 	}
@@ -174,6 +415,113 @@ func readFormField(form map[string][]string, name string, rv reflect.Value) erro
 	return nil
 }
 
+// setFormElemValue sets a slice element, which may be a pointer or implement encoding.TextUnmarshaler.
+func setFormElemValue(rv reflect.Value, value string) error {
+	rv = indirect(rv)
+	if reflect.PtrTo(rv.Type()).Implements(textUnmarshalerType) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	return setFormFieldValue(rv, value)
+}
+
+// readFormInterface populates a field of an interface type with the value created by the registered FormFactory.
+func readFormInterface(form map[string][]string, name string, rv reflect.Value) error {
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	factory, ok := FormFactories[rt]
+	if !ok || !rv.CanSet() {
+		return nil
+	}
+	value, err := factory(form, name)
+	if err != nil || value == nil {
+		return err
+	}
+	rv = indirect(rv)
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(rv.Type()) {
+		return errors.New("Form factory value of type " + v.Type().String() + " does not implement " + rv.Type().String())
+	}
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		if err := readForm(form, name, v); err != nil {
+			return err
+		}
+	} else if v.Kind() == reflect.Ptr {
+		if err := readFormField(form, name, v); err != nil {
+			return err
+		}
+	}
+	rv.Set(v)
+	return nil
+}
+
+// readFormAll populates a map[string][]string field with all form values whose names start with the prefix.
+func readFormAll(form map[string][]string, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Map || rt.Key().Kind() != reflect.String || rt.Elem().Kind() != reflect.Slice || rt.Elem().Elem().Kind() != reflect.String {
+		return errors.New("Unknown type for all form values: " + rt.String())
+	}
+	m := indirect(rv)
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(rt))
+	}
+	for name, value := range form {
+		if prefix != "" {
+			if !strings.HasPrefix(name, prefix+".") {
+				continue
+			}
+			name = name[len(prefix)+1:]
+		}
+		values := reflect.MakeSlice(rt.Elem(), len(value), len(value))
+		for i, v := range value {
+			values.Index(i).SetString(v)
+		}
+		m.SetMapIndex(reflect.ValueOf(name).Convert(rt.Key()), values)
+	}
+	return nil
+}
+
+// readFormMap populates a map field with the form values named in the format of "name[key]".
+func readFormMap(form map[string][]string, name string, rv reflect.Value) error {
+	rt := rv.Type()
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Key().Kind() != reflect.String {
+		return errors.New("Unknown map key type: " + rt.Key().Kind().String())
+	}
+	var m reflect.Value
+	for n, value := range form {
+		key, ok := mapKey(n, name)
+		if !ok {
+			continue
+		}
+		if !m.IsValid() {
+			if m = indirect(rv); m.IsNil() {
+				m.Set(reflect.MakeMap(rt))
+			}
+		}
+		v := reflect.New(rt.Elem()).Elem()
+		if err := readFormField(map[string][]string{key: value}, key, v); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(rt.Key()), v)
+	}
+	return nil
+}
+
+// mapKey returns the key part of a parameter name in the format of "prefix[key]".
+func mapKey(name, prefix string) (string, bool) {
+	if len(name) < len(prefix)+3 || name[:len(prefix)] != prefix || name[len(prefix)] != '[' || name[len(name)-1] != ']' {
+		return "", false
+	}
+	return name[len(prefix)+1 : len(name)-1], true
+}
+
 func setFormFieldValue(rv reflect.Value, value string) error {
 	switch rv.Kind() {
 	case reflect.Bool:
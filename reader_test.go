@@ -2,7 +2,11 @@ package routing
 
 import (
 	"bytes"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,6 +72,7 @@ func TestDefaultDataReader(t *testing.T) {
 		{"t3", "application/x-www-form-urlencoded", "POST", "/test", "A1=abc&A2=100"},
 		{"t4", "application/json", "POST", "/test", `{"A1":"abc","A2":100}`},
 		{"t5", "application/xml", "POST", "/test", `<data><A1>abc</A1><A2>100</A2></data>`},
+		{"t6", "application/cbor", "POST", "/test", "\xa2\x62A1\x63abc\x62A2\x18\x64"},
 	}
 
 	expected := FA{
@@ -108,3 +113,198 @@ func TestTextUnmarshaler(t *testing.T) {
 	assert.Equal(t, "TU_ORIGINAL", a.ATU.UValue)
 	assert.Equal(t, "ORIGINAL", a.NTU)
 }
+
+func TestReadFormMap(t *testing.T) {
+	var a struct {
+		Filter map[string]string   `form:"filter"`
+		Range  map[string]int      `form:"range"`
+		Tags   map[string][]string `form:"tags"`
+		Empty  map[string]string   `form:"empty"`
+	}
+	values := map[string][]string{
+		"filter[status]": {"active", "closed"},
+		"filter[type]":   {"user"},
+		"filter":         {"x"},
+		"filter[]":       {"y"},
+		"range[min]":     {"10"},
+		"tags[a]":        {"x", "y"},
+	}
+	err := ReadFormData(values, &a)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"status": "active", "type": "user"}, a.Filter)
+	assert.Equal(t, map[string]int{"min": 10}, a.Range)
+	assert.Equal(t, map[string][]string{"a": {"x", "y"}}, a.Tags)
+	assert.Nil(t, a.Empty)
+
+	values = map[string][]string{"range[max]": {"abc"}}
+	assert.NotNil(t, ReadFormData(values, &a))
+
+	var b struct {
+		M map[int]string `form:"m"`
+	}
+	assert.NotNil(t, ReadFormData(map[string][]string{"m[1]": {"a"}}, &b))
+}
+
+func TestReadFormPointerSlices(t *testing.T) {
+	var a struct {
+		IDs   []*int `form:"id"`
+		Codes []TU   `form:"code"`
+		Names []*TU  `form:"name"`
+	}
+	values := map[string][]string{
+		"id":   {"1", "2"},
+		"code": {"a"},
+		"name": {"b", "c"},
+	}
+	assert.Nil(t, ReadFormData(values, &a))
+	if assert.Len(t, a.IDs, 2) {
+		assert.Equal(t, 1, *a.IDs[0])
+		assert.Equal(t, 2, *a.IDs[1])
+	}
+	assert.Equal(t, []TU{{"TU_a"}}, a.Codes)
+	assert.Equal(t, []*TU{{"TU_b"}, {"TU_c"}}, a.Names)
+
+	assert.NotNil(t, ReadFormData(map[string][]string{"id": {"x"}}, &a))
+}
+
+type FormShape interface {
+	Area() float64
+}
+
+type formSquare struct {
+	Side float64 `form:"side"`
+}
+
+func (s *formSquare) Area() float64 { return s.Side * s.Side }
+
+type formLabel string
+
+func (l *formLabel) Area() float64 { return 0 }
+
+func TestReadFormInterface(t *testing.T) {
+	defer delete(FormFactories, reflect.TypeOf((*FormShape)(nil)).Elem())
+	RegisterFormFactory((*FormShape)(nil), func(form map[string][]string, name string) (interface{}, error) {
+		key := "type"
+		if name != "" {
+			key = name + ".type"
+		}
+		switch url.Values(form).Get(key) {
+		case "square":
+			return &formSquare{}, nil
+		case "label":
+			return new(formLabel), nil
+		case "invalid":
+			return formSquare{}, nil
+		}
+		return nil, nil
+	})
+
+	var a struct {
+		Shape  FormShape `form:"shape"`
+		Label  FormShape `form:"label"`
+		None   FormShape `form:"none"`
+		Reader interface{}
+		FormShape
+	}
+	values := map[string][]string{
+		"shape.type": {"square"},
+		"shape.side": {"2"},
+		"label.type": {"label"},
+		"label":      {"abc"},
+		"Reader":     {"x"},
+		"type":       {"square"},
+		"side":       {"3"},
+	}
+	assert.Nil(t, ReadFormData(values, &a))
+	assert.Equal(t, 4.0, a.Shape.Area())
+	if assert.IsType(t, new(formLabel), a.Label) {
+		assert.Equal(t, formLabel("abc"), *a.Label.(*formLabel))
+	}
+	assert.Nil(t, a.None)
+	assert.Nil(t, a.Reader)
+	assert.Equal(t, 9.0, a.FormShape.Area())
+
+	values = map[string][]string{"shape.type": {"invalid"}}
+	err := ReadFormData(values, &a)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Form factory value of type routing.formSquare does not implement routing.FormShape", err.Error())
+	}
+
+	assert.Panics(t, func() {
+		RegisterFormFactory(formSquare{}, nil)
+	})
+}
+
+func TestReadFormAll(t *testing.T) {
+	var a struct {
+		Name  string     `form:"name"`
+		All   url.Values `form:"*"`
+		Inner struct {
+			X   int                 `form:"x"`
+			All map[string][]string `form:"*"`
+		} `form:"inner"`
+	}
+	values := map[string][]string{
+		"name":    {"a"},
+		"inner.x": {"1"},
+		"inner.y": {"2", "3"},
+	}
+	assert.Nil(t, ReadFormData(values, &a))
+	assert.Equal(t, "a", a.Name)
+	assert.Equal(t, url.Values(values), a.All)
+	assert.Equal(t, 1, a.Inner.X)
+	assert.Equal(t, map[string][]string{"x": {"1"}, "y": {"2", "3"}}, a.Inner.All)
+
+	var b struct {
+		All map[string]string `form:"*"`
+	}
+	assert.NotNil(t, ReadFormData(values, &b))
+}
+
+func TestReadFormFiles(t *testing.T) {
+	type Meta struct {
+		Thumbnail *multipart.FileHeader `form:"thumbnail"`
+	}
+	type Meta2 struct {
+		Preview *multipart.FileHeader `form:"preview"`
+	}
+	type Upload struct {
+		Name   string `form:"name"`
+		File   *multipart.FileHeader
+		Files  []*multipart.FileHeader `form:"file"`
+		Ignore *multipart.FileHeader   `form:"-"`
+	}
+	req := newMultipartRequest()
+	c := NewContext(httptest.NewRecorder(), req)
+	var u Upload
+	if assert.Nil(t, c.Read(&u)) {
+		assert.Equal(t, "abc", u.Name)
+		assert.Nil(t, u.File)
+		if assert.Equal(t, 1, len(u.Files)) {
+			assert.Equal(t, "a.txt", u.Files[0].Filename)
+			assert.Equal(t, int64(10), u.Files[0].Size)
+		}
+		assert.Nil(t, u.Ignore)
+	}
+
+	files := req.MultipartForm.File
+	var u2 struct {
+		File *multipart.FileHeader `form:"file"`
+		Meta Meta                  `form:"meta"`
+		*Meta2
+		Other *Meta `form:"other"`
+	}
+	assert.Nil(t, ReadFormFiles(map[string][]*multipart.FileHeader{
+		"file":           files["file"],
+		"meta.thumbnail": files["file"],
+		"preview":        files["file"],
+	}, &u2))
+	assert.Equal(t, files["file"][0], u2.File)
+	assert.Equal(t, files["file"][0], u2.Meta.Thumbnail)
+	if assert.NotNil(t, u2.Meta2) {
+		assert.Equal(t, files["file"][0], u2.Preview)
+	}
+	assert.Nil(t, u2.Other)
+
+	assert.NotNil(t, ReadFormFiles(files, u2))
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	// ResourceIndexer is implemented by a resource controller that lists the resources.
+	ResourceIndexer interface {
+		Index(c *Context) error
+	}
+
+	// ResourceShower is implemented by a resource controller that shows a single resource.
+	ResourceShower interface {
+		Show(c *Context) error
+	}
+
+	// ResourceCreator is implemented by a resource controller that creates a resource.
+	ResourceCreator interface {
+		Create(c *Context) error
+	}
+
+	// ResourceUpdater is implemented by a resource controller that updates a resource.
+	ResourceUpdater interface {
+		Update(c *Context) error
+	}
+
+	// ResourceDeleter is implemented by a resource controller that deletes a resource.
+	ResourceDeleter interface {
+		Delete(c *Context) error
+	}
+
+	// ResourceActioner is implemented by a resource controller that provides custom actions
+	// in addition to the conventional ones.
+	ResourceActioner interface {
+		Actions() []ResourceAction
+	}
+
+	// ResourceAction describes a custom action of a resource controller.
+	ResourceAction struct {
+		// Name is the action name which is used to build the route name, e.g. "publish" for "posts.publish".
+		Name string
+		// Methods lists the HTTP methods of the action, separated by commas (e.g. "POST", or "PUT,PATCH").
+		Methods string
+		// Path is the route path relative to the resource path, e.g. "/<id>/publish".
+		Path string
+		// Handler handles the action.
+		Handler Handler
+	}
+)
+
+// Resource registers the conventional routes for the resource controller with the given path.
+// The controller may implement any of ResourceIndexer, ResourceShower, ResourceCreator, ResourceUpdater,
+// ResourceDeleter, and ResourceActioner, and only the routes of the implemented actions are registered.
+// The routes are named after the last segment of the resource path and the action. For example,
+// a controller implementing all actions for the "/users" path is mapped to the following routes:
+//
+//     GET /users              Index   users.index
+//     POST /users             Create  users.create
+//     GET /users/<id>         Show    users.show
+//     PUT,PATCH /users/<id>   Update  users.update
+//     DELETE /users/<id>      Delete  users.delete
+//
+// The path may end with a parameter token to name the parameter identifying a single resource
+// (e.g. "/users/<user>"), which defaults to "id". The parameter must be named differently from those of
+// the parent resources, or Resource will panic. The given handlers are called before the controller actions.
+//
+// The controller may also be a Binding, in which case the actions are the controller methods named
+// Index, Show, Create, Update, and Delete. Note that the custom actions returned by ResourceActioner are
+// registered as they are, so they should be created using the Binding as well.
+//
+// Resource returns a route group for the single resource path (e.g. "/users/<id>"), which can be used to
+// register nested resources. The names of the nested resource routes are prefixed with the parent resource name:
+//
+//     users := router.Resource("/users/<user>", userController{})
+//     users.Resource("/posts", postController{})  // GET /users/<user>/posts/<id> is named users.posts.show
+func (rg *RouteGroup) Resource(path string, controller interface{}, handlers ...Handler) *RouteGroup {
+	param := "<id>"
+	if i := strings.LastIndex(path, "/"); i >= 0 && strings.HasPrefix(path[i+1:], "<") {
+		param, path = path[i+1:], path[:i]
+	}
+	if template := buildURLTemplate(param); strings.Contains(buildURLTemplate(rg.prefix+path), template) {
+		panic(fmt.Sprintf("the parameter %v of the resource %q is already declared by its parent resource: "+
+			"pass the path ending with a parameter of another name, such as %q", template, rg.prefix+path, path+"/<name>"))
+	}
+	name := path[strings.LastIndex(path, "/")+1:]
+	if rg.resource != "" {
+		name = rg.resource + "." + name
+	}
+
+	add := func(methods, path, action string, h Handler) {
+		rg.To(methods, path, append(append([]Handler{}, handlers...), h)...).Name(name + "." + action)
+	}
+	if b, ok := controller.(*Binding); ok {
+		for _, a := range []struct{ methods, path, action, method string }{
+			{"GET", path, "index", "Index"},
+			{"POST", path, "create", "Create"},
+			{"GET", path + "/" + param, "show", "Show"},
+			{"PUT,PATCH", path + "/" + param, "update", "Update"},
+			{"DELETE", path + "/" + param, "delete", "Delete"},
+		} {
+			if b.Has(a.method) {
+				add(a.methods, a.path, a.action, b.Handler(a.method))
+			}
+		}
+		controller = b.controller.Interface()
+	} else {
+		if c, ok := controller.(ResourceIndexer); ok {
+			add("GET", path, "index", c.Index)
+		}
+		if c, ok := controller.(ResourceCreator); ok {
+			add("POST", path, "create", c.Create)
+		}
+		if c, ok := controller.(ResourceShower); ok {
+			add("GET", path+"/"+param, "show", c.Show)
+		}
+		if c, ok := controller.(ResourceUpdater); ok {
+			add("PUT,PATCH", path+"/"+param, "update", c.Update)
+		}
+		if c, ok := controller.(ResourceDeleter); ok {
+			add("DELETE", path+"/"+param, "delete", c.Delete)
+		}
+	}
+	if c, ok := controller.(ResourceActioner); ok {
+		for _, action := range c.Actions() {
+			add(action.Methods, path+action.Path, action.Name, action.Handler)
+		}
+	}
+
+	g := rg.Group(path + "/" + param)
+	g.resource = name
+	return g
+}
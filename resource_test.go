@@ -0,0 +1,91 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userResource struct{}
+
+func (userResource) Index(c *Context) error  { return c.Write("index") }
+func (userResource) Show(c *Context) error   { return c.Write("show " + c.Param("user")) }
+func (userResource) Create(c *Context) error { return c.Write("create") }
+func (userResource) Update(c *Context) error { return c.Write("update " + c.Param("user")) }
+func (userResource) Delete(c *Context) error { return c.Write("delete " + c.Param("user")) }
+
+func (userResource) Actions() []ResourceAction {
+	return []ResourceAction{
+		{"activate", "POST", "/<user>/activate", func(c *Context) error { return c.Write("activate " + c.Param("user")) }},
+	}
+}
+
+type postResource struct{}
+
+func (postResource) Index(c *Context) error { return c.Write("posts of " + c.Param("user")) }
+func (postResource) Show(c *Context) error  { return c.Write("post " + c.Param("id") + " of " + c.Param("user")) }
+
+func TestRouteGroupResource(t *testing.T) {
+	router := New()
+	api := router.Group("/api")
+	users := api.Resource("/users/<user>", userResource{}, func(c *Context) error {
+		c.Response.Header().Set("X-Resource", "users")
+		return nil
+	})
+	users.Resource("/posts", postResource{})
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"GET", "/api/users", "index"},
+		{"POST", "/api/users", "create"},
+		{"GET", "/api/users/1", "show 1"},
+		{"PUT", "/api/users/1", "update 1"},
+		{"PATCH", "/api/users/1", "update 1"},
+		{"DELETE", "/api/users/1", "delete 1"},
+		{"POST", "/api/users/1/activate", "activate 1"},
+		{"GET", "/api/users/1/posts", "posts of 1"},
+		{"GET", "/api/users/1/posts/2", "post 2 of 1"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(test.method, test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusOK, res.Code, test.method+" "+test.path)
+		assert.Equal(t, test.body, res.Body.String(), test.method+" "+test.path)
+	}
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "users", res.Header().Get("X-Resource"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/api/users/1/posts/2", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+
+	assert.Equal(t, "/api/users/1", router.Route("users.show").URL("user", 1))
+	assert.Equal(t, "/api/users/1", router.Route("users.update").URL("user", 1))
+	assert.Equal(t, "/api/users/1/activate", router.Route("users.activate").URL("user", 1))
+	assert.Equal(t, "/api/users/1/posts/2", router.Route("users.posts.show").URL("user", 1, "id", 2))
+	assert.Nil(t, router.Route("users.posts.create"))
+
+	router.Resource("/tags", postResource{})
+	assert.Equal(t, "/tags/x", router.Route("tags.show").URL("id", "x"))
+
+	// the nested resources need their own parameter names
+	articles := router.Resource("/articles", postResource{})
+	assert.PanicsWithValue(t, `the parameter <id> of the resource "/articles/<id>/comments" is already declared by its parent resource: `+
+		`pass the path ending with a parameter of another name, such as "/comments/<name>"`, func() {
+		articles.Resource("/comments", postResource{})
+	})
+	articles.Resource("/comments/<comment>", postResource{})
+	assert.Equal(t, "/articles/1/comments/2", router.Route("articles.comments.show").URL("id", 1, "comment", 2))
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ErrHeaderWritten is returned by the Context methods sending an HTTP status code if the status code
+// of the response has already been sent.
+var ErrHeaderWritten = errors.New("routing: the response header has already been written")
+
+// ResponseTracker wraps http.ResponseWriter in order to record the status and the size of the response written
+// by the handlers, so that an error handler can tell whether the response has been partially written when
+// an error occurs. It is installed by Context.TrackResponse.
+type ResponseTracker struct {
+	http.ResponseWriter
+	// Status is the HTTP status code of the response, or 0 if nothing has been written.
+	Status int
+	// Size is the number of bytes of the response body that have been written.
+	Size int64
+}
+
+// WriteHeader records the response status and then writes HTTP headers. The calls after the status has been
+// written are ignored, except for the informational (1xx) status codes, which are sent without being recorded.
+func (w *ResponseTracker) WriteHeader(status int) {
+	if w.Status != 0 {
+		return
+	}
+	if status >= http.StatusOK || status == http.StatusSwitchingProtocols {
+		w.Status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseTracker) Write(p []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.Size += int64(n)
+	return n, err
+}
+
+// Written returns the status and the number of bytes of the response body that have been written.
+func (w *ResponseTracker) Written() (int, int64) {
+	return w.Status, w.Size
+}
+
+// Flush sends the buffered data to the client if the wrapped writer supports flushing.
+func (w *ResponseTracker) Flush() {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the connection from the HTTP server if the wrapped writer supports hijacking.
+func (w *ResponseTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	if w.Status == 0 {
+		w.Status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the original http.ResponseWriter.
+func (w *ResponseTracker) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writtenReporter is implemented by the response writers that record what the handlers have written.
+type writtenReporter interface {
+	Written() (int, int64)
+}
+
+// TrackResponse wraps the response with a ResponseTracker unless a writer in the chain of the wrapped responses
+// already records what has been written, so that Context.Written reports the status and the size of the response.
+// It is called by the error handlers before calling Next.
+func (c *Context) TrackResponse() {
+	for rw := c.Response; rw != nil; {
+		if _, ok := rw.(writtenReporter); ok {
+			return
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = u.Unwrap()
+	}
+	c.Response = &ResponseTracker{ResponseWriter: c.Response}
+}
+
+// Written returns the status and the number of bytes of the response body that have been written by the handlers,
+// including those still held by a BufferedResponseWriter. The status is 0 if nothing has been written. The result
+// is only available if the response is tracked (see TrackResponse), the router records statistics, or the response
+// is wrapped by a writer recording what has been written, such as BufferedResponseWriter. Otherwise, 0 is returned.
+func (c *Context) Written() (status int, size int64) {
+	for rw := c.Response; rw != nil; {
+		if r, ok := rw.(writtenReporter); ok {
+			return r.Written()
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = u.Unwrap()
+	}
+	return 0, 0
+}
+
+// Status returns the HTTP status code of the response that has been written by the handlers, or 0 if nothing
+// has been written. Like Written, the status is only available if the response is tracked.
+func (c *Context) Status() int {
+	status, _ := c.Written()
+	return status
+}
+
+// WriteHeader sends the HTTP status code of the response. It returns ErrHeaderWritten without sending
+// the status code if the response is tracked (see TrackResponse) and its status code has already been sent,
+// which usually indicates that a handler and a middleware both attempt to write the response.
+func (c *Context) WriteHeader(status int) error {
+	if c.Status() != 0 {
+		return ErrHeaderWritten
+	}
+	c.Response.WriteHeader(status)
+	return nil
+}
+
+// DiscardResponse discards the response written so far, if any, so that it can be replaced, e.g. by an error
+// response. It returns false if a part of the response has already been sent to the client, in which case
+// the response cannot be replaced. The written response can only be discarded if it is still held by
+// a BufferedResponseWriter that has not been committed.
+func (c *Context) DiscardResponse() bool {
+	var trackers []*ResponseTracker
+	for rw := c.Response; rw != nil; {
+		switch w := rw.(type) {
+		case *BufferedResponseWriter:
+			if w.Committed() {
+				return false
+			}
+			w.Reset()
+			for _, t := range trackers {
+				t.Status, t.Size = 0, 0
+			}
+			return true
+		case *ResponseTracker:
+			trackers = append(trackers, w)
+		}
+		u, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = u.Unwrap()
+	}
+	status, _ := c.Written()
+	return status == 0
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextTrackResponse(t *testing.T) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := NewContext(res, req)
+	status, size := c.Written()
+	assert.Equal(t, 0, status)
+	assert.Equal(t, int64(0), size)
+
+	c.TrackResponse()
+	tracker, ok := c.Response.(*ResponseTracker)
+	if assert.True(t, ok) {
+		assert.Equal(t, res, tracker.Unwrap())
+	}
+	c.TrackResponse()
+	assert.Equal(t, tracker, c.Response)
+	assert.True(t, c.DiscardResponse())
+
+	c.Response.WriteHeader(http.StatusCreated)
+	c.Response.Write([]byte("abc"))
+	status, size = c.Written()
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, int64(3), size)
+	assert.False(t, c.DiscardResponse())
+	assert.Equal(t, "abc", res.Body.String())
+}
+
+func TestContextDiscardResponse(t *testing.T) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := NewContext(res, req)
+	rw := NewBufferedResponseWriter(res, 0)
+	c.Response = rw
+	c.TrackResponse()
+	assert.Equal(t, rw, c.Response)
+
+	c.Response = &ResponseTracker{ResponseWriter: rw}
+	c.Response.Write([]byte("abc"))
+	status, size := c.Written()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(3), size)
+	assert.True(t, c.DiscardResponse())
+	status, size = c.Written()
+	assert.Equal(t, 0, status)
+	assert.Equal(t, int64(0), size)
+	assert.Equal(t, 0, rw.Status)
+	assert.Equal(t, 0, len(rw.Body()))
+
+	c.Response.Write([]byte("xyz"))
+	rw.Commit()
+	assert.False(t, c.DiscardResponse())
+	assert.Equal(t, "xyz", res.Body.String())
+}
+
+func TestContextWriteHeader(t *testing.T) {
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := NewContext(res, req)
+	c.TrackResponse()
+	assert.Equal(t, 0, c.Status())
+
+	assert.Nil(t, c.WriteHeader(http.StatusCreated))
+	assert.Equal(t, http.StatusCreated, c.Status())
+	assert.Equal(t, ErrHeaderWritten, c.WriteHeader(http.StatusOK))
+	assert.Equal(t, ErrHeaderWritten, c.WriteWithStatus("abc", http.StatusOK))
+	assert.Equal(t, ErrHeaderWritten, c.Redirect("/new", http.StatusFound))
+	c.Response.WriteHeader(http.StatusNotFound)
+	assert.Equal(t, http.StatusCreated, c.Status())
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "", res.Body.String())
+
+	// untracked responses
+	res = httptest.NewRecorder()
+	c = NewContext(res, req)
+	assert.Nil(t, c.WriteHeader(http.StatusAccepted))
+	assert.Nil(t, c.WriteWithStatus("abc", http.StatusOK))
+	assert.Equal(t, 0, c.Status())
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, "abc", res.Body.String())
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package rewrite provides URL rewrite handlers for the ozzo routing package.
+package rewrite
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+type (
+	// Rule specifies how the requests whose paths match a pattern are rewritten.
+	Rule struct {
+		// Pattern is matched against the whole escaped request path. It is either a regular expression starting
+		// with "^", e.g. `^/old/(\d+)$`, or a path template in the route path format, e.g. "/old/<id>",
+		// `/old/<id:\d+>`, or "/old/*", where "*" can only appear at the end.
+		Pattern string
+		// Target is the URL that the matching requests are rewritten or redirected to. For a regular expression
+		// pattern, "$1" and "${name}" are replaced with the corresponding submatches. For a path template pattern,
+		// "<name>" and "*" are replaced with the values of the corresponding parameter and wildcard.
+		Target string
+		// Status is the status code of the redirect response, e.g. http.StatusMovedPermanently. If zero,
+		// the request is rewritten internally without the client being aware of it.
+		Status int
+	}
+
+	// rule is a compiled Rule.
+	rule struct {
+		Rule
+		regex *regexp.Regexp
+		names []string // the names of the template parameters, indexed by their submatch indexes minus one
+	}
+)
+
+// Handler returns a handler that rewrites the requests according to the given rules. The first rule whose pattern
+// matches the request path applies. A rule either redirects the client to the target URL or rewrites the request
+// internally via routing.Context.Reroute, which dispatches the request to the route matching the target URL.
+// The query string of the request is kept unless the target has one. Handler panics if any pattern is invalid.
+// For example,
+//
+//     import (
+//         "net/http"
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/rewrite"
+//     )
+//
+//     r := routing.New()
+//     r.Pre(rewrite.Handler(
+//         rewrite.Rule{Pattern: "/old/<id>", Target: "/new/<id>"},
+//         rewrite.Rule{Pattern: `^/posts/(\d{4})/(\d+)$`, Target: "/blog/$1/$2", Status: http.StatusMovedPermanently},
+//         rewrite.Rule{Pattern: "/docs/v1/*", Target: "/docs/*"},
+//     ))
+//
+// When registered via Router.Pre, as above, the handler rewrites the request path before it is matched against
+// the routes. It may also be registered via Router.Use or with the routes, in which case the requests are dispatched
+// again after being rewritten.
+func Handler(rules ...Rule) routing.Handler {
+	compiled := make([]rule, len(rules))
+	for i, r := range rules {
+		compiled[i] = compile(r)
+	}
+	return func(c *routing.Context) error {
+		path := c.Request.URL.EscapedPath()
+		for _, r := range compiled {
+			if matches := r.regex.FindStringSubmatchIndex(path); matches != nil {
+				return apply(c, r.expand(path, matches), r.Status)
+			}
+		}
+		return nil
+	}
+}
+
+// To returns a route handler that rewrites the requests to the given target URL, in which "<name>" and "*" are
+// replaced with the values of the corresponding route parameter and wildcard. If status is given, the client
+// is redirected to the target URL with the status code. Otherwise, the request is rewritten internally via
+// routing.Context.Reroute. For example,
+//
+//     r.Get("/users/<id>/profile", rewrite.To("/profiles/<id>"))
+//     r.Get("/files/*", rewrite.To("/static/*", http.StatusMovedPermanently))
+func To(target string, status ...int) routing.Handler {
+	s := 0
+	if len(status) > 0 {
+		s = status[0]
+	}
+	names := templateNames(target)
+	return func(c *routing.Context) error {
+		pairs := make([]string, 0, len(names)*2)
+		for _, name := range names {
+			token := "<" + name + ">"
+			if name == "" {
+				token = "*"
+			}
+			pairs = append(pairs, token, escape(c.Param(name), name == ""))
+		}
+		return apply(c, strings.NewReplacer(pairs...).Replace(target), s)
+	}
+}
+
+// apply redirects the client to the given URL if status is not zero, or reroutes the request otherwise.
+func apply(c *routing.Context, target string, status int) error {
+	if status == 0 {
+		return c.Reroute(target)
+	}
+	if !strings.Contains(target, "?") && c.Request.URL.RawQuery != "" {
+		target += "?" + c.Request.URL.RawQuery
+	}
+	c.Abort()
+	return c.Redirect(target, status)
+}
+
+// compile compiles the pattern of the given rule into a regular expression. It panics if the pattern is invalid.
+func compile(r Rule) rule {
+	compiled, err := compileRule(r)
+	if err != nil {
+		panic(err.Error())
+	}
+	return compiled
+}
+
+// compileRule compiles the pattern of the given rule into a regular expression.
+func compileRule(r Rule) (rule, error) {
+	if strings.HasPrefix(r.Pattern, "^") {
+		regex, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return rule{}, fmt.Errorf("rewrite: invalid pattern %q: %v", r.Pattern, err)
+		}
+		return rule{Rule: r, regex: regex}, nil
+	}
+	names := []string{}
+	expr := "^"
+	pattern := r.Pattern
+	for pattern != "" {
+		switch {
+		case pattern == "*":
+			names = append(names, "")
+			expr += "(.*)"
+			pattern = ""
+		case pattern[0] == '<':
+			end := strings.IndexByte(pattern, '>')
+			if end < 0 {
+				return rule{}, fmt.Errorf("rewrite: unbalanced angle brackets in pattern %q", r.Pattern)
+			}
+			name, p := pattern[1:end], "[^/]*"
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				name, p = name[:i], name[i+1:]
+			}
+			names = append(names, name)
+			expr += "(" + p + ")"
+			pattern = pattern[end+1:]
+		default:
+			end := strings.IndexAny(pattern, "<*")
+			if end < 0 {
+				end = len(pattern)
+			} else if pattern[end] == '*' && end < len(pattern)-1 {
+				return rule{}, fmt.Errorf("rewrite: the wildcard must be at the end of pattern %q", r.Pattern)
+			}
+			if end == 0 {
+				end = 1
+			}
+			expr += regexp.QuoteMeta(pattern[:end])
+			pattern = pattern[end:]
+		}
+	}
+	regex, err := regexp.Compile(expr + "$")
+	if err != nil {
+		return rule{}, fmt.Errorf("rewrite: invalid pattern %q: %v", r.Pattern, err)
+	}
+	return rule{Rule: r, regex: regex, names: names}, nil
+}
+
+// expand returns the target of the rule with the submatches of the path filled in.
+func (r *rule) expand(path string, matches []int) string {
+	if r.names == nil {
+		return string(r.regex.ExpandString(nil, r.Target, path, matches))
+	}
+	pairs := make([]string, 0, len(r.names)*2)
+	for i, name := range r.names {
+		token := "<" + name + ">"
+		if name == "" {
+			token = "*"
+		}
+		pairs = append(pairs, token, path[matches[2*i+2]:matches[2*i+3]])
+	}
+	return strings.NewReplacer(pairs...).Replace(r.Target)
+}
+
+// templateNames returns the names of the "<name>" tokens in the given template, and "" if it contains "*".
+func templateNames(template string) []string {
+	names := []string{}
+	for _, m := range regexp.MustCompile(`<([^>]*)>`).FindAllStringSubmatch(template, -1) {
+		names = append(names, m[1])
+	}
+	if strings.Contains(template, "*") {
+		names = append(names, "")
+	}
+	return names
+}
+
+// escape escapes the given path parameter value. The slashes in a wildcard value are kept.
+func escape(value string, wildcard bool) string {
+	if !wildcard {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(value, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
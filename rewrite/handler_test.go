@@ -0,0 +1,100 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rewrite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter() *routing.Router {
+	router := routing.New()
+	router.Get("/new/<id>", func(c *routing.Context) error {
+		return c.Write("new " + c.Param("id") + " " + c.Request.URL.RawQuery)
+	})
+	router.Get("/docs/*", func(c *routing.Context) error {
+		return c.Write("docs " + c.Param(""))
+	})
+	return router
+}
+
+func TestHandler(t *testing.T) {
+	router := newRouter()
+	router.Pre(Handler(
+		Rule{Pattern: `/old/<id:\d+>`, Target: "/new/<id>"},
+		Rule{Pattern: `^/posts/(\d+)/(?P<slug>[a-z]+)$`, Target: "/new/$1-${slug}", Status: http.StatusMovedPermanently},
+		Rule{Pattern: "/docs/v1/*", Target: "/docs/*"},
+		Rule{Pattern: "/query/<id>", Target: "/new/<id>?q=1"},
+	))
+
+	tests := []struct {
+		tag, url string
+		status   int
+		body     string
+		location string
+	}{
+		{"t1", "/old/123?a=b", http.StatusOK, "new 123 a=b", ""},
+		{"t2", "/old/abc", http.StatusNotFound, "", ""},
+		{"t3", "/posts/2020/hello?a=b", http.StatusMovedPermanently, "", "/new/2020-hello?a=b"},
+		{"t4", "/docs/v1/a/b%20c", http.StatusOK, "docs a/b c", ""},
+		{"t5", "/query/1?a=b", http.StatusOK, "new 1 q=1", ""},
+		{"t6", "/new/1", http.StatusOK, "new 1 ", ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.url, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		if test.body != "" {
+			assert.Equal(t, test.body, res.Body.String(), test.tag)
+		}
+		assert.Equal(t, test.location, res.Header().Get("Location"), test.tag)
+	}
+
+	assert.Panics(t, func() {
+		Handler(Rule{Pattern: "/old/<id", Target: "/new"})
+	})
+	assert.Panics(t, func() {
+		Handler(Rule{Pattern: "/old/*/edit", Target: "/new"})
+	})
+	assert.Panics(t, func() {
+		Handler(Rule{Pattern: "^/old/(", Target: "/new"})
+	})
+}
+
+func TestHandlerUse(t *testing.T) {
+	router := routing.New()
+	router.Use(Handler(Rule{Pattern: "/old/<id>", Target: "/new/<id>"}))
+	router.Get("/new/<id>", func(c *routing.Context) error {
+		return c.Write("new " + c.Param("id"))
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/old/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "new 1", res.Body.String())
+}
+
+func TestTo(t *testing.T) {
+	router := newRouter()
+	router.Get("/users/<id>/profile", To("/new/<id>"))
+	router.Get("/files/*", To("/docs/*", http.StatusFound))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/ab/profile?x=1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "new ab x=1", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/files/a/b c", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusFound, res.Code)
+	assert.Equal(t, "/docs/a/b%20c", res.Header().Get("Location"))
+}
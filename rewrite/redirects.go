@@ -0,0 +1,137 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rewrite
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Redirects returns a handler that redirects the requests according to the given redirect table, which is usually
+// loaded by ReadCSV, ReadJSON, or MapRules. It works like Handler, except that the rules without a status redirect
+// the clients with 301 (Moved Permanently) instead of rewriting the requests internally. The status of a rule must
+// be 301, 302, 303, 307, or 308. Redirects panics if any rule is invalid. For example,
+//
+//     f, _ := os.Open("redirects.csv")
+//     rules, err := rewrite.ReadCSV(f)
+//     if err != nil {
+//         log.Fatal(err)
+//     }
+//     r.Pre(rewrite.Redirects(rules...))
+//
+// where redirects.csv lists the patterns, the targets, and the optional statuses:
+//
+//     /summer-sale,/promotions/summer,302
+//     /blog/<year>/<slug>,/articles/<slug>
+//     /docs/v1/*,https://docs.example.com/v1/*,308
+func Redirects(rules ...Rule) routing.Handler {
+	table := make([]Rule, len(rules))
+	for i, r := range rules {
+		r, err := redirectRule(r)
+		if err != nil {
+			panic(err.Error())
+		}
+		table[i] = r
+	}
+	return Handler(table...)
+}
+
+// MapRules returns the redirect rules for the given table, which maps the patterns to the targets, with the given
+// status. Because a map has no order, the rules are sorted so that the longer patterns, which are usually more
+// specific, are matched first. The patterns of the same length are sorted alphabetically.
+func MapRules(table map[string]string, status int) []Rule {
+	patterns := make([]string, 0, len(table))
+	for pattern := range table {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	rules := make([]Rule, len(patterns))
+	for i, pattern := range patterns {
+		rules[i] = Rule{Pattern: pattern, Target: table[pattern], Status: status}
+	}
+	return rules
+}
+
+// ReadCSV reads a redirect table in the CSV format. Each record consists of a pattern, a target, and an optional
+// status, which defaults to 301. The empty lines and the lines starting with "#" are ignored, and so is the first
+// record if it is a header whose first field is "pattern" or "source". An error is returned if any rule is invalid.
+func ReadCSV(r io.Reader) ([]Rule, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var rules []Rule
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rules, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if n == 1 && (strings.EqualFold(record[0], "pattern") || strings.EqualFold(record[0], "source")) {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("rewrite: record %d: expected 2 or 3 fields, got %d", n, len(record))
+		}
+		rule := Rule{Pattern: record[0], Target: record[1]}
+		if len(record) == 3 && record[2] != "" {
+			if rule.Status, err = strconv.Atoi(record[2]); err != nil {
+				return nil, fmt.Errorf("rewrite: record %d: invalid status %q", n, record[2])
+			}
+		}
+		if rule, err = redirectRule(rule); err != nil {
+			return nil, fmt.Errorf("%v (record %d)", err, n)
+		}
+		rules = append(rules, rule)
+	}
+}
+
+// ReadJSON reads a redirect table in the JSON format, which is an array of the objects with the "pattern", "target",
+// and optional "status" members, e.g. [{"pattern": "/old/<id>", "target": "/new/<id>", "status": 308}].
+// The status defaults to 301. An error is returned if any rule is invalid.
+func ReadJSON(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules {
+		var err error
+		if rules[i], err = redirectRule(rule); err != nil {
+			return nil, fmt.Errorf("%v (rule %d)", err, i)
+		}
+	}
+	return rules, nil
+}
+
+// redirectRule validates the given redirect rule and sets its default status.
+func redirectRule(r Rule) (Rule, error) {
+	switch r.Status {
+	case 0:
+		r.Status = http.StatusMovedPermanently
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return r, fmt.Errorf("rewrite: invalid redirect status %d for pattern %q", r.Status, r.Pattern)
+	}
+	if r.Pattern == "" || r.Target == "" {
+		return r, errors.New("rewrite: the pattern and the target of a redirect must not be empty")
+	}
+	_, err := compileRule(r)
+	return r, err
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package rewrite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirects(t *testing.T) {
+	rules, err := ReadCSV(strings.NewReader(`pattern,target,status
+# marketing
+/summer-sale,/promotions/summer,302
+
+/blog/<year>/<slug>,/articles/<slug>
+/docs/v1/*,https://docs.example.com/v1/*,308
+`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	router := newRouter()
+	router.Pre(Redirects(rules...))
+
+	tests := []struct {
+		tag, url string
+		status   int
+		location string
+	}{
+		{"t1", "/summer-sale?utm=x", http.StatusFound, "/promotions/summer?utm=x"},
+		{"t2", "/blog/2020/hello", http.StatusMovedPermanently, "/articles/hello"},
+		{"t3", "/docs/v1/a/b", http.StatusPermanentRedirect, "https://docs.example.com/v1/a/b"},
+		{"t4", "/new/1", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.url, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.location, res.Header().Get("Location"), test.tag)
+	}
+
+	assert.Panics(t, func() {
+		Redirects(Rule{Pattern: "/old", Target: "/new", Status: http.StatusOK})
+	})
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		tag, data string
+		rules     []Rule
+		err       bool
+	}{
+		{"t1", "/a,/b\n/c,/d,307\n", []Rule{{"/a", "/b", 301}, {"/c", "/d", 307}}, false},
+		{"t2", "source,target\n/a,/b,\n", []Rule{{"/a", "/b", 301}}, false},
+		{"t3", "/a\n", nil, true},
+		{"t4", "/a,/b,abc\n", nil, true},
+		{"t5", "/a,/b,200\n", nil, true},
+		{"t6", "/a/<id,/b\n", nil, true},
+		{"t7", ",/b\n", nil, true},
+	}
+	for _, test := range tests {
+		rules, err := ReadCSV(strings.NewReader(test.data))
+		assert.Equal(t, test.err, err != nil, test.tag)
+		assert.Equal(t, test.rules, rules, test.tag)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	rules, err := ReadJSON(strings.NewReader(`[{"pattern": "/old/<id>", "target": "/new/<id>", "status": 308}, {"pattern": "/a", "target": "/b"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Rule{{"/old/<id>", "/new/<id>", 308}, {"/a", "/b", 301}}, rules)
+
+	_, err = ReadJSON(strings.NewReader(`[{"pattern": "/a", "target": "/b", "status": 404}]`))
+	assert.NotNil(t, err)
+	_, err = ReadJSON(strings.NewReader(`{`))
+	assert.NotNil(t, err)
+}
+
+func TestMapRules(t *testing.T) {
+	rules := MapRules(map[string]string{
+		"/docs/*":    "/help/*",
+		"/docs/v1/*": "/help/v1/*",
+		"/about":     "/company",
+		"/contact":   "/company/contact",
+	}, http.StatusFound)
+	assert.Equal(t, []Rule{
+		{"/docs/v1/*", "/help/v1/*", 302},
+		{"/contact", "/company/contact", 302},
+		{"/docs/*", "/help/*", 302},
+		{"/about", "/company", 302},
+	}, rules)
+}
@@ -6,7 +6,9 @@ package routing
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +18,13 @@ type Route struct {
 	method, path   string
 	name, template string
 	tags           []interface{}
+	meta           map[string]interface{}
+	request        interface{}
+	responses      map[int]interface{}
+	handlers       []Handler
+	offset         int // the index of the first handler registered with the route rather than its group
+	finally        []FinallyHandler
+	priority       int
 	routes         []*Route
 }
 
@@ -43,6 +52,86 @@ func (r *Route) Tag(value interface{}) *Route {
 	return r
 }
 
+// Set associates a named metadata item with the route.
+// Handlers may retrieve the metadata of the route matching the current request via Context.Route().
+func (r *Route) Set(name string, value interface{}) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Set(name, value)
+		}
+		return r
+	}
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[name] = value
+	return r
+}
+
+// Meta returns the named metadata item previously associated with the route by calling Set.
+// If the named metadata item cannot be found, nil will be returned.
+func (r *Route) Meta(name string) interface{} {
+	return r.meta[name]
+}
+
+// Priority sets the priority of the route. When a request matches multiple routes, the route with
+// the highest priority will be chosen. Routes with the same priority (which defaults to 0) are chosen
+// in the order they are added to the router, i.e., the route added first takes precedence.
+func (r *Route) Priority(priority int) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Priority(priority)
+		}
+		return r
+	}
+	r.priority = priority
+	r.group.router.reorderRoutes(r)
+	return r
+}
+
+// Fallback registers the handlers to be called when the handlers of the route cannot find the requested resource,
+// i.e., when they return an HTTPError with the 404 status code, like file.Server does for missing files.
+// This allows a route to respond to the missing resources differently from the rest of the router,
+// e.g. to serve an SPA's index page for unknown paths under "/app/*" while the API routes return JSON errors.
+// The fallback handlers are called within the route group handlers, such as access loggers and error handlers,
+// and the error they return replaces the 404 error. If Fallback is called multiple times, each fallback chain
+// is called in turn as long as the previous ones cannot find the resource either.
+//
+//     router.Get("/app/*", file.Server(file.PathMap{"/app": "/ui/dist"})).
+//         Fallback(file.Content("ui/dist/index.html"))
+func (r *Route) Fallback(handlers ...Handler) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Fallback(handlers...)
+		}
+		return r
+	}
+	r.group.router.checkFrozen()
+	hh := make([]Handler, 0, len(r.handlers)+1)
+	hh = append(hh, r.handlers[:r.offset]...)
+	hh = append(hh, fallback(handlers))
+	r.handlers = append(hh, r.handlers[r.offset:]...)
+	return r
+}
+
+// fallback returns a handler that calls the given handlers if the rest of the handlers return a 404 error.
+func fallback(handlers []Handler) Handler {
+	return func(c *Context) error {
+		err := c.Next()
+		if httpError, ok := err.(HTTPError); !ok || httpError.StatusCode() != http.StatusNotFound {
+			return err
+		}
+		outer, index, timings := c.handlers, c.index, c.timings
+		c.handlers, c.index, c.timings = handlers, -1, nil
+		err = c.Next()
+		c.handlers, c.index, c.timings = outer, index, timings
+		return err
+	}
+}
+
 // Method returns the HTTP method that this route is associated with.
 func (r *Route) Method() string {
 	return r.method
@@ -126,6 +215,34 @@ func (r *Route) URL(pairs ...interface{}) (s string) {
 	return
 }
 
+// AllowedMethods returns the HTTP methods of the routes that share the path pattern and the scheme of this route,
+// in alphabetical order. HEAD is included if the router handles HEAD requests with the GET routes (see RouterOptions).
+// The methods are determined when the routes are added, which is useful for generating API documentation.
+func (r *Route) AllowedMethods() []string {
+	route := r
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		route = r.routes[0]
+	}
+	router := r.group.router
+	allowed := router.allowed[patternKey(route)]
+	if allowed == nil {
+		return []string{}
+	}
+	methods := append([]string{}, allowed.methods...)
+	if router.autoHead && hasMethod(methods, "GET") && !hasMethod(methods, "HEAD") {
+		methods = append(methods, "HEAD")
+		sort.Strings(methods)
+	}
+	return methods
+}
+
+// hasMethod checks if the given sorted methods contain the method.
+func hasMethod(methods []string, method string) bool {
+	i := sort.SearchStrings(methods, method)
+	return i < len(methods) && methods[i] == method
+}
+
 // String returns the string representation of the route.
 func (r *Route) String() string {
 	return r.method + " " + r.group.prefix + r.path
@@ -7,6 +7,8 @@ package routing
 import (
 	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,7 +24,7 @@ func newMockStore() *mockStore {
 }
 
 func (s *mockStore) Add(key string, data interface{}) int {
-	for _, handler := range data.([]Handler) {
+	for _, handler := range data.(*Route).handlers {
 		handler(nil)
 	}
 	return s.store.Add(key, data)
@@ -127,6 +129,30 @@ func TestRouteTag(t *testing.T) {
 	}
 }
 
+func TestRouteSet(t *testing.T) {
+	router := New()
+	r1 := router.Get("/posts").Set("cache", "public")
+	router.To("PUT,PATCH", "/comments").Set("cache", "no-store")
+	assert.Equal(t, "public", r1.Meta("cache"))
+	assert.Nil(t, r1.Meta("unknown"))
+	for _, route := range router.Routes() {
+		if route.path == "/comments" {
+			assert.Equal(t, "no-store", route.Meta("cache"))
+		}
+	}
+
+	var route *Route
+	router.Get("/users", func(c *Context) error {
+		route = c.Route()
+		return nil
+	}).Set("cache", "private")
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if assert.NotNil(t, route) {
+		assert.Equal(t, "private", route.Meta("cache"))
+	}
+}
+
 func TestRouteMethods(t *testing.T) {
 	router := New()
 	for _, method := range Methods {
@@ -207,3 +233,146 @@ POST /users/<id>/profile
 POST /admin/users
 `, s)
 }
+
+func TestRoutePriority(t *testing.T) {
+	router := New()
+	h := func(s string) Handler {
+		return func(c *Context) error {
+			return c.Write(s)
+		}
+	}
+	router.Get("/users/*", h("wildcard"))
+	router.Get("/users/<id>", h("param"))
+	router.Get("/users/me", h("static"))
+	router.Get("/users/<name>", h("name")).Priority(-1)
+
+	tests := []struct {
+		path, expected string
+	}{
+		{"/users/me", "wildcard"},
+		{"/users/123", "wildcard"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.expected, res.Body.String(), test.path)
+	}
+
+	for _, route := range router.Routes() {
+		switch route.path {
+		case "/users/me":
+			route.Priority(2)
+		case "/users/<id>":
+			route.Priority(1)
+		}
+	}
+	tests = []struct {
+		path, expected string
+	}{
+		{"/users/me", "static"},
+		{"/users/123", "param"},
+		{"/users/123/profile", "wildcard"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.expected, res.Body.String(), test.path)
+	}
+
+	// same path: the route with higher priority wins
+	router.To("GET,POST", "/posts", h("first"))
+	router.To("GET,POST", "/posts", h("second")).Priority(1)
+	for _, method := range []string{"GET", "POST"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/posts", nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, "second", res.Body.String(), method)
+	}
+
+	// the routes added later are ordered by their priorities too
+	router = New()
+	router.Get("/items/<name>", h("name")).Priority(-1)
+	router.Get("/items/me", h("static"))
+	router.Get("/items/<id>", h("id")).Priority(-1)
+	router.Get("/items/*", h("wildcard"))
+	tests = []struct {
+		path, expected string
+	}{
+		{"/items/me", "static"},
+		{"/items/123", "wildcard"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.expected, res.Body.String(), test.path)
+	}
+}
+
+func TestRouteFallback(t *testing.T) {
+	var logs []string
+	router := New()
+	router.Timing = true
+	router.Use(func(c *Context) error {
+		err := c.Next()
+		logs = append(logs, fmt.Sprint(err))
+		return err
+	})
+	notFound := func(c *Context) error {
+		return NewHTTPError(http.StatusNotFound)
+	}
+	router.Get("/static/*", func(c *Context) error {
+		if c.Param("") == "a.txt" {
+			c.Abort()
+			return c.Write("a")
+		}
+		if c.Param("") == "fail" {
+			return NewHTTPError(http.StatusForbidden)
+		}
+		return c.Next()
+	}, notFound).Fallback(func(c *Context) error {
+		assert.Nil(t, c.HandlerTimings())
+		if c.Param("") == "b.txt" {
+			c.Abort()
+			return c.Write("fallback b")
+		}
+		return c.Next()
+	}, notFound).Fallback(func(c *Context) error {
+		return c.Write("fallback index")
+	})
+	router.Get("/api/*", notFound)
+
+	tests := []struct {
+		tag, path string
+		status    int
+		body, log string
+	}{
+		{"t1", "/static/a.txt", http.StatusOK, "a", "<nil>"},
+		{"t2", "/static/b.txt", http.StatusOK, "fallback b", "<nil>"},
+		{"t3", "/static/c.txt", http.StatusOK, "fallback index", "<nil>"},
+		{"t4", "/static/fail", http.StatusForbidden, "Forbidden\n", "Forbidden"},
+		{"t5", "/api/users", http.StatusNotFound, "Not Found\n", "Not Found"},
+	}
+	for _, test := range tests {
+		logs = nil
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+		assert.Equal(t, []string{test.log}, logs, test.tag)
+	}
+
+	// composite routes
+	router.To("GET,POST", "/files/*", notFound).Fallback(func(c *Context) error {
+		return c.Write(c.Request.Method)
+	})
+	for _, method := range []string{"GET", "POST"} {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/files/x", nil)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, method, res.Body.String())
+	}
+}
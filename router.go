@@ -6,6 +6,9 @@
 package routing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
@@ -17,28 +20,67 @@ type (
 	// Handler is the function for handling HTTP requests.
 	Handler func(*Context) error
 
+	// FinallyHandler is the function that is called after the handlers of a route are executed.
+	// It receives the error returned by the handlers (nil if none) and returns the error to be handled by the router.
+	FinallyHandler func(*Context, error) error
+
 	// Router manages routes and dispatches HTTP requests to the handlers of the matching routes.
 	Router struct {
 		RouteGroup
-		IgnoreTrailingSlash bool // whether to ignore trailing slashes in the end of the request URL
-		UseEscapedPath      bool // whether to use encoded URL instead of decoded URL to match routes
+		IgnoreTrailingSlash bool                   // whether to ignore trailing slashes in the end of the request URL
+		UseEscapedPath      bool                   // whether to use encoded URL instead of decoded URL to match routes
+		TrustForwardedProto bool                   // whether to use the X-Forwarded-Proto header to determine the request scheme
+		FormOptions         FormOptions            // the options for parsing request bodies as form data
+		Translator          Translator             // the translator used to localize error messages
+		Debug               bool                   // whether to record the route matching trace of every request (see RouteTrace)
+		DebugHeader         string                 // the request header which, when present, enables recording the route matching trace
+		ContextKeys         map[string]interface{} // the request context keys bridged by Context.Get and Context.Set, indexed by data names
+		Timing              bool                   // whether to record the execution time of each handler (see Context.HandlerTimings)
+		AllowUnnamedParams  bool                   // whether to allow parameter tokens without names in route paths (e.g. "<:\d+>")
+		Stats               *Stats                 // the counters of the served requests; nil disables counting
+		History             *History               // the last requests served by each route; nil disables recording
+		ErrorBudget         *ErrorBudget           // the tracker of the 5xx rates of the routes raising alerts; nil disables tracking
+		Exits               *ExitStats             // the counts of the handlers ending the handler chains; nil disables counting
+		StrictPath          bool                   // whether to reject the request paths containing empty segments, NUL, or control characters (see InvalidPath)
+		ForwardMiddleware   bool                   // whether Context.Forward executes the handlers inherited by the target route from the router and the groups
 		pool                sync.Pool
 		routes              []*Route
 		namedRoutes         map[string]*Route
 		stores              map[string]routeStore
+		schemeStores        map[string]map[string]routeStore
+		prioritized         map[string]bool // whether the routes of the stores, indexed by the schemes and methods, have priorities
 		maxParams           int
+		pre                 []Handler
+		autoHead            bool
+		maxBodySize         int64
+		values              map[string]interface{}
+		allowed             map[string]*allowedMethods // the methods of the routes, indexed by the schemes and path patterns of the routes
+		patterns            map[string]routeStore      // the path patterns of the routes, indexed by the schemes of the routes
 		notFound            []Handler
 		notFoundHandlers    []Handler
+		fallback            []Handler
+		invalidPath         []Handler
+		invalidPathHandlers []Handler
+		frozen              bool
+		ctx                 context.Context
+		cancel              context.CancelFunc
+		tasks               sync.WaitGroup
 	}
 
 	// routeStore stores route paths and the corresponding handlers.
 	routeStore interface {
 		Add(key string, data interface{}) int
 		Get(key string, pvalues []string) (data interface{}, pnames []string)
+		Trace(key string, pvalues []string, trace *[]string) (data interface{}, pnames []string)
 		String() string
 	}
 )
 
+// RouteTrace is the key used to store and retrieve the route matching trace in Context when Router.Debug is true
+// or when the request contains the Router.DebugHeader header. The trace is a list of messages ([]string)
+// describing how the request path is matched against the routes, which helps diagnose unexpected 404 or 405 errors.
+const RouteTrace = "RouteTrace"
+
 // Methods lists all supported HTTP methods by Router.
 var Methods = []string{
 	"CONNECT",
@@ -55,11 +97,15 @@ var Methods = []string{
 // New creates a new Router object.
 func New() *Router {
 	r := &Router{
-		namedRoutes: make(map[string]*Route),
-		stores:      make(map[string]routeStore),
+		namedRoutes:  make(map[string]*Route),
+		stores:       make(map[string]routeStore),
+		schemeStores: make(map[string]map[string]routeStore),
+		prioritized:  make(map[string]bool),
 	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
 	r.RouteGroup = *newRouteGroup("", r, make([]Handler, 0))
 	r.NotFound(MethodNotAllowedHandler, NotFoundHandler)
+	r.InvalidPath(InvalidPathHandler)
 	r.pool.New = func() interface{} {
 		return &Context{
 			pvalues: make([]string, r.maxParams),
@@ -72,20 +118,109 @@ func New() *Router {
 // ServeHTTP handles the HTTP request.
 // It is required by http.Handler
 func (r *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if r.Stats != nil || r.History != nil || r.ErrorBudget != nil || r.Exits != nil {
+		r.serveWithStats(res, req)
+		return
+	}
+	c := r.AcquireContext(res, req)
+	r.Dispatch(c)
+	r.ReleaseContext(c)
+}
+
+// AcquireContext returns a Context from the pool managed by the router and initializes it with the given
+// response writer and request. The Context should be returned to the pool by calling ReleaseContext
+// once it is no longer used. Together with Dispatch, this allows servers that do not use http.Handler
+// (e.g. custom protocols or test harnesses) to reuse the routing and handlers of the router.
+func (r *Router) AcquireContext(res http.ResponseWriter, req *http.Request) *Context {
 	c := r.pool.Get().(*Context)
+	if len(c.pvalues) < r.maxParams {
+		// routes with more parameters were added after the context was created
+		c.pvalues = make([]string, r.maxParams)
+	}
 	c.init(res, req)
+	return c
+}
+
+// ReleaseContext returns a Context obtained by AcquireContext to the pool.
+// The Context must not be used after being released.
+func (r *Router) ReleaseContext(c *Context) {
+	r.pool.Put(c)
+}
+
+// Dispatch finds the route matching the request of the given Context and executes its handlers, including the
+// finally handlers. An error returned by the handlers is handled as in ServeHTTP.
+// The Context should be obtained via AcquireContext and be dispatched only once.
+func (r *Router) Dispatch(c *Context) {
+	// the upgraded connections are not read via the request body, so their streams are not limited
+	if r.maxBodySize > 0 && c.Request.Body != nil && !c.IsUpgrade() {
+		if c.Request.ContentLength > r.maxBodySize {
+			r.handleError(c, NewHTTPError(http.StatusRequestEntityTooLarge))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Response, c.Request.Body, r.maxBodySize)
+	}
+	if len(r.pre) > 0 {
+		c.handlers = r.pre
+		c.prerouting = true
+		err := c.Next()
+		c.prerouting = false
+		if err != nil || c.index > len(r.pre) {
+			if err != nil {
+				r.handleError(c, err)
+			}
+			return
+		}
+		c.index = -1
+	}
+	r.match(c)
+	if r.Timing {
+		c.startTiming()
+	}
+	err := c.Next()
+	finally := r.finally
+	if c.route != nil {
+		finally = c.route.finally
+	}
+	for _, h := range finally {
+		err = h(c, err)
+	}
+	if r.Timing {
+		c.setServerTiming()
+	}
+	if err != nil {
+		r.handleError(c, err)
+	}
+}
+
+// match finds the route matching the request of the given Context and sets the route, the handlers,
+// and the parameters of the Context accordingly.
+func (r *Router) match(c *Context) {
+	req := c.Request
+	scheme := ""
+	if len(r.schemeStores) > 0 {
+		scheme = r.requestScheme(req)
+	}
+	path := req.URL.Path
+	if r.UseEscapedPath {
+		path = req.URL.EscapedPath()
+	}
+	path = r.normalizeRequestPath(path)
+	if r.StrictPath && !isValidPath(req.URL.Path) {
+		c.route, c.handlers, c.pnames = nil, r.invalidPathHandlers, nil
+	} else {
+		c.route, c.handlers, c.pnames = r.find(scheme, req.Method, path, c.pvalues)
+		if c.route == nil && r.fallback != nil {
+			c.handlers = r.fallback
+		}
+	}
 	if r.UseEscapedPath {
-		c.handlers, c.pnames = r.find(req.Method, r.normalizeRequestPath(req.URL.EscapedPath()), c.pvalues)
 		for i, v := range c.pvalues {
 			c.pvalues[i], _ = url.QueryUnescape(v)
 		}
-	} else {
-		c.handlers, c.pnames = r.find(req.Method, r.normalizeRequestPath(req.URL.Path), c.pvalues)
 	}
-	if err := c.Next(); err != nil {
-		r.handleError(c, err)
+	if r.Debug || r.DebugHeader != "" && req.Header.Get(r.DebugHeader) != "" {
+		c.Set(RouteTrace, r.trace(scheme, req.Method, path))
 	}
-	r.pool.Put(c)
 }
 
 // Route returns the named route.
@@ -101,21 +236,108 @@ func (r *Router) Routes() []*Route {
 
 // Use appends the specified handlers to the router and shares them with all routes.
 func (r *Router) Use(handlers ...Handler) {
+	r.checkFrozen()
 	r.RouteGroup.Use(handlers...)
 	r.notFoundHandlers = combineHandlers(r.handlers, r.notFound)
+	r.invalidPathHandlers = combineHandlers(r.handlers, r.invalidPath)
+}
+
+// Scheme creates a RouteGroup whose routes only match the requests made with the given URL scheme (e.g. "https").
+// The scheme of a request is "https" if it is received over TLS and "http" otherwise. If TrustForwardedProto
+// is true, the X-Forwarded-Proto header set by a reverse proxy will be used instead when it is present.
+// Routes added to a scheme group take precedence over the routes that are not bound to any scheme.
+// If no handler is provided, the new group will inherit the handlers registered with the router.
+func (r *Router) Scheme(scheme string, handlers ...Handler) *RouteGroup {
+	rg := r.Group("", handlers...)
+	rg.scheme = strings.ToLower(scheme)
+	return rg
+}
+
+// Pre appends the specified handlers to the list of handlers that are invoked for every request before the router
+// matches the request against the routes. Unlike the handlers registered via Use, they are called for all requests,
+// including those matching no route, and they may modify the request (e.g. its Host or URL) to affect the route
+// matching. A pre-handler may stop the dispatching by returning an error, which is handled by the router's default
+// error handler, or by writing the response and calling Context.Abort. Pre-handlers should not call Context.Next.
+//
+//     r := routing.New()
+//     r.Pre(routing.AllowedHosts("example.com", "*.example.com"))
+func (r *Router) Pre(handlers ...Handler) {
+	r.checkFrozen()
+	r.pre = append(r.pre, handlers...)
 }
 
 // NotFound specifies the handlers that should be invoked when the router cannot find any route matching a request.
 // Note that the handlers registered via Use will be invoked first in this case.
 func (r *Router) NotFound(handlers ...Handler) {
+	r.checkFrozen()
 	r.notFound = handlers
 	r.notFoundHandlers = combineHandlers(r.handlers, r.notFound)
 }
 
+// Fallback delegates the requests matching no route to the given http.Handler, such as a legacy ServeMux or
+// a reverse proxy, instead of calling the NotFound handlers. This allows migrating an application from another
+// framework route by route. The handlers registered via Use are not called for the delegated requests, while
+// those registered via Pre and Finally still are. Passing nil restores the NotFound handlers. For example,
+//
+//     r := routing.New()
+//     r.Get("/users/<id>", getUser) // migrated routes
+//     r.Fallback(legacyMux)         // everything else
+//
+// Conversely, since Router is an http.Handler, another framework can delegate its unmatched requests to a Router.
+func (r *Router) Fallback(h http.Handler) {
+	r.checkFrozen()
+	if h == nil {
+		r.fallback = nil
+	} else {
+		r.fallback = []Handler{HTTPHandler(h)}
+	}
+}
+
+// InvalidPath specifies the handlers that should be invoked instead of matching routes when StrictPath is true and
+// the request path contains empty segments (e.g. "/users//1"), NUL, or other control characters. By default,
+// InvalidPathHandler is used to respond with 400 (Bad Request). Note that the handlers registered via Use will
+// be invoked first in this case.
+func (r *Router) InvalidPath(handlers ...Handler) {
+	r.checkFrozen()
+	r.invalidPath = handlers
+	r.invalidPathHandlers = combineHandlers(r.handlers, r.invalidPath)
+}
+
+// Freeze finalizes the routing table. It compacts the internal data structures used to match routes so that
+// requests can be dispatched faster. Because the routing table becomes immutable, it can be safely read by
+// concurrent requests without locking. Freeze should be called after all routes are registered and before
+// the router starts serving requests. Adding routes or changing route priorities, router-level handlers,
+// or not-found handlers afterwards will panic.
+func (r *Router) Freeze() {
+	if r.frozen {
+		return
+	}
+	r.frozen = true
+	freeze := func(stores map[string]routeStore) {
+		for _, store := range stores {
+			if s, ok := store.(interface{ Freeze() }); ok {
+				s.Freeze()
+			}
+		}
+	}
+	freeze(r.stores)
+	for _, stores := range r.schemeStores {
+		freeze(stores)
+	}
+	freeze(r.patterns)
+}
+
+// checkFrozen panics if the routing table is frozen.
+func (r *Router) checkFrozen() {
+	if r.frozen {
+		panic("routing: cannot modify a frozen router")
+	}
+}
+
 // Find determines the handlers and parameters to use for a specified method and path.
 func (r *Router) Find(method, path string) (handlers []Handler, params map[string]string) {
 	pvalues := make([]string, r.maxParams)
-	handlers, pnames := r.find(method, path, pvalues)
+	_, handlers, pnames := r.find("", method, path, pvalues)
 	params = make(map[string]string, len(pnames))
 	for i, n := range pnames {
 		params[n] = pvalues[i]
@@ -123,8 +345,29 @@ func (r *Router) Find(method, path string) (handlers []Handler, params map[strin
 	return handlers, params
 }
 
+// Match returns the route matching the specified method and path, and the parameters extracted from the path,
+// without invoking any handler. Nil is returned if no route matches. It is mainly used to test the routing table.
+func (r *Router) Match(method, path string) (route *Route, params map[string]string) {
+	pvalues := make([]string, r.maxParams)
+	route, _, pnames := r.find("", method, path, pvalues)
+	if route == nil {
+		return nil, nil
+	}
+	params = make(map[string]string, len(pnames))
+	for i, n := range pnames {
+		params[n] = pvalues[i]
+	}
+	return route, params
+}
+
 // handleError is the error handler for handling any unhandled errors.
 func (r *Router) handleError(c *Context, err error) {
+	writeError(c, err)
+}
+
+// writeError writes the given error as a plain text response.
+func writeError(c *Context, err error) {
+	err = c.TranslateError(err)
 	if httpError, ok := err.(HTTPError); ok {
 		http.Error(c.Response, httpError.Error(), httpError.StatusCode())
 	} else {
@@ -133,46 +376,283 @@ func (r *Router) handleError(c *Context, err error) {
 }
 
 func (r *Router) addRoute(route *Route, handlers []Handler) {
-	path := route.group.prefix + route.path
+	r.checkFrozen()
+	if err := validateRoutePath(route.group.prefix+route.path, r.AllowUnnamedParams); err != nil {
+		panic(fmt.Sprintf("invalid route %v: %v", route, err))
+	}
+	route.handlers = handlers
 
 	r.routes = append(r.routes, route)
 
-	store := r.stores[route.method]
-	if store == nil {
-		store = newStore()
-		r.stores[route.method] = store
+	if r.prioritized[route.group.scheme+" "+route.method] {
+		// the route must precede the routes of lower priorities added before
+		r.reorderRoutes(route)
+	} else {
+		stores := r.routeStores(route.group.scheme)
+		store := stores[route.method]
+		if store == nil {
+			store = newStore()
+			stores[route.method] = store
+		}
+		if n := store.Add(storeKey(route), route); n > r.maxParams {
+			r.maxParams = n
+		}
 	}
 
+	r.addAllowedMethod(route)
+}
+
+// addAllowedMethod records the method of the given route among the methods allowed for the path pattern of the route,
+// and precomputes the Allow header of the pattern, so that MethodNotAllowedHandler needs only one lookup per request.
+func (r *Router) addAllowedMethod(route *Route) {
+	if r.allowed == nil {
+		r.allowed = map[string]*allowedMethods{}
+		r.patterns = map[string]routeStore{}
+	}
+	key := patternKey(route)
+	allowed := r.allowed[key]
+	if allowed == nil {
+		allowed = &allowedMethods{}
+		r.allowed[key] = allowed
+		patterns := r.patterns[route.group.scheme]
+		if patterns == nil {
+			patterns = newStore()
+			r.patterns[route.group.scheme] = patterns
+		}
+		patterns.Add(storeKey(route), allowed)
+	}
+	if hasMethod(allowed.methods, route.method) {
+		return
+	}
+	allowed.methods = append(allowed.methods, route.method)
+	sort.Strings(allowed.methods)
+	allowed.header = strings.Join(allowHeaderMethods(allowed.methods, r.autoHead), ", ")
+}
+
+// allowedMethods is the methods of the routes sharing a path pattern.
+type allowedMethods struct {
+	methods []string // the sorted methods of the routes
+	header  string   // the value of the Allow header
+}
+
+// allowHeaderMethods returns the sorted methods listed in the Allow header for the given sorted methods of the routes,
+// which include OPTIONS, and HEAD if autoHead is true and GET is among the methods.
+func allowHeaderMethods(methods []string, autoHead bool) []string {
+	ms := append([]string{}, methods...)
+	if !hasMethod(ms, "OPTIONS") {
+		ms = append(ms, "OPTIONS")
+	}
+	if autoHead && hasMethod(methods, "GET") && !hasMethod(ms, "HEAD") {
+		ms = append(ms, "HEAD")
+	}
+	sort.Strings(ms)
+	return ms
+}
+
+// patternKey returns the key identifying the scheme and the path pattern of the given route.
+func patternKey(route *Route) string {
+	return route.group.scheme + " " + route.group.prefix + route.path
+}
+
+// reorderRoutes rebuilds the store containing the given route so that the routes in the store are ordered
+// by their priorities first and then by the sequence in which they are added. The routes added to the store
+// afterwards are inserted by their priorities as well.
+func (r *Router) reorderRoutes(route *Route) {
+	r.checkFrozen()
+	scheme, method := route.group.scheme, route.method
+	r.prioritized[scheme+" "+method] = true
+	routes := []*Route{}
+	for _, rt := range r.routes {
+		if rt.group.scheme == scheme && rt.method == method {
+			routes = append(routes, rt)
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].priority > routes[j].priority
+	})
+	store := newStore()
+	for _, rt := range routes {
+		if n := store.Add(storeKey(rt), rt); n > r.maxParams {
+			r.maxParams = n
+		}
+	}
+	r.routeStores(scheme)[method] = store
+}
+
+// routeStores returns the stores for the routes bound to the given scheme.
+func (r *Router) routeStores(scheme string) map[string]routeStore {
+	if scheme == "" {
+		return r.stores
+	}
+	stores := r.schemeStores[scheme]
+	if stores == nil {
+		stores = make(map[string]routeStore)
+		r.schemeStores[scheme] = stores
+	}
+	return stores
+}
+
+// validateRoutePath checks if the given route path is well formed. A valid path must be empty or start with a slash.
+// Its parameter tokens must be enclosed in balanced angle brackets and have distinct names, and an asterisk
+// wildcard may only appear at the end of the path.
+func validateRoutePath(path string, allowUnnamed bool) error {
+	if path != "" && path[0] != '/' {
+		return errors.New("the path must start with a slash")
+	}
+	names := map[string]bool{}
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '>':
+			return fmt.Errorf("unexpected '>' at position %v", i)
+		case '*':
+			if i != len(path)-1 {
+				return errors.New("the wildcard '*' must be at the end of the path")
+			}
+		case '<':
+			end := strings.IndexByte(path[i:], '>')
+			if end < 0 {
+				return fmt.Errorf("unclosed '<' at position %v", i)
+			}
+			token := path[i+1 : i+end]
+			name := token
+			if j := strings.IndexByte(token, ':'); j >= 0 {
+				name = token[:j]
+			}
+			if strings.IndexByte(name, '<') >= 0 {
+				return fmt.Errorf("unclosed '<' at position %v", i)
+			}
+			if name == "" && (!allowUnnamed || token == "") {
+				return fmt.Errorf("the parameter at position %v has no name", i)
+			}
+			if name != "" && names[name] {
+				return fmt.Errorf("duplicate parameter name %q", name)
+			}
+			names[name] = true
+			i += end
+		}
+	}
+	return nil
+}
+
+// storeKey returns the key used to add the given route to a store.
+func storeKey(route *Route) string {
+	path := route.group.prefix + route.path
 	// an asterisk at the end matches any number of characters
 	if strings.HasSuffix(path, "*") {
 		path = path[:len(path)-1] + "<:.*>"
 	}
+	return path
+}
 
-	if n := store.Add(path, handlers); n > r.maxParams {
-		r.maxParams = n
+func (r *Router) find(scheme, method, path string, pvalues []string) (route *Route, handlers []Handler, pnames []string) {
+	data, pnames := r.findData(scheme, method, path, pvalues)
+	if data == nil && method == "HEAD" && r.autoHead {
+		data, pnames = r.findData(scheme, "GET", path, pvalues)
+	}
+	if data != nil {
+		route = data.(*Route)
+		return route, route.handlers, pnames
+	}
+	return nil, r.notFoundHandlers, pnames
+}
+
+// findData returns the data of the route matching the given scheme, method, and path.
+func (r *Router) findData(scheme, method, path string, pvalues []string) (data interface{}, pnames []string) {
+	if store := r.schemeStores[scheme][method]; store != nil {
+		data, pnames = store.Get(path, pvalues)
+	}
+	if data == nil {
+		if store := r.stores[method]; store != nil {
+			data, pnames = store.Get(path, pvalues)
+		}
 	}
+	return
 }
 
-func (r *Router) find(method, path string, pvalues []string) (handlers []Handler, pnames []string) {
-	var hh interface{}
-	if store := r.stores[method]; store != nil {
-		hh, pnames = store.Get(path, pvalues)
+// trace walks through the routes matching the given request and returns the steps taken.
+func (r *Router) trace(scheme, method, path string) []string {
+	trace := []string{}
+	pvalues := make([]string, r.maxParams)
+	var data interface{}
+	if store := r.schemeStores[scheme][method]; store != nil {
+		tracef(&trace, "searching %v %v routes for %q", scheme, method, path)
+		data, _ = store.Trace(path, pvalues, &trace)
 	}
-	if hh != nil {
-		return hh.([]Handler), pnames
+	if data == nil {
+		if store := r.stores[method]; store != nil {
+			tracef(&trace, "searching %v routes for %q", method, path)
+			data, _ = store.Trace(path, pvalues, &trace)
+		} else {
+			tracef(&trace, "no %v routes", method)
+		}
+	}
+	if data != nil {
+		tracef(&trace, "matched route %v", data)
+	} else {
+		if r.fallback != nil {
+			tracef(&trace, "no route matched: delegating to the fallback handler")
+		} else {
+			tracef(&trace, "no route matched: calling the NotFound handlers")
+		}
 	}
-	return r.notFoundHandlers, pnames
+	return trace
 }
 
-func (r *Router) findAllowedMethods(path string) map[string]bool {
-	methods := make(map[string]bool)
+// allowHeader returns the value of the Allow header for the given scheme and request path, which lists the methods
+// of the routes sharing the path pattern matched by the path, together with OPTIONS, in alphabetical order.
+// Like the routes, the path patterns are matched in the order they are added.
+// The header is precomputed for each path pattern when the routes are added. If the path matches a pattern
+// of the routes bound to the scheme as well as one of the other routes, the methods of both patterns are listed.
+// An empty string is returned if no route matches the path.
+func (r *Router) allowHeader(scheme, path string) string {
+	if r.patterns == nil {
+		return ""
+	}
 	pvalues := make([]string, r.maxParams)
-	for m, store := range r.stores {
-		if handlers, _ := store.Get(path, pvalues); handlers != nil {
-			methods[m] = true
+	var allowed, schemeAllowed *allowedMethods
+	if store := r.patterns[""]; store != nil {
+		if data, _ := store.Get(path, pvalues); data != nil {
+			allowed = data.(*allowedMethods)
+		}
+	}
+	if store := r.patterns[scheme]; store != nil && scheme != "" {
+		if data, _ := store.Get(path, pvalues); data != nil {
+			schemeAllowed = data.(*allowedMethods)
+		}
+	}
+	if allowed == nil {
+		allowed, schemeAllowed = schemeAllowed, nil
+	}
+	if allowed == nil {
+		return ""
+	}
+	if schemeAllowed == nil {
+		return allowed.header
+	}
+	methods := append([]string{}, allowed.methods...)
+	for _, method := range schemeAllowed.methods {
+		if !hasMethod(methods, method) {
+			methods = append(methods, method)
+			sort.Strings(methods)
+		}
+	}
+	return strings.Join(allowHeaderMethods(methods, r.autoHead), ", ")
+}
+
+// requestScheme determines the URL scheme ("http" or "https") used by the given request.
+func (r *Router) requestScheme(req *http.Request) string {
+	if r.TrustForwardedProto {
+		if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+			if i := strings.IndexByte(proto, ','); i >= 0 {
+				proto = proto[:i]
+			}
+			return strings.ToLower(strings.TrimSpace(proto))
 		}
 	}
-	return methods
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
 }
 
 func (r *Router) normalizeRequestPath(path string) string {
@@ -192,23 +672,32 @@ func NotFoundHandler(*Context) error {
 	return NewHTTPError(http.StatusNotFound)
 }
 
+// InvalidPathHandler returns a 400 HTTP error indicating a request path is rejected because StrictPath is true.
+func InvalidPathHandler(*Context) error {
+	return NewHTTPError(http.StatusBadRequest, "invalid request path")
+}
+
+// isValidPath checks if the given decoded request path contains no empty segments, NUL, or control characters.
+func isValidPath(path string) bool {
+	for i := 0; i < len(path); i++ {
+		if path[i] < 0x20 || path[i] == 0x7f || path[i] == '/' && i > 0 && path[i-1] == '/' {
+			return false
+		}
+	}
+	return true
+}
+
 // MethodNotAllowedHandler handles the situation when a request has matching route without matching HTTP method.
-// In this case, the handler will respond with an Allow HTTP header listing the allowed HTTP methods.
+// In this case, the handler will respond with an Allow HTTP header listing the allowed HTTP methods in alphabetical
+// order, which is determined by the path pattern matched by the request path.
 // Otherwise, the handler will do nothing and let the next handler (usually a NotFoundHandler) to handle the problem.
 func MethodNotAllowedHandler(c *Context) error {
-	methods := c.Router().findAllowedMethods(c.Request.URL.Path)
-	if len(methods) == 0 {
+	r := c.Router()
+	allow := r.allowHeader(r.requestScheme(c.Request), c.Request.URL.Path)
+	if allow == "" {
 		return nil
 	}
-	methods["OPTIONS"] = true
-	ms := make([]string, len(methods))
-	i := 0
-	for method := range methods {
-		ms[i] = method
-		i++
-	}
-	sort.Strings(ms)
-	c.Response.Header().Set("Allow", strings.Join(ms, ", "))
+	c.Response.Header().Set("Allow", allow)
 	if c.Request.Method != "OPTIONS" {
 		c.Response.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -231,3 +720,56 @@ func HTTPHandler(h http.Handler) Handler {
 		return nil
 	}
 }
+
+// HTTPMiddleware adapts a standard net/http middleware (as used by alice, chi, and many other packages)
+// into a routing.Handler. The handlers following this one are executed as the next http.Handler of the middleware,
+// and they see the request and response writer passed by the middleware. If the middleware does not call
+// the next http.Handler, the rest of the handlers are skipped.
+//
+// An error returned by the following handlers is returned by this handler after the middleware finishes,
+// so it is handled by the router (or the error handlers registered before this one) as usual.
+//
+//     r := routing.New()
+//     r.Use(routing.HTTPMiddleware(middleware.RequestID))
+func HTTPMiddleware(m func(http.Handler) http.Handler) Handler {
+	return func(c *Context) error {
+		req, res := c.Request, c.Response
+		var err error
+		called := false
+		m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Request, c.Response = r, w
+			err = c.Next()
+		})).ServeHTTP(res, req)
+		c.Request, c.Response = req, res
+		if !called {
+			c.Abort()
+		}
+		return err
+	}
+}
+
+// NegroniMiddleware adapts a negroni-style middleware, which calls the next handler explicitly in its ServeHTTP
+// method, into a routing.Handler. It works in the same way as HTTPMiddleware.
+func NegroniMiddleware(m interface {
+	ServeHTTP(http.ResponseWriter, *http.Request, http.HandlerFunc)
+}) Handler {
+	return HTTPMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.ServeHTTP(w, r, next.ServeHTTP)
+		})
+	})
+}
+
+// NewHTTPHandler wraps the given handlers into an http.Handler, which allows a chain of routing handlers
+// to be used with other routers or middleware chains. The handlers are executed in order
+// like those of a route. If a handler returns an error, it will be written to the response
+// in the same way as Router does.
+func NewHTTPHandler(handlers ...Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		c := NewContext(res, req, handlers...)
+		if err := c.Next(); err != nil {
+			writeError(c, err)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package routing
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -72,6 +75,49 @@ func TestRouterAdd(t *testing.T) {
 	assert.Equal(t, 1, r.maxParams)
 }
 
+func TestValidateRoutePath(t *testing.T) {
+	tests := []struct {
+		path, err string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"/users/<id:\\d+>/<action>/*", ""},
+		{"/files/<:.*>", "the parameter at position 7 has no name"},
+		{"/files/<>", "the parameter at position 7 has no name"},
+		{"users", "the path must start with a slash"},
+		{"/users/<id>/posts/<id>", `duplicate parameter name "id"`},
+		{"/users/<id", "unclosed '<' at position 7"},
+		{"/users/<id/<name>", "unclosed '<' at position 7"},
+		{"/users/id>", "unexpected '>' at position 9"},
+		{"/users/*/posts", "the wildcard '*' must be at the end of the path"},
+	}
+	for _, test := range tests {
+		err := validateRoutePath(test.path, false)
+		if test.err == "" {
+			assert.Nil(t, err, test.path)
+		} else if assert.NotNil(t, err, test.path) {
+			assert.Equal(t, test.err, err.Error(), test.path)
+		}
+	}
+	assert.Nil(t, validateRoutePath("/files/<:.*>", true))
+	assert.NotNil(t, validateRoutePath("/files/<>", true))
+
+	r := New()
+	assert.PanicsWithValue(t, `invalid route GET /users/<id>/<id>: duplicate parameter name "id"`, func() {
+		r.Get("/users/<id>/<id>")
+	})
+	assert.Panics(t, func() {
+		r.Group("/users/<id>").Get("/posts/<id>")
+	})
+	assert.Panics(t, func() {
+		r.Get("/files/<:\\d+>")
+	})
+	r.AllowUnnamedParams = true
+	assert.NotPanics(t, func() {
+		r.Get("/files/<:\\d+>")
+	})
+}
+
 func TestRouterFind(t *testing.T) {
 	r := New()
 	r.add("GET", "/users/<id>", []Handler{NotFoundHandler})
@@ -82,6 +128,223 @@ func TestRouterFind(t *testing.T) {
 	}
 }
 
+func TestRouterMatch(t *testing.T) {
+	r := New()
+	route := r.Get("/users/<id>", NotFoundHandler)
+	matched, params := r.Match("GET", "/users/1")
+	assert.Equal(t, route, matched)
+	assert.Equal(t, map[string]string{"id": "1"}, params)
+
+	matched, params = r.Match("POST", "/users/1")
+	assert.Nil(t, matched)
+	assert.Nil(t, params)
+}
+
+func TestRouterFallback(t *testing.T) {
+	r := New()
+	var used []string
+	r.Use(func(c *Context) error {
+		used = append(used, c.Request.URL.Path)
+		return nil
+	})
+	r.Get("/users", func(c *Context) error {
+		return c.Write("users")
+	})
+	legacy := http.NewServeMux()
+	legacy.HandleFunc("/legacy", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("legacy " + req.Method))
+	})
+	r.Fallback(legacy)
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "users", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/legacy", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "legacy POST", res.Body.String())
+
+	// a path with routes of other methods is delegated as well
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, []string{"/users"}, used)
+
+	r.Fallback(nil)
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+}
+
+func TestRouterScheme(t *testing.T) {
+	r := New()
+	h := func(s string) Handler {
+		return func(c *Context) error {
+			fmt.Fprint(c.Response, s)
+			return nil
+		}
+	}
+	r.Get("/users", h("any"))
+	r.Get("/login", h("login"))
+	r.Scheme("HTTPS").Get("/users", h("https"))
+	r.Scheme("https").Group("/admin").Post("/users", h("admin"))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "any", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "https://example.com/users", nil)
+	req.TLS = &tls.ConnectionState{}
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "https", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/login", nil)
+	req.TLS = &tls.ConnectionState{}
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "login", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/admin/users", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/users", nil)
+	req.TLS = &tls.ConnectionState{}
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "OPTIONS, POST", res.Header().Get("Allow"))
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+
+	// X-Forwarded-Proto is ignored unless trusted
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "any", res.Body.String())
+
+	r.TrustForwardedProto = true
+	res = httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	assert.Equal(t, "https", res.Body.String())
+}
+
+func TestRouterFreeze(t *testing.T) {
+	router := New()
+	router.Get("/users/<id>", func(c *Context) error {
+		return c.Write("user " + c.Param("id"))
+	})
+	router.Scheme("https").Get("/orders", func(c *Context) error {
+		return c.Write("orders")
+	})
+	router.Freeze()
+	router.Freeze()
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "user 1", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/orders", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+
+	assert.Panics(t, func() { router.Get("/posts") })
+	assert.Panics(t, func() { router.Group("/api").Post("/posts") })
+	assert.Panics(t, func() { router.Routes()[0].Priority(1) })
+	assert.Panics(t, func() { router.Use(NotFoundHandler) })
+	assert.Panics(t, func() { router.NotFound(NotFoundHandler) })
+}
+
+func TestRouterDispatch(t *testing.T) {
+	router := New()
+	router.Get("/users", func(c *Context) error {
+		return c.Write("users")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := router.AcquireContext(res, req)
+	assert.Equal(t, router, c.Router())
+	router.Dispatch(c)
+	assert.Equal(t, "users", res.Body.String())
+	assert.Equal(t, "/users", c.Route().Path())
+	router.ReleaseContext(c)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/posts", nil)
+	c = router.AcquireContext(res, req)
+	assert.Nil(t, c.Route())
+	router.Dispatch(c)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	router.ReleaseContext(c)
+
+	// contexts are resized when routes with more parameters are added
+	router.Get("/users/<id>/posts/<pid>", func(c *Context) error {
+		return c.Write(c.Param("id") + "," + c.Param("pid"))
+	})
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/1/posts/2", nil)
+	c = router.AcquireContext(res, req)
+	router.Dispatch(c)
+	assert.Equal(t, "1,2", res.Body.String())
+	router.ReleaseContext(c)
+}
+
+func TestRouterDebug(t *testing.T) {
+	r := New()
+	var trace interface{}
+	h := func(c *Context) error {
+		trace = c.Get(RouteTrace)
+		return nil
+	}
+	r.Use(h)
+	r.Get("/users/<id:\\d+>", h)
+	r.Get("/users/<name>/posts", h)
+
+	req, _ := http.NewRequest("GET", "/users/abc", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Nil(t, trace)
+
+	r.DebugHeader = "X-Route-Debug"
+	req.Header.Set("X-Route-Debug", "1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{
+		`searching GET routes for "/users/abc"`,
+		`static node "/users/": matched`,
+		`param node "<id:\\d+>": regex "^\\d+" rejected "abc"`,
+		`param node "<name>": matched "abc"`,
+		`node "<name>": no data`,
+		`no route matched: calling the NotFound handlers`,
+	}, trace)
+
+	r.Debug = true
+	req, _ = http.NewRequest("GET", "/users/123", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{
+		`searching GET routes for "/users/123"`,
+		`static node "/users/": matched`,
+		`param node "<id:\\d+>": regex "^\\d+" matched "123"`,
+		`node "<id:\\d+>": found GET /users/<id:\d+> (order 1)`,
+		`param node "<name>": skipped (minOrder 2 >= order 1)`,
+		`matched route GET /users/<id:\d+>`,
+	}, trace)
+
+	req, _ = http.NewRequest("POST", "/users/123", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{
+		`no POST routes`,
+		`no route matched: calling the NotFound handlers`,
+	}, trace)
+}
+
 func TestRouterNormalizeRequestPath(t *testing.T) {
 	tests := []struct {
 		path     string
@@ -101,6 +364,52 @@ func TestRouterNormalizeRequestPath(t *testing.T) {
 	}
 }
 
+func TestRouterStrictPath(t *testing.T) {
+	var logs []string
+	r := New()
+	r.Use(func(c *Context) error {
+		logs = append(logs, c.Request.URL.Path)
+		return nil
+	})
+	r.Get("/files/*", func(c *Context) error {
+		return c.Write("file " + c.Param(""))
+	})
+
+	tests := []struct {
+		tag, url string
+		strict   bool
+		status   int
+		body     string
+	}{
+		{"t1", "/files/a/b", true, http.StatusOK, "file a/b"},
+		{"t2", "/files//etc/passwd", false, http.StatusOK, "file /etc/passwd"},
+		{"t3", "/files//etc/passwd", true, http.StatusBadRequest, "invalid request path\n"},
+		{"t4", "/files/a%00.txt", true, http.StatusBadRequest, "invalid request path\n"},
+		{"t5", "/files/a%0Ab", true, http.StatusBadRequest, "invalid request path\n"},
+		{"t6", "/files/a%7F", true, http.StatusBadRequest, "invalid request path\n"},
+		{"t7", "/files/a%20b", true, http.StatusOK, "file a b"},
+	}
+	for _, test := range tests {
+		logs = nil
+		r.StrictPath = test.strict
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", test.url, nil)
+		r.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+		assert.Len(t, logs, 1, test.tag)
+	}
+
+	r.InvalidPath(func(c *Context) error {
+		return c.WriteWithStatus("rejected", http.StatusNotFound)
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/files//a", nil)
+	r.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "rejected", res.Body.String())
+}
+
 func TestRouterHandleError(t *testing.T) {
 	r := New()
 	res := httptest.NewRecorder()
@@ -130,3 +439,116 @@ func TestHTTPHandler(t *testing.T) {
 	assert.Nil(t, h2(c))
 	assert.Equal(t, http.StatusNotFound, res.Code)
 }
+
+type negroniMiddleware string
+
+func (m negroniMiddleware) ServeHTTP(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	res.Header().Add("X-Chain", string(m))
+	next(res, req)
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	type key int
+	header := func(value string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				res.Header().Add("X-Chain", value)
+				next.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), key(0), value)))
+			})
+		}
+	}
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			http.Error(res, "denied", http.StatusForbidden)
+		})
+	}
+
+	router := New()
+	router.Use(HTTPMiddleware(header("a")), NegroniMiddleware(negroniMiddleware("b")))
+	router.Get("/users", func(c *Context) error {
+		return c.Write(c.Request.Context().Value(key(0)))
+	})
+	router.Get("/error", func(c *Context) error {
+		return NewHTTPError(http.StatusBadRequest, "bad")
+	})
+	router.Get("/denied", HTTPMiddleware(deny), func(c *Context) error {
+		return c.Write("ok")
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "a", res.Body.String())
+	assert.Equal(t, []string{"a", "b"}, res.Header()["X-Chain"])
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/error", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "bad\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/denied", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Equal(t, "denied\n", res.Body.String())
+}
+
+func TestNewHTTPHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHTTPHandler(newHandler("1", &buf), func(c *Context) error {
+		return c.Write("ok")
+	})
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	h.ServeHTTP(res, req)
+	assert.Equal(t, "1", buf.String())
+	assert.Equal(t, "ok", res.Body.String())
+
+	h = NewHTTPHandler(func(c *Context) error {
+		return NewHTTPError(http.StatusUnauthorized)
+	})
+	res = httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Equal(t, "Unauthorized\n", res.Body.String())
+}
+
+func TestRouterAllowedMethods(t *testing.T) {
+	router := New()
+	router.Put("/users/<id>", NotFoundHandler)
+	router.Get("/users/<id>", NotFoundHandler)
+	router.Post("/users/me", NotFoundHandler)
+	users := router.To("DELETE,PATCH", "/users/<id>", NotFoundHandler)
+	router.Get("/items", NotFoundHandler)
+	router.Scheme("https").Post("/items", NotFoundHandler)
+
+	assert.Equal(t, []string{"DELETE", "GET", "PATCH", "PUT"}, users.AllowedMethods())
+	assert.Equal(t, []string{"POST"}, router.Routes()[2].AllowedMethods())
+
+	allow := func(path string, secure bool) string {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("TRACE", path, nil)
+		if secure {
+			req.TLS = &tls.ConnectionState{}
+		}
+		router.ServeHTTP(res, req)
+		return res.Header().Get("Allow")
+	}
+	// the methods are those of the first added path pattern matching the request path
+	assert.Equal(t, "DELETE, GET, OPTIONS, PATCH, PUT", allow("/users/me", false))
+	assert.Equal(t, "DELETE, GET, OPTIONS, PATCH, PUT", allow("/users/1", false))
+	assert.Equal(t, "", allow("/orders", false))
+	assert.Equal(t, "GET, OPTIONS", allow("/items", false))
+	assert.Equal(t, "GET, OPTIONS, POST", allow("/items", true))
+
+	// adding routes updates the precomputed headers
+	router.Options("/users/<id>", NotFoundHandler)
+	assert.Equal(t, "DELETE, GET, OPTIONS, PATCH, PUT", allow("/users/1", false))
+	router.Head("/orders", NotFoundHandler)
+	assert.Equal(t, "HEAD, OPTIONS", allow("/orders", false))
+
+	router, _ = NewWithOptions(RouterOptions{AutoHead: true})
+	assert.Equal(t, []string{"GET", "HEAD"}, router.Get("/users", NotFoundHandler).AllowedMethods())
+	assert.Equal(t, "GET, HEAD, OPTIONS", router.allowHeader("http", "/users"))
+}
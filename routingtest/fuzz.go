@@ -0,0 +1,52 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package routingtest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2/fixture"
+)
+
+// Fuzz runs a fuzz test against the handler, usually a *routing.Router, using the recorded requests as the seed
+// corpus. The fuzzing engine mutates the methods, URLs, and bodies of the requests, and the test fails if
+// the handler panics or responds with 500 (Internal Server Error). For example,
+//
+//     func FuzzRouter(f *testing.F) {
+//         fixtures, _ := routingtest.LoadFixtures("testdata/fixtures")
+//         routingtest.Fuzz(f, newRouter(), fixtures)
+//     }
+//
+// The headers of the recorded requests are not fuzzed. They are sent with the requests having the same methods
+// and URLs as the recorded ones, except the redacted headers.
+func Fuzz(f *testing.F, handler http.Handler, fixtures []*fixture.Fixture) {
+	headers := map[string]http.Header{}
+	for _, fx := range fixtures {
+		f.Add(fx.Request.Method, fx.Request.URL, fx.Request.Body.Bytes())
+		headers[fx.Request.Method+" "+fx.Request.URL] = fx.Request.Header
+	}
+	f.Fuzz(func(t *testing.T, method, url string, body []byte) {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil || req.URL.Path == "" || req.URL.Path[0] != '/' {
+			t.Skip()
+		}
+		for name, values := range headers[method+" "+url] {
+			if len(values) > 0 && values[0] != fixture.Redacted {
+				req.Header[name] = values
+			}
+		}
+		req.RequestURI = req.URL.RequestURI()
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code == http.StatusInternalServerError {
+			t.Errorf("%v %v: status 500: %v", method, url, res.Body.String())
+		}
+	})
+}
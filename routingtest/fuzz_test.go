@@ -0,0 +1,21 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+
+package routingtest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func FuzzReplay(f *testing.F) {
+	req1 := httptest.NewRequest("GET", "/users/1", nil)
+	req1.Header.Set("Authorization", "secret")
+	req2 := httptest.NewRequest("POST", "/echo", strings.NewReader("hello"))
+	fixtures := record(req1, req2)
+	Fuzz(f, newRouter("v1"), fixtures)
+}
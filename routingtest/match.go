@@ -0,0 +1,56 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routingtest
+
+import (
+	"reflect"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// TestingT is the interface of *testing.T used by the assertions.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// AssertMatch asserts that the request with the given method and path matches the expected route and that
+// the parameters extracted from the path equal the expected ones, without invoking any handler. The expected route
+// is given by its name, or by its description if it is not named, e.g. "GET /users/<id>". If the route is empty,
+// it asserts that no route matches. If params is nil, the parameters are not checked. For example,
+//
+//     func TestRoutes(t *testing.T) {
+//         router := newRouter()
+//         routingtest.AssertMatch(t, router, "GET", "/users/5", "users.show", map[string]string{"id": "5"})
+//         routingtest.AssertMatch(t, router, "GET", "/users/abc", "", nil)
+//     }
+//
+// AssertMatch returns whether the assertion succeeds, and reports the failure via t.Errorf otherwise.
+func AssertMatch(t TestingT, router *routing.Router, method, path, route string, params map[string]string) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	matched, values := router.Match(method, path)
+	if route == "" {
+		if matched != nil {
+			t.Errorf("%v %v: expected no matching route, got %v", method, path, matched)
+			return false
+		}
+		return true
+	}
+	if matched == nil {
+		t.Errorf("%v %v: expected route %q, got no matching route", method, path, route)
+		return false
+	}
+	if expected := router.Route(route); expected != matched && matched.String() != route &&
+		(expected == nil || expected.Path() != matched.Path()) {
+		t.Errorf("%v %v: expected route %q, got %v", method, path, route, matched)
+		return false
+	}
+	if params != nil && !reflect.DeepEqual(params, values) {
+		t.Errorf("%v %v: expected parameters %v, got %v", method, path, params, values)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routingtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertMatch(t *testing.T) {
+	handler := func(c *routing.Context) error { return nil }
+	router := routing.New()
+	router.To("GET,PUT", "/users/<id:\\d+>", handler).Name("users.show")
+	router.Group("/admin").Post("/users", handler)
+
+	assert.True(t, AssertMatch(t, router, "GET", "/users/5", "users.show", map[string]string{"id": "5"}))
+	assert.True(t, AssertMatch(t, router, "PUT", "/users/5", "users.show", nil))
+	assert.True(t, AssertMatch(t, router, "POST", "/admin/users", "POST /admin/users", map[string]string{}))
+	assert.True(t, AssertMatch(t, router, "GET", "/users/abc", "", nil))
+
+	tests := []struct {
+		id, method, path, route string
+		params                  map[string]string
+		err                     string
+	}{
+		{"t1", "GET", "/users/abc", "users.show", nil, `GET /users/abc: expected route "users.show", got no matching route`},
+		{"t2", "GET", "/users/5", "", nil, "GET /users/5: expected no matching route, got GET /users/<id:\\d+>"},
+		{"t3", "POST", "/admin/users", "users.show", nil, `POST /admin/users: expected route "users.show", got POST /admin/users`},
+		{"t4", "GET", "/users/5", "users.show", map[string]string{"id": "6"}, "GET /users/5: expected parameters map[id:6], got map[id:5]"},
+	}
+	for _, test := range tests {
+		rt := &recordingT{}
+		assert.False(t, AssertMatch(rt, router, test.method, test.path, test.route, test.params), test.id)
+		assert.Equal(t, []string{test.err}, rt.errors, test.id)
+	}
+}
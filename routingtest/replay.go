@@ -0,0 +1,231 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package routingtest provides utilities for testing the applications built with the ozzo routing package.
+package routingtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2/fixture"
+)
+
+type (
+	// Mismatch describes a difference between a recorded response and the response produced when replaying
+	// the request.
+	Mismatch struct {
+		Fixture *fixture.Fixture
+		// Field is the part of the response that differs, i.e., "status", "header <name>", or "body".
+		// It is "request" if the request cannot be replayed.
+		Field    string
+		Expected string
+		Actual   string
+		// Diff lists the differing lines of the bodies, prefixed with "-" for the expected ones and "+" for
+		// the actual ones. It is only set for the body mismatches.
+		Diff string
+	}
+
+	// ReplayOptions specifies how fixtures are replayed.
+	ReplayOptions struct {
+		// Prepare modifies the requests before they are replayed, e.g. to add the credentials removed by redactors.
+		Prepare func(req *http.Request)
+		// IgnoredHeaders lists the response headers that are not compared. If nil, DefaultIgnoredHeaders is used.
+		IgnoredHeaders []string
+	}
+)
+
+// DefaultIgnoredHeaders lists the response headers that are not compared by default because they usually vary
+// between responses.
+var DefaultIgnoredHeaders = []string{"Date", "X-Request-ID"}
+
+// Replay sends the recorded requests to the handler, usually a *routing.Router, and compares the responses with
+// the recorded ones. It returns the mismatches found, which are empty if the handler still responds the same way.
+// The response headers are compared only if they are recorded and not ignored or redacted, so new headers do not
+// cause mismatches. The JSON bodies are compared regardless of their formatting and the order of object keys.
+// The response bodies that are not fully recorded are not compared, and the fixtures whose request bodies are not
+// fully recorded cannot be replayed.
+//
+// Replay is useful for checking that a refactoring does not change the behavior of an application. For example,
+//
+//     func TestReplay(t *testing.T) {
+//         fixtures, err := routingtest.LoadFixtures("testdata/fixtures")
+//         if err != nil {
+//             t.Fatal(err)
+//         }
+//         for _, m := range routingtest.Replay(newRouter(), fixtures) {
+//             t.Error(m)
+//         }
+//     }
+func Replay(handler http.Handler, fixtures []*fixture.Fixture, opts ...ReplayOptions) []Mismatch {
+	var options ReplayOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	ignored := options.IgnoredHeaders
+	if ignored == nil {
+		ignored = DefaultIgnoredHeaders
+	}
+	ignoredHeaders := map[string]bool{}
+	for _, name := range ignored {
+		ignoredHeaders[http.CanonicalHeaderKey(name)] = true
+	}
+
+	mismatches := []Mismatch{}
+	for _, f := range fixtures {
+		if f.Request.Body.Truncated {
+			mismatches = append(mismatches, Mismatch{Fixture: f, Field: "request", Actual: "the request body is not fully recorded"})
+			continue
+		}
+		req := httptest.NewRequest(f.Request.Method, f.Request.URL, bytes.NewReader(f.Request.Body.Bytes()))
+		for name, values := range f.Request.Header {
+			if len(values) > 0 && values[0] != fixture.Redacted {
+				req.Header[name] = values
+			}
+		}
+		if options.Prepare != nil {
+			options.Prepare(req)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		mismatches = append(mismatches, compare(f, res, ignoredHeaders)...)
+	}
+	return mismatches
+}
+
+// LoadFixtures loads the fixtures from the JSON files under the given directory, such as those written by
+// fixture.Dir. The fixtures are sorted by their file names.
+func LoadFixtures(dir string) ([]*fixture.Fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	fixtures := make([]*fixture.Fixture, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		f := &fixture.Fixture{}
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	return fixtures, nil
+}
+
+// String describes the mismatch, including the diff of the bodies, if any.
+func (m Mismatch) String() string {
+	s := m.Fixture.Request.Method + " " + m.Fixture.Request.URL + ": "
+	if m.Field == "request" {
+		return s + m.Actual
+	}
+	if m.Diff != "" {
+		return s + m.Field + " differs:\n" + m.Diff
+	}
+	return fmt.Sprintf("%v%v differs: expected %q, actual %q", s, m.Field, m.Expected, m.Actual)
+}
+
+// compare compares the recorded response with the actual one.
+func compare(f *fixture.Fixture, res *httptest.ResponseRecorder, ignoredHeaders map[string]bool) []Mismatch {
+	mismatches := []Mismatch{}
+	expected := f.Response
+	if expected.Status != res.Code {
+		mismatches = append(mismatches, Mismatch{Fixture: f, Field: "status", Expected: fmt.Sprint(expected.Status), Actual: fmt.Sprint(res.Code)})
+	}
+
+	names := make([]string, 0, len(expected.Header))
+	for name := range expected.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := expected.Header[name]
+		if ignoredHeaders[http.CanonicalHeaderKey(name)] || len(values) > 0 && values[0] == fixture.Redacted {
+			continue
+		}
+		e, a := strings.Join(values, ", "), strings.Join(res.Header()[name], ", ")
+		if e != a {
+			mismatches = append(mismatches, Mismatch{Fixture: f, Field: "header " + name, Expected: e, Actual: a})
+		}
+	}
+
+	if !expected.Body.Truncated {
+		e, a := expected.Body.Bytes(), res.Body.Bytes()
+		if isJSON(expected.Header.Get("Content-Type")) {
+			e, a = normalizeJSON(e), normalizeJSON(a)
+		}
+		if !bytes.Equal(e, a) {
+			mismatches = append(mismatches, Mismatch{Fixture: f, Field: "body", Expected: string(e), Actual: string(a), Diff: diffLines(string(e), string(a))})
+		}
+	}
+	return mismatches
+}
+
+// isJSON checks if the content type is JSON.
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// normalizeJSON indents JSON data with the object keys sorted. The data is returned as is if it is not valid JSON.
+func normalizeJSON(data []byte) []byte {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return data
+	}
+	normalized, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return data
+	}
+	return normalized
+}
+
+// diffLines returns the lines that differ between the expected and the actual texts, prefixed with "-" and "+"
+// respectively, based on their longest common subsequence of lines.
+func diffLines(expected, actual string) string {
+	a, b := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var buf strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			buf.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			buf.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routingtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/go-ozzo/ozzo-routing/v2/content"
+	"github.com/go-ozzo/ozzo-routing/v2/fixture"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRouter(version string, handlers ...routing.Handler) *routing.Router {
+	router := routing.New()
+	router.Use(handlers...)
+	router.Use(content.TypeNegotiator(content.JSON))
+	router.Get("/users/<id>", func(c *routing.Context) error {
+		if c.Request.Header.Get("Authorization") != "secret" {
+			return routing.NewHTTPError(http.StatusUnauthorized)
+		}
+		c.Response.Header().Set("X-Version", version)
+		c.Response.Header().Set("Date", version)
+		if version == "v1" {
+			return c.Write(map[string]interface{}{"id": c.Param("id"), "name": "a", "tags": []string{"x"}})
+		}
+		return c.Write(map[string]interface{}{"tags": []string{"x"}, "id": c.Param("id"), "name": "b"})
+	})
+	router.Post("/echo", func(c *routing.Context) error {
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		return c.Write(string(body))
+	})
+	return router
+}
+
+func record(requests ...*http.Request) []*fixture.Fixture {
+	var fixtures []*fixture.Fixture
+	router := newRouter("v1", fixture.Handler(func(f *fixture.Fixture) error {
+		fixtures = append(fixtures, f)
+		return nil
+	}))
+	for _, req := range requests {
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	return fixtures
+}
+
+func TestReplay(t *testing.T) {
+	req1 := httptest.NewRequest("GET", "/users/1", nil)
+	req1.Header.Set("Authorization", "secret")
+	req2 := httptest.NewRequest("POST", "/echo", strings.NewReader("hello"))
+	fixtures := record(req1, req2)
+	if !assert.Len(t, fixtures, 2) {
+		return
+	}
+	assert.Equal(t, fixture.Redacted, fixtures[0].Request.Header.Get("Authorization"))
+
+	prepare := ReplayOptions{Prepare: func(req *http.Request) {
+		req.Header.Set("Authorization", "secret")
+	}}
+
+	// the same version replays without mismatches
+	assert.Empty(t, Replay(newRouter("v1"), fixtures, prepare))
+
+	// the redacted header is not sent
+	mismatches := Replay(newRouter("v1"), fixtures)
+	if assert.Len(t, mismatches, 4) {
+		assert.Equal(t, "status", mismatches[0].Field)
+		assert.Equal(t, `GET /users/1: status differs: expected "200", actual "401"`, mismatches[0].String())
+	}
+
+	mismatches = Replay(newRouter("v2"), fixtures, prepare)
+	if assert.Len(t, mismatches, 2) {
+		assert.Equal(t, "header X-Version", mismatches[0].Field)
+		assert.Equal(t, "v1", mismatches[0].Expected)
+		assert.Equal(t, "v2", mismatches[0].Actual)
+		assert.Equal(t, "body", mismatches[1].Field)
+		assert.Equal(t, "-  \"name\": \"a\",\n+  \"name\": \"b\",\n", mismatches[1].Diff)
+		assert.Equal(t, "GET /users/1: body differs:\n-  \"name\": \"a\",\n+  \"name\": \"b\",\n", mismatches[1].String())
+	}
+
+	mismatches = Replay(newRouter("v2"), fixtures, ReplayOptions{Prepare: prepare.Prepare, IgnoredHeaders: []string{"X-Version", "Date"}})
+	assert.Len(t, mismatches, 1)
+
+	fixtures[1].Request.Body.Truncated = true
+	mismatches = Replay(newRouter("v1"), fixtures[1:])
+	if assert.Len(t, mismatches, 1) {
+		assert.Equal(t, "POST /echo: the request body is not fully recorded", mismatches[0].String())
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "routingtest")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("Authorization", "secret")
+	router := newRouter("v1", fixture.Handler(fixture.Dir(dir)))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	fixtures, err := LoadFixtures(dir)
+	if assert.Nil(t, err) && assert.Len(t, fixtures, 2) {
+		assert.Equal(t, "GET /users/<id>", fixtures[0].Route)
+		assert.Empty(t, Replay(newRouter("v1"), fixtures, ReplayOptions{Prepare: func(req *http.Request) {
+			req.Header.Set("Authorization", "secret")
+		}}))
+	}
+
+	ioutil.WriteFile(dir+"/invalid.json", []byte("{"), 0644)
+	_, err = LoadFixtures(dir)
+	assert.NotNil(t, err)
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, "", diffLines("a\nb", "a\nb"))
+	assert.Equal(t, "-b\n+c\n+d\n", diffLines("a\nb\ne", "a\nc\nd\ne"))
+	assert.Equal(t, "-a\n", diffLines("a\nb", "b"))
+	assert.Equal(t, "+a\n", diffLines("b", "a\nb"))
+}
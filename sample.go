@@ -0,0 +1,52 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// Sampled returns whether the current request is sampled at the given rate, which is between 0 (none)
+// and 1 (all). The handlers that sample the requests, such as those logging, tracing, or capturing the request
+// bodies, should use this method so that they agree on which requests are sampled instead of producing
+// inconsistent partial telemetry.
+//
+// Without a key, the decision is based on a random number chosen once per request and cached in the Context,
+// so the calls with the same rate always return the same result for a request, and the requests sampled
+// at a rate are also sampled at any higher rate. With a non-empty key, such as a user ID or a trace ID,
+// the decision is based on a hash of the key, so it is the same for all requests with the same key. For example,
+//
+//     if c.Sampled(0.01) {
+//         logRequestBody(c)
+//     }
+//     if c.Sampled(0.1, c.Request.Header.Get("X-Trace-ID")) {
+//         startSpan(c)
+//     }
+func (c *Context) Sampled(rate float64, key ...string) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	if len(key) > 0 && key[0] != "" {
+		h := fnv.New64a()
+		h.Write([]byte(key[0]))
+		// mix the bits of the hash, whose high bits vary little among similar short keys,
+		// and use the top 53 bits as the mantissa of a number in [0, 1)
+		x := h.Sum64()
+		x ^= x >> 33
+		x *= 0xff51afd7ed558ccd
+		x ^= x >> 33
+		x *= 0xc4ceb9fe1a85ec53
+		x ^= x >> 33
+		return float64(x>>11)/(1<<53) < rate
+	}
+	if !c.hasSample {
+		c.sample, c.hasSample = rand.Float64(), true
+	}
+	return c.sample < rate
+}
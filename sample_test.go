@@ -0,0 +1,54 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSampled(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	counts := map[bool]int{}
+	for i := 0; i < 1000; i++ {
+		c := NewContext(nil, req)
+		assert.False(t, c.Sampled(0))
+		assert.True(t, c.Sampled(1))
+		sampled := c.Sampled(0.5)
+		counts[sampled]++
+		// the decision is cached per request
+		for j := 0; j < 5; j++ {
+			assert.Equal(t, sampled, c.Sampled(0.5))
+		}
+		// a request sampled at a rate is sampled at any higher rate
+		if c.Sampled(0.2) {
+			assert.True(t, c.Sampled(0.3))
+		}
+	}
+	assert.True(t, counts[true] > 350 && counts[false] > 350)
+
+	// the decision is reset with the context
+	c := NewContext(nil, req)
+	c.Sampled(0.5)
+	c.init(nil, req)
+	assert.False(t, c.hasSample)
+
+	counts = map[bool]int{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint("user", i)
+		sampled := NewContext(nil, req).Sampled(0.5, key)
+		counts[sampled]++
+		// the same key gets the same decision in different requests
+		assert.Equal(t, sampled, NewContext(nil, req).Sampled(0.5, key))
+		if NewContext(nil, req).Sampled(0.2, key) {
+			assert.True(t, sampled)
+		}
+	}
+	assert.True(t, counts[true] > 350 && counts[false] > 350)
+}
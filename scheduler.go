@@ -0,0 +1,208 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskFunc is a background task run by the router, such as refreshing a cache or flushing metrics.
+// The given context is canceled when the router is shut down, and the task should return as soon as possible then.
+type TaskFunc func(ctx context.Context)
+
+// Every runs the task in the background repeatedly, waiting for the given interval before each run.
+// The runs never overlap. The task stops when the router is shut down.
+//
+//     r := routing.New()
+//     r.Every(10*time.Minute, func(ctx context.Context) {
+//         keys.Refresh(ctx)
+//     })
+func (r *Router) Every(interval time.Duration, task TaskFunc) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("invalid task interval %v", interval))
+	}
+	r.schedule(func(now time.Time) time.Time {
+		return now.Add(interval)
+	}, task)
+}
+
+// Cron runs the task in the background at the times specified by the cron expression, which consists of
+// five fields: minute (0-59), hour (0-23), day of month (1-31), month (1-12), and day of week (0-6, Sunday is 0 or 7).
+// A field may be "*", a number, a range (e.g. "1-5"), a step (e.g. "*/15" or "0-30/10"), or a comma-separated list
+// of them. The descriptors "@yearly", "@monthly", "@weekly", "@daily", and "@hourly" are also supported.
+// The times are in the local time zone. The runs never overlap: the times passed while the task is running are skipped.
+// The method panics if the expression is invalid. The task stops when the router is shut down.
+//
+//     r := routing.New()
+//     r.Cron("0 3 * * *", func(ctx context.Context) {
+//         sessions.Purge(ctx)
+//     })
+func (r *Router) Cron(spec string, task TaskFunc) {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		panic(fmt.Sprintf("invalid cron expression %q: %v", spec, err))
+	}
+	r.schedule(schedule.next, task)
+}
+
+// Shutdown stops the background tasks of the router and waits for the running ones to return.
+// If the given context is done before that, its error is returned.
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// schedule runs the task in a goroutine at the times returned by next until the router is shut down.
+func (r *Router) schedule(next func(time.Time) time.Time, task TaskFunc) {
+	r.tasks.Add(1)
+	go func() {
+		defer r.tasks.Done()
+		for {
+			now := time.Now()
+			t := next(now)
+			if t.IsZero() {
+				return
+			}
+			timer := time.NewTimer(t.Sub(now))
+			select {
+			case <-r.ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+				task(r.ctx)
+			}
+		}
+	}()
+}
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the allowed values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// whether the day of month or the day of week is restricted. If both are, a day matches if it matches either.
+	domRestricted, dowRestricted bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron parses a cron expression.
+func parseCron(spec string) (*cronSchedule, error) {
+	if s, ok := cronDescriptors[strings.TrimSpace(spec)]; ok {
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %v", len(fields))
+	}
+	s := &cronSchedule{}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, err
+		}
+		*sets[i] = set
+	}
+	if s.dow&(1<<7) != 0 {
+		// both 0 and 7 stand for Sunday
+		s.dow |= 1
+	}
+	s.domRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseCronField parses a field of a cron expression into a bit set of the allowed values.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if step > 1 {
+				// "n/step" means from n to the maximum value
+				hi = max
+			}
+			if lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("value %q out of range %v-%v", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// next returns the first time matching the schedule after the given time, or the zero time if there is
+// no such time within five years.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay checks if the day of the given time matches the schedule.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
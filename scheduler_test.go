@@ -0,0 +1,125 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterEvery(t *testing.T) {
+	router := New()
+	var runs int32
+	stopped := make(chan bool, 1)
+	router.Every(5*time.Millisecond, func(ctx context.Context) {
+		if atomic.AddInt32(&runs, 1) == 3 {
+			<-ctx.Done()
+			stopped <- true
+		}
+	})
+	for atomic.LoadInt32(&runs) < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(t, router.Shutdown(context.Background()))
+	assert.True(t, <-stopped)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+
+	// the tasks added after shutdown do not run
+	router.Every(time.Millisecond, func(ctx context.Context) {
+		atomic.AddInt32(&runs, 1)
+	})
+	assert.Nil(t, router.Shutdown(context.Background()))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+
+	assert.Panics(t, func() {
+		router.Every(0, func(ctx context.Context) {})
+	})
+}
+
+func TestRouterShutdownTimeout(t *testing.T) {
+	router := New()
+	release := make(chan bool)
+	started := make(chan bool)
+	router.Every(time.Millisecond, func(ctx context.Context) {
+		started <- true
+		<-release
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, router.Shutdown(ctx))
+	close(release)
+	assert.Nil(t, router.Shutdown(context.Background()))
+}
+
+func TestRouterCron(t *testing.T) {
+	router := New()
+	router.Cron("@hourly", func(ctx context.Context) {})
+	assert.Nil(t, router.Shutdown(context.Background()))
+
+	assert.PanicsWithValue(t, `invalid cron expression "* * *": expected 5 fields, found 3`, func() {
+		router.Cron("* * *", func(ctx context.Context) {})
+	})
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		tag  string
+		spec string
+		err  string
+	}{
+		{"t1", "* * * * *", ""},
+		{"t2", "0,30 9-17/2 1 */3 1-5", ""},
+		{"t3", "@daily", ""},
+		{"t4", "60 * * * *", `value "60" out of range 0-59`},
+		{"t5", "* * 0 * *", `value "0" out of range 1-31`},
+		{"t6", "*/0 * * * *", `invalid step in "*/0"`},
+		{"t7", "a * * * *", `invalid value "a"`},
+		{"t8", "* 1-b * * *", `invalid value "1-b"`},
+		{"t9", "* 5-1 * * *", `value "5-1" out of range 0-23`},
+		{"t10", "@every 1h", `expected 5 fields, found 2`},
+	}
+	for _, test := range tests {
+		_, err := parseCron(test.spec)
+		if test.err == "" {
+			assert.Nil(t, err, test.tag)
+		} else if assert.NotNil(t, err, test.tag) {
+			assert.Equal(t, test.err, err.Error(), test.tag)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2021-03-10 is a Wednesday
+	from := time.Date(2021, 3, 10, 10, 20, 30, 0, time.UTC)
+	tests := []struct {
+		tag      string
+		spec     string
+		expected time.Time
+	}{
+		{"t1", "* * * * *", time.Date(2021, 3, 10, 10, 21, 0, 0, time.UTC)},
+		{"t2", "*/15 * * * *", time.Date(2021, 3, 10, 10, 30, 0, 0, time.UTC)},
+		{"t3", "0 3 * * *", time.Date(2021, 3, 11, 3, 0, 0, 0, time.UTC)},
+		{"t4", "@hourly", time.Date(2021, 3, 10, 11, 0, 0, 0, time.UTC)},
+		{"t5", "@weekly", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"t6", "0 0 * * 7", time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"t7", "@monthly", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"t8", "@yearly", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"t9", "0 9 1-5 * 5", time.Date(2021, 3, 12, 9, 0, 0, 0, time.UTC)},
+		{"t10", "0 9 */10 * *", time.Date(2021, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"t11", "30 10 29 2 *", time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC)},
+		{"t12", "0 0 31 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := parseCron(test.spec)
+		if assert.Nil(t, err, test.tag) {
+			assert.Equal(t, test.expected, s.next(from), test.tag)
+		}
+	}
+}
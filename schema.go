@@ -0,0 +1,152 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// RequestData is the key used to store and retrieve the request data read by ValidateRequest in Context.
+const RequestData = "RequestData"
+
+// The route metadata names used to store the documentation of a route.
+const (
+	// DocSummary is the metadata name of the route summary set via Route.Doc.
+	DocSummary = "doc.summary"
+	// DocDescription is the metadata name of the route description set via Route.Doc.
+	DocDescription = "doc.description"
+	// DocExamples is the metadata name of the example payloads (map[int]interface{}) set via Route.Example.
+	DocExamples = "doc.examples"
+)
+
+// Validatable is implemented by request data that can validate themselves.
+type Validatable interface {
+	// Validate validates the data and returns an error if validation fails.
+	Validate() error
+}
+
+// Request declares the schema of the request data expected by the route.
+// The schema is an example value (e.g. CreateUser{}) whose type describes the request data.
+// It is used by ValidateRequest to read and validate the request data, and may be used
+// by documentation generators.
+func (r *Route) Request(schema interface{}) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Request(schema)
+		}
+		return r
+	}
+	r.request = schema
+	return r
+}
+
+// Response declares the schema of the response data sent by the route with the given HTTP status code.
+// The schema is an example value (e.g. User{}) whose type describes the response data.
+// A nil schema indicates the response has no body.
+func (r *Route) Response(status int, schema interface{}) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Response(status, schema)
+		}
+		return r
+	}
+	if r.responses == nil {
+		r.responses = make(map[int]interface{})
+	}
+	r.responses[status] = schema
+	return r
+}
+
+// RequestSchema returns the request schema declared via Request.
+// Nil is returned if the route does not declare a request schema.
+func (r *Route) RequestSchema() interface{} {
+	return r.request
+}
+
+// ResponseSchemas returns the response schemas declared via Response, indexed by the HTTP status codes.
+func (r *Route) ResponseSchemas() map[int]interface{} {
+	return r.responses
+}
+
+// Doc associates a short summary and a longer description with the route. They are stored as the route metadata
+// items DocSummary and DocDescription, which may be used by documentation generators and route listings.
+func (r *Route) Doc(summary, description string) *Route {
+	return r.Set(DocSummary, summary).Set(DocDescription, description)
+}
+
+// Example associates an example payload of the response with the given HTTP status code with the route.
+// The examples are stored as the route metadata item DocExamples, indexed by the HTTP status codes.
+func (r *Route) Example(status int, payload interface{}) *Route {
+	if len(r.routes) > 0 {
+		// this route is a composite one (a path with multiple methods)
+		for _, route := range r.routes {
+			route.Example(status, payload)
+		}
+		return r
+	}
+	examples, _ := r.Meta(DocExamples).(map[int]interface{})
+	if examples == nil {
+		examples = make(map[int]interface{})
+		r.Set(DocExamples, examples)
+	}
+	examples[status] = payload
+	return r
+}
+
+// Summary returns the summary of the route set via Doc.
+func (r *Route) Summary() string {
+	s, _ := r.Meta(DocSummary).(string)
+	return s
+}
+
+// Description returns the description of the route set via Doc.
+func (r *Route) Description() string {
+	s, _ := r.Meta(DocDescription).(string)
+	return s
+}
+
+// Examples returns the example payloads set via Example, indexed by the HTTP status codes.
+func (r *Route) Examples() map[int]interface{} {
+	examples, _ := r.Meta(DocExamples).(map[int]interface{})
+	return examples
+}
+
+// ValidateRequest is a handler that reads the request data according to the request schema of the matching route.
+// A new value of the schema type is populated by calling Context.Read and then validated if it implements Validatable.
+// If either step fails, a 400 HTTP error will be returned. Otherwise, a pointer to the value is stored in
+// the context under the key RequestData:
+//
+//     router.Use(routing.ValidateRequest)
+//     router.Post("/users", func(c *routing.Context) error {
+//         user := c.Get(routing.RequestData).(*CreateUser)
+//         ...
+//     }).Request(CreateUser{}).Response(http.StatusCreated, User{})
+//
+// The handler does nothing if the matching route has no request schema.
+func ValidateRequest(c *Context) error {
+	route := c.Route()
+	if route == nil || route.request == nil {
+		return nil
+	}
+	t := reflect.TypeOf(route.request)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	data := reflect.New(t).Interface()
+	if err := c.Read(data); err != nil {
+		// the HTTP errors, such as 413 and 415, are returned as is, while the decoding errors become 400
+		return uploadReadError(c.Request, err)
+	}
+	if v, ok := data.(Validatable); ok {
+		if err := v.Validate(); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	c.Set(RequestData, data)
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type createUser struct {
+	Name string
+}
+
+func (u createUser) Validate() error {
+	if u.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func TestRouteSchema(t *testing.T) {
+	router := New()
+	r := router.Post("/users").Request(createUser{}).Response(http.StatusCreated, "").Response(http.StatusNoContent, nil)
+	assert.Equal(t, createUser{}, r.RequestSchema())
+	assert.Equal(t, map[int]interface{}{http.StatusCreated: "", http.StatusNoContent: nil}, r.ResponseSchemas())
+
+	router.To("PUT,PATCH", "/users/<id>").Request(&createUser{})
+	for _, route := range router.Routes() {
+		if route.path == "/users/<id>" {
+			assert.Equal(t, &createUser{}, route.RequestSchema())
+			assert.Nil(t, route.ResponseSchemas())
+		}
+	}
+}
+
+func TestRouteDoc(t *testing.T) {
+	router := New()
+	r := router.Get("/users/<id>").Doc("Get a user", "Returns the user with the given ID.").
+		Example(http.StatusOK, createUser{"john"}).Example(http.StatusNotFound, nil)
+	assert.Equal(t, "Get a user", r.Summary())
+	assert.Equal(t, "Returns the user with the given ID.", r.Description())
+	assert.Equal(t, "Get a user", r.Meta(DocSummary))
+	assert.Equal(t, map[int]interface{}{http.StatusOK: createUser{"john"}, http.StatusNotFound: nil}, r.Examples())
+
+	r = router.Post("/users")
+	assert.Equal(t, "", r.Summary())
+	assert.Nil(t, r.Examples())
+
+	router.To("PUT,PATCH", "/users/<id>").Doc("Update a user", "").Example(http.StatusOK, "ok")
+	router.Routes()[3].Example(http.StatusBadRequest, "bad")
+	for _, route := range router.Routes()[2:] {
+		assert.Equal(t, "Update a user", route.Summary())
+	}
+	assert.Equal(t, map[int]interface{}{http.StatusOK: "ok"}, router.Routes()[2].Examples())
+	assert.Equal(t, map[int]interface{}{http.StatusOK: "ok", http.StatusBadRequest: "bad"}, router.Routes()[3].Examples())
+}
+
+type unsupportedDataReader struct{}
+
+func (unsupportedDataReader) Read(req *http.Request, data interface{}) error {
+	return NewHTTPError(http.StatusUnsupportedMediaType)
+}
+
+func TestValidateRequest(t *testing.T) {
+	DataReaders["application/x-unsupported"] = unsupportedDataReader{}
+	defer delete(DataReaders, "application/x-unsupported")
+	router := New()
+	router.Use(ValidateRequest)
+	router.Post("/users", func(c *Context) error {
+		return c.Write(c.Get(RequestData).(*createUser).Name)
+	}).Request(&createUser{})
+	router.Post("/posts", func(c *Context) error {
+		assert.Nil(t, c.Get(RequestData))
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"Name":"abc"}`))
+	req.Header.Set("Content-Type", MIME_JSON)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "abc", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"Name":""}`))
+	req.Header.Set("Content-Type", MIME_JSON)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Equal(t, "name is required\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"Name":`))
+	req.Header.Set("Content-Type", MIME_JSON)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/posts", strings.NewReader(`{}`))
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// the HTTP errors of reading the body are kept
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"Name":"abc"}`))
+	req.Header.Set("Content-Type", "application/x-unsupported")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"Name":"abcdef"}`))
+	req.Header.Set("Content-Type", MIME_JSON)
+	req.Body = http.MaxBytesReader(res, req.Body, 5)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
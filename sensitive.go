@@ -0,0 +1,91 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Sensitive is the name of the route metadata item holding the Sensitivity of a route. The logging and recording
+// handlers, such as those in the access and fixture packages, consult it to skip or redact the requests to
+// the sensitive routes. For example,
+//
+//     r.Post("/login", login).Set(routing.Sensitive, routing.Sensitivity{RedactBody: true})
+//     r.Group("/oauth").Set(routing.Sensitive, routing.Sensitivity{RedactQuery: true, RedactHeaders: []string{"Authorization"}})
+//     r.Post("/token", exchangeToken).Set(routing.Sensitive, routing.Sensitivity{Skip: true})
+const Sensitive = "Sensitive"
+
+// Redacted is the value replacing the redacted data.
+const Redacted = "REDACTED"
+
+// Sensitivity specifies how the requests to a sensitive route are logged or recorded.
+type Sensitivity struct {
+	// Skip indicates whether the requests should not be logged or recorded at all.
+	Skip bool
+	// RedactQuery indicates whether the values of the URL query parameters should be replaced with Redacted.
+	RedactQuery bool
+	// RedactHeaders lists the request headers whose values should be replaced with Redacted.
+	RedactHeaders []string
+	// RedactBody indicates whether the request and response bodies should be replaced with Redacted
+	// by the handlers recording them.
+	RedactBody bool
+}
+
+// Sensitivity returns the Sensitivity associated with the route matching the current request via
+// the Sensitive metadata item. It returns nil if the route is not marked as sensitive.
+func (c *Context) Sensitivity() *Sensitivity {
+	if c.route == nil {
+		return nil
+	}
+	switch s := c.route.Meta(Sensitive).(type) {
+	case Sensitivity:
+		return &s
+	case *Sensitivity:
+		return s
+	}
+	return nil
+}
+
+// Redact returns a shallow copy of the request with the query parameter values and the headers redacted
+// as specified by the Sensitivity. The request is returned as is if nothing needs to be redacted.
+func (s *Sensitivity) Redact(req *http.Request) *http.Request {
+	if s == nil || !s.RedactQuery && len(s.RedactHeaders) == 0 {
+		return req
+	}
+	r := new(http.Request)
+	*r = *req
+	if s.RedactQuery && req.URL != nil && req.URL.RawQuery != "" {
+		u := *req.URL
+		u.RawQuery = RedactQuery(u.RawQuery)
+		r.URL = &u
+		r.RequestURI = u.RequestURI()
+	}
+	if len(s.RedactHeaders) > 0 {
+		r.Header = req.Header.Clone()
+		for _, name := range s.RedactHeaders {
+			values := r.Header[http.CanonicalHeaderKey(name)]
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+	}
+	return r
+}
+
+// RedactQuery replaces the values of the parameters in the given URL query string with Redacted, keeping
+// the parameter names. The query string is returned as is if it cannot be parsed.
+func RedactQuery(rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for _, values := range query {
+		for i := range values {
+			values[i] = Redacted
+		}
+	}
+	return query.Encode()
+}
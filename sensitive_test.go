@@ -0,0 +1,65 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSensitivity(t *testing.T) {
+	var sensitivity *Sensitivity
+	handler := func(c *Context) error {
+		sensitivity = c.Sensitivity()
+		return nil
+	}
+	router := New()
+	router.Get("/public", handler)
+	router.Post("/login", handler).Set(Sensitive, Sensitivity{RedactQuery: true})
+	auth := router.Group("/oauth").Set(Sensitive, &Sensitivity{Skip: true})
+	auth.Post("/token", handler)
+
+	tests := []struct {
+		tag, method, url string
+		expected         *Sensitivity
+	}{
+		{"t1", "GET", "/public", nil},
+		{"t2", "POST", "/login", &Sensitivity{RedactQuery: true}},
+		{"t3", "POST", "/oauth/token", &Sensitivity{Skip: true}},
+		{"t4", "GET", "/missing", nil},
+	}
+	for _, test := range tests {
+		sensitivity = nil
+		req, _ := http.NewRequest(test.method, test.url, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, test.expected, sensitivity, test.tag)
+	}
+}
+
+func TestSensitivityRedact(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/login?user=a&code=b", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	req.Header.Set("Accept", "text/plain")
+
+	var s *Sensitivity
+	assert.Equal(t, req, s.Redact(req))
+	s = &Sensitivity{}
+	assert.Equal(t, req, s.Redact(req))
+
+	s = &Sensitivity{RedactQuery: true, RedactHeaders: []string{"authorization"}}
+	r := s.Redact(req)
+	assert.Equal(t, "/login?code=REDACTED&user=REDACTED", r.URL.String())
+	assert.Equal(t, Redacted, r.Header.Get("Authorization"))
+	assert.Equal(t, "text/plain", r.Header.Get("Accept"))
+	// the original request is not changed
+	assert.Equal(t, "/login?user=a&code=b", req.URL.String())
+	assert.Equal(t, "Bearer t", req.Header.Get("Authorization"))
+
+	assert.Equal(t, "a=REDACTED&a=REDACTED&b=REDACTED", RedactQuery("a=1&a=2&b"))
+	assert.Equal(t, "%zz", RedactQuery("%zz"))
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/fcgi"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ServeFCGI serves the requests received by the given listener using the FastCGI protocol.
+// If the listener is nil, the requests are received from os.Stdin, which is how a FastCGI application
+// is started by most web servers. The client address of each request (http.Request.RemoteAddr) is derived
+// by net/http/cgi from the REMOTE_ADDR and REMOTE_PORT parameters passed by the web server, with port 0
+// if REMOTE_PORT is not passed, so that the client IP is available to the handlers as with http.Server.
+//
+//     r := routing.New()
+//     routing.ServeFCGI(nil, r)
+func ServeFCGI(l net.Listener, h http.Handler) error {
+	return fcgi.Serve(l, h)
+}
+
+// ServeCGI serves the current request of a CGI application. It should be called by an application
+// started by a web server as a CGI script. The client address of the request is derived as in ServeFCGI.
+func ServeCGI(h http.Handler) error {
+	return cgi.Serve(h)
+}
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// SystemdListeners returns the listeners passed by systemd socket activation (see sd_listen_fds(3)).
+// The listeners are in the order of the sockets listed in the socket unit. If the process is not started
+// by socket activation, an empty list will be returned. The LISTEN_* environment variables are unset
+// so that they are not inherited by child processes.
+//
+//     listeners, err := routing.SystemdListeners()
+//     if err != nil || len(listeners) == 0 {
+//         log.Fatal("no systemd socket")
+//     }
+//     http.Serve(listeners[0], router)
+func SystemdListeners() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return []net.Listener{}, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return nil, errors.New("invalid LISTEN_FDS: " + os.Getenv("LISTEN_FDS"))
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		// FileListener duplicates the file descriptor, so the original one can be closed
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
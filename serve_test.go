@@ -0,0 +1,81 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net"
+	"net/http/cgi"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemdListeners(t *testing.T) {
+	os.Unsetenv("LISTEN_PID")
+	listeners, err := SystemdListeners()
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "1")
+	listeners, err = SystemdListeners()
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+	assert.Equal(t, "", os.Getenv("LISTEN_FDS"))
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "x")
+	_, err = SystemdListeners()
+	assert.NotNil(t, err)
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "0")
+	listeners, err = SystemdListeners()
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+	assert.Equal(t, "", os.Getenv("LISTEN_PID"))
+}
+
+func TestServeFCGI(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- ServeFCGI(l, New())
+	}()
+	l.Close()
+	assert.NotNil(t, <-done)
+}
+
+func TestCGIRemoteAddr(t *testing.T) {
+	// the CGI and FastCGI requests are parsed by cgi.RequestFromMap
+	tests := []struct {
+		id, ip, port, expected string
+	}{
+		{"t1", "192.168.1.1", "1234", "192.168.1.1:1234"},
+		{"t2", "192.168.1.1", "", "192.168.1.1:0"},
+		{"t3", "::1", "", "[::1]:0"},
+	}
+	for _, test := range tests {
+		params := map[string]string{
+			"REQUEST_METHOD":  "GET",
+			"SERVER_PROTOCOL": "HTTP/1.1",
+			"REQUEST_URI":     "/users",
+			"REMOTE_ADDR":     test.ip,
+		}
+		if test.port != "" {
+			params["REMOTE_PORT"] = test.port
+		}
+		req, err := cgi.RequestFromMap(params)
+		if assert.Nil(t, err, test.id) {
+			assert.Equal(t, test.expected, req.RemoteAddr, test.id)
+			assert.Equal(t, test.ip, remoteIP(req), test.id)
+		}
+	}
+}
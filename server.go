@@ -0,0 +1,197 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// Server serves several routers, such as those of a public API, an admin interface, and metrics, on different
+	// addresses, so that the admin routes are not exposed on the public address. The routers created by a Server
+	// share the middleware registered with it, and they are started and shut down together.
+	//
+	//     s := routing.NewServer()
+	//     s.LogFunc = log.Printf
+	//     s.Use(access.Logger(log.Printf), fault.Recovery(log.Printf))
+	//
+	//     api := s.Router("api", ":8080")
+	//     api.Get("/users", getUsers)
+	//
+	//     admin := s.Router("admin", "127.0.0.1:9090")
+	//     admin.Any("/history", history.Handler())
+	//
+	//     go s.GracefulShutdown(10 * time.Second)
+	//     if err := s.ListenAndServe(); err != nil {
+	//         log.Fatal(err)
+	//     }
+	Server struct {
+		// LogFunc logs the starting and the stopping of the routers. If nil, nothing is logged.
+		LogFunc func(format string, args ...interface{})
+		// Configure customizes the HTTP server of each router before it starts, e.g. to set the timeouts.
+		Configure func(name string, hs *http.Server)
+
+		mu       sync.Mutex
+		handlers []Handler
+		entries  []*serverEntry
+	}
+
+	// serverEntry is a router served by a Server.
+	serverEntry struct {
+		name     string
+		addr     string
+		listener net.Listener
+		router   *Router
+		server   *http.Server
+	}
+)
+
+// NewServer creates a new Server.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Use appends the specified handlers to the middleware shared by the routers. The handlers are registered
+// with the routers subsequently created by Router or RouterWithListener before any of their own handlers.
+func (s *Server) Use(handlers ...Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handlers...)
+}
+
+// Router creates a new router with the shared middleware and binds it to the given TCP address.
+// The name identifies the router in the log messages and in Lookup.
+func (s *Server) Router(name, addr string) *Router {
+	return s.add(&serverEntry{name: name, addr: addr})
+}
+
+// RouterWithListener creates a new router with the shared middleware and binds it to the given listener,
+// e.g. a Unix socket listener or one returned by SystemdListeners.
+func (s *Server) RouterWithListener(name string, l net.Listener) *Router {
+	return s.add(&serverEntry{name: name, addr: l.Addr().String(), listener: l})
+}
+
+// Lookup returns the named router. Nil is returned if the named router cannot be found.
+func (s *Server) Lookup(name string) *Router {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.name == name {
+			return e.router
+		}
+	}
+	return nil
+}
+
+// ListenAndServe starts serving all routers and blocks until they stop. If any address cannot be listened on,
+// none of the routers is served and the error is returned. If any router stops serving because of an error,
+// the other routers are shut down and the error is returned. It returns nil after Shutdown is called.
+func (s *Server) ListenAndServe() error {
+	s.mu.Lock()
+	entries := s.entries
+	opened := []*serverEntry{}
+	for _, e := range entries {
+		if e.listener != nil {
+			continue
+		}
+		l, err := net.Listen("tcp", e.addr)
+		if err != nil {
+			for _, e := range opened {
+				e.listener.Close()
+				e.listener = nil
+			}
+			s.mu.Unlock()
+			return err
+		}
+		e.listener = l
+		opened = append(opened, e)
+	}
+	errs := make(chan error, len(entries))
+	for _, e := range entries {
+		e.server = &http.Server{Addr: e.addr, Handler: e.router}
+		if s.Configure != nil {
+			s.Configure(e.name, e.server)
+		}
+		s.logf("serving %v on %v", e.name, e.listener.Addr())
+		go func(hs *http.Server, l net.Listener) {
+			errs <- hs.Serve(l)
+		}(e.server, e.listener)
+	}
+	s.mu.Unlock()
+
+	var result error
+	for range entries {
+		if err := <-errs; err != http.ErrServerClosed && result == nil {
+			result = err
+			go s.Shutdown(context.Background())
+		}
+	}
+	return result
+}
+
+// Shutdown gracefully shuts down the HTTP servers of all routers and stops the background tasks of the routers
+// (see Router.Shutdown). It returns the first error encountered, e.g. when the context expires before
+// the active requests finish.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	entries := s.entries
+	s.mu.Unlock()
+
+	var result error
+	for _, e := range entries {
+		s.mu.Lock()
+		hs := e.server
+		s.mu.Unlock()
+		if hs != nil {
+			if err := hs.Shutdown(ctx); err != nil {
+				s.logf("error while shutting down %v: %v", e.name, err)
+				if result == nil {
+					result = err
+				}
+			}
+		}
+		if err := e.router.Shutdown(ctx); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// GracefulShutdown shuts down the server gracefully when receiving an os.Interrupt or syscall.SIGTERM signal.
+// It will wait for the specified timeout to stop hanging HTTP handlers and background tasks.
+func (s *Server) GracefulShutdown(timeout time.Duration) {
+	waitForSignal()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.logf("shutting down server with %s timeout", timeout)
+	if err := s.Shutdown(ctx); err == nil {
+		s.logf("server was shut down gracefully")
+	}
+}
+
+// add creates the router of the given entry.
+func (s *Server) add(e *serverEntry) *Router {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.router = New()
+	if len(s.handlers) > 0 {
+		e.router.Use(s.handlers...)
+	}
+	s.entries = append(s.entries, e)
+	return e.router
+}
+
+// logf logs a message using LogFunc, if it is set.
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.LogFunc != nil {
+		s.LogFunc(format, args...)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	var mu sync.Mutex
+	var logs bytes.Buffer
+	s := NewServer()
+	s.LogFunc = func(format string, args ...interface{}) {
+		mu.Lock()
+		fmt.Fprintf(&logs, format+"\n", args...)
+		mu.Unlock()
+	}
+	s.Configure = func(name string, hs *http.Server) {
+		hs.ReadHeaderTimeout = time.Second
+	}
+	s.Use(func(c *Context) error {
+		c.Response.Header().Set("X-Shared", "yes")
+		return nil
+	})
+
+	apiListener, _ := net.Listen("tcp", "127.0.0.1:0")
+	api := s.RouterWithListener("api", apiListener)
+	api.Get("/users", func(c *Context) error {
+		return c.Write("users")
+	})
+	admin := s.Router("admin", "127.0.0.1:0")
+	admin.Get("/stats", func(c *Context) error {
+		return c.Write("stats")
+	})
+	assert.Equal(t, api, s.Lookup("api"))
+	assert.Equal(t, admin, s.Lookup("admin"))
+	assert.Nil(t, s.Lookup("metrics"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ListenAndServe()
+	}()
+	var adminAddr string
+	for i := 0; i < 100 && adminAddr == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		s.mu.Lock()
+		if e := s.entries[1]; e.server != nil {
+			adminAddr = e.listener.Addr().String()
+		}
+		s.mu.Unlock()
+	}
+
+	get := func(url string) (int, string, string) {
+		res, err := http.Get(url)
+		if !assert.Nil(t, err) {
+			return 0, "", ""
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return res.StatusCode, res.Header.Get("X-Shared"), string(body)
+	}
+	status, shared, body := get("http://" + apiListener.Addr().String() + "/users")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "yes", shared)
+	assert.Equal(t, "users", body)
+	status, _, _ = get("http://" + apiListener.Addr().String() + "/stats")
+	assert.Equal(t, http.StatusNotFound, status)
+	status, shared, body = get("http://" + adminAddr + "/stats")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "yes", shared)
+	assert.Equal(t, "stats", body)
+
+	assert.Nil(t, s.Shutdown(context.Background()))
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ListenAndServe did not return")
+	}
+	mu.Lock()
+	assert.Contains(t, logs.String(), "serving api on "+apiListener.Addr().String())
+	assert.Contains(t, logs.String(), "serving admin on 127.0.0.1:")
+	mu.Unlock()
+}
+
+func TestServerListenError(t *testing.T) {
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	defer l.Close()
+	s := NewServer()
+	s.Router("api", "127.0.0.1:0")
+	s.Router("admin", l.Addr().String())
+	assert.NotNil(t, s.ListenAndServe())
+	assert.Nil(t, s.entries[0].listener)
+	assert.Nil(t, s.entries[0].server)
+}
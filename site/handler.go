@@ -0,0 +1,167 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package site provides handlers serving robots.txt, sitemap.xml, and the /.well-known resources for the ozzo routing package.
+package site
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+type (
+	// RobotsOptions specifies the content of robots.txt (RFC 9309) served by Robots.
+	RobotsOptions struct {
+		// Rules are the groups of the rules for the crawlers. If empty, all crawlers are allowed to access all paths.
+		Rules []RobotsRule
+		// Sitemaps are the URLs of the sitemaps. A URL starting with "/" is resolved against the request host.
+		Sitemaps []string
+	}
+
+	// RobotsRule is a group of the rules in robots.txt for the crawlers with the given user agents.
+	RobotsRule struct {
+		UserAgents []string // the user agents that the rule applies to. Defaults to "*", i.e., all crawlers.
+		Allow      []string // the path prefixes that the crawlers may access
+		Disallow   []string // the path prefixes that the crawlers may not access
+		CrawlDelay int      // the number of seconds that the crawlers should wait between requests, if positive
+	}
+
+	// SecurityTxt specifies the content of security.txt (RFC 9116) served by SecurityText.
+	SecurityTxt struct {
+		Contact            []string  // the URIs for reporting security issues, e.g. "mailto:security@example.com" (required)
+		Expires            time.Time // the time after which the content should be considered stale (required)
+		Encryption         []string  // the URIs of the keys for encrypting the reports
+		Acknowledgments    []string  // the URIs of the pages acknowledging the reporters
+		PreferredLanguages []string  // the languages preferred for the reports, e.g. "en"
+		Canonical          []string  // the URIs where the file is located
+		Policy             []string  // the URIs of the vulnerability disclosure policies
+		Hiring             []string  // the URIs of the security-related job positions
+	}
+)
+
+// Robots returns a handler that serves robots.txt with the given options. For example,
+//
+//     r.Get("/robots.txt", site.Robots(site.RobotsOptions{
+//         Rules: []site.RobotsRule{
+//             {Disallow: []string{"/admin/", "/api/"}},
+//         },
+//         Sitemaps: []string{"/sitemap.xml"},
+//     }))
+func Robots(options RobotsOptions) routing.Handler {
+	var b strings.Builder
+	rules := options.Rules
+	if len(rules) == 0 {
+		rules = []RobotsRule{{Disallow: []string{""}}}
+	}
+	for i, rule := range rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		agents := rule.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, agent := range agents {
+			b.WriteString("User-agent: " + agent + "\n")
+		}
+		for _, path := range rule.Allow {
+			b.WriteString("Allow: " + path + "\n")
+		}
+		for _, path := range rule.Disallow {
+			b.WriteString(strings.TrimSpace("Disallow: "+path) + "\n")
+		}
+		if rule.CrawlDelay > 0 {
+			b.WriteString("Crawl-delay: " + strconv.Itoa(rule.CrawlDelay) + "\n")
+		}
+	}
+	content := b.String()
+
+	return func(c *routing.Context) error {
+		s := content
+		if len(options.Sitemaps) > 0 {
+			s += "\n"
+			for _, sitemap := range options.Sitemaps {
+				if strings.HasPrefix(sitemap, "/") {
+					sitemap = baseURL(c.Request) + sitemap
+				}
+				s += "Sitemap: " + sitemap + "\n"
+			}
+		}
+		return c.WriteRaw("text/plain; charset=utf-8", []byte(s))
+	}
+}
+
+// SecurityText returns a handler that serves security.txt with the given content. It panics if Contact or Expires
+// is not specified. The handler is usually mounted by WellKnown as "security.txt".
+func SecurityText(s SecurityTxt) routing.Handler {
+	if len(s.Contact) == 0 || s.Expires.IsZero() {
+		panic("site: Contact and Expires are required by security.txt")
+	}
+	var b strings.Builder
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"Contact", s.Contact},
+		{"Expires", []string{s.Expires.UTC().Format(time.RFC3339)}},
+		{"Encryption", s.Encryption},
+		{"Acknowledgments", s.Acknowledgments},
+		{"Preferred-Languages", []string{strings.Join(s.PreferredLanguages, ", ")}},
+		{"Canonical", s.Canonical},
+		{"Policy", s.Policy},
+		{"Hiring", s.Hiring},
+	}
+	for _, field := range fields {
+		for _, value := range field.values {
+			if value != "" {
+				b.WriteString(field.name + ": " + value + "\n")
+			}
+		}
+	}
+	content := []byte(b.String())
+	return func(c *routing.Context) error {
+		return c.WriteRaw("text/plain; charset=utf-8", content)
+	}
+}
+
+// ChangePassword returns a handler that redirects the clients to the page for changing passwords at the given URL,
+// as required by the "change-password" well-known URL. The handler is usually mounted by WellKnown.
+func ChangePassword(url string) routing.Handler {
+	return func(c *routing.Context) error {
+		return c.Redirect(url, http.StatusFound)
+	}
+}
+
+// WellKnown registers the GET routes serving the given resources under /.well-known (RFC 8615) and returns
+// the route group. The resources are indexed by their names. For example,
+//
+//     site.WellKnown(router, map[string]routing.Handler{
+//         "security.txt":    site.SecurityText(site.SecurityTxt{...}),
+//         "change-password": site.ChangePassword("/account/password"),
+//     })
+func WellKnown(router *routing.Router, resources map[string]routing.Handler) *routing.RouteGroup {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	group := router.Group("/.well-known")
+	for _, name := range names {
+		group.Get("/"+strings.TrimPrefix(name, "/"), resources[name])
+	}
+	return group
+}
+
+// baseURL returns the scheme and the host of the request, e.g. "https://example.com".
+func baseURL(req *http.Request) string {
+	if req.TLS != nil {
+		return "https://" + req.Host
+	}
+	return "http://" + req.Host
+}
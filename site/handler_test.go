@@ -0,0 +1,138 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package site
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobots(t *testing.T) {
+	router := routing.New()
+	router.Get("/robots.txt", Robots(RobotsOptions{
+		Rules: []RobotsRule{
+			{Disallow: []string{"/admin/", "/api/"}},
+			{UserAgents: []string{"BadBot", "WorseBot"}, Disallow: []string{"/"}, Allow: []string{"/public/"}, CrawlDelay: 10},
+		},
+		Sitemaps: []string{"/sitemap.xml", "https://cdn.example.com/sitemap.xml"},
+	}))
+	router.Get("/default.txt", Robots(RobotsOptions{}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://example.com/robots.txt", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, `User-agent: *
+Disallow: /admin/
+Disallow: /api/
+
+User-agent: BadBot
+User-agent: WorseBot
+Allow: /public/
+Disallow: /
+Crawl-delay: 10
+
+Sitemap: http://example.com/sitemap.xml
+Sitemap: https://cdn.example.com/sitemap.xml
+`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/default.txt", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "User-agent: *\nDisallow:\n", res.Body.String())
+}
+
+func TestSitemap(t *testing.T) {
+	router := routing.New()
+	handler := func(c *routing.Context) error { return nil }
+	router.Get("/", handler).Set(Listed, true)
+	router.Get("/about", handler).Set(Listed, Entry{ChangeFreq: "monthly", Priority: 0.5})
+	router.Get("/admin", handler)
+	router.Get("/hidden", handler).Set(Listed, false)
+	router.Post("/contact", handler).Set(Listed, true)
+	router.Get("/articles/<id:\\d+>", handler).Set(Listed, true)
+	router.Get("/files/*", handler).Set(Listed, true)
+	router.Group("/docs").Get("/intro", handler).Set(Listed, true)
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	router.Get("/sitemap.xml", Sitemap(router, SitemapOptions{
+		BaseURL: "https://example.com/",
+		Params: func(route *routing.Route) [][]interface{} {
+			if route.Path() == "/articles/<id:\\d+>" {
+				return [][]interface{}{{"id", 1}, {"id", 2}, {}}
+			}
+			return nil
+		},
+		LastMod: func(route *routing.Route, path string) time.Time {
+			if path == "/articles/1" {
+				return modified
+			}
+			return time.Time{}
+		},
+	}))
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/sitemap.xml", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "application/xml; charset=utf-8", res.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+  </url>
+  <url>
+    <loc>https://example.com/about</loc>
+    <changefreq>monthly</changefreq>
+    <priority>0.5</priority>
+  </url>
+  <url>
+    <loc>https://example.com/articles/1</loc>
+    <lastmod>2020-01-02T03:04:05Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/articles/2</loc>
+  </url>
+  <url>
+    <loc>https://example.com/docs/intro</loc>
+  </url>
+</urlset>`, res.Body.String())
+}
+
+func TestWellKnown(t *testing.T) {
+	router := routing.New()
+	WellKnown(router, map[string]routing.Handler{
+		"security.txt": SecurityText(SecurityTxt{
+			Contact:            []string{"mailto:security@example.com", "https://example.com/security"},
+			Expires:            time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			PreferredLanguages: []string{"en", "de"},
+			Policy:             []string{"https://example.com/policy"},
+		}),
+		"change-password": ChangePassword("/account/password"),
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/.well-known/security.txt", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, `Contact: mailto:security@example.com
+Contact: https://example.com/security
+Expires: 2030-01-01T00:00:00Z
+Preferred-Languages: en, de
+Policy: https://example.com/policy
+`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/.well-known/change-password", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusFound, res.Code)
+	assert.Equal(t, "/account/password", res.Header().Get("Location"))
+
+	assert.Panics(t, func() {
+		SecurityText(SecurityTxt{Contact: []string{"mailto:security@example.com"}})
+	})
+}
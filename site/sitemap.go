@@ -0,0 +1,133 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package site
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+)
+
+// Listed is the name of the route metadata item that lists a GET route in the sitemap. The value is either true
+// or an Entry specifying the change frequency and the priority of the route pages:
+//
+//     r.Get("/about", about).Set(site.Listed, true)
+//     r.Get("/news", news).Set(site.Listed, site.Entry{ChangeFreq: "daily", Priority: 0.8})
+const Listed = "site.listed"
+
+type (
+	// Entry specifies the optional attributes of the sitemap entries of a route.
+	Entry struct {
+		ChangeFreq string  // how frequently the page is likely to change, e.g. "daily", "weekly"
+		Priority   float64 // the priority of the page relative to other pages of the site, between 0 and 1, if positive
+	}
+
+	// SitemapOptions specifies how Sitemap generates sitemap.xml.
+	SitemapOptions struct {
+		// BaseURL is prepended to the route paths to form the page URLs, e.g. "https://example.com".
+		// Defaults to the scheme and the host of the request.
+		BaseURL string
+		// Params returns the parameter values of the pages of a route whose path has parameters, e.g. the IDs
+		// of the articles for "/articles/<id>". Each element is a list of parameter names and values as accepted
+		// by routing.Route.URL. The routes with parameters are not listed if Params is nil or returns nothing,
+		// and the routes with wildcards are never listed.
+		Params func(route *routing.Route) [][]interface{}
+		// LastMod returns the time when the page at the given URL path of a route was last modified.
+		// The time is not listed if LastMod is nil or returns the zero time.
+		LastMod func(route *routing.Route, path string) time.Time
+	}
+
+	// urlSet is the root element of sitemap.xml.
+	urlSet struct {
+		XMLName xml.Name     `xml:"urlset"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}
+
+	// sitemapURL is a page in sitemap.xml.
+	sitemapURL struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod,omitempty"`
+		ChangeFreq string `xml:"changefreq,omitempty"`
+		Priority   string `xml:"priority,omitempty"`
+	}
+)
+
+// Sitemap returns a handler that serves sitemap.xml listing the pages of the GET routes of the router that have
+// the Listed metadata. The sitemap is generated from the routing table for every request, so it stays in sync with
+// the routes. For example,
+//
+//     r.Get("/sitemap.xml", site.Sitemap(r, site.SitemapOptions{
+//         BaseURL: "https://example.com",
+//         Params: func(route *routing.Route) [][]interface{} {
+//             return articleParams() // e.g. {{"id", 1}, {"id", 2}}
+//         },
+//     }))
+func Sitemap(router *routing.Router, options SitemapOptions) routing.Handler {
+	return func(c *routing.Context) error {
+		base := options.BaseURL
+		if base == "" {
+			base = baseURL(c.Request)
+		}
+		base = strings.TrimSuffix(base, "/")
+		set := urlSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for _, route := range router.Routes() {
+			if route.Method() != "GET" {
+				continue
+			}
+			var entry Entry
+			switch v := route.Meta(Listed).(type) {
+			case bool:
+				if !v {
+					continue
+				}
+			case Entry:
+				entry = v
+			default:
+				continue
+			}
+			for _, path := range sitemapPaths(route, options.Params) {
+				u := sitemapURL{Loc: base + path, ChangeFreq: entry.ChangeFreq}
+				if entry.Priority > 0 {
+					u.Priority = strconv.FormatFloat(entry.Priority, 'f', 1, 64)
+				}
+				if options.LastMod != nil {
+					if t := options.LastMod(route, path); !t.IsZero() {
+						u.LastMod = t.UTC().Format(time.RFC3339)
+					}
+				}
+				set.URLs = append(set.URLs, u)
+			}
+		}
+		data, err := xml.MarshalIndent(set, "", "  ")
+		if err != nil {
+			return err
+		}
+		return c.WriteRaw("application/xml; charset=utf-8", append([]byte(xml.Header), data...))
+	}
+}
+
+// sitemapPaths returns the URL paths of the pages of the given route.
+func sitemapPaths(route *routing.Route, params func(*routing.Route) [][]interface{}) []string {
+	if strings.Contains(route.Path(), "*") {
+		return nil
+	}
+	if !strings.Contains(route.Path(), "<") {
+		return []string{route.URL()}
+	}
+	if params == nil {
+		return nil
+	}
+	var paths []string
+	for _, pairs := range params(route) {
+		if path := route.URL(pairs...); !strings.Contains(path, "<") {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,25 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import "log/slog"
+
+// SlogAttrs returns the slog attributes describing the current request, which can be used to enrich the messages
+// logged while handling the request. The attributes include the request ID ("request_id") if the request has
+// the RequestIDHeader header, the HTTP method ("method"), the URL path ("path"), and the path of the matching
+// route ("route") if any.
+//
+//     logger.LogAttrs(c.Request.Context(), slog.LevelInfo, "user created", routing.SlogAttrs(c)...)
+func SlogAttrs(c *Context) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 4)
+	if id := c.Request.Header.Get(RequestIDHeader); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	attrs = append(attrs, slog.String("method", c.Request.Method), slog.String("path", c.Request.URL.Path))
+	if route := c.Route(); route != nil {
+		attrs = append(attrs, slog.String("route", route.Path()))
+	}
+	return attrs
+}
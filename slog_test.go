@@ -0,0 +1,39 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogAttrs(t *testing.T) {
+	var attrs []slog.Attr
+	router := New()
+	router.Get("/users/<id>", func(c *Context) error {
+		attrs = SlogAttrs(c)
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, []slog.Attr{
+		slog.String("request_id", "abc"),
+		slog.String("method", "GET"),
+		slog.String("path", "/users/1"),
+		slog.String("route", "/users/<id>"),
+	}, attrs)
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users", nil)
+	c := NewContext(res, req)
+	assert.Equal(t, []slog.Attr{slog.String("method", "POST"), slog.String("path", "/users")}, SlogAttrs(c))
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// RequestVariant is the key used to store and retrieve the name of the handler variant chosen by Split in Context.
+const RequestVariant = "Variant"
+
+// SplitKeyFunc returns the stable key (e.g. a user ID) used by Split to choose a handler variant for a request.
+// An empty key means the request has no stable key, in which case a variant is chosen randomly.
+type SplitKeyFunc func(*Context) string
+
+// Split returns a handler that routes the given percentage of the requests to handler b and the rest to handler a.
+// This is useful to roll out a new implementation of a handler gradually.
+//
+// The variant is chosen based on a hash of the key returned by the optional key function, so that requests with
+// the same key are always served by the same variant. The hash is salted with the method and the path of the route,
+// so that the splits of different routes choose the requests with the same key independently of each other.
+// If no key function is given or the key is empty, the variant is chosen randomly. The name of the chosen variant
+// ("a" or "b") can be retrieved via Context.Variant for logging or metrics. For example,
+//
+//     r.Get("/users", routing.Split(10, listUsers, listUsersV2, routing.CookieKey("session")))
+func Split(percent int, a, b Handler, key ...SplitKeyFunc) Handler {
+	var keyFunc SplitKeyFunc
+	if len(key) > 0 {
+		keyFunc = key[0]
+	}
+	return func(c *Context) error {
+		if splitBucket(c, "", keyFunc) < percent {
+			c.Set(RequestVariant, "b")
+			return b(c)
+		}
+		c.Set(RequestVariant, "a")
+		return a(c)
+	}
+}
+
+// CookieKey returns a SplitKeyFunc that uses the value of the named cookie as the key.
+func CookieKey(name string) SplitKeyFunc {
+	return func(c *Context) string {
+		if cookie, err := c.Request.Cookie(name); err == nil {
+			return cookie.Value
+		}
+		return ""
+	}
+}
+
+// HeaderKey returns a SplitKeyFunc that uses the value of the named request header as the key.
+func HeaderKey(name string) SplitKeyFunc {
+	return func(c *Context) string {
+		return c.Request.Header.Get(name)
+	}
+}
+
+// Variant returns the name of the handler variant chosen by Split for the current request.
+// An empty string is returned if the request is not served by Split.
+func (c *Context) Variant() string {
+	variant, _ := c.Get(RequestVariant).(string)
+	return variant
+}
+
+// splitBucket returns the bucket of the request between 0 and 99, which is chosen based on a hash of the key
+// returned by the key function, or randomly if there is no key. The hash is salted with the route of the request
+// and the given name, so that the requests with the same key fall into unrelated buckets for different splits.
+func splitBucket(c *Context, name string, key SplitKeyFunc) int {
+	if key != nil {
+		if k := key(c); k != "" {
+			h := fnv.New32a()
+			if route := c.Route(); route != nil {
+				h.Write([]byte(route.Method() + " " + route.Path() + "\x00"))
+			}
+			h.Write([]byte(name + "\x00" + k))
+			return int(h.Sum32() % 100)
+		}
+	}
+	return rand.Intn(100)
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	served := ""
+	a := func(c *Context) error {
+		served = "a"
+		return nil
+	}
+	b := func(c *Context) error {
+		served = "b"
+		return nil
+	}
+	run := func(h Handler, req *http.Request) string {
+		c := NewContext(nil, req)
+		assert.Equal(t, "", c.Variant())
+		assert.Nil(t, h(c))
+		assert.Equal(t, served, c.Variant())
+		return served
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "a", run(Split(0, a, b), req))
+		assert.Equal(t, "b", run(Split(100, a, b), req))
+	}
+
+	h := Split(50, a, b, HeaderKey("X-User"))
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User", fmt.Sprint(i))
+		variant := run(h, req)
+		counts[variant]++
+		// the same key always gets the same variant
+		assert.Equal(t, variant, run(h, req))
+	}
+	assert.True(t, counts["a"] > 50 && counts["b"] > 50)
+
+	h = Split(50, a, b, CookieKey("session"))
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	variant := run(h, req)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, variant, run(h, req))
+	}
+}
+
+func TestSplitSalt(t *testing.T) {
+	variant := func(c *Context) error {
+		return c.Write(c.Variant())
+	}
+	router := New()
+	router.Get("/users", Split(50, variant, variant, HeaderKey("X-User")))
+	router.Get("/posts", Split(50, variant, variant, HeaderKey("X-User")))
+
+	// the same key does not always get the same variant of different splits
+	same := 0
+	for i := 0; i < 100; i++ {
+		variants := ""
+		for _, path := range []string{"/users", "/posts"} {
+			res := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			req.Header.Set("X-User", fmt.Sprint(i))
+			router.ServeHTTP(res, req)
+			variants += res.Body.String()
+		}
+		if variants == "aa" || variants == "bb" {
+			same++
+		}
+	}
+	assert.True(t, same > 20 && same < 80, same)
+}
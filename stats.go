@@ -0,0 +1,121 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Stats counts the requests served by a router. Set Router.Stats to a Stats to enable counting.
+	// Stats implements expvar.Var, so the counters can be published via expvar:
+	//
+	//     router.Stats = &routing.Stats{}
+	//     expvar.Publish("routing", router.Stats)
+	//
+	// Stats is safe for concurrent use.
+	Stats struct {
+		requests, inFlight, panics, timeouts int64
+		statuses                             [6]int64 // indexed by the status classes, e.g. statuses[2] for 2xx
+	}
+
+	// StatsSnapshot holds the values of the counters in Stats at some point.
+	StatsSnapshot struct {
+		Requests  int64 `json:"requests"`   // the number of requests received, including those being served
+		InFlight  int64 `json:"in_flight"`  // the number of requests being served
+		Status1xx int64 `json:"status_1xx"` // the number of responses with 1xx status codes
+		Status2xx int64 `json:"status_2xx"` // the number of responses with 2xx status codes
+		Status3xx int64 `json:"status_3xx"` // the number of responses with 3xx status codes
+		Status4xx int64 `json:"status_4xx"` // the number of responses with 4xx status codes
+		Status5xx int64 `json:"status_5xx"` // the number of responses with 5xx status codes
+		Panics    int64 `json:"panics"`     // the number of panics recovered by the fault handlers
+		Timeouts  int64 `json:"timeouts"`   // the number of requests whose contexts exceeded the deadlines
+	}
+)
+
+// Snapshot returns the current values of the counters.
+func (s *Stats) Snapshot() StatsSnapshot {
+	return StatsSnapshot{
+		Requests:  atomic.LoadInt64(&s.requests),
+		InFlight:  atomic.LoadInt64(&s.inFlight),
+		Status1xx: atomic.LoadInt64(&s.statuses[1]),
+		Status2xx: atomic.LoadInt64(&s.statuses[2]),
+		Status3xx: atomic.LoadInt64(&s.statuses[3]),
+		Status4xx: atomic.LoadInt64(&s.statuses[4]),
+		Status5xx: atomic.LoadInt64(&s.statuses[5]),
+		Panics:    atomic.LoadInt64(&s.panics),
+		Timeouts:  atomic.LoadInt64(&s.timeouts),
+	}
+}
+
+// String returns the current values of the counters in JSON. It implements expvar.Var.
+func (s *Stats) String() string {
+	b, _ := json.Marshal(s.Snapshot())
+	return string(b)
+}
+
+// AddPanic increments the number of recovered panics. It is called by the panic handlers.
+func (s *Stats) AddPanic() {
+	atomic.AddInt64(&s.panics, 1)
+}
+
+// AddTimeout increments the number of timed out requests. The router counts the requests whose contexts
+// exceeded the deadlines. The handlers that time out requests in other ways may call this method.
+func (s *Stats) AddTimeout() {
+	atomic.AddInt64(&s.timeouts, 1)
+}
+
+// serveWithStats serves the request while counting it in r.Stats, recording it in r.History, tracking
+// its status in r.ErrorBudget, and counting the handler ending its handler chain in r.Exits.
+func (r *Router) serveWithStats(res http.ResponseWriter, req *http.Request) {
+	s := r.Stats
+	if s != nil {
+		atomic.AddInt64(&s.requests, 1)
+		atomic.AddInt64(&s.inFlight, 1)
+		defer atomic.AddInt64(&s.inFlight, -1)
+	}
+
+	start := time.Now()
+	w := &ResponseTracker{ResponseWriter: res}
+	c := r.AcquireContext(w, req)
+	r.Dispatch(c)
+	route := c.route
+	if r.Exits != nil {
+		r.Exits.Add(route, c.ChainExit())
+	}
+	r.ReleaseContext(c)
+
+	status := w.Status
+	if status == 0 {
+		// nothing is written: the response will be sent with an implicit 200 status
+		status = http.StatusOK
+	}
+	if r.History != nil {
+		r.History.Add(route, RequestRecord{
+			Time:    start,
+			Path:    req.URL.Path,
+			Status:  status,
+			Size:    w.Size,
+			Latency: float64(time.Since(start).Nanoseconds()) / 1e6,
+			Client:  remoteIP(req),
+		})
+	}
+	if r.ErrorBudget != nil {
+		r.ErrorBudget.Add(route, status)
+	}
+	if s == nil {
+		return
+	}
+	if class := status / 100; class >= 1 && class <= 5 {
+		atomic.AddInt64(&s.statuses[class], 1)
+	}
+	if req.Context().Err() == context.DeadlineExceeded {
+		s.AddTimeout()
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	router := New()
+	router.Stats = &Stats{}
+	var inFlight int64
+	router.Get("/ok", func(c *Context) error {
+		inFlight = router.Stats.Snapshot().InFlight
+		return nil
+	})
+	router.Get("/created", func(c *Context) error {
+		return c.WriteWithStatus("x", http.StatusCreated)
+	})
+	router.Get("/redirect", func(c *Context) error {
+		http.Redirect(c.Response, c.Request, "/ok", http.StatusFound)
+		return nil
+	})
+	router.Get("/error", func(c *Context) error {
+		return errors.New("abc")
+	})
+	router.Get("/slow", func(c *Context) error {
+		<-c.Request.Context().Done()
+		return NewHTTPError(http.StatusServiceUnavailable)
+	})
+
+	for _, path := range []string{"/ok", "/created", "/redirect", "/error", "/missing"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", "/slow", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	assert.Equal(t, int64(1), inFlight)
+	s := router.Stats.Snapshot()
+	assert.Equal(t, StatsSnapshot{
+		Requests:  6,
+		Status2xx: 2,
+		Status3xx: 1,
+		Status4xx: 1,
+		Status5xx: 2,
+		Timeouts:  1,
+	}, s)
+
+	router.Stats.AddPanic()
+	var result map[string]int64
+	assert.Nil(t, json.Unmarshal([]byte(router.Stats.String()), &result))
+	assert.Equal(t, int64(6), result["requests"])
+	assert.Equal(t, int64(1), result["panics"])
+	assert.Equal(t, int64(1), result["status_3xx"])
+}
+
+func TestStatsDisabled(t *testing.T) {
+	router := New()
+	router.Get("/ok", func(c *Context) error {
+		_, ok := c.Response.(*ResponseTracker)
+		assert.False(t, ok)
+		return nil
+	})
+	req, _ := http.NewRequest("GET", "/ok", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
@@ -44,10 +44,21 @@ func (s *store) Add(key string, data interface{}) int {
 // If the data item was added to the store with a parametric key before, the matching
 // parameter names and values will be returned as well.
 func (s *store) Get(path string, pvalues []string) (data interface{}, pnames []string) {
-	data, pnames, _ = s.root.get(path, pvalues)
+	data, pnames, _ = s.root.get(path, pvalues, nil)
 	return
 }
 
+// Trace works like Get while appending the steps of walking through the radix tree to the given trace.
+func (s *store) Trace(path string, pvalues []string, trace *[]string) (data interface{}, pnames []string) {
+	data, pnames, _ = s.root.get(path, pvalues, trace)
+	return
+}
+
+// Freeze compacts the radix tree for faster lookups. No data item can be added to the store afterwards.
+func (s *store) Freeze() {
+	s.root.compact()
+}
+
 // String dumps the radix tree kept in the store as a string.
 func (s *store) String() string {
 	return s.root.print(0)
@@ -63,12 +74,14 @@ type node struct {
 	order    int // the order at which the data was added. used to be pick the first one when matching multiple
 	minOrder int // minimum order among all the child nodes and this node
 
-	children  []*node // child static nodes, indexed by the first byte of each child key
+	children  []*node // child static nodes, indexed by the first byte of each child key minus coffset
+	coffset   byte    // the first byte of the child key stored at children[0]; non-zero only after compaction
 	pchildren []*node // child param nodes
 
-	regex  *regexp.Regexp // regular expression for a param node containing regular expression key
-	pindex int            // the parameter index, meaningful only for param node
-	pnames []string       // the parameter names collected from the root till this node
+	regex  *regexp.Regexp   // regular expression for a param node containing regular expression key
+	match  func(string) int // fast matcher equivalent to regex for common patterns. nil if not available
+	pindex int              // the parameter index, meaningful only for param node
+	pnames []string         // the parameter names collected from the root till this node
 }
 
 // add adds a new data item to the tree rooted at the current node.
@@ -200,6 +213,7 @@ func (n *node) addChild(key string, data interface{}, order int) int {
 	if pattern != "" {
 		// the param token contains a regular expression
 		child.regex = regexp.MustCompile("^" + pattern)
+		child.match = matchers[pattern]
 	}
 	pnames := make([]string, len(n.pnames)+1)
 	copy(pnames, n.pnames)
@@ -219,8 +233,9 @@ func (n *node) addChild(key string, data interface{}, order int) int {
 	return child.addChild(key[p1+1:], data, order)
 }
 
-// get returns the data item with the key matching the tree rooted at the current node
-func (n *node) get(key string, pvalues []string) (data interface{}, pnames []string, order int) {
+// get returns the data item with the key matching the tree rooted at the current node.
+// If trace is not nil, the matching steps will be appended to it.
+func (n *node) get(key string, pvalues []string, trace *[]string) (data interface{}, pnames []string, order int) {
 	order = math.MaxInt32
 
 repeat:
@@ -229,25 +244,51 @@ repeat:
 		// a slightly optimized version of strings.HasPrefix
 		nkl := len(n.key)
 		if nkl > len(key) {
+			if trace != nil {
+				tracef(trace, "static node %q: rejected %q (too short)", n.key, key)
+			}
 			return
 		}
 		for i := nkl - 1; i >= 0; i-- {
 			if n.key[i] != key[i] {
+				if trace != nil {
+					tracef(trace, "static node %q: rejected %q (prefix mismatch)", n.key, key)
+				}
 				return
 			}
 		}
+		if trace != nil && nkl > 0 {
+			tracef(trace, "static node %q: matched", n.key)
+		}
 		key = key[nkl:]
+	} else if n.match != nil {
+		// param node with a common regular expression that can be matched without using regexp
+		i := n.match(key)
+		if i < 0 {
+			if trace != nil {
+				tracef(trace, "param node %q: regex %q rejected %q", n.key, n.regex.String(), key)
+			}
+			return
+		}
+		pvalues[n.pindex] = key[0:i]
+		key = key[i:]
+		if trace != nil {
+			tracef(trace, "param node %q: regex %q matched %q", n.key, n.regex.String(), pvalues[n.pindex])
+		}
 	} else if n.regex != nil {
 		// param node with regular expression
-		if n.regex.String() == "^.*" {
-			pvalues[n.pindex] = key
-			key = ""
-		} else if match := n.regex.FindStringIndex(key); match != nil {
+		if match := n.regex.FindStringIndex(key); match != nil {
 			pvalues[n.pindex] = key[0:match[1]]
 			key = key[match[1]:]
 		} else {
+			if trace != nil {
+				tracef(trace, "param node %q: regex %q rejected %q", n.key, n.regex.String(), key)
+			}
 			return
 		}
+		if trace != nil {
+			tracef(trace, "param node %q: regex %q matched %q", n.key, n.regex.String(), pvalues[n.pindex])
+		}
 	} else {
 		// param node matching non-"/" characters
 		i, kl := 0, len(key)
@@ -262,21 +303,31 @@ repeat:
 			pvalues[n.pindex] = key
 			key = ""
 		}
+		if trace != nil {
+			tracef(trace, "param node %q: matched %q", n.key, pvalues[n.pindex])
+		}
 	}
 
 	if len(key) > 0 {
 		// find a static child that can match the rest of the key
-		if child := n.children[key[0]]; child != nil {
+		if child := n.child(key[0]); child != nil {
 			if len(n.pchildren) == 0 {
 				// use goto to avoid recursion when no param children
 				n = child
 				goto repeat
 			}
-			data, pnames, order = child.get(key, pvalues)
+			data, pnames, order = child.get(key, pvalues, trace)
+		} else if trace != nil && len(n.pchildren) == 0 {
+			tracef(trace, "node %q: no child node for %q", n.key, key)
 		}
 	} else if n.data != nil {
 		// do not return yet: a param node may match an empty string with smaller order
 		data, pnames, order = n.data, n.pnames, n.order
+		if trace != nil {
+			tracef(trace, "node %q: found %v (order %v)", n.key, n.data, n.order)
+		}
+	} else if trace != nil && len(n.pchildren) == 0 {
+		tracef(trace, "node %q: no data", n.key)
 	}
 
 	// try matching param children
@@ -284,13 +335,16 @@ repeat:
 	allocated := false
 	for _, child := range n.pchildren {
 		if child.minOrder >= order {
+			if trace != nil {
+				tracef(trace, "param node %q: skipped (minOrder %v >= order %v)", child.key, child.minOrder, order)
+			}
 			continue
 		}
 		if data != nil && !allocated {
 			tvalues = make([]string, len(pvalues))
 			allocated = true
 		}
-		if d, p, s := child.get(key, tvalues); d != nil && s < order {
+		if d, p, s := child.get(key, tvalues, trace); d != nil && s < order {
 			if allocated {
 				for i := child.pindex; i < len(p); i++ {
 					pvalues[i] = tvalues[i]
@@ -303,6 +357,90 @@ repeat:
 	return
 }
 
+// matchers lists the fast matchers for the common regular expressions used in param tokens.
+// Each matcher returns the length of the longest prefix of the given key matching the regular expression,
+// or -1 if there is no match.
+var matchers = map[string]func(string) int{
+	".*":     func(key string) int { return len(key) }, // matches newlines as well for backward compatibility
+	".+":     func(key string) int { return atLeastOne(scanLine(key)) },
+	`\d+`:    func(key string) int { return atLeastOne(scanDigits(key)) },
+	`\d*`:    scanDigits,
+	"[0-9]+": func(key string) int { return atLeastOne(scanDigits(key)) },
+	"[0-9]*": scanDigits,
+	"[^/]+":  func(key string) int { return atLeastOne(scanSegment(key)) },
+	"[^/]*":  scanSegment,
+}
+
+// scanLine returns the number of leading characters in the key that are not a newline.
+func scanLine(key string) int {
+	if i := strings.IndexByte(key, '\n'); i >= 0 {
+		return i
+	}
+	return len(key)
+}
+
+// scanDigits returns the number of leading ASCII digits in the key.
+func scanDigits(key string) int {
+	i := 0
+	for ; i < len(key) && key[i] >= '0' && key[i] <= '9'; i++ {
+	}
+	return i
+}
+
+// scanSegment returns the number of leading non-"/" characters in the key.
+func scanSegment(key string) int {
+	i := 0
+	for ; i < len(key) && key[i] != '/'; i++ {
+	}
+	return i
+}
+
+// atLeastOne turns an empty match into no match.
+func atLeastOne(n int) int {
+	if n == 0 {
+		return -1
+	}
+	return n
+}
+
+// child returns the static child node whose key starts with the given byte, or nil if there is none.
+func (n *node) child(c byte) *node {
+	if i := int(c) - int(n.coffset); i >= 0 && i < len(n.children) {
+		return n.children[i]
+	}
+	return nil
+}
+
+// compact shrinks the child node lists of the tree rooted at the current node so that the nodes take
+// less memory and are more likely to stay in CPU caches. Data items cannot be added to the tree afterwards.
+func (n *node) compact() {
+	first, last := -1, -1
+	for i, child := range n.children {
+		if child != nil {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			child.compact()
+		}
+	}
+	if first < 0 {
+		n.children, n.coffset = nil, 0
+	} else {
+		n.children = append([]*node(nil), n.children[first:last+1]...)
+		n.coffset = byte(first)
+	}
+	n.pchildren = append([]*node(nil), n.pchildren...)
+	for _, child := range n.pchildren {
+		child.compact()
+	}
+}
+
+// tracef appends a formatted message to the trace.
+func tracef(trace *[]string, format string, args ...interface{}) {
+	*trace = append(*trace, fmt.Sprintf(format, args...))
+}
+
 func (n *node) print(level int) string {
 	r := fmt.Sprintf("%v{key: %v, regex: %v, data: %v, order: %v, minOrder: %v, pindex: %v, pnames: %v}\n", strings.Repeat(" ", level<<2), n.key, n.regex, n.data, n.order, n.minOrder, n.pindex, n.pnames)
 	for _, child := range n.children {
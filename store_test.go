@@ -6,6 +6,7 @@ package routing
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -150,15 +151,67 @@ func TestStoreGet(t *testing.T) {
 		{"/users/abc/xyz", nil, ""},
 	}
 	pvalues := make([]string, maxParams)
-	for _, test := range tests {
-		data, pnames := h.Get(test.key, pvalues)
-		assert.Equal(t, test.value, data, "store.Get("+test.key+") =")
-		params := ""
-		if len(pnames) > 0 {
-			for i, name := range pnames {
-				params += fmt.Sprintf("%v:%v,", name, pvalues[i])
+	for _, frozen := range []bool{false, true} {
+		if frozen {
+			h.Freeze()
+		}
+		for _, test := range tests {
+			data, pnames := h.Get(test.key, pvalues)
+			assert.Equal(t, test.value, data, "store.Get("+test.key+") =")
+			params := ""
+			if len(pnames) > 0 {
+				for i, name := range pnames {
+					params += fmt.Sprintf("%v:%v,", name, pvalues[i])
+				}
 			}
+			assert.Equal(t, test.params, params, "store.Get("+test.key+").params =")
 		}
-		assert.Equal(t, test.params, params, "store.Get("+test.key+").params =")
 	}
+	// "/" has static children "all/", "gopher/", and "users/"
+	n := h.root.child('/')
+	assert.Equal(t, byte('a'), n.coffset)
+	assert.Len(t, n.children, 'u'-'a'+1)
+	assert.Nil(t, n.child('b'))
+	assert.Nil(t, n.child('z'))
+}
+
+func TestStoreMatchers(t *testing.T) {
+	keys := []string{"", "123", "123abc", "abc", "abc/123", "12/34", "a\nb", "/", "été/1"}
+	for pattern, match := range matchers {
+		regex := regexp.MustCompile("^" + pattern)
+		for _, key := range keys {
+			expected := -1
+			if m := regex.FindStringIndex(key); m != nil {
+				expected = m[1]
+			}
+			if pattern == ".*" {
+				// ".*" always matches the whole key
+				expected = len(key)
+			}
+			assert.Equal(t, expected, match(key), fmt.Sprintf("%q matching %q", pattern, key))
+		}
+	}
+}
+
+func benchmarkStoreGet(b *testing.B, pattern, key string) {
+	s := newStore()
+	s.Add("/users/"+pattern+"/profile", "1")
+	pvalues := make([]string, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Get(key, pvalues)
+	}
+}
+
+func BenchmarkStoreGetParam(b *testing.B) {
+	benchmarkStoreGet(b, "<id>", "/users/12345/profile")
+}
+
+func BenchmarkStoreGetCommonRegex(b *testing.B) {
+	benchmarkStoreGet(b, `<id:\d+>`, "/users/12345/profile")
+}
+
+func BenchmarkStoreGetRegex(b *testing.B) {
+	benchmarkStoreGet(b, `<id:\d{1,8}>`, "/users/12345/profile")
 }
@@ -0,0 +1,163 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestionDistance is the maximum edit distance between a request path and a route path
+// for the route to be suggested by Router.Suggest.
+var MaxSuggestionDistance = 2
+
+// MaxSuggestionCandidates is the maximum number of the routes compared with a request path by Router.Suggest,
+// which limits the work done for each request without matching route in an application with many routes.
+var MaxSuggestionCandidates = 1000
+
+// Suggest returns up to max paths of the routes with the given HTTP method that are near misses of the given path.
+// A route is a near miss if its path has the same number of segments as the given path, and the static segments
+// differ from those in the given path by a total edit distance not greater than MaxSuggestionDistance.
+// Differences in letter case and trailing slashes are not counted. The parameter tokens in the route path are
+// replaced with the corresponding segments of the given path, and the returned paths are URL-escaped unless
+// Router.UseEscapedPath is true, in which case the given path should be escaped already. The closest matches
+// are returned first. Only the first MaxSuggestionCandidates routes with the given method are compared.
+func (r *Router) Suggest(method, path string, max int) []string {
+	type suggestion struct {
+		path     string
+		distance int
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	suggestions := []suggestion{}
+	seen := map[string]bool{path: true}
+	candidates := 0
+	for _, route := range r.routes {
+		if route.method != method || strings.Contains(route.Path(), "*") {
+			continue
+		}
+		if candidates++; candidates > MaxSuggestionCandidates {
+			break
+		}
+		s, distance, ok := suggestPath(route.Path(), segments, !r.UseEscapedPath)
+		if ok && !seen[s] {
+			seen[s] = true
+			suggestions = append(suggestions, suggestion{s, distance})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	paths := []string{}
+	for i := 0; i < len(suggestions) && i < max; i++ {
+		paths = append(paths, suggestions[i].path)
+	}
+	return paths
+}
+
+// suggestPath builds the path suggested by the given route path for the request path segments.
+// If escape is true, the segments of the suggested path are URL-escaped.
+func suggestPath(routePath string, segments []string, escape bool) (string, int, bool) {
+	tokens := strings.Split(strings.Trim(routePath, "/"), "/")
+	if len(tokens) != len(segments) {
+		return "", 0, false
+	}
+	distance := 0
+	for i, token := range tokens {
+		if strings.HasPrefix(token, "<") && strings.HasSuffix(token, ">") {
+			tokens[i] = segments[i]
+			continue
+		}
+		if distance += editDistance(strings.ToLower(token), strings.ToLower(segments[i])); distance > MaxSuggestionDistance {
+			return "", 0, false
+		}
+	}
+	if escape {
+		for i, token := range tokens {
+			tokens[i] = url.PathEscape(token)
+		}
+	}
+	s := "/" + strings.Join(tokens, "/")
+	if strings.HasSuffix(routePath, "/") && s != "/" {
+		s += "/"
+	}
+	return s, distance, true
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// SuggestionError is a 404 HTTP error that carries the paths suggested for the requested path.
+type SuggestionError struct {
+	Status      int      `json:"status" xml:"status"`
+	Message     string   `json:"message" xml:"message"`
+	Suggestions []string `json:"suggestions" xml:"suggestions>path"`
+}
+
+// Error returns the error message.
+func (e *SuggestionError) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code.
+func (e *SuggestionError) StatusCode() int {
+	return e.Status
+}
+
+// SuggestionHandler returns a handler that suggests up to max near-miss routes (see Router.Suggest) for a request
+// without matching route. The suggestions are sent as "Link" response headers with the "alternate" relation, and the
+// handler returns a SuggestionError carrying them. If there is no suggestion, the handler does nothing and lets
+// the next handler (usually a NotFoundHandler) handle the request. The handler should be used with Router.NotFound:
+//
+//     r := routing.New()
+//     r.NotFound(routing.MethodNotAllowedHandler, routing.SuggestionHandler(3), routing.NotFoundHandler)
+func SuggestionHandler(max int) Handler {
+	return func(c *Context) error {
+		r := c.Router()
+		path := c.Request.URL.Path
+		if r.UseEscapedPath {
+			path = c.Request.URL.EscapedPath()
+		}
+		suggestions := r.Suggest(c.Request.Method, path, max)
+		if len(suggestions) == 0 {
+			return nil
+		}
+		for _, s := range suggestions {
+			c.Response.Header().Add("Link", "<"+s+">; rel=\"alternate\"")
+		}
+		c.Abort()
+		return &SuggestionError{http.StatusNotFound, http.StatusText(http.StatusNotFound), suggestions}
+	}
+}
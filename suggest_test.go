@@ -0,0 +1,103 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("", ""))
+	assert.Equal(t, 3, editDistance("abc", ""))
+	assert.Equal(t, 1, editDistance("user", "users"))
+	assert.Equal(t, 2, editDistance("users", "usres"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+}
+
+func TestRouterSuggest(t *testing.T) {
+	router := New()
+	router.Get("/users")
+	router.Get("/users/<id>")
+	router.Get("/users/<id>/posts/")
+	router.Get("/orders")
+	router.Get("/files/*")
+	router.Post("/user")
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/user", []string{"/users"}},
+		{"/Users", []string{"/users"}},
+		{"/users/", []string{"/users"}},
+		{"/usr/1", []string{"/users/1"}},
+		{"/users/1/post", []string{"/users/1/posts/"}},
+		{"/order", []string{"/orders"}},
+		{"/ordrs/1", []string{}},
+		{"/files", []string{}},
+		{"/customers", []string{}},
+		{"/", []string{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, router.Suggest("GET", test.path, 3), test.path)
+	}
+	assert.Equal(t, []string{"/user"}, router.Suggest("POST", "/users", 3))
+	assert.Equal(t, []string{}, router.Suggest("GET", "/user", 0))
+
+	router.Get("/userz")
+	assert.Equal(t, []string{"/users", "/userz"}, router.Suggest("GET", "/user", 3))
+	assert.Equal(t, []string{"/users"}, router.Suggest("GET", "/user", 1))
+
+	// the segments taken from the request path are escaped
+	assert.Equal(t, []string{"/users/a%3E%3B%20rel=%22x%22/posts/"}, router.Suggest("GET", `/users/a>; rel="x"/post`, 3))
+
+	// only the first MaxSuggestionCandidates routes are compared
+	defer func(n int) { MaxSuggestionCandidates = n }(MaxSuggestionCandidates)
+	MaxSuggestionCandidates = 1
+	assert.Equal(t, []string{"/users"}, router.Suggest("GET", "/user", 3))
+	assert.Equal(t, []string{}, router.Suggest("GET", "/order", 3))
+}
+
+func TestSuggestionHandler(t *testing.T) {
+	router := New()
+	router.NotFound(MethodNotAllowedHandler, SuggestionHandler(3), NotFoundHandler)
+	router.Get("/users")
+	router.Post("/posts")
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/user", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, []string{`</users>; rel="alternate"`}, res.Header()["Link"])
+
+	router.Get("/users/<id>")
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/user/%3Cx%3E%22", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, []string{`</users/%3Cx%3E%22>; rel="alternate"`}, res.Header()["Link"])
+	assert.NotContains(t, res.Body.String(), "<x>")
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/customers", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.Equal(t, "", res.Header().Get("Link"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/posts", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+
+	err := &SuggestionError{http.StatusNotFound, "Not Found", []string{"/users"}}
+	assert.Equal(t, http.StatusNotFound, err.StatusCode())
+	assert.Equal(t, "Not Found", err.Error())
+	b, _ := json.Marshal(err)
+	assert.Equal(t, `{"status":404,"message":"Not Found","suggestions":["/users"]}`, string(b))
+}
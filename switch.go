@@ -0,0 +1,150 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// Alternate is a handler chain that a Switch serves instead of its default handlers while the alternate
+	// is active, e.g. a maintenance page during a deployment window or a seasonal version of an endpoint.
+	Alternate struct {
+		// Name identifies the alternate. It is reported by Context.Variant and used by Switch.Activate.
+		Name string
+		// Handlers are the handlers serving the requests while the alternate is active.
+		Handlers []Handler
+		// From and Until specify the time window in which the alternate is active. A zero From means the window
+		// has no start, and a zero Until means it has no end. If both are zero, the alternate is only active
+		// after being activated by Switch.Activate.
+		From, Until time.Time
+		// Percent is the percentage of the requests served by the alternate while it is active, which is useful
+		// for weighted A/B tests. The requests are chosen as Split does, using Key if given, with the hash
+		// of the key also salted with Name. Zero means all requests.
+		Percent int
+		// Key returns the stable key of a request used to choose the requests served by the alternate.
+		Key SplitKeyFunc
+	}
+
+	// Switch serves a route with its default handlers or one of the alternate handler chains, which are activated
+	// by time windows or at runtime. The alternates can be replaced at any time by calling Load, which takes effect
+	// atomically for the subsequent requests without restarting the server. For example,
+	//
+	//     checkout := routing.NewSwitch(checkoutHandler)
+	//     checkout.Load(
+	//         routing.Alternate{Name: "maintenance", Handlers: []routing.Handler{maintenancePage}},
+	//         routing.Alternate{Name: "holiday", Handlers: []routing.Handler{holidayCheckout},
+	//             From: holidayStart, Until: holidayEnd, Percent: 50, Key: routing.CookieKey("session")},
+	//     )
+	//     r.Post("/checkout", checkout.Handler())
+	//
+	//     // during a deployment
+	//     checkout.Activate("maintenance")
+	//
+	// Switch is safe for concurrent use.
+	Switch struct {
+		handlers []Handler
+		mu       sync.Mutex
+		state    atomic.Value // *switchState
+		now      func() time.Time
+	}
+
+	// switchState is the immutable state of a Switch, which is replaced as a whole when the state changes.
+	switchState struct {
+		alternates []Alternate
+		active     string
+	}
+)
+
+// NewSwitch creates a Switch serving the requests with the given default handlers when no alternate is active.
+func NewSwitch(handlers ...Handler) *Switch {
+	s := &Switch{handlers: handlers}
+	s.state.Store(&switchState{})
+	return s
+}
+
+// Load replaces the alternates of the switch with the given ones, which are checked in order for each request.
+// The alternate activated by Activate stays active if an alternate of the same name is loaded.
+func (s *Switch) Load(alternates ...Alternate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state.Load().(*switchState)
+	active := ""
+	for _, a := range alternates {
+		if a.Name == state.active {
+			active = a.Name
+		}
+	}
+	s.state.Store(&switchState{append([]Alternate{}, alternates...), active})
+}
+
+// Activate activates the named alternate, which then serves the requests regardless of its time window until
+// another alternate is activated or Activate is called with an empty name. An error is returned if the alternate
+// is not loaded.
+func (s *Switch) Activate(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state.Load().(*switchState)
+	if name != "" {
+		found := false
+		for _, a := range state.alternates {
+			found = found || a.Name == name
+		}
+		if !found {
+			return errors.New("the alternate " + name + " is not loaded")
+		}
+	}
+	s.state.Store(&switchState{state.alternates, name})
+	return nil
+}
+
+// Active returns the name of the alternate activated by Activate, or an empty string if there is none.
+func (s *Switch) Active() string {
+	return s.state.Load().(*switchState).active
+}
+
+// Handler returns a handler that calls the handlers of the active alternate, or the default handlers if no
+// alternate is active. The name of the alternate is stored in the Context as the variant (see Context.Variant).
+// The execution times of the called handlers are not recorded separately when Router.Timing is true; they are
+// included in that of the switch handler.
+func (s *Switch) Handler() Handler {
+	return func(c *Context) error {
+		handlers := s.handlers
+		if a := s.choose(c); a != nil {
+			c.Set(RequestVariant, a.Name)
+			handlers = a.Handlers
+		}
+		outer, index, timings := c.handlers, c.index, c.timings
+		c.handlers, c.index, c.timings = handlers, -1, nil
+		err := c.Next()
+		c.handlers, c.index, c.timings = outer, index, timings
+		return err
+	}
+}
+
+// choose returns the alternate serving the current request, or nil if the default handlers should be used.
+func (s *Switch) choose(c *Context) *Alternate {
+	state := s.state.Load().(*switchState)
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	for i := range state.alternates {
+		a := &state.alternates[i]
+		if a.Name != state.active {
+			if a.From.IsZero() && a.Until.IsZero() || !a.From.IsZero() && now.Before(a.From) || !a.Until.IsZero() && !now.Before(a.Until) {
+				continue
+			}
+		}
+		if a.Percent > 0 && a.Percent < 100 && splitBucket(c, a.Name, a.Key) >= a.Percent {
+			continue
+		}
+		return a
+	}
+	return nil
+}
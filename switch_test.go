@@ -0,0 +1,112 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitch(t *testing.T) {
+	serve := func(name string) Handler {
+		return func(c *Context) error {
+			return c.Write(name)
+		}
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSwitch(serve("default"))
+	s.now = func() time.Time { return now }
+
+	router := New()
+	router.Get("/", s.Handler(), func(c *Context) error {
+		return c.Write("|" + c.Variant())
+	})
+	run := func(req *http.Request) string {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	assert.Equal(t, "default|", run(req))
+
+	s.Load(
+		Alternate{Name: "maintenance", Handlers: []Handler{serve("maintenance")}},
+		Alternate{Name: "holiday", Handlers: []Handler{serve("holiday")}, From: now.Add(time.Hour), Until: now.Add(2 * time.Hour)},
+	)
+	assert.Equal(t, "default|", run(req), "alternates without a window are inactive")
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, "holiday|holiday", run(req), "the window starts")
+	now = now.Add(time.Hour)
+	assert.Equal(t, "default|", run(req), "the window ends")
+
+	assert.NotNil(t, s.Activate("unknown"))
+	assert.Nil(t, s.Activate("maintenance"))
+	assert.Equal(t, "maintenance", s.Active())
+	assert.Equal(t, "maintenance|maintenance", run(req))
+
+	// reloading keeps the activated alternate
+	s.Load(Alternate{Name: "maintenance", Handlers: []Handler{serve("maintenance2")}})
+	assert.Equal(t, "maintenance", s.Active())
+	assert.Equal(t, "maintenance2|maintenance", run(req))
+	s.Load()
+	assert.Equal(t, "", s.Active())
+	assert.Equal(t, "default|", run(req))
+
+	s.Load(Alternate{Name: "beta", Handlers: []Handler{serve("beta")}, Percent: 50, Key: HeaderKey("X-User")})
+	assert.Nil(t, s.Activate("beta"))
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User", fmt.Sprint(i))
+		result := run(req)
+		counts[result]++
+		// the same key always gets the same handlers
+		assert.Equal(t, result, run(req))
+	}
+	assert.True(t, counts["beta|beta"] > 50 && counts["default|"] > 50)
+
+	assert.Nil(t, s.Activate(""))
+	assert.Equal(t, "", s.Active())
+	assert.Equal(t, "default|", run(req))
+}
+
+func TestSwitchTiming(t *testing.T) {
+	var timings []HandlerTiming
+	s := NewSwitch(func(c *Context) error {
+		return c.Write("default")
+	})
+	s.Load(Alternate{Name: "v2", Handlers: []Handler{
+		func(c *Context) error {
+			assert.Nil(t, c.HandlerTimings())
+			return c.Next()
+		},
+		func(c *Context) error { return c.Next() },
+		func(c *Context) error { return c.Write("v2") },
+	}})
+	assert.Nil(t, s.Activate("v2"))
+
+	router := New()
+	router.Timing = true
+	router.Use(func(c *Context) error {
+		err := c.Next()
+		timings = c.HandlerTimings()
+		return err
+	})
+	router.Get("/", s.Handler())
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "v2", res.Body.String())
+	// only the switch handler is timed; the middleware is still running
+	assert.Len(t, timings, 1)
+}
@@ -0,0 +1,164 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type (
+	// RouteFingerprint is a stable summary of the routing table returned by Router.Fingerprint. It can be dumped
+	// as JSON at startup or in CI and compared with the dump of another deployment by DiffRoutes.
+	RouteFingerprint struct {
+		Hash   string       `json:"hash"`   // the hex-encoded SHA-256 hash of the routes
+		Routes []RouteEntry `json:"routes"` // the routes sorted by their paths, methods, and schemes
+	}
+
+	// RouteEntry describes a route in a RouteFingerprint.
+	RouteEntry struct {
+		Scheme     string `json:"scheme,omitempty"`   // the scheme the route is bound to, if any
+		Method     string `json:"method"`             // the HTTP method
+		Path       string `json:"path"`               // the path pattern, e.g. "/users/<id:\d+>"
+		Name       string `json:"name,omitempty"`     // the route name
+		Priority   int    `json:"priority,omitempty"` // the route priority
+		Middleware int    `json:"middleware"`         // the number of handlers inherited from the router and groups
+		Handlers   int    `json:"handlers"`           // the number of handlers registered with the route
+	}
+
+	// RouteChange describes a route that differs between two route fingerprints.
+	RouteChange struct {
+		Old *RouteEntry // the route in the old fingerprint, or nil if the route is added
+		New *RouteEntry // the route in the new fingerprint, or nil if the route is removed
+	}
+)
+
+// Fingerprint returns a stable summary of the routing table, including the methods, the path patterns, and
+// the numbers of handlers of the routes. Its hash changes whenever a route is added, removed, or has its handlers
+// changed, so deployment tooling can detect unexpected route changes, and its string form can be logged
+// as a startup banner:
+//
+//     fp := router.Fingerprint()
+//     log.Print(fp)
+//     data, _ := json.Marshal(fp)
+//     ioutil.WriteFile("routes.json", data, 0644)
+func (r *Router) Fingerprint() *RouteFingerprint {
+	entries := make([]RouteEntry, 0, len(r.routes))
+	for _, route := range r.routes {
+		entries = append(entries, RouteEntry{
+			Scheme:     route.group.scheme,
+			Method:     route.method,
+			Path:       route.Path(),
+			Name:       route.name,
+			Priority:   route.priority,
+			Middleware: route.offset,
+			Handlers:   len(route.handlers) - route.offset,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Scheme < b.Scheme
+	})
+
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\t%s\t%s\t%s\t%d\t%d\t%d\n", e.Scheme, e.Method, e.Path, e.Name, e.Priority, e.Middleware, e.Handlers)
+	}
+	return &RouteFingerprint{Hash: hex.EncodeToString(h.Sum(nil)), Routes: entries}
+}
+
+// String returns a banner listing the routes, one per line, after a line showing the number of routes and
+// the beginning of the hash.
+func (f *RouteFingerprint) String() string {
+	var b strings.Builder
+	hash := f.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	fmt.Fprintf(&b, "%d routes, fingerprint %s\n", len(f.Routes), hash)
+	for i := range f.Routes {
+		fmt.Fprintf(&b, "  %s\n", &f.Routes[i])
+	}
+	return b.String()
+}
+
+// String returns the method and the path of the route, followed by the scheme, the name, the priority,
+// and the numbers of handlers.
+func (e *RouteEntry) String() string {
+	s := e.Method + " " + e.Path
+	if e.Scheme != "" {
+		s += " scheme=" + e.Scheme
+	}
+	if e.Name != "" {
+		s += " name=" + e.Name
+	}
+	if e.Priority != 0 {
+		s += fmt.Sprintf(" priority=%d", e.Priority)
+	}
+	return s + fmt.Sprintf(" middleware=%d handlers=%d", e.Middleware, e.Handlers)
+}
+
+// key identifies the route when comparing fingerprints.
+func (e *RouteEntry) key() string {
+	return e.Scheme + " " + e.Method + " " + e.Path
+}
+
+// DiffRoutes compares two route fingerprints and returns the routes that are removed, changed, or added in the current
+// one, in the order of the routes in the fingerprints. Routes are identified by their schemes, methods, and paths.
+// An empty list is returned if the fingerprints have the same routes. For example,
+//
+//     var old routing.RouteFingerprint
+//     data, _ := ioutil.ReadFile("routes.json")
+//     json.Unmarshal(data, &old)
+//     for _, change := range routing.DiffRoutes(&old, router.Fingerprint()) {
+//         fmt.Println(change)
+//     }
+func DiffRoutes(old, current *RouteFingerprint) []RouteChange {
+	changes := []RouteChange{}
+	if old.Hash != "" && old.Hash == current.Hash {
+		return changes
+	}
+	newRoutes := make(map[string]*RouteEntry, len(current.Routes))
+	for i := range current.Routes {
+		newRoutes[current.Routes[i].key()] = &current.Routes[i]
+	}
+	oldRoutes := make(map[string]bool, len(old.Routes))
+	for i := range old.Routes {
+		o := &old.Routes[i]
+		oldRoutes[o.key()] = true
+		if n := newRoutes[o.key()]; n == nil {
+			changes = append(changes, RouteChange{Old: o})
+		} else if *n != *o {
+			changes = append(changes, RouteChange{Old: o, New: n})
+		}
+	}
+	for i := range current.Routes {
+		if n := &current.Routes[i]; !oldRoutes[n.key()] {
+			changes = append(changes, RouteChange{New: n})
+		}
+	}
+	return changes
+}
+
+// String returns the description of the change, prefixed with "+" for an added route, "-" for a removed route,
+// and "~" for a changed route.
+func (c RouteChange) String() string {
+	switch {
+	case c.Old == nil:
+		return "+ " + c.New.String()
+	case c.New == nil:
+		return "- " + c.Old.String()
+	}
+	return fmt.Sprintf("~ %s -> %s", c.Old, c.New)
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterFingerprint(t *testing.T) {
+	newRouter := func(extra bool) *Router {
+		router := New()
+		router.Use(NotFoundHandler)
+		api := router.Group("/api")
+		api.Use(NotFoundHandler)
+		api.Get("/users/<id>", NotFoundHandler, NotFoundHandler).Name("user")
+		api.Post("/users", NotFoundHandler)
+		if extra {
+			router.Delete("/users/<id>", NotFoundHandler)
+			api.Post("/users", NotFoundHandler, NotFoundHandler)
+		}
+		router.Scheme("https").Get("/login", NotFoundHandler).Priority(1)
+		return router
+	}
+
+	fp := newRouter(false).Fingerprint()
+	assert.Len(t, fp.Hash, 64)
+	assert.Equal(t, []RouteEntry{
+		{Method: "POST", Path: "/api/users", Middleware: 2, Handlers: 1},
+		{Method: "GET", Path: "/api/users/<id>", Name: "user", Middleware: 2, Handlers: 2},
+		{Scheme: "https", Method: "GET", Path: "/login", Priority: 1, Middleware: 1, Handlers: 1},
+	}, fp.Routes)
+	assert.Equal(t, fp, newRouter(false).Fingerprint())
+	assert.Equal(t, `3 routes, fingerprint `+fp.Hash[:12]+`
+  POST /api/users middleware=2 handlers=1
+  GET /api/users/<id> name=user middleware=2 handlers=2
+  GET /login scheme=https priority=1 middleware=1 handlers=1
+`, fp.String())
+
+	data, err := json.Marshal(fp)
+	assert.Nil(t, err)
+	var old RouteFingerprint
+	assert.Nil(t, json.Unmarshal(data, &old))
+	assert.Equal(t, []RouteChange{}, DiffRoutes(&old, fp))
+
+	current := newRouter(true).Fingerprint()
+	assert.NotEqual(t, fp.Hash, current.Hash)
+	changes := DiffRoutes(&old, current)
+	descriptions := []string{}
+	for _, change := range changes {
+		descriptions = append(descriptions, change.String())
+	}
+	assert.Equal(t, []string{
+		"~ POST /api/users middleware=2 handlers=1 -> POST /api/users middleware=2 handlers=2",
+		"+ DELETE /users/<id> middleware=1 handlers=1",
+	}, descriptions)
+
+	changes = DiffRoutes(current, &old)
+	if assert.Len(t, changes, 2) {
+		assert.Equal(t, "- DELETE /users/<id> middleware=1 handlers=1", changes[1].String())
+	}
+}
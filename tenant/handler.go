@@ -0,0 +1,140 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package tenant provides a handler that resolves the tenant of each request for the ozzo routing package.
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// Key is the key used to store and retrieve the tenant in routing.Context.
+	Key = "Tenant"
+	// IDKey is the key used to store and retrieve the tenant ID in routing.Context, which can be used
+	// by the handlers that do not know the type of the tenant, such as rate limiters and loggers.
+	IDKey = "TenantID"
+)
+
+type (
+	// Tenant is the tenant information returned by a LoaderFunc, such as a struct holding the tenant settings.
+	Tenant interface{}
+
+	// Extractor returns the ID of the tenant specified by the current request, or an empty string if there is none.
+	Extractor func(c *routing.Context) string
+
+	// LoaderFunc loads the tenant with the given ID. It should return nil (with a nil error) if the tenant
+	// does not exist or is not active.
+	LoaderFunc func(c *routing.Context, id string) (Tenant, error)
+)
+
+// Handler returns a handler that resolves the tenant of the current request. The tenant ID is obtained by calling
+// the extractors in order until one of them returns a non-empty ID, and then the tenant is loaded by the loader.
+// The tenant and its ID are stored in the routing context with the keys named Key and IDKey, respectively, so that
+// the following handlers can access them:
+//
+//     import (
+//         "github.com/go-ozzo/ozzo-routing/v2"
+//         "github.com/go-ozzo/ozzo-routing/v2/tenant"
+//     )
+//
+//     r := routing.New()
+//     r.Use(tenant.Handler(func(c *routing.Context, id string) (tenant.Tenant, error) {
+//         return store.FindTenant(id)
+//     }, tenant.FromSubdomain("example.com"), tenant.FromHeader("X-Tenant-ID")))
+//     r.Get("/users", func(c *routing.Context) error {
+//         t := c.Get(tenant.Key).(*Tenant)
+//         ...
+//     })
+//
+// If no tenant ID is found, an http.StatusBadRequest error is returned. If the tenant does not exist,
+// an http.StatusNotFound error is returned. If the loader fails, its error is returned.
+func Handler(loader LoaderFunc, extractors ...Extractor) routing.Handler {
+	return func(c *routing.Context) error {
+		id := ""
+		for _, extract := range extractors {
+			if id = extract(c); id != "" {
+				break
+			}
+		}
+		if id == "" {
+			return routing.NewHTTPError(http.StatusBadRequest, "missing tenant")
+		}
+		t, err := loader(c, id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return routing.NewHTTPError(http.StatusNotFound, fmt.Sprintf("tenant %q not found", id))
+		}
+		c.Set(Key, t)
+		c.Set(IDKey, id)
+		return nil
+	}
+}
+
+// ID returns the ID of the tenant resolved by Handler, or an empty string if there is none.
+func ID(c *routing.Context) string {
+	id, _ := c.Get(IDKey).(string)
+	return id
+}
+
+// FromHeader returns an Extractor that takes the tenant ID from the given request header.
+func FromHeader(name string) Extractor {
+	return func(c *routing.Context) string {
+		return strings.TrimSpace(c.Request.Header.Get(name))
+	}
+}
+
+// FromSubdomain returns an Extractor that takes the tenant ID from the subdomain of the given domain in the request
+// host. For example, if the domain is "example.com", the tenant ID of the host "acme.example.com" is "acme".
+// The hosts that are not direct subdomains of the domain do not specify any tenant.
+func FromSubdomain(domain string) Extractor {
+	suffix := "." + strings.ToLower(strings.Trim(domain, "."))
+	return func(c *routing.Context) string {
+		host := c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := host[:len(host)-len(suffix)]
+		if strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// FromClaim returns an Extractor that takes the tenant ID from the given claim of the JWT token stored
+// in the routing context by auth.JWT. It must be used after auth.JWT with the default token handler.
+// A numeric claim is converted into its decimal representation.
+func FromClaim(name string) Extractor {
+	return func(c *routing.Context) string {
+		token, ok := c.Get("JWT").(*jwt.Token)
+		if !ok {
+			return ""
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return ""
+		}
+		switch value := claims[name].(type) {
+		case string:
+			return value
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return ""
+	}
+}
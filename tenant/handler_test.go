@@ -0,0 +1,79 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package tenant
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-routing/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+type account struct {
+	Name string
+}
+
+func TestHandler(t *testing.T) {
+	loader := func(c *routing.Context, id string) (Tenant, error) {
+		switch id {
+		case "acme", "globex":
+			return &account{id}, nil
+		case "broken":
+			return nil, errors.New("database error")
+		}
+		return nil, nil
+	}
+	router := routing.New()
+	router.Use(Handler(loader, FromSubdomain("example.com"), FromHeader("X-Tenant-ID")))
+	router.Get("/users", func(c *routing.Context) error {
+		return c.Write(c.Get(Key).(*account).Name + ":" + ID(c))
+	})
+
+	tests := []struct {
+		tag    string
+		host   string
+		header string
+		status int
+		body   string
+	}{
+		{"t1", "acme.example.com", "", http.StatusOK, "acme:acme"},
+		{"t2", "ACME.Example.com:8080", "globex", http.StatusOK, "acme:acme"},
+		{"t3", "example.com", "globex", http.StatusOK, "globex:globex"},
+		{"t4", "a.acme.example.com", " globex ", http.StatusOK, "globex:globex"},
+		{"t5", "example.com", "", http.StatusBadRequest, "missing tenant\n"},
+		{"t6", "initech.example.com", "", http.StatusNotFound, "tenant \"initech\" not found\n"},
+		{"t7", "broken.example.com", "", http.StatusInternalServerError, "database error\n"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users", nil)
+		req.Host = test.host
+		req.Header.Set("X-Tenant-ID", test.header)
+		router.ServeHTTP(res, req)
+		assert.Equal(t, test.status, res.Code, test.tag)
+		assert.Equal(t, test.body, res.Body.String(), test.tag)
+	}
+}
+
+func TestFromClaim(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := routing.NewContext(nil, req)
+	extract := FromClaim("tenant")
+	assert.Equal(t, "", extract(c))
+	assert.Equal(t, "", ID(c))
+
+	c.Set("JWT", &jwt.Token{Claims: jwt.MapClaims{"tenant": "acme"}})
+	assert.Equal(t, "acme", extract(c))
+	c.Set("JWT", &jwt.Token{Claims: jwt.MapClaims{"tenant": float64(123)}})
+	assert.Equal(t, "123", extract(c))
+	c.Set("JWT", &jwt.Token{Claims: jwt.MapClaims{"tenant": true}})
+	assert.Equal(t, "", extract(c))
+	c.Set("JWT", &jwt.Token{Claims: &jwt.StandardClaims{}})
+	assert.Equal(t, "", extract(c))
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// HandlerTiming describes the execution time of a handler recorded when Router.Timing is true.
+type HandlerTiming struct {
+	// Name is the name of the handler function.
+	Name string
+	// Duration is the time spent in the handler, excluding that spent in the handlers it calls via Context.Next.
+	Duration time.Duration
+}
+
+// HandlerTimings returns the execution times of the handlers that have been executed for the current request.
+// The timings are only recorded when Router.Timing is true. Otherwise, nil will be returned.
+// The returned timings are in the order of the handlers. A handler which calls Context.Next
+// is not timed until it returns.
+func (c *Context) HandlerTimings() []HandlerTiming {
+	if c.timings == nil {
+		return nil
+	}
+	timings := []HandlerTiming{}
+	for i, d := range c.timings {
+		if d >= 0 && i < len(c.handlers) {
+			timings = append(timings, HandlerTiming{handlerName(c.handlers[i]), d})
+		}
+	}
+	return timings
+}
+
+// HandlerName returns the name of the handler function being executed, e.g. "main.getUser". When Context.Next
+// returns an error or panics, it is the name of the handler that returned the error or panicked, which helps locate
+// the failures in the logs. An empty string is returned if no handler is being executed.
+func (c *Context) HandlerName() string {
+	if c.index < 0 || c.index >= len(c.handlers) {
+		return ""
+	}
+	return handlerName(c.handlers[c.index])
+}
+
+// callTimed calls the handler at the given index and records its execution time.
+func (c *Context) callTimed(index int) error {
+	outer := c.nested
+	c.nested = 0
+	start := time.Now()
+	err := c.handlers[index](c)
+	total := time.Since(start)
+	c.timings[index] = total - c.nested
+	c.nested = outer + total
+	return err
+}
+
+// startTiming prepares the context for recording the handler execution times.
+func (c *Context) startTiming() {
+	c.timings = make([]time.Duration, len(c.handlers))
+	for i := range c.timings {
+		c.timings[i] = -1
+	}
+	c.nested = 0
+}
+
+// setServerTiming sends the handler execution times in the Server-Timing trailer so that they can be inspected
+// using browser development tools. A trailer is used because the response headers are usually sent
+// before all handlers finish. The timings are not sent for streaming responses, i.e., WebSocket connections and
+// server-sent events, because the handlers serving them last as long as the streams do.
+func (c *Context) setServerTiming() {
+	timings := c.HandlerTimings()
+	if len(timings) == 0 || isStreaming(c) {
+		return
+	}
+	metrics := make([]string, len(timings))
+	for i, t := range timings {
+		metrics[i] = fmt.Sprintf("h%v;desc=%q;dur=%.3f", i, t.Name, float64(t.Duration)/float64(time.Millisecond))
+	}
+	c.Response.Header().Set(http.TrailerPrefix+"Server-Timing", strings.Join(metrics, ", "))
+}
+
+// handlerName returns the short name of the given handler function, e.g. "auth.Basic.func1",
+// or its alias in HandlerNames.
+func handlerName(h Handler) string {
+	name := "unknown"
+	if f := runtime.FuncForPC(reflect.ValueOf(h).Pointer()); f != nil {
+		name = f.Name()
+	}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if alias, ok := HandlerNames[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// isStreaming checks if the current request is an upgrade request (see Context.IsUpgrade), such as a WebSocket
+// handshake, or its response is a stream of server-sent events.
+func isStreaming(c *Context) bool {
+	return c.IsUpgrade() ||
+		strings.HasPrefix(c.Response.Header().Get("Content-Type"), "text/event-stream")
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func slowHandler(c *Context) error {
+	time.Sleep(20 * time.Millisecond)
+	return c.Next()
+}
+
+func TestRouterTiming(t *testing.T) {
+	var timings []HandlerTiming
+	router := New()
+	router.Timing = true
+	router.Use(func(c *Context) error {
+		err := c.Next()
+		timings = c.HandlerTimings()
+		return err
+	}, slowHandler)
+	router.Get("/users", func(c *Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return c.Write("users")
+	}, func(c *Context) error {
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "users", res.Body.String())
+	// the first handler is still running when retrieving the timings
+	if assert.Len(t, timings, 3) {
+		assert.Equal(t, "v2.slowHandler", timings[0].Name)
+		assert.True(t, timings[0].Duration >= 20*time.Millisecond && timings[0].Duration < 50*time.Millisecond, timings[0].Duration)
+		assert.True(t, timings[1].Duration >= 50*time.Millisecond, timings[1].Duration)
+		assert.True(t, timings[2].Duration < 20*time.Millisecond, timings[2].Duration)
+	}
+	trailer := res.Result().Trailer.Get("Server-Timing")
+	assert.Equal(t, 4, strings.Count(trailer, ";dur="), trailer)
+	assert.True(t, strings.HasPrefix(trailer, `h0;desc="`), trailer)
+
+	router.Timing = false
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Nil(t, timings)
+	assert.Equal(t, "", res.Result().Trailer.Get("Server-Timing"))
+}
+
+func TestHandlerName(t *testing.T) {
+	assert.Equal(t, "v2.NotFoundHandler", handlerName(NotFoundHandler))
+	assert.Equal(t, "v2.TestHandlerName.func1", handlerName(func(c *Context) error { return nil }))
+}
+
+func TestContextHandlerName(t *testing.T) {
+	var names []string
+	c := NewContext(nil, nil, func(c *Context) error {
+		names = append(names, c.HandlerName())
+		err := c.Next()
+		names = append(names, c.HandlerName())
+		return err
+	}, NotFoundHandler)
+	assert.Equal(t, "", c.HandlerName())
+	assert.NotNil(t, c.Next())
+	assert.Equal(t, []string{"v2.TestContextHandlerName.func1", "v2.NotFoundHandler"}, names)
+}
+
+func TestRouterTimingStreaming(t *testing.T) {
+	router := New()
+	router.Timing = true
+	router.Get("/events", func(c *Context) error {
+		c.Response.Header().Set("Content-Type", "text/event-stream")
+		return c.Write("data: a\n\n")
+	})
+	router.Get("/ws", func(c *Context) error {
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events", nil)
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "", res.Result().Trailer.Get("Server-Timing"))
+
+	res = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "WebSocket")
+	router.ServeHTTP(res, req)
+	assert.Equal(t, "", res.Result().Trailer.Get("Server-Timing"))
+}
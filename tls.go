@@ -0,0 +1,110 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+// TLSInfo describes the TLS connection over which a request is received.
+type TLSInfo struct {
+	Version            uint16              // the TLS version, e.g. tls.VersionTLS13
+	CipherSuite        uint16              // the cipher suite, e.g. tls.TLS_AES_128_GCM_SHA256
+	ServerName         string              // the server name requested by the client via SNI, if any
+	NegotiatedProtocol string              // the application protocol negotiated via ALPN, e.g. "h2"
+	DidResume          bool                // whether the connection resumes a previous TLS session
+	PeerCertificates   []*x509.Certificate // the certificates sent by the client, if any
+}
+
+// TLS returns the information of the TLS connection over which the current request is received.
+// Nil is returned if the request is not received over TLS.
+//
+//     if info := c.TLS(); info == nil || info.Version < tls.VersionTLS12 {
+//         return routing.NewHTTPError(http.StatusUpgradeRequired)
+//     }
+func (c *Context) TLS() *TLSInfo {
+	return GetTLSInfo(c.Request)
+}
+
+// GetTLSInfo returns the information of the TLS connection over which the given request is received.
+// Nil is returned if the request is not received over TLS.
+func GetTLSInfo(req *http.Request) *TLSInfo {
+	if req.TLS == nil {
+		return nil
+	}
+	return &TLSInfo{
+		Version:            req.TLS.Version,
+		CipherSuite:        req.TLS.CipherSuite,
+		ServerName:         req.TLS.ServerName,
+		NegotiatedProtocol: req.TLS.NegotiatedProtocol,
+		DidResume:          req.TLS.DidResume,
+		PeerCertificates:   req.TLS.PeerCertificates,
+	}
+}
+
+// VersionName returns the name of the TLS version, e.g. "TLS 1.3".
+func (i *TLSInfo) VersionName() string {
+	switch i.Version {
+	case tls.VersionSSL30:
+		return "SSL 3.0"
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04X", i.Version)
+}
+
+// CipherSuiteName returns the standard name of the cipher suite, e.g. "TLS_AES_128_GCM_SHA256".
+func (i *TLSInfo) CipherSuiteName() string {
+	if name, ok := cipherSuiteNames[i.CipherSuite]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", i.CipherSuite)
+}
+
+// PeerCertificate returns the leaf certificate sent by the client, or nil if the client sends no certificate.
+func (i *TLSInfo) PeerCertificate() *x509.Certificate {
+	if len(i.PeerCertificates) == 0 {
+		return nil
+	}
+	return i.PeerCertificates[0]
+}
+
+// cipherSuiteNames maps the cipher suites implemented by crypto/tls to their standard names.
+var cipherSuiteNames = map[uint16]string{
+	tls.TLS_RSA_WITH_RC4_128_SHA:                "TLS_RSA_WITH_RC4_128_SHA",
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA:           "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA:            "TLS_RSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA:            "TLS_RSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256:         "TLS_RSA_WITH_AES_128_CBC_SHA256",
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256:         "TLS_RSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384:         "TLS_RSA_WITH_AES_256_GCM_SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA:        "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA:    "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA:    "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA:          "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA:     "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA:      "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA:      "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256:   "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:   "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:   "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305:    "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305:  "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+	tls.TLS_AES_128_GCM_SHA256:                  "TLS_AES_128_GCM_SHA256",
+	tls.TLS_AES_256_GCM_SHA384:                  "TLS_AES_256_GCM_SHA384",
+	tls.TLS_CHACHA20_POLY1305_SHA256:            "TLS_CHACHA20_POLY1305_SHA256",
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextTLS(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+	assert.Nil(t, c.TLS())
+
+	cert := &x509.Certificate{}
+	req.TLS = &tls.ConnectionState{
+		Version:            tls.VersionTLS13,
+		CipherSuite:        tls.TLS_AES_128_GCM_SHA256,
+		ServerName:         "example.com",
+		NegotiatedProtocol: "h2",
+		PeerCertificates:   []*x509.Certificate{cert},
+	}
+	info := c.TLS()
+	if assert.NotNil(t, info) {
+		assert.Equal(t, "TLS 1.3", info.VersionName())
+		assert.Equal(t, "TLS_AES_128_GCM_SHA256", info.CipherSuiteName())
+		assert.Equal(t, "example.com", info.ServerName)
+		assert.Equal(t, "h2", info.NegotiatedProtocol)
+		assert.Equal(t, cert, info.PeerCertificate())
+	}
+
+	req.TLS = &tls.ConnectionState{Version: 0x0305, CipherSuite: 0xabcd}
+	info = GetTLSInfo(req)
+	assert.Equal(t, "0x0305", info.VersionName())
+	assert.Equal(t, "0xABCD", info.CipherSuiteName())
+	assert.Nil(t, info.PeerCertificate())
+}
+
+func TestContextTLSServer(t *testing.T) {
+	var info *TLSInfo
+	router := New()
+	router.Get("/", func(c *Context) error {
+		info = c.TLS()
+		return nil
+	})
+	server := httptest.NewTLSServer(router)
+	defer server.Close()
+
+	res, err := server.Client().Get(server.URL)
+	if assert.Nil(t, err) {
+		res.Body.Close()
+		if assert.NotNil(t, info) {
+			assert.Equal(t, res.TLS.Version, info.Version)
+			assert.Equal(t, res.TLS.CipherSuite, info.CipherSuite)
+			assert.NotEqual(t, "", info.CipherSuiteName())
+		}
+	}
+}
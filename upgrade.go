@@ -0,0 +1,70 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"net/http"
+	"strings"
+)
+
+// UpgradePolicy is the name of the route metadata item that specifies how the operational handlers, such as
+// the concurrency limiter, apply their limits to the upgraded connections (e.g. WebSocket) of the route.
+// The value should be UpgradeExempt or UpgradeLifetime. Defaults to UpgradeExempt.
+const UpgradePolicy = "upgrade.policy"
+
+// The policies of applying the limits of the operational handlers to the upgraded connections.
+const (
+	// UpgradeExempt exempts the upgraded connections from the limits, so that the long-lived connections are
+	// neither killed by the timeouts nor holding the slots of the concurrency limits.
+	UpgradeExempt = "exempt"
+	// UpgradeLifetime applies the limits for the whole lifetime of the upgraded connections, e.g. to cap the number
+	// of the WebSocket connections of a route using limit.MaxConcurrent. The limits that cannot apply to
+	// the upgraded connections, such as the response size limits, still exempt them.
+	UpgradeLifetime = "lifetime"
+)
+
+// IsUpgrade returns whether the current request asks to upgrade the connection to another protocol, such as
+// WebSocket, i.e. it is a GET request without body that has the Connection header containing "upgrade" and
+// the Upgrade header, or whether the connection has been upgraded, i.e. the response status is
+// 101 (Switching Protocols) (see Context.Status). The requests with bodies are not treated as upgrade requests
+// before being upgraded, so that a client cannot escape the limits on the requests by adding the headers.
+func (c *Context) IsUpgrade() bool {
+	req := c.Request
+	if req.Method == "GET" && req.ContentLength == 0 && len(req.TransferEncoding) == 0 && req.Header.Get("Upgrade") != "" {
+		for _, value := range req.Header["Connection"] {
+			for _, token := range strings.Split(value, ",") {
+				if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+					return true
+				}
+			}
+		}
+	}
+	return c.Status() == http.StatusSwitchingProtocols
+}
+
+// UpgradeExempted returns whether the current request is an upgrade request (see IsUpgrade) exempted from
+// the limits of the operational handlers, i.e. the route matching it does not set UpgradePolicy to UpgradeLifetime.
+// The handlers limiting the duration or the concurrency of the requests should skip the requests for which
+// this method returns true. For example,
+//
+//     func Timeout(timeout time.Duration) routing.Handler {
+//         return func(c *routing.Context) error {
+//             if c.UpgradeExempted() {
+//                 return nil
+//             }
+//             ...
+//         }
+//     }
+func (c *Context) UpgradeExempted() bool {
+	if !c.IsUpgrade() {
+		return false
+	}
+	if c.route != nil {
+		if policy, ok := c.route.Meta(UpgradePolicy).(string); ok && policy == UpgradeLifetime {
+			return false
+		}
+	}
+	return true
+}
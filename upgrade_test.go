@@ -0,0 +1,120 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextIsUpgrade(t *testing.T) {
+	tests := []struct {
+		id, connection, upgrade string
+		expected                bool
+	}{
+		{"t1", "", "", false},
+		{"t2", "Upgrade", "websocket", true},
+		{"t3", "keep-alive, upgrade", "websocket", true},
+		{"t4", "keep-alive", "websocket", false},
+		{"t5", "Upgrade", "", false},
+		{"t6", "Upgrade", "websocket", false},
+		{"t7", "Upgrade", "websocket", false},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		switch test.id {
+		case "t6":
+			// an upgrade request has no body
+			req, _ = http.NewRequest("GET", "/", strings.NewReader("abc"))
+		case "t7":
+			req, _ = http.NewRequest("POST", "/", nil)
+		}
+		if test.connection != "" {
+			req.Header.Set("Connection", test.connection)
+		}
+		if test.upgrade != "" {
+			req.Header.Set("Upgrade", test.upgrade)
+		}
+		c := NewContext(httptest.NewRecorder(), req)
+		assert.Equal(t, test.expected, c.IsUpgrade(), test.id)
+	}
+
+	// the connection has been upgraded
+	req, _ := http.NewRequest("GET", "/", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+	c.TrackResponse()
+	c.Response.WriteHeader(http.StatusSwitchingProtocols)
+	assert.True(t, c.IsUpgrade())
+}
+
+func TestContextUpgradeExempted(t *testing.T) {
+	var exempted bool
+	router := New()
+	router.Use(func(c *Context) error {
+		exempted = c.UpgradeExempted()
+		return nil
+	})
+	router.Get("/ws", func(c *Context) error { return nil })
+	router.Get("/ws/capped", func(c *Context) error { return nil }).Set(UpgradePolicy, UpgradeLifetime)
+
+	tests := []struct {
+		id, path string
+		upgrade  bool
+		expected bool
+	}{
+		{"t1", "/ws", false, false},
+		{"t2", "/ws", true, true},
+		{"t3", "/ws/capped", true, false},
+		{"t4", "/missing", true, true},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", test.path, nil)
+		if test.upgrade {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, test.expected, exempted, test.id)
+	}
+}
+
+func TestRouterMaxBodySizeUpgrade(t *testing.T) {
+	router, _ := NewWithOptions(RouterOptions{MaxBodySize: 3})
+	router.Get("/ws", func(c *Context) error {
+		return c.Write("ok")
+	})
+
+	router.Post("/ws", func(c *Context) error {
+		_, err := ioutil.ReadAll(c.Request.Body)
+		return err
+	})
+
+	req, _ := http.NewRequest("GET", "/ws", strings.NewReader("abcdef"))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+
+	req, _ = http.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// the upgrade headers do not exempt the requests with bodies
+	for _, method := range []string{"GET", "POST"} {
+		req, _ = http.NewRequest(method, "/ws", strings.NewReader(strings.Repeat("a", 1000)))
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "x")
+		res = httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code, method)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StatusClientClosedRequest is the non-standard status code of the HTTP errors indicating that the client has closed
+// the connection before the request is processed, e.g. while its body is being uploaded.
+const StatusClientClosedRequest = 499
+
+type (
+	// UploadOptions specifies how Context.Upload streams the request body.
+	UploadOptions struct {
+		// The maximum number of bytes allowed in the body. A body exceeding the limit is rejected with a 413 HTTP
+		// error. Zero means no limit.
+		MaxSize int64
+		// A function that is called after each chunk of the body is written, with the number of bytes written
+		// so far and the total number of bytes, which is -1 if the request does not specify Content-Length.
+		Progress func(written, total int64)
+		// The size of the chunks in which the body is copied. Defaults to 32KB.
+		BufferSize int
+	}
+
+	// UploadResult describes a request body streamed by Context.Upload.
+	UploadResult struct {
+		Size   int64  // the number of bytes in the body
+		MD5    []byte // the MD5 checksum of the body
+		SHA256 []byte // the SHA-256 checksum of the body
+	}
+)
+
+// Upload streams the request body to the given writer, such as a file or the writer of a pipe read by
+// a cloud storage uploader, without buffering the whole body in memory, and returns the size and the checksums
+// of the body. For example,
+//
+//     router.Put("/files/<name>", func(c *routing.Context) error {
+//         pr, pw := io.Pipe()
+//         go uploader.Upload(c.Param("name"), pr)
+//         result, err := c.Upload(pw, routing.UploadOptions{
+//             MaxSize:  10 << 30,
+//             Progress: func(written, total int64) { report(c.Param("name"), written, total) },
+//         })
+//         if err != nil {
+//             return err
+//         }
+//         pw.Close()
+//         return c.Write(result)
+//     })
+//
+// If the request has the Content-MD5 header (RFC 1864) or the Digest header (RFC 3230) with the "MD5" or "SHA-256"
+// digests, the checksums of the body are verified against them after the whole body is read, and a 400 HTTP error
+// is returned if they do not match. A 413 HTTP error is returned if the body exceeds UploadOptions.MaxSize,
+// a StatusClientClosedRequest HTTP error is returned if the client disconnects, and the errors returned by
+// the writer are returned as is. In all these cases, if the writer has the CloseWithError method, such as
+// *io.PipeWriter, the method is called with the error, so that the reading end can abort the upload instead of
+// storing an incomplete or corrupted body. Note that the data written before the failure are not reverted.
+func (c *Context) Upload(w io.Writer, opts ...UploadOptions) (UploadResult, error) {
+	var options UploadOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	var result UploadResult
+	req := c.Request
+	if options.MaxSize > 0 && req.ContentLength > options.MaxSize {
+		return result, abortUpload(w, NewHTTPError(http.StatusRequestEntityTooLarge))
+	}
+	size := options.BufferSize
+	if size <= 0 {
+		size = 32 << 10
+	}
+
+	md5Hash, sha256Hash := md5.New(), sha256.New()
+	buf := make([]byte, size)
+	done := req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return result, abortUpload(w, NewHTTPError(StatusClientClosedRequest, "client closed request"))
+		default:
+		}
+		n, err := req.Body.Read(buf)
+		if n > 0 {
+			if options.MaxSize > 0 && result.Size+int64(n) > options.MaxSize {
+				return result, abortUpload(w, NewHTTPError(http.StatusRequestEntityTooLarge))
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return result, abortUpload(w, err)
+			}
+			md5Hash.Write(buf[:n])
+			sha256Hash.Write(buf[:n])
+			result.Size += int64(n)
+			if options.Progress != nil {
+				options.Progress(result.Size, req.ContentLength)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, abortUpload(w, uploadReadError(req, err))
+		}
+	}
+
+	result.MD5, result.SHA256 = md5Hash.Sum(nil), sha256Hash.Sum(nil)
+	if err := verifyChecksums(req.Header, result); err != nil {
+		return result, abortUpload(w, err)
+	}
+	return result, nil
+}
+
+// uploadReadError converts an error of reading the request body into an HTTP error.
+func uploadReadError(req *http.Request, err error) error {
+	if _, ok := err.(HTTPError); ok {
+		return err
+	}
+	if req.Context().Err() != nil {
+		return NewHTTPError(StatusClientClosedRequest, "client closed request")
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		// returned by http.MaxBytesReader, e.g. when Router.MaxBodySize is set
+		return NewHTTPError(http.StatusRequestEntityTooLarge)
+	}
+	return NewHTTPError(http.StatusBadRequest, err.Error())
+}
+
+// abortUpload closes the writer with the error if it supports CloseWithError, and returns the error.
+func abortUpload(w io.Writer, err error) error {
+	if closer, ok := w.(interface{ CloseWithError(error) error }); ok {
+		closer.CloseWithError(err)
+	}
+	return err
+}
+
+// verifyChecksums verifies the checksums of the uploaded body against the Content-MD5 and Digest request headers.
+func verifyChecksums(header http.Header, result UploadResult) error {
+	expected := map[string]string{}
+	if s := header.Get("Content-MD5"); s != "" {
+		expected["md5"] = strings.TrimSpace(s)
+	}
+	for _, value := range header["Digest"] {
+		for _, digest := range strings.Split(value, ",") {
+			if i := strings.Index(digest, "="); i > 0 {
+				expected[strings.ToLower(strings.TrimSpace(digest[:i]))] = strings.TrimSpace(digest[i+1:])
+			}
+		}
+	}
+	for algorithm, sum := range map[string][]byte{"md5": result.MD5, "sha-256": result.SHA256} {
+		s, ok := expected[algorithm]
+		if !ok {
+			continue
+		}
+		if data, err := base64.StdEncoding.DecodeString(s); err != nil || !bytes.Equal(data, sum) {
+			return NewHTTPError(http.StatusBadRequest, strings.ToUpper(algorithm)+" checksum mismatch")
+		}
+	}
+	return nil
+}
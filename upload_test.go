@@ -0,0 +1,137 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routing
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextUpload(t *testing.T) {
+	body := strings.Repeat("abcdefgh", 10)
+	md5Sum := md5.Sum([]byte(body))
+	sha256Sum := sha256.Sum256([]byte(body))
+	md5Digest := base64.StdEncoding.EncodeToString(md5Sum[:])
+	sha256Digest := base64.StdEncoding.EncodeToString(sha256Sum[:])
+
+	tests := []struct {
+		tag     string
+		headers map[string]string
+		length  int64
+		maxSize int64
+		status  int
+	}{
+		{"t1", nil, 80, 0, 0},
+		{"t2", map[string]string{"Content-MD5": md5Digest}, 80, 0, 0},
+		{"t3", map[string]string{"Digest": "SHA-256=" + sha256Digest + ", md5=" + md5Digest}, 80, 0, 0},
+		{"t4", map[string]string{"Content-MD5": sha256Digest}, 80, 0, http.StatusBadRequest},
+		{"t5", map[string]string{"Digest": "sha-256=invalid"}, 80, 0, http.StatusBadRequest},
+		{"t6", nil, 80, 79, http.StatusRequestEntityTooLarge},
+		{"t7", nil, -1, 79, http.StatusRequestEntityTooLarge},
+		{"t8", nil, -1, 80, 0},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("PUT", "/files/a", strings.NewReader(body))
+		req.ContentLength = test.length
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		c := NewContext(httptest.NewRecorder(), req)
+		var (
+			buf      bytes.Buffer
+			progress []int64
+		)
+		result, err := c.Upload(&buf, UploadOptions{
+			MaxSize:    test.maxSize,
+			BufferSize: 16,
+			Progress: func(written, total int64) {
+				assert.Equal(t, test.length, total, test.tag)
+				progress = append(progress, written)
+			},
+		})
+		if test.status != 0 {
+			if assert.NotNil(t, err, test.tag) {
+				assert.Equal(t, test.status, err.(HTTPError).StatusCode(), test.tag)
+			}
+			continue
+		}
+		if assert.Nil(t, err, test.tag) {
+			assert.Equal(t, body, buf.String(), test.tag)
+			assert.Equal(t, int64(80), result.Size, test.tag)
+			assert.Equal(t, md5Sum[:], result.MD5, test.tag)
+			assert.Equal(t, sha256Sum[:], result.SHA256, test.tag)
+			assert.Equal(t, []int64{16, 32, 48, 64, 80}, progress, test.tag)
+		}
+	}
+}
+
+func TestContextUploadAbort(t *testing.T) {
+	// the reading end of a pipe receives the checksum error
+	req, _ := http.NewRequest("PUT", "/files/a", strings.NewReader("abc"))
+	req.Header.Set("Content-MD5", "invalid")
+	c := NewContext(httptest.NewRecorder(), req)
+	pr, pw := io.Pipe()
+	received := make(chan error, 1)
+	go func() {
+		_, err := ioutil.ReadAll(pr)
+		received <- err
+	}()
+	_, err := c.Upload(pw)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(HTTPError).StatusCode())
+		assert.Equal(t, err, <-received)
+	}
+
+	// the errors of the writer are returned as is
+	writeErr := errors.New("storage unavailable")
+	req, _ = http.NewRequest("PUT", "/files/a", strings.NewReader("abc"))
+	c = NewContext(httptest.NewRecorder(), req)
+	_, err = c.Upload(failingWriter{writeErr})
+	assert.Equal(t, writeErr, err)
+
+	// the upload stops when the client disconnects
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ = http.NewRequest("PUT", "/files/a", strings.NewReader("abc"))
+	c = NewContext(httptest.NewRecorder(), req.WithContext(ctx))
+	_, err = c.Upload(ioutil.Discard)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, StatusClientClosedRequest, err.(HTTPError).StatusCode())
+	}
+
+	// the body limited by the router is rejected with 413
+	req, _ = http.NewRequest("PUT", "/files/a", strings.NewReader("abcdef"))
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 3)
+	c = NewContext(httptest.NewRecorder(), req)
+	_, err = c.Upload(ioutil.Discard)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(HTTPError).StatusCode())
+	}
+
+	// the wrapped body size errors are detected too
+	err = uploadReadError(req, fmt.Errorf("decoding: %w", &http.MaxBytesError{Limit: 3}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, err.(HTTPError).StatusCode())
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
@@ -1,6 +1,7 @@
 package routing
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 )
@@ -13,8 +14,19 @@ type DataWriter interface {
 	Write(http.ResponseWriter, interface{}) error
 }
 
+// ContextDataWriter is a DataWriter that depends on the details of the current request to write data, such as
+// a writer formatting numbers according to the negotiated language, or pretty-printing JSON when the request has
+// a "pretty" query parameter. Context.Write calls WriteContext instead of Write if the data writer set via
+// Context.SetDataWriter implements this interface, so the existing data writers keep working unchanged.
+type ContextDataWriter interface {
+	DataWriter
+	// WriteContext writes the given data into the response of the given context.
+	WriteContext(c *Context, data interface{}) error
+}
+
 // DefaultDataWriter writes the given data in an HTTP response.
-// If the data is neither string nor byte array, it will use fmt.Fprint() to write it into the response.
+// A string, a byte array, or a json.RawMessage is written as is without changing the Content-Type header.
+// Other data is written using fmt.Fprint().
 var DefaultDataWriter DataWriter = &dataWriter{}
 
 type dataWriter struct{}
@@ -26,6 +38,8 @@ func (w *dataWriter) Write(res http.ResponseWriter, data interface{}) error {
 	switch data.(type) {
 	case []byte:
 		bytes = data.([]byte)
+	case json.RawMessage:
+		bytes = data.(json.RawMessage)
 	case string:
 		bytes = []byte(data.(string))
 	default:
@@ -1,6 +1,9 @@
 package routing
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -18,6 +21,11 @@ func TestDefaultDataWriter(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "abc", res.Body.String())
 
+	res = httptest.NewRecorder()
+	err = DefaultDataWriter.Write(res, json.RawMessage(`{"a":1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1}`, res.Body.String())
+
 	res = httptest.NewRecorder()
 	err = DefaultDataWriter.Write(res, 123)
 	assert.Nil(t, err)
@@ -34,3 +42,58 @@ func TestDefaultDataWriter(t *testing.T) {
 	assert.Nil(t, c.Write("abc"))
 	assert.Equal(t, "abc", res.Body.String())
 }
+
+func TestContextWriteRaw(t *testing.T) {
+	res := httptest.NewRecorder()
+	c := NewContext(res, nil)
+	c.SetDataWriter(&testDataWriter{})
+	assert.Nil(t, c.WriteRaw("application/json", []byte(`{"a":1}`)))
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Equal(t, `{"a":1}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	c = NewContext(res, nil)
+	res.Header().Set("Content-Type", "text/csv")
+	assert.Nil(t, c.WriteRaw("", []byte("a,b")))
+	assert.Equal(t, "text/csv", res.Header().Get("Content-Type"))
+	assert.Equal(t, "a,b", res.Body.String())
+}
+
+func TestContextDataWriter(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/users?pretty", nil)
+	res := httptest.NewRecorder()
+	c := NewContext(res, req)
+	c.SetDataWriter(&testContextDataWriter{})
+	assert.Nil(t, c.Write("abc"))
+	assert.Equal(t, "pretty \"abc\"", res.Body.String())
+
+	// a writer without WriteContext is called as usual
+	res = httptest.NewRecorder()
+	c = NewContext(res, req)
+	c.SetDataWriter(&testDataWriter{})
+	assert.Nil(t, c.Write("abc"))
+	assert.Equal(t, "encoded \"abc\"", res.Body.String())
+}
+
+type testDataWriter struct{}
+
+func (w *testDataWriter) SetHeader(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "text/plain")
+}
+
+func (w *testDataWriter) Write(res http.ResponseWriter, data interface{}) error {
+	_, err := fmt.Fprintf(res, "encoded %q", data)
+	return err
+}
+
+type testContextDataWriter struct {
+	testDataWriter
+}
+
+func (w *testContextDataWriter) WriteContext(c *Context, data interface{}) error {
+	if _, ok := c.Request.URL.Query()["pretty"]; ok {
+		_, err := fmt.Fprintf(c.Response, "pretty %q", data)
+		return err
+	}
+	return w.Write(c.Response, data)
+}